@@ -0,0 +1,115 @@
+//go:build freebsd
+// +build freebsd
+
+package cmd
+
+import (
+	"HosterCore/internal/pkg/emojlog"
+	HosterBulk "HosterCore/internal/pkg/hoster/bulk"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	bulkTags         []string
+	bulkNames        []string
+	bulkOwner        string
+	bulkProduction   string
+	bulkResourceType string
+	bulkSetTag       string
+	bulkSnapshotKeep int
+	bulkSnapshotType string
+	bulkSshKey       string
+	bulkSshEndpoint  string
+	bulkSshPort      int
+	bulkSpeedLimit   int
+	bulkDryRun       bool
+	bulkYes          bool
+
+	bulkCmd = &cobra.Command{
+		Use:       "bulk [action]",
+		Short:     "Apply an action to multiple VMs and Jails at once",
+		Long:      "Apply an action to every VM and Jail matching the selector flags, e.g. `hoster bulk stop --tag web`.\nAvailable actions: " + strings.Join(HosterBulk.Actions, ", ") + ".",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: HosterBulk.Actions,
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+
+			err := runBulkAction(args[0])
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func runBulkAction(action string) error {
+	sel := HosterBulk.Selector{Tags: bulkTags, Names: bulkNames, Owner: bulkOwner, ResourceType: bulkResourceType}
+	if len(bulkProduction) > 0 {
+		production, err := strconv.ParseBool(bulkProduction)
+		if err != nil {
+			return fmt.Errorf("--production must be either true or false")
+		}
+		sel.Production = &production
+	}
+
+	params, err := HosterBulk.ValidateParams(action, HosterBulk.Params{
+		Tag:             bulkSetTag,
+		SnapshotsToKeep: bulkSnapshotKeep,
+		SnapshotType:    bulkSnapshotType,
+		SshKey:          bulkSshKey,
+		SshEndpoint:     bulkSshEndpoint,
+		SshPort:         bulkSshPort,
+		SpeedLimit:      bulkSpeedLimit,
+	})
+	if err != nil {
+		return err
+	}
+
+	targets, err := HosterBulk.Resolve(sel)
+	if err != nil {
+		return err
+	}
+	if len(targets) < 1 {
+		emojlog.PrintLogMessage("no resources match the selector", emojlog.Info)
+		return nil
+	}
+
+	if bulkDryRun {
+		for _, v := range targets {
+			emojlog.PrintLogMessage(fmt.Sprintf("%s would be applied to %s: %s", action, v.ResourceType, v.ResourceName), emojlog.Info)
+		}
+		return nil
+	}
+
+	if !bulkYes {
+		for _, v := range targets {
+			fmt.Printf("  %s: %s\n", v.ResourceType, v.ResourceName)
+		}
+		if !confirmAction(fmt.Sprintf("%s will be applied to the %d resource(s) listed above.", action, len(targets))) {
+			emojlog.PrintLogMessage("Bulk "+action+" was cancelled", emojlog.Info)
+			os.Exit(1)
+		}
+	}
+
+	failed := 0
+	HosterBulk.Run(action, targets, params, func(res HosterBulk.Result) {
+		if res.Success {
+			emojlog.PrintLogMessage(fmt.Sprintf("%s: %s %s", action, res.ResourceType, res.ResourceName), emojlog.Changed)
+			return
+		}
+		failed += 1
+		emojlog.PrintLogMessage(fmt.Sprintf("%s: %s %s: %s", action, res.ResourceType, res.ResourceName, res.Error), emojlog.Error)
+	})
+
+	if failed > 0 {
+		return fmt.Errorf("%s has failed for %d out of %d resources", action, failed, len(targets))
+	}
+
+	return nil
+}
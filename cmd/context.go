@@ -0,0 +1,218 @@
+//go:build freebsd
+// +build freebsd
+
+package cmd
+
+import (
+	"HosterCore/internal/pkg/emojlog"
+	HosterCliContext "HosterCore/internal/pkg/hoster/cli_context"
+	ApiV2Client "HosterCore/pkg/api_v2_client"
+	"fmt"
+	"os"
+
+	"github.com/aquasecurity/table"
+	"github.com/spf13/cobra"
+)
+
+// Set using the global --context flag, routes the supported commands through the remote node's REST API
+var cliContextName string
+
+// Commands which can be executed on a remote node have to opt in explicitly, using this annotation.
+// Everything else is refused on a remote context, instead of silently running on the local host.
+const remoteAnnotation = "hoster_remote"
+
+var remoteSupported = map[string]string{remoteAnnotation: "true"}
+
+var (
+	contextCmd = &cobra.Command{
+		Use:   "context",
+		Short: "Remote Hoster nodes (contexts) management",
+		Long: "Manage the named contexts, each pointing to a remote Hoster node's REST API.\n" +
+			"Use the global `--context` flag (or the " + HosterCliContext.CONTEXT_ENV + " environment variable) to execute `vm list/start/stop/info`, `jail`, `snapshot` and `scheduler` commands on a remote node.",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+)
+
+var (
+	contextAddEndpoint string
+	contextAddUser     string
+	contextAddPassword string
+	contextAddToken    string
+	contextAddCACert   string
+	contextAddInsecure bool
+
+	contextAddCmd = &cobra.Command{
+		Use:   "add [contextName]",
+		Short: "Add a new context (or replace an existing one)",
+		Long:  "Add a new context (or replace an existing one), e.g. `hoster context add node2 --endpoint https://10.0.0.5:3000 --user admin --password secret`.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := HosterCliContext.Add(HosterCliContext.Context{
+				Name:       args[0],
+				Endpoint:   contextAddEndpoint,
+				User:       contextAddUser,
+				Password:   contextAddPassword,
+				Token:      contextAddToken,
+				CACertFile: contextAddCACert,
+				Insecure:   contextAddInsecure,
+			})
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			emojlog.PrintLogMessage("Context has been saved: "+args[0], emojlog.Changed)
+		},
+	}
+)
+
+var (
+	contextListUnixStyle bool
+
+	contextListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List all available contexts",
+		Long:  "List all available contexts (credentials are not shown).",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := generateContextsTable(contextListUnixStyle)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+var (
+	contextRemoveCmd = &cobra.Command{
+		Use:   "remove [contextName]",
+		Short: "Remove a context",
+		Long:  "Remove a context.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := HosterCliContext.Remove(args[0])
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			emojlog.PrintLogMessage("Context has been removed: "+args[0], emojlog.Changed)
+		},
+	}
+)
+
+func generateContextsTable(unix bool) error {
+	c, err := HosterCliContext.Load()
+	if err != nil {
+		return err
+	}
+
+	var t = table.New(os.Stdout)
+	t.SetAlignment(
+		table.AlignRight,  // ID
+		table.AlignLeft,   // Context Name
+		table.AlignLeft,   // Endpoint
+		table.AlignCenter, // Auth
+		table.AlignCenter, // TLS
+	)
+
+	if unix {
+		t.SetDividers(table.Dividers{
+			ALL: " ",
+			NES: " ",
+			NSW: " ",
+			NEW: " ",
+			ESW: " ",
+			NE:  " ",
+			NW:  " ",
+			SW:  " ",
+			ES:  " ",
+			EW:  " ",
+			NS:  " ",
+		})
+		t.SetRowLines(false)
+		t.SetBorderTop(false)
+		t.SetBorderBottom(false)
+	} else {
+		t.SetHeaders("Hoster Contexts")
+		t.SetHeaderColSpans(0, 5)
+
+		t.AddHeaders(
+			"#",
+			"Context\nName",
+			"API\nEndpoint",
+			"Auth\nType",
+			"TLS\nVerification",
+		)
+
+		t.SetLineStyle(table.StyleBrightCyan)
+		t.SetDividers(table.UnicodeRoundedDividers)
+		t.SetHeaderStyle(table.StyleBold)
+	}
+
+	for i, v := range c.Contexts {
+		auth := "basic (" + v.User + ")"
+		if len(v.Token) > 0 {
+			auth = "token"
+		}
+
+		tls := "system CA"
+		if len(v.CACertFile) > 0 {
+			tls = v.CACertFile
+		}
+		if v.Insecure {
+			tls = "disabled"
+		}
+
+		t.AddRow(
+			fmt.Sprintf("%d", i+1),
+			v.Name,
+			v.Endpoint,
+			auth,
+			tls,
+		)
+	}
+
+	t.Render()
+	return nil
+}
+
+// Returns the name of the context the command must be executed on, empty string means local execution.
+func remoteContextName() string {
+	if len(cliContextName) > 0 {
+		return cliContextName
+	}
+	return os.Getenv(HosterCliContext.CONTEXT_ENV)
+}
+
+// Returns true if the command must be executed on a remote node.
+func isRemote() bool {
+	return len(remoteContextName()) > 0
+}
+
+// Root command's PersistentPreRunE: fails if a remote context is selected, but the command doesn't support the remote execution.
+// Context management (and the help/completion commands) always run locally.
+func checkRemoteSupport(cmd *cobra.Command, args []string) error {
+	if !isRemote() || cmd.Annotations[remoteAnnotation] == "true" {
+		return nil
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == contextCmd || c.Name() == "help" || c.Name() == "completion" || c.Name() == cobra.ShellCompRequestCmd {
+			return nil
+		}
+	}
+
+	cmd.SilenceUsage = true
+	return fmt.Errorf("command not supported on remote contexts: %s (context: %s)", cmd.CommandPath(), remoteContextName())
+}
+
+// Returns the REST API client for the selected context.
+func remoteClient() (*ApiV2Client.Client, error) {
+	ctx, err := HosterCliContext.Get(remoteContextName())
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.Client()
+}
@@ -0,0 +1,237 @@
+//go:build freebsd
+// +build freebsd
+
+package cmd
+
+import (
+	"HosterCore/internal/pkg/emojlog"
+	HosterTables "HosterCore/internal/pkg/hoster/cli_tables"
+	HosterFleet "HosterCore/internal/pkg/hoster/fleet"
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fleetNodes     []string
+	fleetJson      bool
+	fleetUnixStyle bool
+
+	fleetCmd = &cobra.Command{
+		Use:   "fleet",
+		Short: "Multi-node view of the whole Hoster fleet",
+		Long: "Query all saved contexts (or the ones selected with --node) in parallel, using their REST API.\n" +
+			"Nodes that can't be reached are reported, but don't prevent the rest of the fleet from being shown.",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+)
+
+var (
+	fleetVmsFilters []string
+	fleetVmsSort    string
+
+	fleetVmsCmd = &cobra.Command{
+		Use:   "vms",
+		Short: "List VMs running on all nodes",
+		Long:  "List VMs from all nodes in a single table, with a node column.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := fleetVms()
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+var (
+	fleetJailsFilters []string
+	fleetJailsSort    string
+
+	fleetJailsCmd = &cobra.Command{
+		Use:   "jails",
+		Short: "List Jails running on all nodes",
+		Long:  "List Jails from all nodes in a single table, with a node column.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := fleetJails()
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+var (
+	fleetHostsCmd = &cobra.Command{
+		Use:   "hosts",
+		Short: "Show the capacity summary for all nodes",
+		Long:  "Show the capacity summary (VMs, CPUs, RAM, storage, health) for all nodes, and the fleet totals.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := fleetHosts()
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+var (
+	fleetFailedJobsCmd = &cobra.Command{
+		Use:   "failed-jobs",
+		Short: "List failed scheduler jobs on all nodes",
+		Long:  "List failed scheduler jobs (snapshots, replications, etc) on all nodes, newest first.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := fleetFailedJobs()
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+var (
+	fleetLocateCmd = &cobra.Command{
+		Use:   "locate [vmName]",
+		Short: "Find the nodes holding the primary and backup copies of a VM",
+		Long:  "Find the nodes holding the primary (active) and backup (replicated) copies of a VM.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := fleetLocate(args[0])
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func fleetVms() error {
+	query, err := HosterListQuery.ParseFilters(fleetVmsFilters, fleetVmsSort)
+	if err != nil {
+		return err
+	}
+	nodes, err := HosterFleet.Nodes(fleetNodes)
+	if err != nil {
+		return err
+	}
+
+	vms, errs, err := HosterFleet.ListVms(nodes, query)
+	if err != nil {
+		return err
+	}
+	if fleetJson {
+		return printFleetJson(vms, errs)
+	}
+
+	HosterTables.RenderFleetVmsTable(fleetUnixStyle, vms)
+	return reportFleetErrors(errs, len(nodes))
+}
+
+func fleetJails() error {
+	query, err := HosterListQuery.ParseFilters(fleetJailsFilters, fleetJailsSort)
+	if err != nil {
+		return err
+	}
+	nodes, err := HosterFleet.Nodes(fleetNodes)
+	if err != nil {
+		return err
+	}
+
+	jails, errs, err := HosterFleet.ListJails(nodes, query)
+	if err != nil {
+		return err
+	}
+	if fleetJson {
+		return printFleetJson(jails, errs)
+	}
+
+	HosterTables.RenderFleetJailsTable(fleetUnixStyle, jails)
+	return reportFleetErrors(errs, len(nodes))
+}
+
+func fleetHosts() error {
+	nodes, err := HosterFleet.Nodes(fleetNodes)
+	if err != nil {
+		return err
+	}
+
+	hosts, errs := HosterFleet.ListHosts(nodes)
+	if fleetJson {
+		return printFleetJson(hosts, errs)
+	}
+
+	HosterTables.RenderFleetHostsTable(fleetUnixStyle, hosts)
+	return reportFleetErrors(errs, len(nodes))
+}
+
+func fleetFailedJobs() error {
+	nodes, err := HosterFleet.Nodes(fleetNodes)
+	if err != nil {
+		return err
+	}
+
+	jobs, errs := HosterFleet.ListFailedJobs(nodes)
+	if fleetJson {
+		return printFleetJson(jobs, errs)
+	}
+
+	HosterTables.RenderFleetFailedJobsTable(fleetUnixStyle, jobs)
+	return reportFleetErrors(errs, len(nodes))
+}
+
+func fleetLocate(vmName string) error {
+	nodes, err := HosterFleet.Nodes(fleetNodes)
+	if err != nil {
+		return err
+	}
+
+	locations, errs := HosterFleet.LocateVm(nodes, vmName)
+	if fleetJson {
+		return printFleetJson(locations, errs)
+	}
+
+	if len(locations) < 1 && len(errs) < len(nodes) {
+		emojlog.PrintLogMessage("VM was not found on any of the reachable nodes: "+vmName, emojlog.Warning)
+	} else {
+		HosterTables.RenderFleetVmLocationsTable(fleetUnixStyle, locations)
+	}
+	return reportFleetErrors(errs, len(nodes))
+}
+
+func printFleetJson(items interface{}, errs []HosterFleet.NodeError) error {
+	out, err := json.MarshalIndent(struct {
+		Items  interface{}             `json:"items"`
+		Errors []HosterFleet.NodeError `json:"errors"`
+	}{items, errs}, "", "   ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+	return nil
+}
+
+// Unreachable nodes are reported as warnings, the command only fails if none of the nodes could be reached
+func reportFleetErrors(errs []HosterFleet.NodeError, nodes int) error {
+	for _, v := range errs {
+		emojlog.PrintLogMessage(v.Node+": "+v.Error, emojlog.Warning)
+	}
+
+	if len(errs) > 0 && len(errs) == nodes {
+		return errors.New("none of the fleet nodes could be reached")
+	}
+	return nil
+}
@@ -4,8 +4,11 @@
 package cmd
 
 import (
+	"HosterCore/internal/pkg/byteconversion"
+	DiskImage "HosterCore/internal/pkg/disk_image"
 	"HosterCore/internal/pkg/emojlog"
 	HosterHost "HosterCore/internal/pkg/hoster/host"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
 	"archive/zip"
 	"encoding/json"
 	"errors"
@@ -56,13 +59,11 @@ var (
 			}
 
 			if len(imageDataset) < 1 {
-				hostCfg, err := HosterHost.GetHostConfig()
+				imageDataset, err = defaultImageDataset()
 				if err != nil {
 					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
 					os.Exit(1)
 				}
-
-				imageDataset = hostCfg.ActiveZfsDatasets[0]
 			}
 
 			err = imageUnzip(imageDataset, args[0])
@@ -74,32 +75,127 @@ var (
 	}
 )
 
-func imageUnzip(imageDataset string, imageOsType string) error {
-	emojlog.PrintLogMessage("Initiating image 'unzip' process", emojlog.Info)
+var (
+	imageImportDataset string
+
+	imageImportCmd = &cobra.Command{
+		Use:   "import [osType] [imageFile]",
+		Short: "Import a qcow2, VMDK, VHDX or raw disk image as a VM template",
+		Long: "Convert a qcow2 (including the zlib compressed clusters and backing files), VMDK (monolithic, split or stream-optimized) or VHDX disk image\n" +
+			"into the sparse raw template image: template-[osType]/disk0.img. The existing template image is only replaced after a successful conversion.",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
 
+			var err error
+			if len(imageImportDataset) < 1 {
+				imageImportDataset, err = defaultImageDataset()
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+			}
+
+			err = imageImport(imageImportDataset, args[0], args[1])
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func imageImport(imageDataset string, imageOsType string, imageFile string) error {
+	err := HosterVmUtils.ValidateOsType(imageOsType)
+	if err != nil {
+		return err
+	}
+
+	img, err := DiskImage.Open(imageFile)
+	if err != nil {
+		return err
+	}
+	defer img.Close()
+	emojlog.PrintLogMessage("Detected "+img.Format()+" disk image, virtual size: "+byteconversion.BytesToHuman(uint64(img.Size())), emojlog.Info)
+
+	templateFolder, err := imageTemplateFolder(imageDataset, imageOsType)
+	if err != nil {
+		return err
+	}
+
+	bar := diskImageProgressBar(img.Size(), " 📤 Converting the disk image || "+imageFile+" || "+templateFolder+"/disk0.img")
+	err = HosterVmUtils.ImportDiskImage(img, templateFolder+"/disk0.img", bar)
+	if err != nil {
+		return err
+	}
+	bar.Finish()
+	time.Sleep(time.Millisecond * 250)
+	fmt.Println()
+
+	emojlog.PrintLogMessage("Process finished for: template-"+imageOsType, emojlog.Changed)
+	return nil
+}
+
+func diskImageProgressBar(size int64, description string) *progressbar.ProgressBar {
+	return progressbar.NewOptions64(
+		size,
+		progressbar.OptionSetRenderBlankState(true),
+		progressbar.OptionEnableColorCodes(true),
+		progressbar.OptionShowBytes(true),
+		progressbar.OptionFullWidth(),
+		progressbar.OptionSetDescription(description),
+	)
+}
+
+// Creates the template dataset (if it doesn't exist yet), and returns its mountpoint.
+// Returns the first active ZFS dataset, which is used if the dataset wasn't set explicitly
+func defaultImageDataset() (string, error) {
+	hostCfg, err := HosterHost.GetHostConfig()
+	if err != nil {
+		return "", err
+	}
+	if len(hostCfg.ActiveZfsDatasets) < 1 {
+		return "", errors.New("there are no active ZFS datasets in the host config, use --dataset to set one")
+	}
+
+	return hostCfg.ActiveZfsDatasets[0], nil
+}
+
+func imageTemplateFolder(imageDataset string, imageOsType string) (string, error) {
 	// Load host config
 	hostConfig, err := HosterHost.GetHostConfig()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if !slices.Contains(hostConfig.ActiveZfsDatasets, imageDataset) {
-		return errors.New("dataset is not being used for VMs or doesn't exist")
+		return "", errors.New("dataset is not being used for VMs or doesn't exist")
 	}
 
 	_, err = os.Stat("/" + imageDataset + "/")
 	if err != nil {
-		return errors.New("dataset doesn't exist, or is not mounted")
+		return "", errors.New("dataset doesn't exist, or is not mounted")
 	}
 	_, err = os.Stat("/" + imageDataset + "/template-" + imageOsType)
 	if err != nil {
 		emojlog.PrintLogMessage("Created new image template dataset: "+imageDataset+"/template-"+imageOsType, emojlog.Debug)
 		out, err := exec.Command("zfs", "create", imageDataset+"/template-"+imageOsType).CombinedOutput()
 		if err != nil {
-			return errors.New("could not run zfs create: " + string(out))
+			return "", errors.New("could not run zfs create: " + string(out))
 		}
 	}
 
+	return "/" + imageDataset + "/template-" + imageOsType, nil
+}
+
+func imageUnzip(imageDataset string, imageOsType string) error {
+	emojlog.PrintLogMessage("Initiating image 'unzip' process", emojlog.Info)
+
+	_, err := imageTemplateFolder(imageDataset, imageOsType)
+	if err != nil {
+		return err
+	}
+
 	_, diskErr := os.Stat("/" + imageDataset + "/template-" + imageOsType + "/disk0.img")
 	if diskErr == nil {
 		emojlog.PrintLogMessage("Removed old disk image here: /"+imageDataset+"/template-"+imageOsType+"/disk0.img", emojlog.Debug)
@@ -26,11 +26,20 @@ var (
 
 var (
 	jailStartCmd = &cobra.Command{
-		Use:   "start [jailName]",
-		Short: "Start a specific Jail",
-		Long:  `Start a specific Jail using it's name`,
-		Args:  cobra.ExactArgs(1),
+		Use:         "start [jailName]",
+		Short:       "Start a specific Jail",
+		Long:        `Start a specific Jail using it's name`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteJailStart(args[0])
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			err := HosterJail.Start(args[0])
@@ -64,11 +73,20 @@ var (
 
 var (
 	jailStopCmd = &cobra.Command{
-		Use:   "stop [jailName]",
-		Short: "Stop a specific Jail",
-		Long:  `Stop a specific Jail using it's name`,
-		Args:  cobra.ExactArgs(1),
+		Use:         "stop [jailName]",
+		Short:       "Stop a specific Jail",
+		Long:        `Stop a specific Jail using it's name`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteJailStop(args[0])
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			err := HosterJail.Stop(args[0])
@@ -103,11 +121,20 @@ var (
 	jailListCmdUnixStyle bool
 
 	jailListCmd = &cobra.Command{
-		Use:   "list",
-		Short: "List all available Jails in a single table",
-		Long:  `List all available Jails in a single table.`,
-		Args:  cobra.NoArgs,
+		Use:         "list",
+		Short:       "List all available Jails in a single table",
+		Long:        `List all available Jails in a single table.`,
+		Args:        cobra.NoArgs,
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteJailList(jailListCmdUnixStyle)
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			err := HosterTables.GenerateJailsTable(jailListCmdUnixStyle)
@@ -8,6 +8,7 @@ import (
 	"os"
 
 	HosterTables "HosterCore/internal/pkg/hoster/cli_tables"
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
 
 	"github.com/spf13/cobra"
 )
@@ -16,12 +17,14 @@ var rootCmd = &cobra.Command{
 	Use:   "hoster",
 	Short: "HosterCore is a highly opinionated Bhyve automation platform written in Go",
 
+	PersistentPreRunE: checkRemoteSupport,
+
 	Run: func(cmd *cobra.Command, args []string) {
 		checkInitFile()
 		HosterTables.GenerateHostInfoTable(false)
 		printZfsDatasetInfo()
 		printNetworkInfoTable()
-		HosterTables.GenerateVMsTable(false)
+		HosterTables.GenerateVMsTable(false, HosterListQuery.Query{})
 		HosterTables.GenerateJailsTable(false)
 	},
 }
@@ -34,6 +37,36 @@ func Execute() {
 }
 
 func init() {
+	// Remote node (context) selection, only works for the commands annotated with remoteSupported (see checkRemoteSupport)
+	rootCmd.PersistentFlags().StringVarP(&cliContextName, "context", "", "", "Execute the command on a remote node, using one of the saved contexts (see `hoster context`)")
+	rootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextAddCmd)
+	contextAddCmd.Flags().StringVarP(&contextAddEndpoint, "endpoint", "e", "", "REST API endpoint, e.g. `https://10.0.0.5:3000`")
+	contextAddCmd.Flags().StringVarP(&contextAddUser, "user", "u", "", "REST API user")
+	contextAddCmd.Flags().StringVarP(&contextAddPassword, "password", "p", "", "REST API password")
+	contextAddCmd.Flags().StringVarP(&contextAddToken, "token", "t", "", "REST API bearer token (used instead of the user and password)")
+	contextAddCmd.Flags().StringVarP(&contextAddCACert, "ca-cert", "", "", "CA certificate file used to verify the endpoint")
+	contextAddCmd.Flags().BoolVarP(&contextAddInsecure, "insecure", "", false, "Skip the TLS certificate verification")
+	contextAddCmd.MarkFlagRequired("endpoint")
+	contextCmd.AddCommand(contextListCmd)
+	contextListCmd.Flags().BoolVarP(&contextListUnixStyle, "unix-style", "u", false, "Show Unix style table (useful for scripting)")
+	contextCmd.AddCommand(contextRemoveCmd)
+
+	// Fleet (multi-node) command section
+	rootCmd.AddCommand(fleetCmd)
+	fleetCmd.PersistentFlags().StringSliceVarP(&fleetNodes, "node", "n", []string{}, "Only query these nodes (context names), all saved contexts are used by default")
+	fleetCmd.PersistentFlags().BoolVarP(&fleetJson, "json", "j", false, "Output as JSON (useful for automation)")
+	fleetCmd.PersistentFlags().BoolVarP(&fleetUnixStyle, "unix-style", "u", false, "Show Unix style table (useful for scripting)")
+	fleetCmd.AddCommand(fleetVmsCmd)
+	fleetVmsCmd.Flags().StringSliceVarP(&fleetVmsFilters, "filter", "f", []string{}, "Filter the list on each node, e.g. --filter tag=web,running=true (keys: tag, running, production, backup, owner, dataset, name)")
+	fleetVmsCmd.Flags().StringVarP(&fleetVmsSort, "sort", "", "", "Comma separated list of fields to sort the whole fleet list by, e.g. -running,name")
+	fleetCmd.AddCommand(fleetJailsCmd)
+	fleetJailsCmd.Flags().StringSliceVarP(&fleetJailsFilters, "filter", "f", []string{}, "Filter the list on each node, e.g. --filter tag=web,running=true (keys: tag, running, production, backup, owner, dataset, name)")
+	fleetJailsCmd.Flags().StringVarP(&fleetJailsSort, "sort", "", "", "Comma separated list of fields to sort the whole fleet list by, e.g. -running,name")
+	fleetCmd.AddCommand(fleetHostsCmd)
+	fleetCmd.AddCommand(fleetFailedJobsCmd)
+	fleetCmd.AddCommand(fleetLocateCmd)
+
 	// Host Command Section
 	rootCmd.AddCommand(hostCmd)
 	hostCmd.Flags().BoolVarP(&jsonHostInfoOutput, "json", "j", false, "Output as JSON (useful for automation)")
@@ -89,6 +122,23 @@ func init() {
 	schedulerSnapshotAllCmd.Flags().StringVarP(&schedulerSnapshotAllType, "type", "t", "custom", "Snapshot type: custom, frequent, hourly, daily, weekly, monthly, yearly")
 	schedulerSnapshotAllCmd.Flags().IntVarP(&schedulerSnapshotAllToKeep, "keep", "k", 5, "How many snapshots to keep")
 
+	// Bulk cmd
+	rootCmd.AddCommand(bulkCmd)
+	bulkCmd.Flags().StringSliceVarP(&bulkTags, "tag", "t", []string{}, "Select resources by tag (all tags must match)")
+	bulkCmd.Flags().StringSliceVarP(&bulkNames, "name", "n", []string{}, "Select resources by name or glob pattern, e.g. web-*")
+	bulkCmd.Flags().StringVarP(&bulkOwner, "owner", "o", "", "Select resources by owner")
+	bulkCmd.Flags().StringVarP(&bulkProduction, "production", "", "", "Select resources by production flag (true or false)")
+	bulkCmd.Flags().StringVarP(&bulkResourceType, "type", "", "", "Select only VMs (vm) or Jails (jail)")
+	bulkCmd.Flags().StringVarP(&bulkSetTag, "set-tag", "", "", "Tag to add or remove (add-tag and remove-tag actions)")
+	bulkCmd.Flags().IntVarP(&bulkSnapshotKeep, "keep", "k", 5, "How many snapshots to keep (snapshot action)")
+	bulkCmd.Flags().StringVarP(&bulkSnapshotType, "snapshot-type", "", "custom", "Snapshot type: custom, frequent, hourly, daily, weekly, monthly, yearly (snapshot action)")
+	bulkCmd.Flags().StringVarP(&bulkSshEndpoint, "endpoint", "e", "", "SSH endpoint to send the replicated data to (replicate action)")
+	bulkCmd.Flags().StringVarP(&bulkSshKey, "key", "", "/root/.ssh/id_rsa", "SSH key location (replicate action)")
+	bulkCmd.Flags().IntVarP(&bulkSshPort, "port", "p", 22, "Endpoint SSH port (replicate action)")
+	bulkCmd.Flags().IntVarP(&bulkSpeedLimit, "speed-limit", "s", 50, "Replication speed limit (replicate action)")
+	bulkCmd.Flags().BoolVarP(&bulkDryRun, "dry-run", "", false, "Only print the list of selected resources")
+	bulkCmd.Flags().BoolVarP(&bulkYes, "yes", "y", false, "Don't ask for a confirmation")
+
 	// HA
 	rootCmd.AddCommand(carpHaCmd)
 	// HA -> start
@@ -150,6 +200,8 @@ func init() {
 	vmListCmd.Flags().BoolVarP(&jsonOutputVm, "json", "j", false, "Output as JSON (useful for automation)")
 	vmListCmd.Flags().BoolVarP(&jsonPrettyOutputVm, "json-pretty", "", false, "Pretty JSON Output")
 	vmListCmd.Flags().BoolVarP(&tableUnixOutputVm, "unix-style", "u", false, "Show Unix style table (useful for scripting)")
+	vmListCmd.Flags().StringSliceVarP(&vmListFilters, "filter", "f", []string{}, "Filter the list, e.g. --filter tag=web --filter running=true,name=web-* (keys: tag, running, production, backup, owner, dataset, name)")
+	vmListCmd.Flags().StringVarP(&vmListSort, "sort", "", "", "Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name")
 
 	// VM cmd -> info
 	vmCmd.AddCommand(vmInfoCmd)
@@ -190,6 +242,52 @@ func init() {
 	vmDiskExpandCmd.Flags().IntVarP(&expansionSize, "size", "s", 10, "How much size to add, in Gb")
 	vmDiskCmd.AddCommand(vmDiskAddCmd)
 	vmDiskAddCmd.Flags().IntVarP(&vmDiskAddSize, "size", "s", 10, "Initial size of the image, in Gb")
+	vmDiskAddCmd.Flags().BoolVarP(&vmDiskAddZvol, "zvol", "", false, "Create a ZFS volume (zvol) under the VM dataset, instead of the image file")
+	vmDiskAddCmd.Flags().StringVarP(&vmDiskAddVolBlockSize, "volblocksize", "", "", "zvol block size, e.g. 16K (ZFS default is used if not set)")
+	vmDiskAddCmd.Flags().StringVarP(&vmDiskAddCompression, "compression", "", "", "zvol compression, e.g. lz4, zstd or off (inherited from the parent dataset if not set)")
+	vmDiskAddCmd.Flags().BoolVarP(&vmDiskAddSparse, "sparse", "", false, "Create a sparse (thin provisioned) zvol")
+	vmDiskAddCmd.Flags().StringVarP(&vmDiskAddFrom, "from", "", "", "Populate the new disk from a qcow2, VMDK, VHDX or raw disk image")
+	vmDiskCmd.AddCommand(vmDiskConvertCmd)
+	vmDiskConvertCmd.Flags().StringVarP(&vmDiskConvertImage, "image", "i", "disk0.img", "Disk image name (or zvol name), which should be converted")
+	vmDiskConvertCmd.Flags().StringVarP(&vmDiskConvertTo, "to", "t", "zvol", "Conversion target: zvol or file")
+	vmDiskConvertCmd.Flags().StringVarP(&vmDiskConvertVolBlockSize, "volblocksize", "", "", "zvol block size, e.g. 16K (ZFS default is used if not set)")
+	vmDiskConvertCmd.Flags().StringVarP(&vmDiskConvertCompression, "compression", "", "", "zvol compression, e.g. lz4, zstd or off (inherited from the parent dataset if not set)")
+	vmDiskConvertCmd.Flags().BoolVarP(&vmDiskConvertSparse, "sparse", "", false, "Create a sparse (thin provisioned) zvol")
+	vmDiskConvertCmd.Flags().BoolVarP(&vmDiskConvertKeepSource, "keep-source", "", false, "Keep the source image file (or zvol) after the conversion")
+	vmDiskCmd.AddCommand(vmDiskDetachCmd)
+	vmDiskDetachCmd.Flags().StringVarP(&vmDiskDetachImage, "image", "i", "", "Disk image name (or zvol name), which should be detached")
+	vmDiskDetachCmd.MarkFlagRequired("image")
+	vmDiskCmd.AddCommand(vmDiskRemoveCmd)
+	vmDiskRemoveCmd.Flags().StringVarP(&vmDiskRemoveImage, "image", "i", "", "Disk image name (or zvol name), which should be removed")
+	vmDiskRemoveCmd.MarkFlagRequired("image")
+	vmDiskRemoveCmd.Flags().BoolVarP(&vmDiskRemoveYes, "yes", "y", false, "Don't ask for a confirmation")
+	vmDiskCmd.AddCommand(vmDiskReorderCmd)
+	vmDiskReorderCmd.Flags().StringSliceVarP(&vmDiskReorderOrder, "order", "o", []string{}, "Comma separated list of disk images, the first one becomes the boot disk (e.g. disk1.img,disk0.img)")
+	vmDiskReorderCmd.MarkFlagRequired("order")
+
+	// VM cmd -> network interfaces
+	vmCmd.AddCommand(vmNicCmd)
+	vmNicCmd.AddCommand(vmNicListCmd)
+	vmNicCmd.AddCommand(vmNicAddCmd)
+	vmNicAddCmd.Flags().StringVarP(&vmNicAddNetwork, "network", "n", "", "Network name (check `hoster network list`)")
+	vmNicAddCmd.MarkFlagRequired("network")
+	vmNicAddCmd.Flags().StringVarP(&vmNicAddDriver, "driver", "d", "virtio-net", "Network driver: virtio-net or e1000")
+	vmNicAddCmd.Flags().StringVarP(&vmNicAddMac, "mac", "", "", "MAC address (generated if not set)")
+	vmNicAddCmd.Flags().StringVarP(&vmNicAddIp, "ip", "", "", "IP address (allocated from the network range if not set)")
+	vmNicAddCmd.Flags().IntVarP(&vmNicAddVlan, "vlan", "", 0, "802.1Q VLAN tag (1-4094), the traffic is untagged if not set")
+	vmNicAddCmd.Flags().StringVarP(&vmNicAddComment, "comment", "c", "", "Network interface comment")
+	vmNicCmd.AddCommand(vmNicRemoveCmd)
+	vmNicRemoveCmd.Flags().IntVarP(&vmNicRemoveIndex, "nic", "", 0, "Network interface index (check `hoster vm nic list`)")
+	vmNicRemoveCmd.MarkFlagRequired("nic")
+	vmNicCmd.AddCommand(vmNicSetCmd)
+	vmNicSetCmd.Flags().IntVarP(&vmNicSetIndex, "nic", "", 0, "Network interface index (check `hoster vm nic list`)")
+	vmNicSetCmd.Flags().StringVarP(&vmNicSetNetwork, "network", "n", "", "Move the interface to another network")
+	vmNicSetCmd.Flags().StringVarP(&vmNicSetDriver, "driver", "d", "", "Network driver: virtio-net or e1000")
+	vmNicSetCmd.Flags().StringVarP(&vmNicSetMac, "mac", "", "", "Set the MAC address")
+	vmNicSetCmd.Flags().BoolVarP(&vmNicSetRegenerateMac, "regenerate-mac", "", false, "Generate a new random MAC address")
+	vmNicSetCmd.Flags().StringVarP(&vmNicSetIp, "ip", "", "", "Set the IP address")
+	vmNicSetCmd.Flags().IntVarP(&vmNicSetVlan, "vlan", "", 0, "802.1Q VLAN tag (1-4094), 0 removes the tag")
+	vmNicSetCmd.Flags().StringVarP(&vmNicSetComment, "comment", "c", "", "Network interface comment")
 
 	// VM cmd -> connect to the serial console
 	vmCmd.AddCommand(vmSerialConsoleCmd)
@@ -197,6 +295,20 @@ func init() {
 	// VM cmd -> vm destroy
 	vmCmd.AddCommand(vmDestroyCmd)
 
+	// VM cmd -> vm rename
+	vmCmd.AddCommand(vmRenameCmd)
+
+	// VM cmd -> vm export/import
+	vmCmd.AddCommand(vmExportCmd)
+	vmExportCmd.Flags().StringVarP(&vmExportTo, "to", "t", "", "Archive file location, e.g. /tank/exports/vm-1.tar.zst (- writes the archive into stdout)")
+	vmExportCmd.MarkFlagRequired("to")
+	vmCmd.AddCommand(vmImportCmd)
+	vmImportCmd.Flags().StringVarP(&vmImportName, "name", "n", "", "New VM name (the exported VM name is used by default)")
+	vmImportCmd.Flags().StringVarP(&vmImportDataset, "dataset", "d", "", "Target dataset (first available dataset will be chosen as default)")
+	vmImportCmd.Flags().StringVarP(&vmImportNetwork, "network", "", "", "Move all network interfaces to this network (by default they keep their network)")
+	vmImportCmd.Flags().BoolVarP(&vmImportRegenerateMac, "regenerate-mac", "", false, "Always generate new MAC addresses (e.g. if the source VM is still running on the same network)")
+	vmImportCmd.Flags().BoolVarP(&vmImportResetIid, "reset-instance-id", "", false, "Reset the cloud-init instance-id if the primary NIC address changes (regenerates the SSH host keys, and applies the user-data again)")
+
 	// VM cmd -> vm deploy
 	vmCmd.AddCommand(vmDeployCmd)
 	vmDeployCmd.Flags().StringVarP(&vmDeployCmdVmName, "name", "n", "test-vm", "Set the VM name (automatically generated if left empty)")
@@ -211,6 +323,23 @@ func init() {
 	vmDeployCmd.Flags().StringVarP(&vmDeployCmdFromIso, "from-iso", "", "", "Deploy this VM using an ISO file (e.g. `/root/custom_os.iso`)")
 	// vmDeployCmd.Flags().StringVarP(&vmDeployCmdIsoFilePath, "path-to-iso", "", "", "Path to the ISO file")
 
+	// VM cmd -> vm apply
+	vmCmd.AddCommand(vmApplyCmd)
+	vmApplyCmd.Flags().StringVarP(&vmApplyFile, "file", "f", "", "VM spec file (YAML or JSON)")
+	vmApplyCmd.MarkFlagRequired("file")
+	vmApplyCmd.Flags().BoolVarP(&vmApplyDryRun, "dry-run", "", false, "Only show the plan, without applying it")
+	vmApplyCmd.Flags().BoolVarP(&vmApplyJson, "json", "j", false, "Output the plan as JSON")
+	vmApplyCmd.Flags().BoolVarP(&vmApplyYes, "yes", "y", false, "Apply the plan without asking for a confirmation")
+
+	// VM cmd -> vm validate
+	vmCmd.AddCommand(vmValidateCmd)
+	vmValidateCmd.Flags().BoolVarP(&vmValidateAll, "all", "a", false, "Validate all VMs on this node")
+	vmValidateCmd.Flags().BoolVarP(&vmValidateJson, "json", "j", false, "Output the results as JSON")
+
+	// VM cmd -> vm migrate-configs
+	vmCmd.AddCommand(vmMigrateConfigsCmd)
+	vmMigrateConfigsCmd.Flags().BoolVarP(&vmMigrateConfigsDryRun, "dry-run", "", false, "Only show which configs would be migrated, without changing them")
+
 	// VM cmd -> vm cireset
 	vmCmd.AddCommand(vmCiResetCmd)
 	vmCiResetCmd.Flags().StringVarP(&ciResetCmdNewVmName, "new-name", "n", "", "Set a new VM name (if you'd like to rename the VM as well)")
@@ -298,6 +427,8 @@ func init() {
 	rootCmd.AddCommand(imageCmd)
 	imageCmd.AddCommand(imageDownloadCmd)
 	imageDownloadCmd.Flags().StringVarP(&imageDataset, "dataset", "d", "", "Specify the dataset for this particular image (first available dataset is picked otherwise)")
+	imageCmd.AddCommand(imageImportCmd)
+	imageImportCmd.Flags().StringVarP(&imageImportDataset, "dataset", "d", "", "Specify the dataset for this particular image (first available dataset is picked otherwise)")
 
 	// VM cmd -> secrets
 	vmCmd.AddCommand(vmSecretsCmd)
@@ -0,0 +1,274 @@
+//go:build freebsd
+// +build freebsd
+
+package cmd
+
+import (
+	"HosterCore/internal/pkg/emojlog"
+	HosterCliJson "HosterCore/internal/pkg/hoster/cli_json"
+	HosterTables "HosterCore/internal/pkg/hoster/cli_tables"
+	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	ApiV2Client "HosterCore/pkg/api_v2_client"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// The commands below are executed on a remote node (--context flag), using it's REST API v2.
+// The output is rendered using the same tables and JSON formatting as the local commands.
+
+func remoteVmList(unix bool, q HosterListQuery.Query) error {
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	vms, _, err := client.VmList(context.Background(), q.Values())
+	if err != nil {
+		return err
+	}
+
+	HosterTables.RenderVMsTable(unix, HosterVmUtils.ListTableFromApi(vms))
+	return nil
+}
+
+func remoteVmInfo(vmName string) error {
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	vmInfo, err := client.VmInfo(context.Background(), vmName)
+	if err != nil {
+		return err
+	}
+
+	printVmInfoJson(vmInfo)
+	return nil
+}
+
+func remoteVmStart(vmName string, waitForVnc bool, debug bool) error {
+	if debug {
+		return errors.New("--debug-run flag is not supported in the remote mode")
+	}
+
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	if waitForVnc {
+		_, err = client.VmPostStartAndWaitVnc(context.Background(), vmName)
+	} else {
+		_, err = client.VmPostStart(context.Background(), vmName)
+	}
+	if err != nil {
+		return err
+	}
+
+	emojlog.PrintLogMessage("VM start has been initiated: "+vmName, emojlog.Changed)
+	return nil
+}
+
+func remoteVmStop(vmName string, forceStop bool, cleanUp bool) error {
+	if cleanUp {
+		return errors.New("--cleanup flag is not supported in the remote mode")
+	}
+
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	if forceStop {
+		_, err = client.VmPostStopForce(context.Background(), vmName)
+	} else {
+		_, err = client.VmPostStop(context.Background(), vmName)
+	}
+	if err != nil {
+		return err
+	}
+
+	emojlog.PrintLogMessage("VM stop has been initiated: "+vmName, emojlog.Changed)
+	return nil
+}
+
+func remoteJailList(unix bool) error {
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	jails, _, err := client.JailList(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	HosterTables.RenderJailsTable(unix, HosterJailUtils.ExtendedTableFromApi(jails))
+	return nil
+}
+
+func remoteJailStart(jailName string) error {
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.JailStart(context.Background(), jailName)
+	if err != nil {
+		return err
+	}
+
+	emojlog.PrintLogMessage("The Jail is now running: "+jailName, emojlog.Changed)
+	return nil
+}
+
+func remoteJailStop(jailName string) error {
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.JailStop(context.Background(), jailName)
+	if err != nil {
+		return err
+	}
+
+	emojlog.PrintLogMessage("The Jail has been stopped: "+jailName, emojlog.Changed)
+	return nil
+}
+
+func remoteSnapshotList(resName string) error {
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	resType, err := remoteResourceType(client, resName)
+	if err != nil {
+		return err
+	}
+
+	snaps, err := client.SnapshotList(context.Background(), resName)
+	if err != nil {
+		return err
+	}
+
+	renderSnapshotTable(resName, resType, snaps)
+	return nil
+}
+
+func remoteSnapshotNew(resName string, snapshotType string, snapshotsToKeep int) error {
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.SnapshotTakeImmediate(context.Background(), ApiV2Types.SnapshotInput{
+		ResourceName:    resName,
+		SnapshotType:    snapshotType,
+		SnapshotsToKeep: snapshotsToKeep,
+	})
+	if err != nil {
+		return err
+	}
+
+	emojlog.PrintLogMessage("Took a new snapshot for: "+resName, emojlog.Changed)
+	return nil
+}
+
+func remoteSnapshotDestroy(resName string, snapshotName string) error {
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.SnapshotDestroy(context.Background(), ApiV2Types.SnapshotName{ResourceName: resName, SnapshotName: snapshotName})
+	if err != nil {
+		return err
+	}
+
+	emojlog.PrintLogMessage("The snapshot has been destroyed: "+snapshotName, emojlog.Changed)
+	return nil
+}
+
+func remoteSnapshotRollback(resName string, snapshotName string) error {
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.SnapshotRollback(context.Background(), ApiV2Types.SnapshotName{ResourceName: resName, SnapshotName: snapshotName})
+	if err != nil {
+		return err
+	}
+
+	emojlog.PrintLogMessage("Resource has been rolled back to: "+snapshotName, emojlog.Changed)
+	return nil
+}
+
+func remoteSchedulerList(unix bool, json bool, jsonPretty bool) error {
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	jobs, err := client.SchedulerGetJobs(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if json || jsonPretty {
+		return HosterCliJson.PrintSchedulerJson(jobs, jsonPretty)
+	}
+
+	HosterTables.RenderJobsTable(unix, jobs)
+	return nil
+}
+
+func remoteSchedulerInfo(jobID string, jsonPretty bool) error {
+	client, err := remoteClient()
+	if err != nil {
+		return err
+	}
+
+	jobs, err := client.SchedulerGetJobs(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, v := range jobs {
+		if v.JobId == jobID {
+			return HosterCliJson.PrintSchedulerJson(v, jsonPretty)
+		}
+	}
+
+	return fmt.Errorf("job not found: %s", jobID)
+}
+
+// Returns the resource type ("VM" or "Jail"), as shown in the snapshot table.
+func remoteResourceType(client *ApiV2Client.Client, resName string) (string, error) {
+	query := url.Values{"name": []string{resName}}
+
+	vms, _, err := client.VmList(context.Background(), query)
+	if err != nil {
+		return "", err
+	}
+	if len(vms) > 0 {
+		return "VM", nil
+	}
+
+	jails, _, err := client.JailList(context.Background(), query)
+	if err != nil {
+		return "", err
+	}
+	if len(jails) > 0 {
+		return "Jail", nil
+	}
+
+	return "", errors.New("can't find VM/Jail with this name: " + resName)
+}
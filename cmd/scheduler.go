@@ -95,11 +95,20 @@ var (
 	schedulerInfoJsonPretty bool
 
 	schedulerInfoCmd = &cobra.Command{
-		Use:   "info [jobID]",
-		Short: "Show info for one of the scheduled jobs",
-		Long:  "Show a JSON-formatted info for one of the scheduled jobs",
-		Args:  cobra.ExactArgs(1),
+		Use:         "info [jobID]",
+		Short:       "Show info for one of the scheduled jobs",
+		Long:        "Show a JSON-formatted info for one of the scheduled jobs",
+		Args:        cobra.ExactArgs(1),
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteSchedulerInfo(args[0], schedulerInfoJsonPretty)
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			err := HosterCliJson.GenerateSchedulerJobInfo(args[0], schedulerInfoJsonPretty)
@@ -117,11 +126,20 @@ var (
 	schedulerListJsonPretty bool
 
 	schedulerListCmd = &cobra.Command{
-		Use:   "list",
-		Short: "Show a list of scheduled jobs",
-		Long:  "Show a list of scheduled, completed, and in-progress jobs.",
-		Args:  cobra.NoArgs,
+		Use:         "list",
+		Short:       "Show a list of scheduled jobs",
+		Long:        "Show a list of scheduled, completed, and in-progress jobs.",
+		Args:        cobra.NoArgs,
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteSchedulerList(schedulerListUnix, schedulerListJson, schedulerListJsonPretty)
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			if schedulerListJson || schedulerListJsonPretty {
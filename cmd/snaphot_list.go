@@ -25,11 +25,20 @@ var (
 	snapshotListUnixStyleTable bool
 
 	snapshotListCmd = &cobra.Command{
-		Use:   "list [vmName or jailName]",
-		Short: "List VM specific snapshots",
-		Long:  `List VM specific snapshot information including snapshot name, size and time taken`,
-		Args:  cobra.ExactArgs(1),
+		Use:         "list [vmName or jailName]",
+		Short:       "List VM specific snapshots",
+		Long:        `List VM specific snapshot information including snapshot name, size and time taken`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteSnapshotList(args[0])
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			err := generateSnapshotTableNew(args[0])
@@ -70,7 +79,8 @@ func GetSnapshotInfo(vmName string, ignoreVmExistsCheck bool) ([]SnapshotInfo, e
 		return []SnapshotInfo{}, err
 	}
 
-	out, err := exec.Command("zfs", "list", "-rpH", "-t", "snapshot", "-o", "name,used", vmDataset).CombinedOutput()
+	// Depth 1: the snapshots of the zvol disks (children of the VM dataset) are not listed separately
+	out, err := exec.Command("zfs", "list", "-pH", "-d", "1", "-t", "snapshot", "-o", "name,used", vmDataset).CombinedOutput()
 	if err != nil {
 		fmt.Println(string(out))
 		return []SnapshotInfo{}, err
@@ -93,7 +103,49 @@ func GetSnapshotInfo(vmName string, ignoreVmExistsCheck bool) ([]SnapshotInfo, e
 }
 
 func generateSnapshotTableNew(vmName string) error {
-	var ID = 0
+	resFound := false
+	resType := ""
+	vms, _ := HosterVmUtils.ListAllSimple()
+	jails, _ := HosterJailUtils.ListAllSimple()
+
+	for _, v := range vms {
+		if v.VmName == vmName {
+			resFound = true
+			resType = "VM"
+		}
+	}
+	if !resFound {
+		for _, v := range jails {
+			if v.JailName == vmName {
+				resFound = true
+				resType = "Jail"
+			}
+		}
+	}
+
+	if !resFound {
+		return errors.New("can't find VM/Jail with this name: " + vmName)
+	}
+
+	snapList, err := zfsutils.SnapshotListWithDescriptions()
+	if err != nil {
+		return err
+	}
+
+	snaps := []zfsutils.SnapshotInfo{}
+	reMatch := regexp.MustCompile(`/` + vmName + `@`)
+	for _, vv := range snapList {
+		if reMatch.MatchString(vv.Name) {
+			snaps = append(snaps, vv)
+		}
+	}
+
+	renderSnapshotTable(vmName, resType, snaps)
+	return nil
+}
+
+// Used for both the local and the remote (--context) snapshot list
+func renderSnapshotTable(resName string, resType string, snaps []zfsutils.SnapshotInfo) {
 	var t = table.New(os.Stdout)
 	t.SetAlignment(table.AlignRight, //ID
 		table.AlignLeft,   // Resource Name
@@ -145,53 +197,19 @@ func generateSnapshotTableNew(vmName string) error {
 		t.SetHeaderStyle(table.StyleBold)
 	}
 
-	resFound := false
-	resType := ""
-	vms, _ := HosterVmUtils.ListAllSimple()
-	jails, _ := HosterJailUtils.ListAllSimple()
-
-	for _, v := range vms {
-		if v.VmName == vmName {
-			resFound = true
-			resType = "VM"
-		}
-	}
-	if !resFound {
-		for _, v := range jails {
-			if v.JailName == vmName {
-				resFound = true
-				resType = "Jail"
-			}
-		}
-	}
-
-	if !resFound {
-		return errors.New("can't find VM/Jail with this name: " + vmName)
-	}
-
-	snapList, err := zfsutils.SnapshotListWithDescriptions()
-	if err != nil {
-		return err
-	}
-
-	reMatch := regexp.MustCompile(`/` + vmName + `@`)
-	for _, vv := range snapList {
-		if reMatch.MatchString(vv.Name) {
-			ID = ID + 1
-			t.AddRow(
-				strconv.Itoa(ID),
-				vmName,
-				resType,
-				vv.Name,
-				vv.SizeHuman,
-				// fmt.Sprintf("%d", vv.SizeBytes),
-				fmt.Sprintf("%v", vv.Locked),
-				fmt.Sprintf("%d", len(vv.Clones)),
-				vv.Description,
-			)
-		}
+	for i, vv := range snaps {
+		t.AddRow(
+			strconv.Itoa(i+1),
+			resName,
+			resType,
+			vv.Name,
+			vv.SizeHuman,
+			// fmt.Sprintf("%d", vv.SizeBytes),
+			fmt.Sprintf("%v", vv.Locked),
+			fmt.Sprintf("%d", len(vv.Clones)),
+			vv.Description,
+		)
 	}
 
 	t.Render()
-	return nil
 }
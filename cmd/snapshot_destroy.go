@@ -16,11 +16,20 @@ import (
 
 var (
 	snapshotDestroyCmd = &cobra.Command{
-		Use:   "destroy [vmName] [snapshotName]",
-		Short: "Destroy one of the VM's snapshots",
-		Long:  `Destroy one of the VM's snapshots.`,
-		Args:  cobra.ExactArgs(2),
+		Use:         "destroy [vmName] [snapshotName]",
+		Short:       "Destroy one of the VM's snapshots",
+		Long:        `Destroy one of the VM's snapshots.`,
+		Args:        cobra.ExactArgs(2),
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteSnapshotDestroy(args[0], args[1])
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			// err := ZfsSnapshotDestroy(args[0], args[1])
@@ -63,7 +72,7 @@ func ZfsSnapshotDestroy(vmName string, snapshotName string) error {
 		return errors.New("snapshot specified doesn't exist")
 	}
 
-	out, err := exec.Command("zfs", "destroy", snapshotName).CombinedOutput()
+	out, err := exec.Command("zfs", "destroy", "-r", snapshotName).CombinedOutput()
 	if err != nil {
 		return errors.New("something went wrong: " + string(out) + "; exit code: " + err.Error())
 	}
@@ -22,11 +22,20 @@ var (
 	snapshotNewSnapsToKeep int
 
 	snapshotNewCmd = &cobra.Command{
-		Use:   "new [resourceName]",
-		Short: "Create a new snapshot immediately",
-		Long:  `Create a new snapshot immediately.`,
-		Args:  cobra.ExactArgs(1),
+		Use:         "new [resourceName]",
+		Short:       "Create a new snapshot immediately",
+		Long:        `Create a new snapshot immediately.`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteSnapshotNew(args[0], snapshotNewType, snapshotNewSnapsToKeep)
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			_, err := SchedulerClient.AddSnapshotJob(args[0], snapshotNewSnapsToKeep, snapshotNewType, true)
@@ -161,7 +170,8 @@ func getVmSnapshots(vmDataset string) ([]string, error) {
 func takeNewSnapshot(vmDataset string, snapshotType string) error {
 	now := time.Now()
 	timeNow := now.Format("2006-01-02_15-04-05")
-	cmd := exec.Command("zfs", "snapshot", vmDataset+"@"+snapshotType+"_"+timeNow)
+	// Recursive, to include the zvol disks that live under the VM dataset
+	cmd := exec.Command("zfs", "snapshot", "-r", vmDataset+"@"+snapshotType+"_"+timeNow)
 	err := cmd.Run()
 	if err != nil {
 		return errors.New("zfs snapshot exited with an error: " + err.Error())
@@ -204,7 +214,7 @@ func cleanupOldSnapshots(vmSnapshots []string, snapshotType string, snapshotsToK
 	destrSnapCmd1 := "zfs"
 	destrSnapCmd2 := "destroy"
 	for _, v := range result.snapsToDelete {
-		cmd := exec.Command(destrSnapCmd1, destrSnapCmd2, v)
+		cmd := exec.Command(destrSnapCmd1, destrSnapCmd2, "-r", v)
 		stdout, stderr := cmd.CombinedOutput()
 		if stderr != nil {
 			return cleanupOldSnapshotsStruct{}, errors.New("zfs snapshot exited with an error: " + string(stdout))
@@ -8,6 +8,7 @@ import (
 	"HosterCore/internal/pkg/emojlog"
 	HosterVm "HosterCore/internal/pkg/hoster/vm"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	zfsutils "HosterCore/internal/pkg/zfs_utils"
 	"errors"
 	"os"
 	"os/exec"
@@ -20,11 +21,20 @@ var (
 	snapshotRollbackForceStart = false
 
 	snapshotRollbackCmd = &cobra.Command{
-		Use:   "rollback [vmName] [snapshotName]",
-		Short: "Rollback the VM to one of it's previous states",
-		Long:  `Rollback the VM to one of it's previous states.`,
-		Args:  cobra.ExactArgs(2),
+		Use:         "rollback [vmName] [snapshotName]",
+		Short:       "Rollback the VM to one of it's previous states",
+		Long:        `Rollback the VM to one of it's previous states.`,
+		Args:        cobra.ExactArgs(2),
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteSnapshotRollback(args[0], args[1])
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			// err := ZfsSnapshotRollback(args[0], args[1], snapshotRollbackForceStop, snapshotRollbackForceStart)
@@ -81,6 +91,10 @@ func ZfsSnapshotRollback(vmName string, snapshotName string, forceStop bool, for
 	if err != nil {
 		return errors.New("something went wrong: " + string(out) + "; exit code: " + err.Error())
 	}
+	err = zfsutils.RollbackZvolChildren(snapshotName)
+	if err != nil {
+		return err
+	}
 
 	emojlog.PrintLogMessage("VM has been rolled back to: "+snapshotName, emojlog.Changed)
 
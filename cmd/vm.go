@@ -7,6 +7,7 @@ import (
 	"HosterCore/internal/pkg/emojlog"
 	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
 	HosterTables "HosterCore/internal/pkg/hoster/cli_tables"
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
 	HosterVm "HosterCore/internal/pkg/hoster/vm"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
 	"fmt"
@@ -35,11 +36,20 @@ var (
 	vmStartCmdDebug          bool
 
 	vmStartCmd = &cobra.Command{
-		Use:   "start [vmName]",
-		Short: "Start a particular VM using it's name",
-		Long:  `Start a particular VM using it's name`,
-		Args:  cobra.ExactArgs(1),
+		Use:         "start [vmName]",
+		Short:       "Start a particular VM using it's name",
+		Long:        `Start a particular VM using it's name`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteVmStart(args[0], vmStartCmdWaitForVnc, vmStartCmdDebug)
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			err := HosterVm.Start(args[0], vmStartCmdWaitForVnc, vmStartCmdDebug)
@@ -55,11 +65,20 @@ var (
 	vmStopCmdForceStop bool
 	vmStopCmdCleanUp   bool
 	vmStopCmd          = &cobra.Command{
-		Use:   "stop [vmName]",
-		Short: "Stop a particular VM using it's name",
-		Long:  `Stop a particular VM using it's name`,
-		Args:  cobra.ExactArgs(1),
+		Use:         "stop [vmName]",
+		Short:       "Stop a particular VM using it's name",
+		Long:        `Stop a particular VM using it's name`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteVmStop(args[0], vmStopCmdForceStop, vmStopCmdCleanUp)
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			err := HosterVm.Stop(args[0], vmStopCmdForceStop, vmStopCmdCleanUp)
@@ -155,19 +174,51 @@ var (
 	}
 )
 
+var (
+	vmRenameCmd = &cobra.Command{
+		Use:   "rename [oldName] [newName]",
+		Short: "Rename the VM",
+		Long: "Rename the VM: ZFS dataset, VM config paths, cloud-init hostname, cron jobs and DNS records are updated.\n" +
+			"Unlike `cireset --new-name`, the MAC and IP addresses, passwords and SSH keys are kept as is. The VM has to be offline.",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+
+			err := HosterVm.RenameVm(args[0], args[1])
+			if err != nil {
+				emojlog.PrintLogMessage("Could not rename the VM: "+err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			emojlog.PrintLogMessage("VM "+args[0]+" was renamed to "+args[1], emojlog.Changed)
+		},
+	}
+)
+
 var (
 	jsonOutputVm       bool
 	jsonPrettyOutputVm bool
 	tableUnixOutputVm  bool
+	vmListFilters      []string
+	vmListSort         string
 
 	vmListCmd = &cobra.Command{
-		Use:   "list",
-		Short: "VM list",
-		Long:  `VM list in the form of tables, json, or json pretty`,
+		Use:         "list",
+		Short:       "VM list",
+		Long:        `VM list in the form of tables, json, or json pretty`,
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
-			checkInitFile()
+			query, err := HosterListQuery.ParseFilters(vmListFilters, vmListSort)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
 
-			err := HosterTables.GenerateVMsTable(tableUnixOutputVm)
+			if isRemote() {
+				err = remoteVmList(tableUnixOutputVm, query)
+			} else {
+				checkInitFile()
+				err = HosterTables.GenerateVMsTable(tableUnixOutputVm, query)
+			}
 			if err != nil {
 				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
 				os.Exit(1)
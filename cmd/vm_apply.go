@@ -0,0 +1,151 @@
+//go:build freebsd
+// +build freebsd
+
+package cmd
+
+import (
+	"HosterCore/internal/pkg/emojlog"
+	termcolors "HosterCore/internal/pkg/hoster/terminal_colours"
+	HosterVm "HosterCore/internal/pkg/hoster/vm"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	vmApplyFile   string
+	vmApplyDryRun bool
+	vmApplyJson   bool
+	vmApplyYes    bool
+
+	vmApplyCmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Apply the declarative VM spec",
+		Long: "Compare the VM spec (YAML or JSON file) with the current VM state, show the plan as a diff, and apply it once confirmed (or right away with --yes).\n" +
+			"Only the minimal set of changes is applied: deploy, CPU/RAM and other settings, new disks, disk expansion and new network interfaces.\n" +
+			"Re-applying an unchanged spec is a no-op.",
+		Args:        cobra.NoArgs,
+		Annotations: remoteSupported,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := vmApply(vmApplyFile, vmApplyDryRun)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func vmApply(file string, dryRun bool) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	spec, err := HosterVm.ParseVmSpec(data)
+	if err != nil {
+		return err
+	}
+	if vmApplyJson && !dryRun && !vmApplyYes {
+		return errors.New("--json can only be used together with --dry-run or --yes")
+	}
+	if !isRemote() {
+		checkInitFile()
+	}
+
+	// Always show the plan first, the changes are only applied after the confirmation
+	plan, err := vmApplySpec(spec, true)
+	if err != nil {
+		return err
+	}
+	if dryRun || len(plan.Changes) < 1 {
+		return printVmApply(plan)
+	}
+	if !vmApplyJson {
+		printVmApplyResult(plan)
+	}
+	if !vmApplyYes && !confirmAction(fmt.Sprintf("%d change(s) will be applied to %s.", len(plan.Changes), plan.VmName)) {
+		return errors.New("apply has been cancelled")
+	}
+
+	result, err := vmApplySpec(spec, false)
+	if err != nil {
+		return err
+	}
+	if vmApplyJson {
+		return printVmApply(result)
+	}
+	emojlog.PrintLogMessage(fmt.Sprintf("%d change(s) applied", len(result.Changes)), emojlog.Changed)
+	return nil
+}
+
+// Plans (dryRun) or applies the spec, either locally or on the remote node
+func vmApplySpec(spec HosterVm.VmSpec, dryRun bool) (r HosterVm.VmApplyResult, e error) {
+	if isRemote() {
+		client, err := remoteClient()
+		if err != nil {
+			e = err
+			return
+		}
+		return client.VmApply(context.Background(), url.Values{"dry_run": []string{fmt.Sprintf("%v", dryRun)}}, spec)
+	}
+
+	r, e = HosterVm.Apply(spec, dryRun)
+	if e != nil {
+		return
+	}
+	if !dryRun && len(r.Changes) > 0 {
+		_, e = HosterVmUtils.WriteCache()
+	}
+	return
+}
+
+func printVmApply(result HosterVm.VmApplyResult) error {
+	if vmApplyJson {
+		out, err := json.MarshalIndent(result, "", "   ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	printVmApplyResult(result)
+	return nil
+}
+
+func printVmApplyResult(result HosterVm.VmApplyResult) {
+	if len(result.Changes) < 1 {
+		emojlog.PrintLogMessage("VM already matches the spec, nothing to do: "+result.VmName, emojlog.Info)
+		return
+	}
+
+	fmt.Println("VM: " + result.VmName)
+	for _, v := range result.Changes {
+		line := ""
+		switch v.Action {
+		case HosterVm.APPLY_SET, HosterVm.APPLY_EXPAND_DISK:
+			line = fmt.Sprintf("%s  ~ %-22s %s -> %s", termcolors.LIGHT_YELLOW, v.Target, v.Old, v.New)
+		default:
+			line = fmt.Sprintf("%s  + %-22s %s", termcolors.LIGHT_GREEN, v.Target, v.Action+": "+v.New)
+		}
+		if v.RestartRequired {
+			line += " (restart required)"
+		}
+		if v.StopRequired {
+			line += " (requires stop)"
+		}
+		fmt.Println(line + termcolors.NC)
+	}
+
+	if result.DryRun {
+		emojlog.PrintLogMessage(fmt.Sprintf("%d change(s) planned", len(result.Changes)), emojlog.Info)
+	} else {
+		emojlog.PrintLogMessage(fmt.Sprintf("%d change(s) applied", len(result.Changes)), emojlog.Changed)
+	}
+}
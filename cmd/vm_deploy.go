@@ -138,6 +138,11 @@ func deployVmFromIso(vmName string, networkName string, osType string, dsParent
 		return err
 	}
 
+	err = HosterVm.CheckDeployAdmission(HosterVm.VmDeployInput{VmName: vmName, VCpus: cpus, RAM: ram, TargetDataset: dsParent})
+	if err != nil {
+		return err
+	}
+
 	// Set CPU cores and RAM
 	c.Cpus = cpus
 	c.Ram = ram
@@ -4,12 +4,20 @@
 package cmd
 
 import (
+	"HosterCore/internal/pkg/byteconversion"
+	DiskImage "HosterCore/internal/pkg/disk_image"
+	"HosterCore/internal/pkg/emojlog"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"bufio"
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -48,16 +56,34 @@ var (
 )
 
 var (
-	vmDiskAddSize int
+	vmDiskAddSize         int
+	vmDiskAddZvol         bool
+	vmDiskAddVolBlockSize string
+	vmDiskAddCompression  string
+	vmDiskAddSparse       bool
+	vmDiskAddFrom         string
 
 	vmDiskAddCmd = &cobra.Command{
 		Use:   "add [vmName]",
 		Short: "Add a new disk image",
-		Long:  `Add a new disk image to this VM dataset. Can only be done offline due to the fact that bhyve can't hot-reload settings.`,
-		Args:  cobra.ExactArgs(1),
+		Long: "Add a new disk image to this VM dataset. Can only be done offline due to the fact that bhyve can't hot-reload settings.\n" +
+			"Use --zvol to create a ZFS volume under the VM dataset, instead of the image file.\n" +
+			"Use --from to populate the new disk from a qcow2, VMDK, VHDX or raw disk image (the disk gets the image's virtual size, unless a bigger --size is set).",
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			checkInitFile()
-			err := diskAddOffline(args[0], vmDiskAddSize)
+			var err error
+			if len(vmDiskAddFrom) > 0 {
+				size := 0
+				if cmd.Flags().Changed("size") {
+					size = vmDiskAddSize
+				}
+				err = diskAddFromImage(args[0], vmDiskAddFrom, size)
+			} else if vmDiskAddZvol {
+				err = diskAddZvolOffline(args[0], vmDiskAddSize)
+			} else {
+				err = diskAddOffline(args[0], vmDiskAddSize)
+			}
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -65,6 +91,107 @@ var (
 	}
 )
 
+var (
+	vmDiskConvertImage        string
+	vmDiskConvertTo           string
+	vmDiskConvertVolBlockSize string
+	vmDiskConvertCompression  string
+	vmDiskConvertSparse       bool
+	vmDiskConvertKeepSource   bool
+
+	vmDiskConvertCmd = &cobra.Command{
+		Use:   "convert [vmName]",
+		Short: "Convert the VM disk between an image file and a zvol",
+		Long: "Convert the VM disk image file into a ZFS volume (zvol) under the VM dataset (--to zvol), or a zvol back into an image file inside of the VM folder (--to file).\n" +
+			"Can only be done offline. The source is removed after the successful conversion, unless --keep-source is used.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+			opts := HosterVmUtils.ZvolOptions{VolBlockSize: vmDiskConvertVolBlockSize, Compression: vmDiskConvertCompression, Sparse: vmDiskConvertSparse}
+			newImage, err := HosterVmUtils.ConvertVmDisk(args[0], vmDiskConvertImage, vmDiskConvertTo, opts, vmDiskConvertKeepSource)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			emojlog.PrintLogMessage("Disk "+vmDiskConvertImage+" was converted, new disk image: "+newImage, emojlog.Changed)
+		},
+	}
+)
+
+var (
+	vmDiskDetachImage string
+
+	vmDiskDetachCmd = &cobra.Command{
+		Use:   "detach [vmName]",
+		Short: "Detach the disk from the VM",
+		Long:  "Detach the disk from the VM, the disk image (or zvol) itself is kept. Can only be done offline.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+			_, err := HosterVmUtils.DetachVmDisk(context.Background(), args[0], vmDiskDetachImage)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			emojlog.PrintLogMessage("Disk "+vmDiskDetachImage+" was detached from "+args[0]+", the image was kept", emojlog.Changed)
+		},
+	}
+)
+
+var (
+	vmDiskRemoveImage string
+	vmDiskRemoveYes   bool
+
+	vmDiskRemoveCmd = &cobra.Command{
+		Use:   "remove [vmName]",
+		Short: "Detach the disk from the VM, and delete its image",
+		Long: "Detach the disk from the VM, and permanently delete its image file (or zvol). Can only be done offline.\n" +
+			"Fails if any of the VM snapshots (or their clones) still reference the disk data.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+			if !vmDiskRemoveYes && !confirmAction("Disk "+vmDiskRemoveImage+" will be permanently deleted from "+args[0]+".") {
+				emojlog.PrintLogMessage("Disk removal was cancelled", emojlog.Info)
+				os.Exit(1)
+			}
+			err := HosterVmUtils.RemoveVmDisk(context.Background(), args[0], vmDiskRemoveImage)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			emojlog.PrintLogMessage("Disk "+vmDiskRemoveImage+" was removed from "+args[0], emojlog.Changed)
+		},
+	}
+)
+
+var (
+	vmDiskReorderOrder []string
+
+	vmDiskReorderCmd = &cobra.Command{
+		Use:   "reorder [vmName]",
+		Short: "Change the VM disk order",
+		Long: "Move the listed disks to the top of the disk list, in the given order (the rest of the disks keep their relative order).\n" +
+			"The first disk is the boot disk. Takes effect after the next VM start.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+			err := HosterVmUtils.ReorderVmDisks(context.Background(), args[0], vmDiskReorderOrder)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			emojlog.PrintLogMessage("Disk order was changed for "+args[0]+", restart the VM to apply it", emojlog.Changed)
+		},
+	}
+)
+
+// Asks the user to type "yes", returns false for any other input.
+func confirmAction(message string) bool {
+	fmt.Print(message + " Type 'yes' to continue: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(answer) == "yes"
+}
+
 func DiskExpandOffline(vmName string, diskImage string, expansionSize int) error {
 	vm, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
@@ -148,6 +275,67 @@ func diskAddOffline(vmName string, imageSize int) error {
 	return nil
 }
 
+func diskAddZvolOffline(vmName string, volumeSize int) error {
+	vm, err := HosterVmUtils.InfoJsonApi(vmName)
+	if err != nil {
+		return err
+	}
+	if vm.Running {
+		return errors.New("vm has to be offline, due to the fact that bhyve can't hot-reload settings")
+	}
+	if vm.Backup {
+		return errors.New("this vm is a backup")
+	}
+	if volumeSize < 1 {
+		return errors.New("zvol size must be at least 1G")
+	}
+
+	var diskConfig HosterVmUtils.VmDisk
+	diskConfig.DiskType = "nvme"
+	diskConfig.DiskLocation = HosterVmUtils.DISK_LOCATION_ZVOL
+	diskConfig.DiskInputSize = uint64(volumeSize)
+	diskConfig.Comment = "Additional zvol disk"
+	opts := HosterVmUtils.ZvolOptions{VolBlockSize: vmDiskAddVolBlockSize, Compression: vmDiskAddCompression, Sparse: vmDiskAddSparse}
+	if opts != (HosterVmUtils.ZvolOptions{}) {
+		diskConfig.Zvol = &opts
+	}
+
+	return HosterVmUtils.AddNewVmDisk(context.Background(), vmName, diskConfig)
+}
+
+func diskAddFromImage(vmName string, imageFile string, minSize int) error {
+	img, err := DiskImage.Open(imageFile)
+	if err != nil {
+		return err
+	}
+	defer img.Close()
+	emojlog.PrintLogMessage("Detected "+img.Format()+" disk image, virtual size: "+byteconversion.BytesToHuman(uint64(img.Size())), emojlog.Info)
+
+	var diskConfig HosterVmUtils.VmDisk
+	diskConfig.DiskType = "nvme"
+	diskConfig.DiskLocation = "internal"
+	diskConfig.DiskInputSize = uint64(minSize)
+	if vmDiskAddZvol {
+		diskConfig.DiskLocation = HosterVmUtils.DISK_LOCATION_ZVOL
+		opts := HosterVmUtils.ZvolOptions{VolBlockSize: vmDiskAddVolBlockSize, Compression: vmDiskAddCompression, Sparse: vmDiskAddSparse}
+		if opts != (HosterVmUtils.ZvolOptions{}) {
+			diskConfig.Zvol = &opts
+		}
+	}
+
+	bar := diskImageProgressBar(img.Size(), " 📤 Converting the disk image || "+imageFile+" || "+vmName)
+	disk, err := HosterVmUtils.AddVmDiskFromImage(vmName, img, diskConfig, bar)
+	if err != nil {
+		return err
+	}
+	bar.Finish()
+	time.Sleep(time.Millisecond * 250)
+	fmt.Println()
+
+	emojlog.PrintLogMessage("New disk was added to "+vmName+": "+disk.DiskImage, emojlog.Changed)
+	return nil
+}
+
 // Returns true if VM disk image exists. Takes in disk absolute path as a parameter.
 func diskImageExists(diskLocation string) bool {
 	_, err := os.Stat(diskLocation)
@@ -4,8 +4,11 @@
 package cmd
 
 import (
+	AtomicFile "HosterCore/internal/pkg/atomic_file"
 	"HosterCore/internal/pkg/emojlog"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -31,28 +34,79 @@ var (
 	}
 )
 
+// The config is edited in a temporary copy, and is only written back if it's valid JSON,
+// and the original file hasn't been changed by someone else (e.g. the REST API) in the meantime.
 func manuallyEditConfig(vmName string) error {
 	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
 		return fmt.Errorf("can't open your editor: %s", err.Error())
 	}
 
-	vmFolder := vmInfo.Simple.Mountpoint + "/" + vmInfo.Name
-	textEditor := os.Getenv("EDITOR")
+	confLocation := vmInfo.Simple.Mountpoint + "/" + vmInfo.Name + "/" + HosterVmUtils.VM_CONFIG_NAME
+	original, err := os.ReadFile(confLocation)
+	if err != nil {
+		return fmt.Errorf("can't read the VM config: %s", err.Error())
+	}
+	revision := AtomicFile.Revision(original)
+	version, _ := HosterVmUtils.VmConfigVersion(original)
+	err = HosterVmUtils.CheckVmConfigVersion(version)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp("", vmName+"-vm_config-*.json")
+	if err != nil {
+		return fmt.Errorf("can't create a temporary config copy: %s", err.Error())
+	}
+	tmpLocation := tmpFile.Name()
+	_, err = tmpFile.Write(original)
+	tmpFile.Close()
+	if err != nil {
+		os.Remove(tmpLocation)
+		return fmt.Errorf("can't create a temporary config copy: %s", err.Error())
+	}
 
+	textEditor := os.Getenv("EDITOR")
 	if len(textEditor) < 1 {
 		textEditor = "vi"
 	}
 
-	tailCmd := exec.Command(textEditor, vmFolder+"/vm_config.json")
+	tailCmd := exec.Command(textEditor, tmpLocation)
 	tailCmd.Stdin = os.Stdin
 	tailCmd.Stdout = os.Stdout
 	tailCmd.Stderr = os.Stderr
 
 	err = tailCmd.Run()
 	if err != nil {
+		os.Remove(tmpLocation)
 		return fmt.Errorf("can't open your editor: %s", err.Error())
 	}
 
+	edited, err := os.ReadFile(tmpLocation)
+	if err != nil {
+		return fmt.Errorf("can't read the edited config: %s", err.Error())
+	}
+	if bytes.Equal(original, edited) {
+		os.Remove(tmpLocation)
+		emojlog.PrintLogMessage("no changes were made", emojlog.Info)
+		return nil
+	}
+
+	conf := HosterVmUtils.VmConfig{}
+	err = json.Unmarshal(edited, &conf)
+	if err != nil {
+		return fmt.Errorf("edited config is not valid, your changes were saved here: %s (%s)", tmpLocation, err.Error())
+	}
+	err = HosterVmUtils.CheckVmConfigVersion(conf.ConfigVersion)
+	if err != nil {
+		return fmt.Errorf("edited config is not valid, your changes were saved here: %s (%s)", tmpLocation, err.Error())
+	}
+
+	err = AtomicFile.WriteFileIfMatch(confLocation, edited, 0644, revision)
+	if err != nil {
+		return fmt.Errorf("could not save the config, your changes were saved here: %s (%s)", tmpLocation, err.Error())
+	}
+
+	os.Remove(tmpLocation)
 	return nil
 }
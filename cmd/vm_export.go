@@ -0,0 +1,105 @@
+//go:build freebsd
+// +build freebsd
+
+package cmd
+
+import (
+	"HosterCore/internal/pkg/byteconversion"
+	"HosterCore/internal/pkg/emojlog"
+	HosterVm "HosterCore/internal/pkg/hoster/vm"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	vmExportTo string
+
+	vmExportCmd = &cobra.Command{
+		Use:   "export [vmName]",
+		Short: "Export the VM into a portable archive",
+		Long: "Export the VM into a portable tar.zst archive: VM config, disk images (holes are skipped), cloud-init seed, README and a checksum manifest.\n" +
+			"Use `--to -` to write the archive into stdout. The VM has to be offline.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+
+			if vmExportTo == "-" {
+				export, err := HosterVm.NewVmExport(args[0])
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				err = export.Write(os.Stdout)
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
+
+			err := HosterVm.ExportVm(args[0], vmExportTo)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			info, err := os.Stat(vmExportTo)
+			if err == nil {
+				emojlog.PrintLogMessage("VM "+args[0]+" was exported to "+vmExportTo+" ("+byteconversion.BytesToHuman(uint64(info.Size()))+")", emojlog.Changed)
+			}
+		},
+	}
+)
+
+var (
+	vmImportName          string
+	vmImportDataset       string
+	vmImportNetwork       string
+	vmImportRegenerateMac bool
+	vmImportResetIid      bool
+
+	vmImportCmd = &cobra.Command{
+		Use:   "import [archive]",
+		Short: "Import the VM from an export archive",
+		Long: "Recreate the VM from an export archive (use - to read it from stdin). All files are verified against the checksum manifest.\n" +
+			"Parent host and VNC port are reset, network interfaces are moved to the target network, and get new MAC/IP addresses if they conflict with this host.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+
+			var archive io.Reader = os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				defer f.Close()
+				archive = f
+			}
+
+			input := HosterVm.VmImportInput{
+				VmName:          vmImportName,
+				Dataset:         vmImportDataset,
+				Network:         vmImportNetwork,
+				RegenerateMac:   vmImportRegenerateMac,
+				ResetInstanceId: vmImportResetIid,
+			}
+			result, err := HosterVm.ImportVm(archive, input)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+
+			for _, v := range result.Warnings {
+				emojlog.PrintLogMessage(v, emojlog.Warning)
+			}
+			for i, v := range result.Networks {
+				emojlog.PrintLogMessage("NIC "+strconv.Itoa(i)+": "+v.NetworkBridge+", "+v.NetworkMac+", "+v.IPAddress, emojlog.Info)
+			}
+			emojlog.PrintLogMessage("VM "+result.VmName+" was imported into "+result.Dataset+" (VNC port "+strconv.Itoa(result.VncPort)+")", emojlog.Changed)
+		},
+	}
+)
@@ -18,11 +18,20 @@ var (
 	jsonPrettyVmInfo bool
 
 	vmInfoCmd = &cobra.Command{
-		Use:   "info [vmName]",
-		Short: "Print out the VM Info",
-		Long:  `Print out the VM Info.`,
-		Args:  cobra.ExactArgs(1),
+		Use:         "info [vmName]",
+		Short:       "Print out the VM Info",
+		Long:        `Print out the VM Info.`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: remoteSupported,
 		Run: func(cmd *cobra.Command, args []string) {
+			if isRemote() {
+				err := remoteVmInfo(args[0])
+				if err != nil {
+					emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+					os.Exit(1)
+				}
+				return
+			}
 			checkInitFile()
 
 			err := printVmInfo(args[0])
@@ -40,6 +49,12 @@ func printVmInfo(vmName string) error {
 		return err
 	}
 
+	printVmInfoJson(vmInfo)
+	return nil
+}
+
+// Used for both the local and the remote (--context) VM info
+func printVmInfoJson(vmInfo HosterVmUtils.VmApi) {
 	if jsonPrettyVmInfo {
 		jsonPretty, err := json.MarshalIndent(vmInfo, "", "   ")
 		if err != nil {
@@ -53,6 +68,4 @@ func printVmInfo(vmName string) error {
 		}
 		println(string(jsonOutput))
 	}
-
-	return nil
 }
@@ -0,0 +1,130 @@
+//go:build freebsd
+// +build freebsd
+
+package cmd
+
+import (
+	"HosterCore/internal/pkg/emojlog"
+	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aquasecurity/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vmMigrateConfigsDryRun bool
+
+	vmMigrateConfigsCmd = &cobra.Command{
+		Use:   "migrate-configs",
+		Short: "Upgrade all VM configs to the latest schema version",
+		Long: fmt.Sprintf("Upgrade the vm_config.json files on this node to the latest schema version (v%d).\n", HosterVmUtils.VM_CONFIG_VERSION) +
+			"The original configs are kept next to the new ones as vm_config.json.v<old version>.bak.\n" +
+			"Backup VMs (replicated from the other nodes) are skipped, they'll be migrated on their parent host.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+			err := vmMigrateConfigs(vmMigrateConfigsDryRun)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func vmMigrateConfigs(dryRun bool) error {
+	vms, err := HosterVmUtils.ListAllSimple()
+	if err != nil {
+		return err
+	}
+	hostname, _ := FreeBSDsysctls.SysctlKernHostname()
+
+	var t = table.New(os.Stdout)
+	t.SetAlignment(
+		table.AlignLeft,   // VM Name
+		table.AlignCenter, // From
+		table.AlignCenter, // To
+		table.AlignLeft,   // Status
+	)
+	t.AddHeaders(
+		"VM\nName",
+		"Config\nVersion",
+		"Target\nVersion",
+		"Status",
+	)
+	t.SetLineStyle(table.StyleBrightCyan)
+	t.SetDividers(table.UnicodeRoundedDividers)
+	t.SetHeaderStyle(table.StyleBold)
+
+	failed := 0
+	changed := 0
+	for _, v := range vms {
+		confLocation := v.Mountpoint + "/" + v.VmName + "/" + HosterVmUtils.VM_CONFIG_NAME
+		target := fmt.Sprintf("v%d", HosterVmUtils.VM_CONFIG_VERSION)
+
+		data, err := os.ReadFile(confLocation)
+		if err != nil {
+			failed += 1
+			t.AddRow(v.VmName, "-", target, "error: "+err.Error())
+			continue
+		}
+		conf, version, err := HosterVmUtils.MigrateVmConfig(data)
+		if err != nil {
+			failed += 1
+			t.AddRow(v.VmName, fmt.Sprintf("v%d", version), target, "error: "+err.Error())
+			continue
+		}
+		from := fmt.Sprintf("v%d", version)
+
+		if version > HosterVmUtils.VM_CONFIG_VERSION {
+			t.AddRow(v.VmName, from, target, "skipped: newer than supported")
+			continue
+		}
+		if version == HosterVmUtils.VM_CONFIG_VERSION {
+			t.AddRow(v.VmName, from, target, "up to date")
+			continue
+		}
+		// Modifying the backup configs would break the incremental replication from the parent host
+		if conf.ParentHost != hostname {
+			t.AddRow(v.VmName, from, target, "skipped: backup VM (parent "+conf.ParentHost+")")
+			continue
+		}
+
+		pending := strings.Join(HosterVmUtils.PendingVmConfigMigrations(version), "\n")
+		if dryRun {
+			changed += 1
+			t.AddRow(v.VmName, from, target, "would migrate:\n"+pending)
+			continue
+		}
+
+		_, err = HosterVmUtils.MigrateVmConfigFile(confLocation)
+		if err != nil {
+			failed += 1
+			t.AddRow(v.VmName, from, target, "error: "+err.Error())
+			continue
+		}
+		changed += 1
+		t.AddRow(v.VmName, from, target, "migrated:\n"+pending)
+	}
+
+	t.Render()
+
+	if dryRun {
+		emojlog.PrintLogMessage(fmt.Sprintf("Dry run, %d config(s) would be migrated", changed), emojlog.Info)
+	} else if changed > 0 {
+		emojlog.PrintLogMessage(fmt.Sprintf("%d config(s) migrated, the originals were saved as %s.v<N>.bak", changed, HosterVmUtils.VM_CONFIG_NAME), emojlog.Changed)
+		_, err = HosterVmUtils.WriteCache()
+		if err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d config(s) could not be migrated", failed)
+	}
+	return nil
+}
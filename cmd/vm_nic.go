@@ -0,0 +1,181 @@
+//go:build freebsd
+// +build freebsd
+
+package cmd
+
+import (
+	"HosterCore/internal/pkg/emojlog"
+	HosterVm "HosterCore/internal/pkg/hoster/vm"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/aquasecurity/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vmNicCmd = &cobra.Command{
+		Use:   "nic",
+		Short: "VM network interface related commands",
+		Long:  `VM network interface related commands: list, add, remove, set.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+)
+
+var (
+	vmNicListCmd = &cobra.Command{
+		Use:   "list [vmName]",
+		Short: "List the VM network interfaces",
+		Long:  `List the VM network interfaces, the NIC index is used by the other nic commands.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+			vm, err := HosterVmUtils.InfoJsonApi(args[0])
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			printVmNicTable(vm.Networks)
+		},
+	}
+)
+
+var (
+	vmNicAddNetwork string
+	vmNicAddDriver  string
+	vmNicAddMac     string
+	vmNicAddIp      string
+	vmNicAddVlan    int
+	vmNicAddComment string
+
+	vmNicAddCmd = &cobra.Command{
+		Use:   "add [vmName]",
+		Short: "Add a new network interface",
+		Long:  `Add a new network interface to the VM. MAC and IP addresses are generated if not set. Takes effect after the next VM start.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+			input := HosterVmUtils.VmNetwork{
+				NetworkAdaptorType: vmNicAddDriver,
+				NetworkBridge:      vmNicAddNetwork,
+				NetworkMac:         vmNicAddMac,
+				IPAddress:          vmNicAddIp,
+				VlanTag:            vmNicAddVlan,
+				Comment:            vmNicAddComment,
+			}
+			err := HosterVm.AddNewVmNetwork(context.Background(), args[0], input)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			emojlog.PrintLogMessage("New network interface was added to "+args[0]+", restart the VM to apply it", emojlog.Changed)
+		},
+	}
+)
+
+var (
+	vmNicRemoveIndex int
+
+	vmNicRemoveCmd = &cobra.Command{
+		Use:   "remove [vmName]",
+		Short: "Remove the network interface",
+		Long:  `Remove the network interface from the VM, and release its IP address. The VM must be stopped.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+			nic, err := HosterVm.RemoveVmNetwork(context.Background(), args[0], vmNicRemoveIndex)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			emojlog.PrintLogMessage("Network interface "+nic.NetworkMac+" ("+nic.IPAddress+") was removed from "+args[0], emojlog.Changed)
+		},
+	}
+)
+
+var (
+	vmNicSetIndex         int
+	vmNicSetNetwork       string
+	vmNicSetDriver        string
+	vmNicSetMac           string
+	vmNicSetRegenerateMac bool
+	vmNicSetIp            string
+	vmNicSetVlan          int
+	vmNicSetComment       string
+
+	vmNicSetCmd = &cobra.Command{
+		Use:   "set [vmName]",
+		Short: "Change the network interface settings",
+		Long: "Change the network interface settings: network, driver, MAC address, IP address, VLAN tag or comment.\n" +
+			"If the interface is moved to another network, a new IP address is allocated (unless it's set explicitly). Takes effect after the next VM start.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+			update := HosterVm.VmNetworkUpdate{RegenerateMac: vmNicSetRegenerateMac}
+			if cmd.Flags().Changed("network") {
+				update.NetworkBridge = &vmNicSetNetwork
+			}
+			if cmd.Flags().Changed("driver") {
+				update.NetworkAdaptorType = &vmNicSetDriver
+			}
+			if cmd.Flags().Changed("mac") {
+				update.NetworkMac = &vmNicSetMac
+			}
+			if cmd.Flags().Changed("ip") {
+				update.IPAddress = &vmNicSetIp
+			}
+			if cmd.Flags().Changed("vlan") {
+				update.VlanTag = &vmNicSetVlan
+			}
+			if cmd.Flags().Changed("comment") {
+				update.Comment = &vmNicSetComment
+			}
+
+			nic, err := HosterVm.UpdateVmNetwork(context.Background(), args[0], vmNicSetIndex, update)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+			emojlog.PrintLogMessage("Network interface "+strconv.Itoa(vmNicSetIndex)+" was updated ("+nic.NetworkBridge+", "+nic.NetworkMac+", "+nic.IPAddress+"), restart the VM to apply the changes", emojlog.Changed)
+		},
+	}
+)
+
+func printVmNicTable(nics []HosterVmUtils.VmNetwork) {
+	var t = table.New(os.Stdout)
+	t.SetAlignment(
+		table.AlignRight, // Index
+		table.AlignLeft,  // Network
+		table.AlignLeft,  // Driver
+		table.AlignLeft,  // MAC
+		table.AlignLeft,  // IP
+		table.AlignLeft,  // VLAN
+		table.AlignLeft,  // Comment
+	)
+	t.AddHeaders(
+		"Index",
+		"Network",
+		"Driver",
+		"MAC\nAddress",
+		"IP\nAddress",
+		"VLAN",
+		"Comment",
+	)
+	t.SetLineStyle(table.StyleBrightCyan)
+	t.SetDividers(table.UnicodeRoundedDividers)
+	t.SetHeaderStyle(table.StyleBold)
+
+	for i, v := range nics {
+		vlan := "-"
+		if v.VlanTag > 0 {
+			vlan = strconv.Itoa(v.VlanTag)
+		}
+		t.AddRow(strconv.Itoa(i), v.NetworkBridge, v.NetworkAdaptorType, v.NetworkMac, v.IPAddress, vlan, v.Comment)
+	}
+
+	t.Render()
+}
@@ -7,6 +7,7 @@ import (
 	"HosterCore/internal/pkg/byteconversion"
 	"HosterCore/internal/pkg/emojlog"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	zfsutils "HosterCore/internal/pkg/zfs_utils"
 	"bufio"
 	"errors"
 	"fmt"
@@ -147,14 +148,14 @@ func replicateVm(vmName string, replicationEndpoint string, endpointSshPort int,
 	// fmt.Println(snapsToSend)
 
 	if len(remoteVmSnaps) < 1 {
-		err = sendInitialSnapshot(vmDataset, localVmSnaps[0], replicationEndpoint, endpointSshPort, sshKeyLocation, scriptName, speedLimit)
+		err = sendInitialSnapshot(vmDataset, localVmSnaps[0], replicationEndpoint, endpointSshPort, sshKeyLocation, scriptName, speedLimit, false)
 		if err != nil {
 			return err
 		}
 	} else {
 		for i, v := range localVmSnaps {
 			if slices.Contains(snapsToSend, v) {
-				err = sendIncrementalSnapshot(vmDataset, localVmSnaps[i-1], v, replicationEndpoint, endpointSshPort, sshKeyLocation, scriptName, speedLimit)
+				err = sendIncrementalSnapshot(vmDataset, localVmSnaps[i-1], v, replicationEndpoint, endpointSshPort, sshKeyLocation, scriptName, speedLimit, false)
 				if err != nil {
 					return err
 				}
@@ -165,6 +166,11 @@ func replicateVm(vmName string, replicationEndpoint string, endpointSshPort int,
 		}
 	}
 
+	err = replicateVmZvols(vmDataset, replicationEndpoint, endpointSshPort, sshKeyLocation, scriptName, speedLimit)
+	if err != nil {
+		return err
+	}
+
 	if len(remoteVmSnaps) > 0 {
 		emojlog.PrintLogMessage("Replication for "+remoteVmDataset[0]+" is now finished", emojlog.Info)
 	}
@@ -172,6 +178,42 @@ func replicateVm(vmName string, replicationEndpoint string, endpointSshPort int,
 	return nil
 }
 
+// Replicates the zvol disks (child datasets of the VM dataset), must be executed after the VM dataset itself was replicated
+func replicateVmZvols(vmDataset string, replicationEndpoint string, endpointSshPort int, sshKeyLocation string, scriptName string, speedLimit int) error {
+	zfsDatasets, err := getRemoteZfsDatasets(replicationEndpoint, endpointSshPort, sshKeyLocation)
+	if err != nil {
+		return err
+	}
+
+	steps, toRemove, err := zfsutils.ZvolReplicationSteps(vmDataset, zfsDatasets)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range toRemove {
+		sshPort := strconv.Itoa(endpointSshPort)
+		stdout, stderr := exec.Command("ssh", "-oBatchMode=yes", "-i", sshKeyLocation, "-p"+sshPort, replicationEndpoint, "zfs", "destroy", v).CombinedOutput()
+		if stderr != nil {
+			return errors.New("ssh connection error: " + string(stdout))
+		}
+		emojlog.PrintLogMessage("Destroyed an old REMOTE snapshot: "+v, emojlog.Changed)
+	}
+
+	// Properties are sent along, otherwise the remote zvols get the default volmode and GEOM tastes the guest partitions
+	for _, v := range steps {
+		if len(v.From) < 1 {
+			err = sendInitialSnapshot(v.Dataset, v.To, replicationEndpoint, endpointSshPort, sshKeyLocation, scriptName, speedLimit, true)
+		} else {
+			err = sendIncrementalSnapshot(v.Dataset, v.From, v.To, replicationEndpoint, endpointSshPort, sshKeyLocation, scriptName, speedLimit, true)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func checkSshConnection(replicationEndpoint string, endpointSshPort int, sshKeyLocation string) (string, error) {
 	const SshConnectionTimeout = "timeout"
 	const SshConnectionLoginFailure = "login failure"
@@ -217,8 +259,9 @@ func getRemoteZfsDatasets(replicationEndpoint string, endpointSshPort int, sshKe
 	return remoteDatasetList, nil
 }
 
-// scriptLocation should be set to an empty string by default, unless you want to specify the replication script location
-func sendInitialSnapshot(endpointDataset string, snapshotToSend string, replicationEndpoint string, endpointSshPort int, sshKeyLocation string, replScriptName string, speedLimit int) error {
+// scriptLocation should be set to an empty string by default, unless you want to specify the replication script location.
+// sendProperties includes the dataset properties into the stream (zfs send -p), used for the zvols.
+func sendInitialSnapshot(endpointDataset string, snapshotToSend string, replicationEndpoint string, endpointSshPort int, sshKeyLocation string, replScriptName string, speedLimit int, sendProperties bool) error {
 	replicationDir := "/var/run/replication"
 	os.Mkdir(replicationDir, 0750)
 	replicationScriptLocation := replicationDir + "/aa_default_replication_job.sh"
@@ -260,7 +303,11 @@ func sendInitialSnapshot(endpointDataset string, snapshotToSend string, replicat
 
 	os.Setenv("SPEED_LIMIT_MB_PER_SECOND", strconv.Itoa(speedLimit))
 	emojlog.PrintLogMessage("Replication speed limit is set to: "+strconv.Itoa(speedLimit)+"MB/s", emojlog.Debug)
-	bashScript := []byte("zfs send -Pv " + snapshotToSend + " | /opt/hoster-core/mbuffer | ssh -i " + sshKeyLocation + " -p " + strconv.Itoa(endpointSshPort) + " " + replicationEndpoint + " zfs receive -F " + endpointDataset)
+	sendFlags := "-Pv"
+	if sendProperties {
+		sendFlags = "-Pvp"
+	}
+	bashScript := []byte("zfs send " + sendFlags + " " + snapshotToSend + " | /opt/hoster-core/mbuffer | ssh -i " + sshKeyLocation + " -p " + strconv.Itoa(endpointSshPort) + " " + replicationEndpoint + " zfs receive -F " + endpointDataset)
 	err = os.WriteFile(replicationScriptLocation, bashScript, 0600)
 	if err != nil {
 		return err
@@ -302,7 +349,7 @@ func sendInitialSnapshot(endpointDataset string, snapshotToSend string, replicat
 	return nil
 }
 
-func sendIncrementalSnapshot(endpointDataset string, prevSnap string, incrementalSnap string, replicationEndpoint string, endpointSshPort int, sshKeyLocation string, replScriptName string, speedLimit int) error {
+func sendIncrementalSnapshot(endpointDataset string, prevSnap string, incrementalSnap string, replicationEndpoint string, endpointSshPort int, sshKeyLocation string, replScriptName string, speedLimit int, sendProperties bool) error {
 	replicationDir := "/var/run/replication"
 	os.Mkdir(replicationDir, 0750)
 	replicationScriptLocation := replicationDir + "/aa_default_replication_job.sh"
@@ -344,7 +391,11 @@ func sendIncrementalSnapshot(endpointDataset string, prevSnap string, incrementa
 
 	os.Setenv("SPEED_LIMIT_MB_PER_SECOND", strconv.Itoa(speedLimit))
 	emojlog.PrintLogMessage("Replication speed limit is set to: "+strconv.Itoa(speedLimit)+"MB/s", emojlog.Debug)
-	bashScript := []byte("zfs send -Pvi " + prevSnap + " " + incrementalSnap + " | /opt/hoster-core/mbuffer | ssh -i " + sshKeyLocation + " -p " + strconv.Itoa(endpointSshPort) + " " + replicationEndpoint + " zfs receive -F " + endpointDataset)
+	sendFlags := "-Pv"
+	if sendProperties {
+		sendFlags = "-Pvp"
+	}
+	bashScript := []byte("zfs send " + sendFlags + "i " + prevSnap + " " + incrementalSnap + " | /opt/hoster-core/mbuffer | ssh -i " + sshKeyLocation + " -p " + strconv.Itoa(endpointSshPort) + " " + replicationEndpoint + " zfs receive -F " + endpointDataset)
 	err = os.WriteFile(replicationScriptLocation, bashScript, 0600)
 	if err != nil {
 		return err
@@ -67,6 +67,8 @@ func connectToSerialConsole(vmName string) error {
 	return nil
 }
 
+// cu(1) creates the UUCP lock file for the device (/var/spool/lock/LCK..nmdm-<vm_name>-1B), which is the same lock the REST API serial console session uses,
+// so cu refuses to connect (port in use) while the API clients are attached, and vice versa.
 func newTmuxSession(vmName string) error {
 	tmuxCreate := exec.Command("tmux", "new-session", "-s", vmName, "/usr/bin/cu", "-l", "/dev/nmdm-"+vmName+"-1B")
 	tmuxCreate.Stdin = os.Stdin
@@ -0,0 +1,127 @@
+//go:build freebsd
+// +build freebsd
+
+package cmd
+
+import (
+	"HosterCore/internal/pkg/emojlog"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aquasecurity/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vmValidateAll  bool
+	vmValidateJson bool
+
+	vmValidateCmd = &cobra.Command{
+		Use:   "validate [vmName]",
+		Short: "Validate the VM config",
+		Long: "Validate the VM config (or all VM configs on this node, using --all), and report every problem found with its field path and a suggested fix.\n" +
+			"The same checks are executed right before the VM start.",
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			checkInitFile()
+			vmName := ""
+			if len(args) > 0 {
+				vmName = args[0]
+			}
+			err := vmValidate(vmName, vmValidateAll)
+			if err != nil {
+				emojlog.PrintLogMessage(err.Error(), emojlog.Error)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+type vmValidateResult struct {
+	VmName string                        `json:"vm_name"`
+	Issues []HosterVmUtils.VmConfigIssue `json:"issues"`
+}
+
+func vmValidate(vmName string, all bool) error {
+	if len(vmName) < 1 && !all {
+		return errors.New("please specify the VM name, or use --all to validate all VMs")
+	}
+	if len(vmName) > 0 && all {
+		return errors.New("VM name and --all can't be used together")
+	}
+
+	vms, err := HosterVmUtils.ListJsonApi()
+	if err != nil {
+		return err
+	}
+
+	hostState, err := HosterVmUtils.LoadVmHostState()
+	if err != nil {
+		return err
+	}
+
+	results := []vmValidateResult{}
+	for _, v := range vms {
+		if !all && v.Name != vmName {
+			continue
+		}
+		issues := hostState.CheckVmConfig(v.Name, v.Simple.Mountpoint+"/"+v.Name, v.VmConfig)
+		results = append(results, vmValidateResult{VmName: v.Name, Issues: issues})
+	}
+	if len(results) < 1 {
+		return errors.New("vm doesn't exist")
+	}
+
+	problems := 0
+	for _, v := range results {
+		problems += len(v.Issues)
+	}
+
+	if vmValidateJson {
+		out, err := json.MarshalIndent(results, "", "   ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else if problems > 0 {
+		printVmValidateTable(results)
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d problem(s) found", problems)
+	}
+	if !vmValidateJson {
+		emojlog.PrintLogMessage(fmt.Sprintf("%d VM config(s) validated, no problems found", len(results)), emojlog.Info)
+	}
+	return nil
+}
+
+func printVmValidateTable(results []vmValidateResult) {
+	var t = table.New(os.Stdout)
+	t.SetAlignment(
+		table.AlignLeft, // VM Name
+		table.AlignLeft, // Field
+		table.AlignLeft, // Problem
+		table.AlignLeft, // Suggested Fix
+	)
+	t.AddHeaders(
+		"VM\nName",
+		"Field",
+		"Problem",
+		"Suggested\nFix",
+	)
+	t.SetLineStyle(table.StyleBrightCyan)
+	t.SetDividers(table.UnicodeRoundedDividers)
+	t.SetHeaderStyle(table.StyleBold)
+
+	for _, v := range results {
+		for _, vv := range v.Issues {
+			t.AddRow(v.VmName, vv.Field, vv.Problem, vv.Fix)
+		}
+	}
+
+	t.Render()
+}
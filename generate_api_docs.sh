@@ -8,3 +8,6 @@ cd ./internal/app/rest_api_v2
 GOOS=freebsd swag init --pd
 
 cd "${WORKDIR}"
+
+# Regenerate the REST API client, using the updated route handler annotations
+go generate ./pkg/api_v2_client
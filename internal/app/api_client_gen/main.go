@@ -0,0 +1,478 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+// Generates the REST API v2 client route methods (pkg/api_v2_client/routes_gen.go).
+//
+// Routes are read from the REST API router (internal/app/rest_api_v2/main.go), and the method signatures are built
+// from the swag annotations of the route handlers, so the client always covers every registered route.
+// Run it using `go generate ./pkg/api_v2_client`, after the API docs were regenerated.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const routerFile = "internal/app/rest_api_v2/main.go"
+const aliasesFile = "pkg/api_v2_types/aliases.go"
+const outputFile = "pkg/api_v2_client/routes_gen.go"
+
+// Handler packages, by the import name used in the router
+var handlerDirs = map[string]string{
+	"handlers":   "internal/app/rest_api_v2/pkg/handlers",
+	"HandlersHA": "internal/app/rest_api_v2/pkg/handlers_ha",
+}
+
+type route struct {
+	Path    string
+	Method  string
+	Package string
+	Handler string
+}
+
+type param struct {
+	Name        string
+	In          string
+	Type        string
+	Description string
+}
+
+type header struct {
+	Name string
+	Type string
+}
+
+type annotations struct {
+	Summary   string
+	Params    []param
+	Success   string
+	Websocket bool
+	Stream    bool // request body is sent as is (@Accept octet-stream)
+	Headers   []header
+}
+
+var reParam = regexp.MustCompile(`^@Param\s+(\S+)\s+(\S+)\s+(\S+)\s+\S+\s*(?:"(.*)")?`)
+var reSuccess = regexp.MustCompile(`^@Success\s+(\d+)\s*(?:\{(\w+)\}\s+(\S+))?`)
+var reHeader = regexp.MustCompile(`^@Header\s+\d+\s+\{(\w+)\}\s+(\S+)`)
+var rePathVar = regexp.MustCompile(`\{(\w+)\}`)
+
+func main() {
+	root, err := moduleRoot()
+	if err != nil {
+		fatal(err)
+	}
+
+	routes, err := parseRoutes(filepath.Join(root, routerFile))
+	if err != nil {
+		fatal(err)
+	}
+
+	docs := make(map[string]annotations)
+	for pkg, dir := range handlerDirs {
+		err = parseHandlers(filepath.Join(root, dir), pkg, docs)
+		if err != nil {
+			fatal(err)
+		}
+	}
+
+	aliases, err := parseAliases(filepath.Join(root, aliasesFile))
+	if err != nil {
+		fatal(err)
+	}
+
+	code, err := generate(routes, docs, aliases)
+	if err != nil {
+		fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(root, outputFile), code, 0644)
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Printf("generated %d API client methods: %s\n", len(routes), outputFile)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "api_client_gen: "+err.Error())
+	os.Exit(1)
+}
+
+func moduleRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		_, err := os.Stat(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("could not find the module root (go.mod)")
+		}
+		dir = parent
+	}
+}
+
+// Finds the `r.HandleFunc("/api/v2/...", pkg.Handler).Methods(...)` calls.
+// A handler registered multiple times (e.g. the additional POST routes for the DELETE-less clients) is only used once.
+func parseRoutes(file string) (r []route, e error) {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		e = err
+		return
+	}
+
+	seen := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Methods" || len(call.Args) < 1 {
+			return true
+		}
+		inner, ok := sel.X.(*ast.CallExpr)
+		if !ok || len(inner.Args) != 2 {
+			return true
+		}
+		innerSel, ok := inner.Fun.(*ast.SelectorExpr)
+		if !ok || innerSel.Sel.Name != "HandleFunc" {
+			return true
+		}
+
+		lit, ok := inner.Args[0].(*ast.BasicLit)
+		if !ok {
+			return true
+		}
+		path, _ := strconv.Unquote(lit.Value)
+		handler, ok := inner.Args[1].(*ast.SelectorExpr)
+		if !ok || !strings.HasPrefix(path, "/api/v2/") {
+			return true
+		}
+		pkg := handler.X.(*ast.Ident).Name
+		if _, ok := handlerDirs[pkg]; !ok {
+			return true
+		}
+
+		method := ""
+		switch m := call.Args[0].(type) {
+		case *ast.SelectorExpr:
+			method = strings.ToUpper(strings.TrimPrefix(m.Sel.Name, "Method"))
+		case *ast.BasicLit:
+			method, _ = strconv.Unquote(m.Value)
+		}
+
+		key := pkg + "." + handler.Sel.Name
+		if seen[key] {
+			return true
+		}
+		seen[key] = true
+		r = append(r, route{Path: path, Method: method, Package: pkg, Handler: handler.Sel.Name})
+
+		return true
+	})
+
+	return
+}
+
+func parseHandlers(dir string, pkg string, docs map[string]annotations) error {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pkgs {
+		for _, f := range p.Files {
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Doc == nil || fn.Recv != nil {
+					continue
+				}
+
+				a := annotations{}
+				for _, line := range strings.Split(fn.Doc.Text(), "\n") {
+					line = strings.TrimSpace(line)
+					switch {
+					case strings.HasPrefix(line, "@Summary"):
+						a.Summary = strings.TrimSpace(strings.TrimPrefix(line, "@Summary"))
+					case strings.HasPrefix(line, "@Accept"):
+						a.Stream = strings.Contains(line, "octet-stream")
+					case strings.HasPrefix(line, "@Param"):
+						m := reParam.FindStringSubmatch(line)
+						if m != nil {
+							a.Params = append(a.Params, param{Name: m[1], In: m[2], Type: m[3], Description: m[4]})
+						}
+					case strings.HasPrefix(line, "@Success"):
+						m := reSuccess.FindStringSubmatch(line)
+						if m == nil {
+							continue
+						}
+						if m[1] == "101" {
+							a.Websocket = true
+							continue
+						}
+						a.Success = m[3]
+						if m[2] == "array" {
+							a.Success = "[]" + m[3]
+						}
+					case strings.HasPrefix(line, "@Header"):
+						m := reHeader.FindStringSubmatch(line)
+						if m != nil {
+							a.Headers = append(a.Headers, header{Type: m[1], Name: m[2]})
+						}
+					}
+				}
+
+				docs[pkg+"."+fn.Name.Name] = a
+			}
+		}
+	}
+
+	return nil
+}
+
+// Maps the aliased types to the alias names, e.g. "HosterVmUtils.VmApi" -> "VmApi".
+func parseAliases(file string) (r map[string]string, e error) {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		e = err
+		return
+	}
+
+	r = make(map[string]string)
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			sel, ok := ts.Type.(*ast.SelectorExpr)
+			if !ts.Assign.IsValid() || !ok {
+				continue
+			}
+			r[sel.X.(*ast.Ident).Name+"."+sel.Sel.Name] = ts.Name.Name
+		}
+	}
+
+	return
+}
+
+// Converts the swag type into the client type.
+// Returns an empty string for the standard success response (the method returns the message instead).
+func goType(swagType string, aliases map[string]string) (string, error) {
+	swagType = strings.TrimSuffix(swagType, "{}")
+
+	if strings.HasPrefix(swagType, "[]") {
+		t, err := goType(swagType[2:], aliases)
+		return "[]" + t, err
+	}
+
+	switch swagType {
+	case "SwaggerSuccess", "handlers.SwaggerSuccess":
+		return "", nil
+	case "string", "int", "bool":
+		return swagType, nil
+	case "integer":
+		return "int", nil
+	case "boolean":
+		return "bool", nil
+	case "object":
+		return "interface{}", nil
+	case "file":
+		return "io.ReadCloser", nil
+	}
+
+	if strings.HasPrefix(swagType, "ApiV2Types.") {
+		return swagType, nil
+	}
+	alias, ok := aliases[swagType]
+	if !ok {
+		return "", fmt.Errorf("type %s has no alias in %s", swagType, aliasesFile)
+	}
+
+	return "ApiV2Types." + alias, nil
+}
+
+func methodName(r route) string {
+	if r.Package == "HandlersHA" {
+		return "Ha" + strings.TrimPrefix(r.Handler, "Handle")
+	}
+	return r.Handler
+}
+
+// vm_name -> vmName, If-Match -> ifMatch
+func argName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' })
+	for i := range parts {
+		if i == 0 {
+			parts[i] = strings.ToLower(parts[i])
+			continue
+		}
+		parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+	}
+	return strings.Join(parts, "")
+}
+
+// X-Total-Count -> totalCount
+func headerArgName(name string) string {
+	return argName(strings.TrimPrefix(name, "X-"))
+}
+
+func generate(routes []route, docs map[string]annotations, aliases map[string]string) ([]byte, error) {
+	sort.SliceStable(routes, func(i, j int) bool { return routes[i].Path < routes[j].Path })
+
+	b := &bytes.Buffer{}
+	usesIo := false
+
+	for _, r := range routes {
+		a, ok := docs[r.Package+"."+r.Handler]
+		if !ok {
+			return nil, fmt.Errorf("handler %s.%s has no swag annotations", r.Package, r.Handler)
+		}
+
+		args := []string{"ctx context.Context"}
+		pathExpr := strconv.Quote(r.Path)
+		for _, v := range rePathVar.FindAllStringSubmatch(r.Path, -1) {
+			args = append(args, argName(v[1])+" string")
+			pathExpr = strings.Replace(pathExpr, "{"+v[1]+"}", `" + url.PathEscape(`+argName(v[1])+`) + "`, 1)
+		}
+		pathExpr = strings.TrimSuffix(pathExpr, ` + ""`)
+
+		queryDocs := []string{}
+		bodyType := ""
+		headerArgs := []string{}
+		for _, p := range a.Params {
+			switch p.In {
+			case "query":
+				queryDocs = append(queryDocs, fmt.Sprintf("//   - %s (%s): %s", p.Name, p.Type, p.Description))
+			case "body":
+				t, err := goType(p.Type, aliases)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s", r.Handler, err.Error())
+				}
+				bodyType = t
+				if a.Stream {
+					bodyType = "io.Reader"
+					usesIo = true
+				}
+			case "header":
+				headerArgs = append(headerArgs, p.Name)
+			}
+		}
+		if len(queryDocs) > 0 {
+			args = append(args, "query url.Values")
+		}
+		for _, h := range headerArgs {
+			args = append(args, argName(h)+" string")
+		}
+		if len(bodyType) > 0 {
+			args = append(args, "input "+bodyType)
+		}
+
+		// Doc comment
+		fmt.Fprintf(b, "\n// %s\n//\n// %s %s\n", strings.TrimSuffix(a.Summary, "."), r.Method, r.Path)
+		if len(queryDocs) > 0 {
+			b.WriteString("//\n// Query parameters:\n" + strings.Join(queryDocs, "\n") + "\n")
+		}
+
+		if a.Websocket {
+			fmt.Fprintf(b, "func (c *Client) %s(%s) (*websocket.Conn, error) {\n", methodName(r), strings.Join(args, ", "))
+			queryArg := "nil"
+			if len(queryDocs) > 0 {
+				queryArg = "query"
+			}
+			fmt.Fprintf(b, "\treturn c.dial(ctx, %s, %s)\n}\n", pathExpr, queryArg)
+			continue
+		}
+
+		resType, err := goType(a.Success, aliases)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", r.Handler, err.Error())
+		}
+		if len(a.Success) < 1 {
+			return nil, fmt.Errorf("handler %s has no @Success annotation", r.Handler)
+		}
+		if len(resType) < 1 {
+			resType = "string"
+		}
+		if resType == "io.ReadCloser" {
+			usesIo = true
+		}
+
+		results := []string{"r " + resType}
+		for _, h := range a.Headers {
+			t := "string"
+			if h.Type == "integer" {
+				t = "int"
+			}
+			results = append(results, headerArgName(h.Name)+" "+t)
+		}
+		results = append(results, "e error")
+
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (%s) {\n", methodName(r), strings.Join(args, ", "), strings.Join(results, ", "))
+		fmt.Fprintf(b, "\treq := request{method: http.Method%s, path: %s}\n", methodConst(r.Method), pathExpr)
+		if len(queryDocs) > 0 {
+			b.WriteString("\treq.query = query\n")
+		}
+		for _, h := range headerArgs {
+			fmt.Fprintf(b, "\tif len(%s) > 0 {\n\t\treq.header = http.Header{%q: {%s}}\n\t}\n", argName(h), h, argName(h))
+		}
+		if len(bodyType) > 0 {
+			b.WriteString("\treq.body = input\n")
+		}
+
+		if resType == "io.ReadCloser" {
+			b.WriteString("\tr, e = c.stream(ctx, req)\n\treturn\n}\n")
+			continue
+		}
+		if len(a.Headers) < 1 {
+			b.WriteString("\t_, e = c.do(ctx, req, &r)\n\treturn\n}\n")
+			continue
+		}
+		b.WriteString("\theader, e := c.do(ctx, req, &r)\n\tif e != nil {\n\t\treturn\n\t}\n")
+		for _, h := range a.Headers {
+			if h.Type == "integer" {
+				fmt.Fprintf(b, "\t%s = parseInt(header.Get(%q))\n", headerArgName(h.Name), h.Name)
+			} else {
+				fmt.Fprintf(b, "\t%s = header.Get(%q)\n", headerArgName(h.Name), h.Name)
+			}
+		}
+		b.WriteString("\treturn\n}\n")
+	}
+
+	head := &bytes.Buffer{}
+	head.WriteString("// Code generated by api_client_gen. DO NOT EDIT.\n\n")
+	head.WriteString("package ApiV2Client\n\n")
+	head.WriteString("import (\n\tApiV2Types \"HosterCore/pkg/api_v2_types\"\n\t\"context\"\n")
+	if usesIo {
+		head.WriteString("\t\"io\"\n")
+	}
+	head.WriteString("\t\"net/http\"\n\t\"net/url\"\n\n\t\"github.com/gorilla/websocket\"\n)\n\n")
+	b = bytes.NewBuffer(append(head.Bytes(), b.Bytes()...))
+
+	code, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("could not format the generated code: %s\n%s", err.Error(), b.String())
+	}
+	return code, nil
+}
+
+func methodConst(method string) string {
+	return strings.ToUpper(method[:1]) + strings.ToLower(method[1:])
+}
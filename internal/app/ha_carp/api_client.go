@@ -0,0 +1,87 @@
+package main
+
+import (
+	CarpUtils "HosterCore/internal/app/ha_carp/utils"
+	RestApiConfig "HosterCore/internal/app/rest_api_v2/pkg/config"
+	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	ApiV2Client "HosterCore/pkg/api_v2_client"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+)
+
+const HTTP_CALL_TIMEOUT = 5 * time.Second
+
+// Returns the REST API client for the other HA node, authenticated as the local HA user.
+func haApiClient(address string) (*ApiV2Client.Client, error) {
+	apiConfig, err := RestApiConfig.LoadApiConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range apiConfig.HTTPAuth {
+		if !v.HaUser {
+			continue
+		}
+
+		endpoint := apiConfig.Protocol + "://" + net.JoinHostPort(address, strconv.Itoa(apiConfig.Port))
+		return ApiV2Client.New(endpoint, ApiV2Client.WithBasicAuth(v.User, v.Password), ApiV2Client.WithTimeout(HTTP_CALL_TIMEOUT))
+	}
+
+	return nil, errors.New("no HA user found in the config")
+}
+
+// Pings the CARP master, and returns it's hostname.
+func pingMasterNode(carpConfig CarpUtils.CarpConfig) (r string, e error) {
+	hostname, err := FreeBSDsysctls.SysctlKernHostname()
+	if err != nil {
+		e = err
+		return
+	}
+
+	client, err := haApiClient(carpConfig.MasterIpAddress)
+	if err != nil {
+		e = err
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), HTTP_CALL_TIMEOUT)
+	defer cancel()
+
+	res, err := client.CarpPing(ctx, CarpUtils.HostInfo{HostName: hostname})
+	if err != nil {
+		e = err
+		return
+	}
+
+	r = res.Hostname
+	return
+}
+
+func sendLocalState(haState CarpUtils.HaStatus, remoteIp string, masterHostname string) error {
+	client, err := haApiClient(remoteIp)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), HTTP_CALL_TIMEOUT)
+	defer cancel()
+
+	_, err = client.CarpReceiveHostState(ctx, masterHostname, haState)
+	return err
+}
+
+func returnBackups(host CarpUtils.HostInfo) (r []CarpUtils.BackupInfo, e error) {
+	client, err := haApiClient(host.IpAddress)
+	if err != nil {
+		e = err
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), HTTP_CALL_TIMEOUT)
+	defer cancel()
+
+	return client.CarpReturnListOfBackups(ctx)
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	CarpUtils "HosterCore/internal/app/ha_carp/utils"
-	ApiV2client "HosterCore/internal/pkg/api_v2_client"
 	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
 	"slices"
 	"sort"
@@ -46,7 +45,7 @@ func checkIfMaster() {
 }
 
 func pingMaster() {
-	hostname, err := ApiV2client.PingMaster(activeHaConfig)
+	hostname, err := pingMasterNode(activeHaConfig)
 	if err != nil {
 		log.Error("Error pinging master:", err)
 		return
@@ -76,7 +75,7 @@ func syncState() {
 		go func(v CarpUtils.HostInfo, wg *sync.WaitGroup) {
 			defer wg.Done()
 
-			err := ApiV2client.SendLocalState(ha, v.IpAddress, currentMaster)
+			err := sendLocalState(ha, v.IpAddress, currentMaster)
 			if err != nil {
 				log.Errorf("STATE SYNC: Error sending local state to %s: %s", v.IpAddress, err.Error())
 			}
@@ -98,7 +97,7 @@ func getRemoteBackups() {
 	}
 
 	for _, v := range listHosts() { // Get backups from all hosts (naive approach, for now)
-		tmp, err := ApiV2client.ReturnBackups(v)
+		tmp, err := returnBackups(v)
 		if err != nil {
 			log.Errorf("Error getting backups from %s: %s", v.IpAddress, err.Error())
 			continue
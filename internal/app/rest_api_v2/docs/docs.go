@@ -19,6 +19,222 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/audit": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Query the audit log of all mutating API requests (newest first).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: only REST user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Query the API audit log.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by user name",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by source IP address",
+                        "name": "source_ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by the target resource name (e.g. VM or Jail name)",
+                        "name": "resource",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by route (substring match), e.g. /vm/destroy",
+                        "name": "route",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by HTTP method",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by outcome: success or failure",
+                        "name": "outcome",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only show the entries after this time (RFC3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only show the entries before this time (RFC3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries to return (100 by default)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ApiAudit.Entry"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/bulk/operations": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "List the recent bulk operations (newest first).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bulk"
+                ],
+                "summary": "List bulk operations.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/HosterBulk.Operation"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/bulk/operations/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Get the bulk operation status, including the per-resource results.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bulk"
+                ],
+                "summary": "Get the bulk operation status.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/HosterBulk.Operation"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/bulk/{action}": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Apply the action to every VM and/or Jail matching the selector, e.g. stop everything tagged ` + "`" + `web` + "`" + `.\u003cbr\u003eAvailable actions: ` + "`" + `start` + "`" + `, ` + "`" + `stop` + "`" + `, ` + "`" + `snapshot` + "`" + `, ` + "`" + `replicate` + "`" + `, ` + "`" + `add-tag` + "`" + `, ` + "`" + `remove-tag` + "`" + `.\u003cbr\u003eThe operation runs in the background, use the returned ID to check the per-resource results.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bulk"
+                ],
+                "summary": "Start a bulk operation.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bulk action, e.g. stop",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ApiV2Types.BulkInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/HosterBulk.Operation"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
         "/carp-ha/backups": {
             "get": {
                 "security": [
@@ -165,7 +381,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/handlers.DatasetInfo"
+                                "$ref": "#/definitions/ApiV2Types.DatasetInfo"
                             }
                         }
                     },
@@ -200,7 +416,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.DatasetEncryptionInput"
+                            "$ref": "#/definitions/ApiV2Types.DatasetEncryptionInput"
                         }
                     }
                 ],
@@ -220,6 +436,40 @@ const docTemplate = `{
                 }
             }
         },
+        "/errors": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "List the stable error codes returned in the ` + "`" + `code` + "`" + ` field of the error responses, together with their HTTP statuses and ` + "`" + `details` + "`" + ` parameters.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "List the API error codes.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ErrorMappings.CatalogEntry"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
         "/ha/jail-list": {
             "get": {
                 "security": [
@@ -241,7 +491,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/HandlersHA.HaJail"
+                                "$ref": "#/definitions/ApiV2Types.HaJail"
                             }
                         }
                     },
@@ -390,7 +640,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/HandlersHA.HaVm"
+                                "$ref": "#/definitions/ApiV2Types.HaVm"
                             }
                         }
                     },
@@ -575,26 +825,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/host/settings": {
+        "/host/security/lockouts": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Get Host Settings.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: only REST user is allowed.",
+                "description": "List the source IPs and user names with recent authentication failures, including the active lockouts. User name failures are counted across all source IPs. Locked out requests get ` + "`" + `429` + "`" + ` (` + "`" + `AUTH_LOCKED_OUT` + "`" + `) with the ` + "`" + `Retry-After` + "`" + ` header.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: only REST user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Host"
                 ],
-                "summary": "Get Host Settings.",
+                "summary": "List authentication failures and lockouts.",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/HosterHost.HostConfig"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ApiAuth.LockoutEntry"
+                            }
                         }
                     },
                     "500": {
@@ -606,29 +859,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/host/settings/add-ssh-key": {
-            "post": {
+        "/host/security/lockouts/clear": {
+            "delete": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Add a new VM SSH access key.",
+                "description": "Clear the lockout for a specific source IP (` + "`" + `type: ip` + "`" + `) or user name (` + "`" + `type: user` + "`" + `). Send an empty payload to clear all lockouts.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: only REST user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Host"
                 ],
-                "summary": "Add a new VM SSH access key.",
+                "summary": "Clear authentication lockouts.",
                 "parameters": [
                     {
-                        "description": "Request Payload",
+                        "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SshKeyInput"
+                            "$ref": "#/definitions/ApiV2Types.LockoutClearInput"
                         }
                     }
                 ],
@@ -648,35 +901,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/host/settings/add-tag/{tag}": {
-            "post": {
+        "/host/settings": {
+            "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Add a new Host-related tag.",
+                "description": "Get Host Settings.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: only REST user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tags"
-                ],
-                "summary": "Add a new Host-related tag.",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Host Tag",
-                        "name": "tag",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Host"
                 ],
+                "summary": "Get Host Settings.",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                            "$ref": "#/definitions/HosterHost.HostConfig"
                         }
                     },
                     "500": {
@@ -688,21 +932,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/host/settings/add-upstream-dns": {
+        "/host/settings/add-ssh-key": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Add a new upstream DNS server.",
+                "description": "Add a new VM SSH access key.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Host"
                 ],
-                "summary": "Add a new upstream DNS server.",
+                "summary": "Add a new VM SSH access key.",
                 "parameters": [
                     {
                         "description": "Request Payload",
@@ -710,7 +954,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.UpstreamDnsInput"
+                            "$ref": "#/definitions/ApiV2Types.SshKeyInput"
                         }
                     }
                 ],
@@ -730,26 +974,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/host/settings/api": {
-            "get": {
+        "/host/settings/add-tag/{tag}": {
+            "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Get RestAPI Settings (including HA settings).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: only REST user is allowed.",
+                "description": "Add a new Host-related tag.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Host"
+                    "Tags"
+                ],
+                "summary": "Add a new Host-related tag.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Host Tag",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get RestAPI Settings (including HA settings).",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/RestApiConfig.RestApiConfig"
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
                         }
                     },
                     "500": {
@@ -761,7 +1014,114 @@ const docTemplate = `{
                 }
             }
         },
-        "/host/settings/delete-ssh-key": {
+        "/host/settings/add-upstream-dns": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Add a new upstream DNS server.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Host"
+                ],
+                "summary": "Add a new upstream DNS server.",
+                "parameters": [
+                    {
+                        "description": "Request Payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ApiV2Types.UpstreamDnsInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/host/settings/api": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Get RestAPI Settings (including HA settings).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: only REST user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Host"
+                ],
+                "summary": "Get RestAPI Settings (including HA settings).",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RestApiConfig.RestApiConfig"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/host/settings/api/reload": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Re-read and validate the RestAPI config file, and put it into use without restarting the service.\u003cbr\u003eInvalid config is rejected, and the last good config stays in use.\u003cbr\u003eReturns the list of changed settings (some of them only take effect after the service restart).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: only REST user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Host"
+                ],
+                "summary": "Reload RestAPI Settings.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/RestApiConfig.SettingChange"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/host/settings/delete-ssh-key": {
             "delete": {
                 "security": [
                     {
@@ -783,7 +1143,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SshKeyInput"
+                            "$ref": "#/definitions/ApiV2Types.SshKeyInput"
                         }
                     }
                 ],
@@ -865,7 +1225,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.UpstreamDnsInput"
+                            "$ref": "#/definitions/ApiV2Types.UpstreamDnsInput"
                         }
                     }
                 ],
@@ -907,7 +1267,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.DnsSearchDomainInput"
+                            "$ref": "#/definitions/ApiV2Types.DnsSearchDomainInput"
                         }
                     }
                 ],
@@ -949,7 +1309,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.HostAuthSshKeyInput"
+                            "$ref": "#/definitions/ApiV2Types.HostAuthSshKeyInput"
                         }
                     }
                 ],
@@ -991,7 +1351,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.VmTemplateLink"
+                            "$ref": "#/definitions/ApiV2Types.VmTemplateLink"
                         }
                     }
                 ],
@@ -1026,6 +1386,74 @@ const docTemplate = `{
                     "Jails"
                 ],
                 "summary": "List all Jails.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by tag (comma separated list, all tags must match)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the running state",
+                        "name": "running",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the production flag",
+                        "name": "production",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the backup status",
+                        "name": "backup",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by owner",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by ZFS dataset, e.g. zroot/vm-encrypted",
+                        "name": "dataset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by name (glob pattern), e.g. web-*",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of fields to return, e.g. name,running",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -1034,6 +1462,12 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/HosterJailUtils.JailApi"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of items matching the filters"
+                            }
                         }
                     },
                     "500": {
@@ -1060,6 +1494,74 @@ const docTemplate = `{
                     "Jails"
                 ],
                 "summary": "List all Jails (cached version).",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by tag (comma separated list, all tags must match)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the running state",
+                        "name": "running",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the production flag",
+                        "name": "production",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the backup status",
+                        "name": "backup",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by owner",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by ZFS dataset, e.g. zroot/vm-encrypted",
+                        "name": "dataset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by name (glob pattern), e.g. web-*",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of fields to return, e.g. name,running",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -1068,6 +1570,12 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/HosterJailUtils.JailApi"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of items matching the filters"
+                            }
                         }
                     },
                     "500": {
@@ -1101,7 +1609,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.JailCloneInput"
+                            "$ref": "#/definitions/ApiV2Types.JailCloneInput"
                         }
                     }
                 ],
@@ -1231,7 +1739,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.JailShells"
+                            "$ref": "#/definitions/ApiV2Types.JailShells"
                         }
                     },
                     "500": {
@@ -1354,13 +1862,19 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.TagInput"
+                            "$ref": "#/definitions/ApiV2Types.TagInput"
                         }
                     }
                 ],
@@ -1409,6 +1923,12 @@ const docTemplate = `{
                         "name": "limit",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -1450,13 +1970,19 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.ResourceDescription"
+                            "$ref": "#/definitions/ApiV2Types.ResourceDescription"
                         }
                     }
                 ],
@@ -1499,13 +2025,19 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.JailDnsInput"
+                            "$ref": "#/definitions/ApiV2Types.JailDnsInput"
                         }
                     }
                 ],
@@ -1548,13 +2080,19 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.JailNetworkInput"
+                            "$ref": "#/definitions/ApiV2Types.JailNetworkInput"
                         }
                     }
                 ],
@@ -1603,6 +2141,12 @@ const docTemplate = `{
                         "name": "production",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -1650,6 +2194,12 @@ const docTemplate = `{
                         "name": "limit",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -1697,6 +2247,12 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/HosterJailUtils.JailConfig"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Config revision, send it back in the If-Match header to avoid overwriting someone else's changes"
+                            }
                         }
                     },
                     "500": {
@@ -2143,7 +2699,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/handlers.CronFile"
+                                "$ref": "#/definitions/ApiV2Types.CronFile"
                             }
                         }
                     },
@@ -2298,7 +2854,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SnapshotInput"
+                            "$ref": "#/definitions/ApiV2Types.SnapshotInput"
                         }
                     }
                 ],
@@ -2340,7 +2896,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SnapshotName"
+                            "$ref": "#/definitions/ApiV2Types.SnapshotName"
                         }
                     }
                 ],
@@ -2382,7 +2938,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SnapshotName"
+                            "$ref": "#/definitions/ApiV2Types.SnapshotName"
                         }
                     }
                 ],
@@ -2424,7 +2980,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SnapshotInput"
+                            "$ref": "#/definitions/ApiV2Types.SnapshotInput"
                         }
                     }
                 ],
@@ -2459,6 +3015,74 @@ const docTemplate = `{
                     "VMs"
                 ],
                 "summary": "List all VMs.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by tag (comma separated list, all tags must match)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the running state",
+                        "name": "running",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the production flag",
+                        "name": "production",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the backup status",
+                        "name": "backup",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by owner",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by ZFS dataset, e.g. zroot/vm-encrypted",
+                        "name": "dataset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by name (glob pattern), e.g. web-*",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of fields to return, e.g. name,running",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -2467,6 +3091,12 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/HosterVmUtils.VmApi"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of items matching the filters"
+                            }
                         }
                     },
                     "500": {
@@ -2493,17 +3123,91 @@ const docTemplate = `{
                     "VMs"
                 ],
                 "summary": "List all VMs (cached version).",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/HosterVmUtils.VmApi"
-                            }
-                        }
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by tag (comma separated list, all tags must match)",
+                        "name": "tag",
+                        "in": "query"
                     },
-                    "500": {
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the running state",
+                        "name": "running",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the production flag",
+                        "name": "production",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by the backup status",
+                        "name": "backup",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by owner",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by ZFS dataset, e.g. zroot/vm-encrypted",
+                        "name": "dataset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by name (glob pattern), e.g. web-*",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of fields to return, e.g. name,running",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/HosterVmUtils.VmApi"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of items matching the filters"
+                            }
+                        }
+                    },
+                    "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/handlers.SwaggerError"
@@ -2512,29 +3216,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/clone": {
+        "/vm/apply": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Clone the VM using it's name, and optionally specify the snapshot name to be used for cloning.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Compare the VM spec with the current VM state, and apply the minimal set of changes needed (deploy, CPU/RAM and other settings, new disks, disk expansion, new network interfaces).\u003cbr\u003eRe-applying an unchanged spec is a no-op.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Clone the VM.",
+                "summary": "Apply the declarative VM spec.",
                 "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only return the plan, without applying it",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
                     {
                         "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.VmCloneInput"
+                            "$ref": "#/definitions/HosterVm.VmSpec"
                         }
                     }
                 ],
@@ -2542,7 +3252,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                            "$ref": "#/definitions/HosterVm.VmApplyResult"
                         }
                     },
                     "500": {
@@ -2554,28 +3264,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/cloud-init/mount-iso/{vm_name}": {
+        "/vm/clone": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Mount a real CloudInit ISO.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `REST` + "`" + ` user is allowed.",
+                "description": "Clone the VM using it's name, and optionally specify the snapshot name to be used for cloning.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Mount a real CloudInit ISO.",
+                "summary": "Clone the VM.",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "VM Name",
-                        "name": "vm_name",
-                        "in": "path",
-                        "required": true
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ApiV2Types.VmCloneInput"
+                        }
                     }
                 ],
                 "responses": {
@@ -2594,21 +3306,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/cloud-init/unmount-iso/{vm_name}": {
+        "/vm/cloud-init/mount-iso/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Replace a real CloudInit ISO with an empty one. Useful in the situations where multiple users reside on the same VM, because an empty ISO will protect the VM's secrets.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `REST` + "`" + ` user is allowed.",
+                "description": "Mount a real CloudInit ISO.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `REST` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Replace a real CloudInit ISO with an empty one.",
+                "summary": "Mount a real CloudInit ISO.",
                 "parameters": [
                     {
                         "type": "string",
@@ -2634,30 +3346,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/deploy": {
+        "/vm/cloud-init/unmount-iso/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Deploy a new VM.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Replace a real CloudInit ISO with an empty one. Useful in the situations where multiple users reside on the same VM, because an empty ISO will protect the VM's secrets.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `REST` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Deploy the new VM.",
+                "summary": "Replace a real CloudInit ISO with an empty one.",
                 "parameters": [
                     {
-                        "description": "Request payload",
-                        "name": "Input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/HosterVm.VmDeployInput"
-                        }
+                        "type": "string",
+                        "description": "VM Name",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -2676,21 +3386,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/destroy/{vm_name}": {
-            "delete": {
+        "/vm/console/serial/{vm_name}": {
+            "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Destroy the VM using it's name.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Attach to the VM's serial console (nmdm device) using a WebSocket connection. Console output is sent as binary messages, and the last N KB of output are replayed on connect.\u003cbr\u003eOnly one client can hold the write access at a time (` + "`" + `?write=true` + "`" + `), any number of clients can connect in the read-only mode.\u003cbr\u003eThe console device is released once the last client disconnects, and ` + "`" + `409` + "`" + ` is returned while it's used by the CLI (` + "`" + `hoster vm serial-console` + "`" + `).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Destroy the VM.",
+                "summary": "Connect to the VM's serial console (WebSocket).",
                 "parameters": [
                     {
                         "type": "string",
@@ -2698,13 +3408,22 @@ const docTemplate = `{
                         "name": "vm_name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Request the write access to the console",
+                        "name": "write",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                            "$ref": "#/definitions/handlers.SwaggerError"
                         }
                     },
                     "500": {
@@ -2716,35 +3435,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/info/{vm_name}": {
+        "/vm/console/vnc/sessions": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Get the VM Info.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "description": "List active VNC proxy sessions.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Get the VM Info.",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "VM Name",
-                        "name": "vm_name",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "summary": "List active VNC proxy sessions.",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/HosterVmUtils.VmApi"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/VncProxy.Session"
+                            }
                         }
                     },
                     "500": {
@@ -2756,21 +3469,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/readme/{vm_name}": {
-            "get": {
+        "/vm/console/vnc/token/{vm_name}": {
+            "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Get README.MD for a particular VM.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Issue a short-lived, single-use token, which can be used to connect to the VM's VNC console over the WebSocket proxy (e.g. using noVNC).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Get README.MD for a particular VM.",
+                "summary": "Issue a VNC proxy session token.",
                 "parameters": [
                     {
                         "type": "string",
@@ -2784,7 +3497,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                            "$ref": "#/definitions/ApiV2Types.VncTokenResponse"
                         }
                     },
                     "500": {
@@ -2796,56 +3509,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/add-tag/{vm_name}": {
-            "post": {
-                "security": [
-                    {
-                        "BasicAuth": []
-                    }
-                ],
-                "description": "Add a new tag for any particular VM.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
-                "produces": [
-                    "application/json"
-                ],
+        "/vm/console/vnc/{token}": {
+            "get": {
+                "description": "WebSocket-to-TCP VNC proxy, compatible with noVNC. Use the token issued by ` + "`" + `/vm/console/vnc/token/{vm_name}` + "`" + `, each token can only be used once, and only from the IP address it was issued to.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: session token.",
                 "tags": [
-                    "VMs",
-                    "Tags"
+                    "VMs"
                 ],
-                "summary": "Add a new tag for any particular VM.",
+                "summary": "Connect to the VM's VNC console (WebSocket).",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "VM Name",
-                        "name": "vm_name",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "New Tag",
-                        "name": "new_tag",
+                        "description": "VNC session token",
+                        "name": "token",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Request payload",
-                        "name": "Input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.TagInput"
-                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                            "$ref": "#/definitions/handlers.SwaggerError"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
                             "$ref": "#/definitions/handlers.SwaggerError"
                         }
@@ -2853,36 +3544,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/cpu/{vm_name}": {
+        "/vm/deploy": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Modify VM's CPU settings.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Deploy a new VM.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Modify VM's CPU settings.",
+                "summary": "Deploy the new VM.",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Name of the VM",
-                        "name": "vm_name",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.VmCpuInput"
+                            "$ref": "#/definitions/HosterVm.VmDeployInput"
                         }
                     }
                 ],
@@ -2902,22 +3586,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/delete-tag/{vm_name}": {
+        "/vm/destroy/{vm_name}": {
             "delete": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Delete an existing tag for any specific VM.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Destroy the VM using it's name.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "VMs",
-                    "Tags"
+                    "VMs"
                 ],
-                "summary": "Delete an existing tag for any specific VM.",
+                "summary": "Destroy the VM.",
                 "parameters": [
                     {
                         "type": "string",
@@ -2925,15 +3608,6 @@ const docTemplate = `{
                         "name": "vm_name",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Request payload",
-                        "name": "Input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.TagInput"
-                        }
                     }
                 ],
                 "responses": {
@@ -2952,21 +3626,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/description/{vm_name}": {
-            "post": {
+        "/vm/export/{vm_name}": {
+            "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Update VM's description.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Stream the VM export archive (tar.zst): VM config, disk images and zvols (holes are skipped), cloud-init seed and files, README and a checksum manifest.\u003cbr\u003eExternal disks are not exported. The VM has to be offline.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
-                    "application/json"
+                    "application/zstd"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Update VM's description.",
+                "summary": "Export the VM into a portable archive (streamed).",
                 "parameters": [
                     {
                         "type": "string",
@@ -2974,22 +3648,13 @@ const docTemplate = `{
                         "name": "vm_name",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Request payload",
-                        "name": "Input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.ResourceDescription"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                            "type": "file"
                         }
                     },
                     "500": {
@@ -3001,36 +3666,62 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/disk/add-new/{vm_name}": {
+        "/vm/import": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Add a new VM data disk.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Recreate the VM from the export archive sent as the request body (` + "`" + `Content-Type: application/octet-stream` + "`" + `). All files are verified against the checksum manifest.\u003cbr\u003eParent host and VNC port are reset, network interfaces are moved to the target network, and get new MAC/IP addresses if they conflict with this host.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "consumes": [
+                    "application/octet-stream"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Add a new VM data disk.",
+                "summary": "Import the VM from an export archive (streamed).",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Name of the VM",
+                        "description": "New VM name (the name stored in the archive is used by default)",
                         "name": "vm_name",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
                     },
                     {
-                        "description": "Request payload",
+                        "type": "string",
+                        "description": "Parent dataset, e.g. zroot/vm-encrypted (the first active dataset is used by default)",
+                        "name": "dataset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Move all network interfaces to this network",
+                        "name": "network",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Always generate new MAC addresses",
+                        "name": "regenerate_mac",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Reset the cloud-init instance-id if the primary NIC address changes (regenerates the SSH host keys, and applies the user-data again)",
+                        "name": "reset_instance_id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "VM export archive (tar.zst)",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/HosterVmUtils.VmDisk"
+                            "type": "string"
                         }
                     }
                 ],
@@ -3038,7 +3729,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                            "$ref": "#/definitions/HosterVm.VmImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "ARCHIVE_INVALID (bad archive, manifest or checksum mismatch), DATASET_NOT_ACTIVE",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    },
+                    "409": {
+                        "description": "NAME_CONFLICT, IMPORT_NETWORK_MISSING, ADMISSION_DENIED",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
                         }
                     },
                     "500": {
@@ -3050,44 +3753,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/disk/expand/{vm_name}": {
-            "post": {
+        "/vm/info/{vm_name}": {
+            "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Expand an existing VM disk.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Get the VM Info.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Expand an existing VM disk.",
+                "summary": "Get the VM Info.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Name of the VM",
+                        "description": "VM Name",
                         "name": "vm_name",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Request payload",
-                        "name": "Input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.VmDiskExpandInput"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                            "$ref": "#/definitions/HosterVmUtils.VmApi"
                         }
                     },
                     "500": {
@@ -3099,35 +3793,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/firmware/{vm_name}/{firmware}": {
-            "post": {
+        "/vm/readme/{vm_name}": {
+            "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Modify VM's Firmware type (e.g. bootloader type, bios vs uefi).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Get README.MD for a particular VM.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Modify VM's Firmware type (e.g. bootloader type, bios vs uefi).",
+                "summary": "Get README.MD for a particular VM.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Name of the VM",
+                        "description": "VM Name",
                         "name": "vm_name",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Firmware type (bootloader type), e.g. bios or uefi",
-                        "name": "firmware",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -3146,28 +3833,37 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/mount-iso/{vm_name}": {
+        "/vm/rename/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Mount a real ISO. This could be an installation ISO, or an ISO with OS drivers, etc.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `REST` + "`" + ` user is allowed.",
+                "description": "Rename the VM: ZFS dataset, VM config paths, cloud-init hostname, cron jobs and DNS records are updated.\u003cbr\u003eMAC and IP addresses, passwords and SSH keys are kept as is. The VM has to be offline, and it can't be renamed while it's being replicated.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Mount a real ISO.",
+                "summary": "Rename the VM.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "VM Name",
+                        "description": "Name of the VM",
                         "name": "vm_name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ApiV2Types.VmRenameInput"
+                        }
                     }
                 ],
                 "responses": {
@@ -3186,37 +3882,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/network/add/{vm_name}": {
+        "/vm/settings/add-tag/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Add a new VM network interface.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Add a new tag for any particular VM.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs",
-                    "Networks"
+                    "Tags"
                 ],
-                "summary": "Add a new VM network interface.",
+                "summary": "Add a new tag for any particular VM.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Name of the VM",
+                        "description": "VM Name",
                         "name": "vm_name",
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "New Tag",
+                        "name": "new_tag",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/HosterVmUtils.VmNetwork"
+                            "$ref": "#/definitions/ApiV2Types.TagInput"
                         }
                     }
                 ],
@@ -3236,21 +3945,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/os-info/{vm_name}": {
+        "/vm/settings/cpu/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Modify VM's OS info (e.g. os_type - debian12, os_comment - Debian 12).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Modify VM's CPU settings.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Modify VM's OS info (e.g. os_type - debian12, os_comment - Debian 12).",
+                "summary": "Modify VM's CPU settings.",
                 "parameters": [
                     {
                         "type": "string",
@@ -3259,13 +3968,19 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.VmOsSettings"
+                            "$ref": "#/definitions/ApiV2Types.VmCpuInput"
                         }
                     }
                 ],
@@ -3285,35 +4000,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/production/{vm_name}/{production}": {
-            "post": {
+        "/vm/settings/delete-tag/{vm_name}": {
+            "delete": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Modify VM's Workload type (e.g. is this a production VM, true or false).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Delete an existing tag for any specific VM.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "VMs"
+                    "VMs",
+                    "Tags"
                 ],
-                "summary": "Modify VM's Workload type (e.g. is this a production VM, true or false).",
+                "summary": "Delete an existing tag for any specific VM.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Name of the VM",
+                        "description": "VM Name",
                         "name": "vm_name",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Workload type (is this a production VM), e.g. true or false",
-                        "name": "production",
-                        "in": "path",
-                        "required": true
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ApiV2Types.TagInput"
+                        }
                     }
                 ],
                 "responses": {
@@ -3332,21 +4056,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/ram/{vm_name}": {
+        "/vm/settings/description/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Modify VM's RAM settings.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Update VM's description.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Modify VM's RAM settings.",
+                "summary": "Update VM's description.",
                 "parameters": [
                     {
                         "type": "string",
@@ -3355,13 +4079,19 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.VmRamInput"
+                            "$ref": "#/definitions/ApiV2Types.ResourceDescription"
                         }
                     }
                 ],
@@ -3381,28 +4111,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/unmount-iso/{vm_name}": {
+        "/vm/settings/disk/add-new/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Unmount an installation ISO. This could be an installation ISO, or an ISO with OS drivers, etc.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `REST` + "`" + ` user is allowed.",
+                "description": "Add a new VM data disk.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Unmount an installation ISO.",
+                "summary": "Add a new VM data disk.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "VM Name",
+                        "description": "Name of the VM",
                         "name": "vm_name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/HosterVmUtils.VmDisk"
+                        }
                     }
                 ],
                 "responses": {
@@ -3421,21 +4166,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/vnc-resolution/{vm_name}/{resolution}": {
+        "/vm/settings/disk/detach/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Modify VM's VNC Resolution.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "description": "Detach a disk from the VM, the disk image (or zvol) itself is kept. VM has to be offline.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Modify VM's VNC Resolution.",
+                "summary": "Detach a VM disk.",
                 "parameters": [
                     {
                         "type": "string",
@@ -3445,11 +4190,19 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Resolution code, e.g. 3 for 1024x768",
-                        "name": "resolution",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ApiV2Types.VmDiskDetachInput"
+                        }
                     }
                 ],
                 "responses": {
@@ -3468,35 +4221,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/settings/{vm_name}": {
-            "get": {
+        "/vm/settings/disk/expand/{vm_name}": {
+            "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Get the settings for a particular VM.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only REST user is allowed.",
+                "description": "Expand an existing VM disk.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Get the settings for a particular VM.",
+                "summary": "Expand an existing VM disk.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "VM Name",
+                        "description": "Name of the VM",
                         "name": "vm_name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ApiV2Types.VmDiskExpandInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/HosterVmUtils.VmConfig"
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
                         }
                     },
                     "500": {
@@ -3508,28 +4276,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/start-all/{production}": {
+        "/vm/settings/disk/remove/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Start all VMs.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "description": "Detach a disk from the VM, and permanently delete its image (or zvol). VM has to be offline.\u003cbr\u003eFails if any of the VM snapshots (or their clones) still reference the disk data. External disk images are never deleted, detach them instead.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Start all VMs.",
+                "summary": "Remove a VM disk.",
                 "parameters": [
                     {
-                        "type": "boolean",
-                        "description": "Start only production VMs (true or false)",
-                        "name": "production",
+                        "type": "string",
+                        "description": "Name of the VM",
+                        "name": "vm_name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ApiV2Types.VmDiskRemoveInput"
+                        }
                     }
                 ],
                 "responses": {
@@ -3539,6 +4322,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.SwaggerSuccess"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -3548,28 +4337,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/start/wait-vnc/{vm_name}": {
+        "/vm/settings/disk/reorder/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Start a specific VM using it's name as a parameter (and wait for a VNC screen connection).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `REST` + "`" + `-type user is allowed.",
+                "description": "Move the listed disks to the top of the disk list, in the given order. The first disk is the boot disk. Takes effect after the next VM start.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Start a specific VM (and wait for a VNC screen connection).",
+                "summary": "Change the VM disk order.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "VM Name",
+                        "description": "Name of the VM",
                         "name": "vm_name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ApiV2Types.VmDiskReorderInput"
+                        }
                     }
                 ],
                 "responses": {
@@ -3588,28 +4392,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/start/{vm_name}": {
+        "/vm/settings/firmware/{vm_name}/{firmware}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Start a specific VM using it's name as a parameter.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "description": "Modify VM's Firmware type (e.g. bootloader type, bios vs uefi).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Start a specific VM.",
+                "summary": "Modify VM's Firmware type (e.g. bootloader type, bios vs uefi).",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "VM Name",
+                        "description": "Name of the VM",
                         "name": "vm_name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Firmware type (bootloader type), e.g. bios or uefi",
+                        "name": "firmware",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -3628,28 +4445,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/stop-all/{force}": {
+        "/vm/settings/mount-iso/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Stop all VMs.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "description": "Mount a real ISO. This could be an installation ISO, or an ISO with OS drivers, etc.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `REST` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs"
                 ],
-                "summary": "Stop all VMs.",
+                "summary": "Mount a real ISO.",
                 "parameters": [
                     {
-                        "type": "boolean",
-                        "description": "Forcefully stop all the VMs (true or false)",
-                        "name": "force",
+                        "type": "string",
+                        "description": "VM Name",
+                        "name": "vm_name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -3668,36 +4491,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/stop/force/{vm_name}": {
+        "/vm/settings/network/add/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Stop (forcefully) a specific VM using it's name as a parameter.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "description": "Add a new VM network interface.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "VMs"
+                    "VMs",
+                    "Networks"
                 ],
-                "summary": "Stop (forcefully) a specific VM.",
+                "summary": "Add a new VM network interface.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "VM Name",
+                        "description": "Name of the VM",
                         "name": "vm_name",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.SwaggerSuccess"
-                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/HosterVmUtils.VmNetwork"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -3708,28 +4547,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/stop/{vm_name}": {
+        "/vm/settings/network/remove/{vm_name}/{nic_index}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Stop a specific VM using it's name as a parameter.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "description": "Remove a VM network interface, and release its IP address. The VM must be stopped.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "VMs"
+                    "VMs",
+                    "Networks"
                 ],
-                "summary": "Stop a specific VM.",
+                "summary": "Remove a VM network interface.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "VM Name",
+                        "description": "Name of the VM",
                         "name": "vm_name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Network interface index (0 is the first interface)",
+                        "name": "nic_index",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -3739,6 +4592,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.SwaggerSuccess"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -3748,30 +4607,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/vm/templates": {
+        "/vm/settings/network/update/{vm_name}/{nic_index}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Get the list of VM templates.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only REST user is allowed.",
+                "description": "Change the VM network interface settings: network, driver, MAC address (set or regenerate), IP address, VLAN tag or comment. Only the fields that are set are changed.\u003cbr\u003eIf the interface is moved to another network, a new IP address is allocated (unless it's set explicitly). Takes effect after the next VM start.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "VMs",
-                    "Templates"
+                    "Networks"
                 ],
-                "summary": "Get the list of VM templates.",
+                "summary": "Change the VM network interface settings.",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the VM",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Network interface index (0 is the first interface)",
+                        "name": "nic_index",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.ZfsDatasetInput"
+                            "$ref": "#/definitions/HosterVm.VmNetworkUpdate"
                         }
                     }
                 ],
@@ -3779,7 +4658,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/HosterVmUtils.VmTemplate"
+                            "$ref": "#/definitions/HosterVmUtils.VmNetwork"
                         }
                     },
                     "500": {
@@ -3791,32 +4670,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/wireguard/script": {
+        "/vm/settings/os-info/{vm_name}": {
             "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Accept any arbitrary bash script that brings up the WG interfaces.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only REST user is allowed.",
-                "consumes": [
-                    "plain/text"
-                ],
+                "description": "Modify VM's OS info (e.g. os_type - debian12, os_comment - Debian 12).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "WireGuard"
+                    "VMs"
                 ],
-                "summary": "Accept any arbitrary bash script that brings up the WG interfaces.",
+                "summary": "Modify VM's OS info (e.g. os_type - debian12, os_comment - Debian 12).",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the VM",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "Request payload",
                         "name": "Input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/ApiV2Types.VmOsSettings"
                         }
                     }
                 ],
@@ -3835,94 +4724,1268 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "CarpUtils.BackupInfo": {
-            "type": "object",
-            "properties": {
-                "current_host": {
-                    "description": "Current host name",
-                    "type": "string"
-                },
-                "failover_strategy": {
-                    "description": "Failover strategy, e.g. \"cireset\" or \"change_parent\"",
-                    "type": "string"
-                },
-                "last_snapshot": {
-                    "description": "Last snapshot name",
-                    "type": "string"
-                },
-                "parent_host": {
-                    "description": "Parent host name",
-                    "type": "string"
-                },
-                "resource_name": {
-                    "description": "Resource name",
-                    "type": "string"
-                },
-                "resource_type": {
-                    "description": "Resource type, e.g. \"vm\", \"jail\"",
-                    "type": "string"
-                },
-                "type": {
-                    "type": "string"
-                }
-            }
         },
-        "CarpUtils.CarpPingResponse": {
-            "type": "object",
-            "properties": {
-                "hostname": {
-                    "description": "hostname",
-                    "type": "string"
-                },
-                "message": {
-                    "description": "success",
-                    "type": "string"
+        "/vm/settings/production/{vm_name}/{production}": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Modify VM's Workload type (e.g. is this a production VM, true or false).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Modify VM's Workload type (e.g. is this a production VM, true or false).",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the VM",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Workload type (is this a production VM), e.g. true or false",
+                        "name": "production",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
                 }
             }
         },
-        "CarpUtils.HaStatus": {
-            "type": "object",
-            "properties": {
-                "current_master": {
-                    "description": "Current master hostname",
-                    "type": "string"
-                },
-                "hosts": {
-                    "description": "List of hosts",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/CarpUtils.HostInfo"
+        "/vm/settings/ram/{vm_name}": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
                     }
-                },
-                "resources": {
-                    "description": "List of resources",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/CarpUtils.BackupInfo"
+                ],
+                "description": "Modify VM's RAM settings.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Modify VM's RAM settings.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the VM",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ApiV2Types.VmRamInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
                     }
-                },
-                "service_health": {
-                    "description": "Health status: OK, WARN, CRIT",
-                    "type": "string"
-                },
-                "status": {
-                    "description": "Current HA status: MASTER, BACKUP, INIT",
-                    "type": "string"
-                },
-                "type": {
-                    "type": "string"
                 }
             }
         },
-        "CarpUtils.HostInfo": {
-            "type": "object",
-            "properties": {
-                "host_name": {
-                    "description": "Host name",
-                    "type": "string"
+        "/vm/settings/unmount-iso/{vm_name}": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Unmount an installation ISO. This could be an installation ISO, or an ISO with OS drivers, etc.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `REST` + "`" + ` user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Unmount an installation ISO.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "VM Name",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vm/settings/vnc-resolution/{vm_name}/{resolution}": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Modify VM's VNC Resolution.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Modify VM's VNC Resolution.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the VM",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resolution code, e.g. 3 for 1024x768",
+                        "name": "resolution",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vm/settings/{vm_name}": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Get the settings for a particular VM.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only REST user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Get the settings for a particular VM.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "VM Name",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/HosterVmUtils.VmConfig"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Config revision, send it back in the If-Match header to avoid overwriting someone else's changes"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Apply the JSON Merge Patch (RFC 7396) to the VM config, e.g. ` + "`" + `{\"cpu_cores\": 4, \"memory\": \"8G\", \"description\": null}` + "`" + `.\u003cbr\u003eThe resulting config is validated as a whole, and is only written if it's valid.\u003cbr\u003eReturns the list of changed settings, and whether the VM restart is required for them to take effect.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `rest` + "`" + ` user is allowed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Modify VM settings using a JSON Merge Patch.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the VM",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "JSON Merge Patch",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/HosterVmUtils.VmConfigChange"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vm/start-all/{production}": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Start all VMs.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Start all VMs.",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Start only production VMs (true or false)",
+                        "name": "production",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vm/start/wait-vnc/{vm_name}": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Start a specific VM using it's name as a parameter (and wait for a VNC screen connection).\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only ` + "`" + `REST` + "`" + `-type user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Start a specific VM (and wait for a VNC screen connection).",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "VM Name",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vm/start/{vm_name}": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Start a specific VM using it's name as a parameter.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Start a specific VM.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "VM Name",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vm/stop-all/{force}": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Stop all VMs.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Stop all VMs.",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Forcefully stop all the VMs (true or false)",
+                        "name": "force",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vm/stop/force/{vm_name}": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Stop (forcefully) a specific VM using it's name as a parameter.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Stop (forcefully) a specific VM.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "VM Name",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vm/stop/{vm_name}": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Stop a specific VM using it's name as a parameter.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Both users are allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs"
+                ],
+                "summary": "Stop a specific VM.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "VM Name",
+                        "name": "vm_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vm/templates": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Get the list of VM templates.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only REST user is allowed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VMs",
+                    "Templates"
+                ],
+                "summary": "Get the list of VM templates.",
+                "parameters": [
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ApiV2Types.ZfsDatasetInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/HosterVmUtils.VmTemplate"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/wireguard/script": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Accept any arbitrary bash script that brings up the WG interfaces.\u003cbr\u003e` + "`" + `AUTH` + "`" + `: Only REST user is allowed.",
+                "consumes": [
+                    "plain/text"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WireGuard"
+                ],
+                "summary": "Accept any arbitrary bash script that brings up the WG interfaces.",
+                "parameters": [
+                    {
+                        "description": "Request payload",
+                        "name": "Input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerSuccess"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SwaggerError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "ApiAudit.ConfigChange": {
+            "type": "object",
+            "properties": {
+                "after": {},
+                "before": {},
+                "path": {
+                    "description": "e.g. cpu_cores, or disks[1].disk_image",
+                    "type": "string"
+                }
+            }
+        },
+        "ApiAudit.Entry": {
+            "type": "object",
+            "properties": {
+                "authenticated": {
+                    "type": "boolean"
+                },
+                "changes": {
+                    "description": "before/after diff for the config changing requests",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ApiAudit.ConfigChange"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "payload": {
+                    "description": "sanitized request payload"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "resource": {
+                    "type": "string"
+                },
+                "resource_type": {
+                    "description": "vm, jail, host, snapshot, etc",
+                    "type": "string"
+                },
+                "route": {
+                    "type": "string"
+                },
+                "source_ip": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiAuth.LockoutEntry": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "source IP address or user name",
+                    "type": "string"
+                },
+                "last_failure": {
+                    "type": "string"
+                },
+                "locked": {
+                    "type": "boolean"
+                },
+                "locked_until": {
+                    "type": "string"
+                },
+                "lockouts": {
+                    "type": "integer"
+                },
+                "source_ip": {
+                    "description": "user lockouts only: source IP of the last failure",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "ip or user",
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.BulkInput": {
+            "type": "object",
+            "properties": {
+                "params": {
+                    "$ref": "#/definitions/HosterBulk.Params"
+                },
+                "selector": {
+                    "$ref": "#/definitions/HosterBulk.Selector"
+                }
+            }
+        },
+        "ApiV2Types.CronFile": {
+            "type": "object",
+            "properties": {
+                "cron_jobs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ApiV2Types.CronJob"
+                    }
+                },
+                "cron_variables": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "file_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.CronJob": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "disabled": {
+                    "type": "boolean"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.DatasetEncryptionInput": {
+            "type": "object",
+            "properties": {
+                "dataset": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.DatasetInfo": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "integer"
+                },
+                "available_human": {
+                    "type": "string"
+                },
+                "encrypted": {
+                    "type": "boolean"
+                },
+                "mounted": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_human": {
+                    "type": "string"
+                },
+                "used": {
+                    "type": "integer"
+                },
+                "used_human": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.DnsSearchDomainInput": {
+            "type": "object",
+            "properties": {
+                "dns_search_domain": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.HaJail": {
+            "type": "object",
+            "properties": {
+                "current_host": {
+                    "type": "string"
+                },
+                "jail_name": {
+                    "type": "string"
+                },
+                "latest_snapshot": {
+                    "type": "string"
+                },
+                "live": {
+                    "type": "boolean"
+                },
+                "parent_host": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.HaVm": {
+            "type": "object",
+            "properties": {
+                "current_host": {
+                    "type": "string"
+                },
+                "latest_snapshot": {
+                    "type": "string"
+                },
+                "live": {
+                    "type": "boolean"
+                },
+                "parent_host": {
+                    "type": "string"
+                },
+                "vm_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.HostAuthSshKeyInput": {
+            "type": "object",
+            "properties": {
+                "key_value": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.JailCloneInput": {
+            "type": "object",
+            "properties": {
+                "jail_name": {
+                    "type": "string"
+                },
+                "new_jail_name": {
+                    "type": "string"
+                },
+                "snapshot_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.JailDnsInput": {
+            "type": "object",
+            "properties": {
+                "dns_server": {
+                    "type": "string"
+                },
+                "search_domain": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.JailNetworkInput": {
+            "type": "object",
+            "properties": {
+                "ip_address": {
+                    "type": "string"
+                },
+                "network_bridge": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.JailShells": {
+            "type": "object",
+            "properties": {
+                "available_shells": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "ApiV2Types.LockoutClearInput": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "description": "source IP address or user name",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "ip or user",
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.ResourceDescription": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.SnapshotInput": {
+            "type": "object",
+            "properties": {
+                "new_res_name": {
+                    "description": "Used in clone operation, e.g. newVmName, the internal call will automatically append the dataset name",
+                    "type": "string"
+                },
+                "res_name": {
+                    "description": "VM or Jail name",
+                    "type": "string"
+                },
+                "snapshot_description": {
+                    "description": "Description of the snapshot",
+                    "type": "string"
+                },
+                "snapshot_name": {
+                    "description": "Full snapshot name, including the whole path, e.g. \"tank/vm-encrypted/vmTest1@snap1\"",
+                    "type": "string"
+                },
+                "snapshot_type": {
+                    "description": "\"hourly\", \"daily\", \"weekly\", \"monthly\", \"frequent\"",
+                    "type": "string"
+                },
+                "snapshots_to_keep": {
+                    "description": "How many snapshots to keep, e.g. 5",
+                    "type": "integer"
+                }
+            }
+        },
+        "ApiV2Types.SnapshotName": {
+            "type": "object",
+            "properties": {
+                "resource_name": {
+                    "description": "VM or Jail name",
+                    "type": "string"
+                },
+                "snapshot_name": {
+                    "description": "Full snapshot name, including the whole path, e.g. \"tank/vm-encrypted/vmTest1@snap1\"",
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.SshKeyInput": {
+            "type": "object",
+            "properties": {
+                "key_comment": {
+                    "type": "string"
+                },
+                "key_value": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.TagInput": {
+            "type": "object",
+            "properties": {
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.UpstreamDnsInput": {
+            "type": "object",
+            "properties": {
+                "dns_server": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.VmCloneInput": {
+            "type": "object",
+            "properties": {
+                "new_vm_name": {
+                    "type": "string"
+                },
+                "snapshot_name": {
+                    "type": "string"
+                },
+                "vm_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.VmCpuInput": {
+            "type": "object",
+            "properties": {
+                "cpu_cores": {
+                    "type": "integer"
+                },
+                "cpu_sockets": {
+                    "type": "integer"
+                },
+                "cpu_threads": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ApiV2Types.VmDiskDetachInput": {
+            "type": "object",
+            "properties": {
+                "disk_image": {
+                    "description": "disk image name (or zvol name), or an absolute path for the external disks",
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.VmDiskExpandInput": {
+            "type": "object",
+            "properties": {
+                "disk_image": {
+                    "type": "string"
+                },
+                "expansion_size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ApiV2Types.VmDiskRemoveInput": {
+            "type": "object",
+            "properties": {
+                "confirm": {
+                    "description": "must be set to true, the disk image is deleted permanently",
+                    "type": "boolean"
+                },
+                "disk_image": {
+                    "description": "disk image name (or zvol name)",
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.VmDiskReorderInput": {
+            "type": "object",
+            "properties": {
+                "order": {
+                    "description": "disk images, the first one becomes the boot disk, the disks that are not listed keep their relative order",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "ApiV2Types.VmOsSettings": {
+            "type": "object",
+            "properties": {
+                "os_comment": {
+                    "type": "string"
+                },
+                "os_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.VmRamInput": {
+            "type": "object",
+            "properties": {
+                "bytes_value": {
+                    "type": "string"
+                },
+                "ram_amount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ApiV2Types.VmRenameInput": {
+            "type": "object",
+            "properties": {
+                "new_name": {
+                    "description": "MAC and IP addresses, passwords and the cloud-init instance-id are kept as is",
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.VmTemplateLink": {
+            "type": "object",
+            "properties": {
+                "vm_template_link": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.VncTokenResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "vm_name": {
+                    "type": "string"
+                },
+                "vnc_password": {
+                    "type": "string"
+                },
+                "ws_path": {
+                    "type": "string"
+                }
+            }
+        },
+        "ApiV2Types.ZfsDatasetInput": {
+            "type": "object",
+            "properties": {
+                "dataset": {
+                    "type": "string"
+                }
+            }
+        },
+        "CarpUtils.BackupInfo": {
+            "type": "object",
+            "properties": {
+                "current_host": {
+                    "description": "Current host name",
+                    "type": "string"
+                },
+                "failover_strategy": {
+                    "description": "Failover strategy, e.g. \"cireset\" or \"change_parent\"",
+                    "type": "string"
+                },
+                "last_snapshot": {
+                    "description": "Last snapshot name",
+                    "type": "string"
+                },
+                "parent_host": {
+                    "description": "Parent host name",
+                    "type": "string"
+                },
+                "resource_name": {
+                    "description": "Resource name",
+                    "type": "string"
+                },
+                "resource_type": {
+                    "description": "Resource type, e.g. \"vm\", \"jail\"",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "CarpUtils.CarpPingResponse": {
+            "type": "object",
+            "properties": {
+                "hostname": {
+                    "description": "hostname",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "success",
+                    "type": "string"
+                }
+            }
+        },
+        "CarpUtils.HaStatus": {
+            "type": "object",
+            "properties": {
+                "current_master": {
+                    "description": "Current master hostname",
+                    "type": "string"
+                },
+                "hosts": {
+                    "description": "List of hosts",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CarpUtils.HostInfo"
+                    }
+                },
+                "resources": {
+                    "description": "List of resources",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CarpUtils.BackupInfo"
+                    }
+                },
+                "service_health": {
+                    "description": "Health status: OK, WARN, CRIT",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Current HA status: MASTER, BACKUP, INIT",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "CarpUtils.HostInfo": {
+            "type": "object",
+            "properties": {
+                "host_name": {
+                    "description": "Host name",
+                    "type": "string"
                 },
                 "ip_address": {
                     "description": "IP address",
@@ -3941,6 +6004,27 @@ const docTemplate = `{
                 }
             }
         },
+        "ErrorMappings.CatalogEntry": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "details": {
+                    "description": "names of the parameters returned in the error details",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "http_status": {
+                    "type": "integer"
+                }
+            }
+        },
         "FreeBSDOsInfo.ArcInfo": {
             "type": "object",
             "properties": {
@@ -4009,80 +6093,204 @@ const docTemplate = `{
                 "ram_free_human": {
                     "type": "string"
                 },
-                "ram_overall_bytes": {
+                "ram_overall_bytes": {
+                    "type": "integer"
+                },
+                "ram_overall_human": {
+                    "type": "string"
+                },
+                "ram_used_bytes": {
+                    "type": "integer"
+                },
+                "ram_used_human": {
+                    "type": "string"
+                }
+            }
+        },
+        "FreeBSDOsInfo.SwapInfo": {
+            "type": "object",
+            "properties": {
+                "swap_free_bytes": {
+                    "type": "integer"
+                },
+                "swap_free_human": {
+                    "type": "string"
+                },
+                "swap_overall_bytes": {
+                    "type": "integer"
+                },
+                "swap_overall_human": {
+                    "type": "string"
+                },
+                "swap_used_bytes": {
+                    "type": "integer"
+                },
+                "swap_used_human": {
+                    "type": "string"
+                }
+            }
+        },
+        "HosterBulk.Operation": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "finished": {
+                    "description": "not set while the operation is running",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "params": {
+                    "$ref": "#/definitions/HosterBulk.Params"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/HosterBulk.Result"
+                    }
+                },
+                "selector": {
+                    "$ref": "#/definitions/HosterBulk.Selector"
+                },
+                "started": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "targets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/HosterBulk.Target"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "HosterBulk.Params": {
+            "type": "object",
+            "properties": {
+                "snapshot_type": {
+                    "description": "snapshot, custom by default",
+                    "type": "string"
+                },
+                "snapshots_to_keep": {
+                    "description": "snapshot, 5 by default",
                     "type": "integer"
                 },
-                "ram_overall_human": {
+                "speed_limit": {
+                    "description": "replicate, 50 by default",
+                    "type": "integer"
+                },
+                "ssh_endpoint": {
+                    "description": "replicate",
                     "type": "string"
                 },
-                "ram_used_bytes": {
+                "ssh_key": {
+                    "description": "replicate, /root/.ssh/id_rsa by default",
+                    "type": "string"
+                },
+                "ssh_port": {
+                    "description": "replicate, 22 by default",
                     "type": "integer"
                 },
-                "ram_used_human": {
+                "tag": {
+                    "description": "add-tag, remove-tag",
                     "type": "string"
                 }
             }
         },
-        "FreeBSDOsInfo.SwapInfo": {
+        "HosterBulk.Result": {
             "type": "object",
             "properties": {
-                "swap_free_bytes": {
-                    "type": "integer"
-                },
-                "swap_free_human": {
+                "error": {
                     "type": "string"
                 },
-                "swap_overall_bytes": {
-                    "type": "integer"
-                },
-                "swap_overall_human": {
+                "job_id": {
+                    "description": "scheduler job ID (snapshot action)",
                     "type": "string"
                 },
-                "swap_used_bytes": {
-                    "type": "integer"
+                "resource_name": {
+                    "type": "string"
                 },
-                "swap_used_human": {
+                "resource_type": {
                     "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
                 }
             }
         },
-        "HandlersHA.HaJail": {
+        "HosterBulk.Selector": {
             "type": "object",
             "properties": {
-                "current_host": {
-                    "type": "string"
-                },
-                "jail_name": {
-                    "type": "string"
+                "names": {
+                    "description": "resource names or glob patterns, any of them must match",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "latest_snapshot": {
+                "owner": {
+                    "description": "VM owner",
                     "type": "string"
                 },
-                "live": {
+                "production": {
+                    "description": "production flag",
                     "type": "boolean"
                 },
-                "parent_host": {
+                "resource_type": {
+                    "description": "vm, jail, or empty for both",
                     "type": "string"
+                },
+                "tags": {
+                    "description": "all of the tags must be present",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "HandlersHA.HaVm": {
+        "HosterBulk.Target": {
             "type": "object",
             "properties": {
-                "current_host": {
+                "resource_name": {
                     "type": "string"
                 },
-                "latest_snapshot": {
+                "resource_type": {
                     "type": "string"
+                }
+            }
+        },
+        "HosterHost.AdmissionPolicy": {
+            "type": "object",
+            "properties": {
+                "max_ram_commit_percent": {
+                    "description": "Max RAM committed to VMs and Jails, in % of the host RAM",
+                    "type": "integer"
                 },
-                "live": {
-                    "type": "boolean"
+                "max_vcpu_ratio": {
+                    "description": "Max vCPU:pCPU ratio, e.g. 4 for 4:1",
+                    "type": "number"
                 },
-                "parent_host": {
-                    "type": "string"
+                "min_pool_free_percent": {
+                    "description": "Min free space left on the target pool, in %",
+                    "type": "integer"
                 },
-                "vm_name": {
-                    "type": "string"
+                "owner_quotas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/HosterHost.OwnerQuota"
+                    }
                 }
             }
         },
@@ -4112,6 +6320,9 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "admission_policy": {
+                    "$ref": "#/definitions/HosterHost.AdmissionPolicy"
+                },
                 "dns_search_domain": {
                     "type": "string"
                 },
@@ -4155,6 +6366,24 @@ const docTemplate = `{
                 }
             }
         },
+        "HosterHost.OwnerQuota": {
+            "type": "object",
+            "properties": {
+                "max_ram": {
+                    "description": "e.g. 64G",
+                    "type": "string"
+                },
+                "max_vcpus": {
+                    "type": "integer"
+                },
+                "max_vms": {
+                    "type": "integer"
+                },
+                "owner": {
+                    "type": "string"
+                }
+            }
+        },
         "HosterHostUtils.HostInfo": {
             "type": "object",
             "properties": {
@@ -4529,6 +6758,51 @@ const docTemplate = `{
                 }
             }
         },
+        "HosterVm.VmApplyChange": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "deploy, set, add_disk, expand_disk or add_network",
+                    "type": "string"
+                },
+                "new": {
+                    "type": "string"
+                },
+                "old": {
+                    "type": "string"
+                },
+                "restart_required": {
+                    "description": "the VM is running, and the change will only be applied after the VM restart",
+                    "type": "boolean"
+                },
+                "stop_required": {
+                    "description": "the VM is running, and the change can't be applied until the VM is stopped (dry run only)",
+                    "type": "boolean"
+                },
+                "target": {
+                    "description": "VM setting or device, e.g. memory, disk1, network0",
+                    "type": "string"
+                }
+            }
+        },
+        "HosterVm.VmApplyResult": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "description": "empty if the VM already matches the spec",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/HosterVm.VmApplyChange"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "vm_name": {
+                    "type": "string"
+                }
+            }
+        },
         "HosterVm.VmDeployInput": {
             "type": "object",
             "properties": {
@@ -4544,19 +6818,192 @@ const docTemplate = `{
                 "os_type": {
                     "type": "string"
                 },
-                "ram": {
+                "owner": {
+                    "description": "Defaults to \"system\", used by the admission owner quotas",
+                    "type": "string"
+                },
+                "ram": {
+                    "type": "string"
+                },
+                "start_when_ready": {
+                    "type": "boolean"
+                },
+                "target_dataset": {
+                    "type": "string"
+                },
+                "vcpus": {
+                    "type": "integer"
+                },
+                "vm_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "HosterVm.VmImportResult": {
+            "type": "object",
+            "properties": {
+                "dataset": {
+                    "type": "string"
+                },
+                "networks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/HosterVmUtils.VmNetwork"
+                    }
+                },
+                "vm_name": {
+                    "type": "string"
+                },
+                "vnc_port": {
+                    "type": "integer"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "HosterVm.VmNetworkUpdate": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "description": "must be within the network range",
+                    "type": "string"
+                },
+                "network_adaptor_type": {
+                    "description": "virtio-net or e1000",
+                    "type": "string"
+                },
+                "network_bridge": {
+                    "description": "move the NIC to another network, the IP address is re-allocated if it doesn't fit the new network range",
+                    "type": "string"
+                },
+                "network_mac": {
+                    "description": "set a specific MAC address",
+                    "type": "string"
+                },
+                "regenerate_mac": {
+                    "description": "generate a new random MAC address",
+                    "type": "boolean"
+                },
+                "vlan_tag": {
+                    "description": "802.1Q VLAN tag (1-4094), 0 removes the tag",
+                    "type": "integer"
+                }
+            }
+        },
+        "HosterVm.VmSpec": {
+            "type": "object",
+            "properties": {
+                "cloud_init": {
+                    "$ref": "#/definitions/HosterVm.VmSpecCloudInit"
+                },
+                "cpu_cores": {
+                    "type": "integer"
+                },
+                "cpu_sockets": {
+                    "description": "1 by default",
+                    "type": "integer"
+                },
+                "cpu_threads": {
+                    "description": "1 by default",
+                    "type": "integer"
+                },
+                "dataset": {
+                    "description": "parent dataset for a new VM, the first active dataset is used by default",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "disks": {
+                    "description": "the first disk is the OS disk",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/HosterVm.VmSpecDisk"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "networks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/HosterVm.VmSpecNetwork"
+                    }
+                },
+                "os_type": {
+                    "description": "template used to deploy a new VM, ignored for the existing VMs",
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "production": {
+                    "type": "boolean"
+                },
+                "ram": {
+                    "description": "e.g. 4G",
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "HosterVm.VmSpecCloudInit": {
+            "type": "object",
+            "properties": {
+                "include_host_ssh_keys": {
+                    "type": "boolean"
+                },
+                "ssh_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/HosterVmUtils.VmSshKey"
+                    }
+                }
+            }
+        },
+        "HosterVm.VmSpecDisk": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "e.g. 20G, disks are only ever expanded, never shrunk",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "nvme (default), virtio-blk or ahci-hd",
+                    "type": "string"
+                }
+            }
+        },
+        "HosterVm.VmSpecNetwork": {
+            "type": "object",
+            "properties": {
+                "comment": {
                     "type": "string"
                 },
-                "start_when_ready": {
-                    "type": "boolean"
-                },
-                "target_dataset": {
+                "driver": {
+                    "description": "virtio-net (default) or e1000",
                     "type": "string"
                 },
-                "vcpus": {
-                    "type": "integer"
+                "ip_address": {
+                    "description": "a free IP is picked automatically if not set",
+                    "type": "string"
                 },
-                "vm_name": {
+                "network": {
                     "type": "string"
                 }
             }
@@ -4587,6 +7034,10 @@ const docTemplate = `{
                 "backup": {
                     "type": "boolean"
                 },
+                "config_version": {
+                    "description": "schema version, check VM_CONFIG_VERSION and config_migrations.go",
+                    "type": "integer"
+                },
                 "cpu_cores": {
                     "type": "integer"
                 },
@@ -4699,6 +7150,10 @@ const docTemplate = `{
                         "$ref": "#/definitions/HosterVmUtils.VmSshKey"
                     }
                 },
+                "vnc_localhost_only": {
+                    "description": "bind VNC to 127.0.0.1, so it's only reachable through the REST API VNC proxy",
+                    "type": "boolean"
+                },
                 "vnc_password": {
                     "type": "string"
                 },
@@ -4719,6 +7174,10 @@ const docTemplate = `{
                         "$ref": "#/definitions/HosterVmUtils.Virtio9P"
                     }
                 },
+                "config_version": {
+                    "description": "schema version, check VM_CONFIG_VERSION and config_migrations.go",
+                    "type": "integer"
+                },
                 "cpu_cores": {
                     "type": "integer"
                 },
@@ -4810,6 +7269,10 @@ const docTemplate = `{
                         "$ref": "#/definitions/HosterVmUtils.VmSshKey"
                     }
                 },
+                "vnc_localhost_only": {
+                    "description": "bind VNC to 127.0.0.1, so it's only reachable through the REST API VNC proxy",
+                    "type": "boolean"
+                },
                 "vnc_password": {
                     "type": "string"
                 },
@@ -4821,6 +7284,18 @@ const docTemplate = `{
                 }
             }
         },
+        "HosterVmUtils.VmConfigChange": {
+            "type": "object",
+            "properties": {
+                "restart_required": {
+                    "description": "the VM is running, and the change will only be applied after the VM restart",
+                    "type": "boolean"
+                },
+                "setting": {
+                    "type": "string"
+                }
+            }
+        },
         "HosterVmUtils.VmDisk": {
             "type": "object",
             "properties": {
@@ -4828,14 +7303,14 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "disk_image": {
-                    "description": "this is a disk image name (internal: disk0.img) or an absolute path to the disk image (external: /tank/windows.iso).",
+                    "description": "this is a disk image name (internal: disk0.img, zvol: disk1) or an absolute path to the disk image (external: /tank/windows.iso).",
                     "type": "string"
                 },
                 "disk_input_size": {
                     "type": "integer"
                 },
                 "disk_location": {
-                    "description": "this is a disk location, e.g. internal or external.",
+                    "description": "this is a disk location, e.g. internal, external or zvol.",
                     "type": "string"
                 },
                 "disk_type": {
@@ -4853,6 +7328,14 @@ const docTemplate = `{
                 },
                 "used_human": {
                     "type": "string"
+                },
+                "zvol": {
+                    "description": "zvol creation settings, only used if the disk_location is zvol",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/HosterVmUtils.ZvolOptions"
+                        }
+                    ]
                 }
             }
         },
@@ -4894,6 +7377,10 @@ const docTemplate = `{
                 "network_mac": {
                     "description": "rename to mac_address in the v2 release",
                     "type": "string"
+                },
+                "vlan_tag": {
+                    "description": "802.1Q VLAN tag (1-4094), the traffic is untagged if not set",
+                    "type": "integer"
                 }
             }
         },
@@ -4936,553 +7423,394 @@ const docTemplate = `{
                 }
             }
         },
-        "HosterZfs.MountPoint": {
+        "HosterVmUtils.ZvolOptions": {
             "type": "object",
             "properties": {
-                "dsName": {
+                "compression": {
+                    "description": "e.g. lz4, zstd or off, inherited from the parent dataset if not set",
                     "type": "string"
                 },
-                "encrypted": {
+                "sparse": {
+                    "description": "thin provisioned volume, space is not reserved upfront",
                     "type": "boolean"
                 },
-                "mountpoint": {
-                    "description": "ZFS Dataset Mountpoint. For example: \"/tank/vm-encrypted\"",
+                "volblocksize": {
+                    "description": "e.g. 16K, ZFS default is used if not set",
                     "type": "string"
                 }
             }
         },
-        "RestApiConfig.HaNode": {
+        "HosterZfs.MountPoint": {
             "type": "object",
             "properties": {
-                "address": {
-                    "type": "string"
-                },
-                "backup_node": {
-                    "type": "boolean"
-                },
-                "failover_strategy": {
-                    "type": "string"
-                },
-                "failover_time": {
-                    "type": "integer"
-                },
-                "hostname": {
-                    "type": "string"
-                },
-                "password": {
-                    "type": "string"
-                },
-                "port": {
-                    "type": "string"
-                },
-                "protocol": {
+                "dsName": {
                     "type": "string"
                 },
-                "registered": {
+                "encrypted": {
                     "type": "boolean"
                 },
-                "startup_time": {
-                    "type": "integer"
-                },
-                "times_failed": {
-                    "type": "integer"
-                },
-                "user": {
+                "mountpoint": {
+                    "description": "ZFS Dataset Mountpoint. For example: \"/tank/vm-encrypted\"",
                     "type": "string"
                 }
             }
         },
-        "RestApiConfig.RestApiConfig": {
+        "RestApiConfig.HTTPAuthUser": {
             "type": "object",
             "properties": {
-                "bind": {
-                    "description": "can be empty, 0.0.0.0 used by default",
-                    "type": "string"
-                },
-                "ha_debug": {
-                    "description": "ha_debug allows you to test the HA Mode, because instead of applying the real actions, ha_debug will only log them instead",
+                "admin_user": {
+                    "description": "Admin User has access to the Admin API routes",
                     "type": "boolean"
                 },
-                "ha_mode": {
-                    "description": "whether to start the API server in an HA cluster mode",
+                "ha_user": {
+                    "description": "HA User has access to a different set of routes than the regular REST API user, and vise versa. Has been implemented to limit per-user API exposure, aka normal user is not authorized to call HA related routes.",
                     "type": "boolean"
                 },
-                "http_auth": {
-                    "type": "array",
-                    "items": {
-                        "type": "object",
-                        "properties": {
-                            "admin_user": {
-                                "description": "Admin User has access to the Admin API routes",
-                                "type": "boolean"
-                            },
-                            "ha_user": {
-                                "description": "HA User has access to a different set of routes than the regular REST API user, and vise versa. Has been implemented to limit per-user API exposure, aka normal user is not authorized to call HA related routes.",
-                                "type": "boolean"
-                            },
-                            "password": {
-                                "description": "password for the basic HTTP auth",
-                                "type": "string"
-                            },
-                            "prometheus_user": {
-                                "description": "Prometheus User has access to the Prometheus metrics endpoint",
-                                "type": "boolean"
-                            },
-                            "user": {
-                                "description": "user name for the basic HTTP auth",
-                                "type": "string"
-                            }
-                        }
-                    }
-                },
-                "log_level": {
-                    "description": "DEBUG, INFO, WARN, or ERROR",
+                "password": {
+                    "description": "password for the basic HTTP auth",
                     "type": "string"
                 },
-                "port": {
-                    "description": "port to bind the HTTP server to",
-                    "type": "integer"
+                "prometheus_user": {
+                    "description": "Prometheus User has access to the Prometheus metrics endpoint",
+                    "type": "boolean"
                 },
-                "protocol": {
-                    "description": "http or https -\u003e not implemented yet, will require another parameter: key_location",
+                "token": {
+                    "description": "optional API token, accepted in the \"Authorization: Bearer \u003ctoken\u003e\" header instead of the user name and password",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "user name for the basic HTTP auth",
                     "type": "string"
                 }
             }
         },
-        "SchedulerUtils.Job": {
+        "RestApiConfig.HaNode": {
             "type": "object",
             "properties": {
-                "job_done": {
-                    "type": "boolean"
-                },
-                "job_done_logged": {
-                    "type": "boolean"
-                },
-                "job_error": {
+                "address": {
                     "type": "string"
                 },
-                "job_failed": {
-                    "type": "boolean"
-                },
-                "job_failed_logged": {
+                "backup_node": {
                     "type": "boolean"
                 },
-                "job_id": {
+                "failover_strategy": {
                     "type": "string"
                 },
-                "job_in_progress": {
-                    "type": "boolean"
-                },
-                "job_next": {
-                    "type": "boolean"
+                "failover_time": {
+                    "type": "integer"
                 },
-                "job_type": {
+                "hostname": {
                     "type": "string"
                 },
-                "replication": {
-                    "$ref": "#/definitions/SchedulerUtils.ReplicationJob"
-                },
-                "res_type": {
+                "password": {
                     "type": "string"
                 },
-                "snapshot": {
-                    "$ref": "#/definitions/SchedulerUtils.SnapshotJob"
-                },
-                "time_added": {
-                    "type": "integer"
-                },
-                "time_finished": {
-                    "type": "integer"
-                }
-            }
-        },
-        "SchedulerUtils.ReplicationJob": {
-            "type": "object",
-            "properties": {
-                "done_snaps": {
-                    "type": "integer"
-                },
-                "progress_bytes_done": {
-                    "type": "integer"
-                },
-                "progress_bytes_total": {
-                    "type": "integer"
-                },
-                "res_name": {
+                "port": {
                     "type": "string"
                 },
-                "scripts_remove": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "scripts_replicate": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "speed_limit": {
-                    "type": "integer"
-                },
-                "ssh_endpoint": {
+                "protocol": {
                     "type": "string"
                 },
-                "ssh_key": {
-                    "type": "string"
+                "registered": {
+                    "type": "boolean"
                 },
-                "ssh_port": {
+                "startup_time": {
                     "type": "integer"
                 },
-                "total_snaps": {
+                "times_failed": {
                     "type": "integer"
                 },
-                "zfs_dataset": {
+                "user": {
                     "type": "string"
                 }
             }
         },
-        "SchedulerUtils.SnapshotJob": {
+        "RestApiConfig.RestApiConfig": {
             "type": "object",
             "properties": {
-                "res_name": {
-                    "type": "string"
-                },
-                "snapshot_name": {
-                    "description": "only used in the snapshot destroy jobs",
-                    "type": "string"
+                "allow_missing_if_match": {
+                    "description": "accept the VM and jail settings changes without the If-Match header (ETag from the GET settings response), rejected with 428 by default",
+                    "type": "boolean"
                 },
-                "snapshot_type": {
+                "audit_log_file": {
+                    "description": "audit log location (JSON lines), /var/log/hoster_api_audit.jsonl by default",
                     "type": "string"
                 },
-                "snapshots_to_keep": {
-                    "type": "integer"
-                },
-                "take_immediately": {
+                "audit_syslog": {
+                    "description": "forward the audit log entries to syslog",
                     "type": "boolean"
                 },
-                "zfs_dataset": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.CronFile": {
-            "type": "object",
-            "properties": {
-                "cron_jobs": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/handlers.CronJob"
-                    }
-                },
-                "cron_variables": {
+                "auth_allow_list": {
+                    "description": "IP addresses or CIDR ranges that are never locked out (HA peers are always included)",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "file_name": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.CronJob": {
-            "type": "object",
-            "properties": {
-                "command": {
-                    "type": "string"
-                },
-                "comment": {
-                    "type": "string"
-                },
-                "enabled": {
-                    "type": "boolean"
-                },
-                "time": {
-                    "type": "string"
-                },
-                "user": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.DatasetEncryptionInput": {
-            "type": "object",
-            "properties": {
-                "dataset": {
-                    "type": "string"
-                },
-                "password": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.DatasetInfo": {
-            "type": "object",
-            "properties": {
-                "available": {
+                "auth_lockout_max_time": {
+                    "description": "maximum lockout time in seconds, 3600 by default",
                     "type": "integer"
                 },
-                "available_human": {
+                "auth_lockout_time": {
+                    "description": "initial lockout time in seconds, doubled on every subsequent lockout, 30 by default",
+                    "type": "integer"
+                },
+                "auth_max_failures": {
+                    "description": "number of failed authentication attempts (per source IP or user name) before the lockout is applied, 5 by default",
+                    "type": "integer"
+                },
+                "bind": {
+                    "description": "can be empty, 0.0.0.0 used by default",
                     "type": "string"
                 },
-                "encrypted": {
+                "ha_debug": {
+                    "description": "ha_debug allows you to test the HA Mode, because instead of applying the real actions, ha_debug will only log them instead",
                     "type": "boolean"
                 },
-                "mounted": {
+                "ha_mode": {
+                    "description": "whether to start the API server in an HA cluster mode",
                     "type": "boolean"
                 },
-                "name": {
+                "http_auth": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/RestApiConfig.HTTPAuthUser"
+                    }
+                },
+                "log_level": {
+                    "description": "DEBUG, INFO, WARN, or ERROR",
                     "type": "string"
                 },
-                "total": {
+                "port": {
+                    "description": "port to bind the HTTP server to",
                     "type": "integer"
                 },
-                "total_human": {
+                "protocol": {
+                    "description": "http or https -\u003e not implemented yet, will require another parameter: key_location",
                     "type": "string"
                 },
-                "used": {
+                "serial_console_buffer_kb": {
+                    "description": "how much of the VM serial console output (in KB) is kept in memory and replayed to the new clients, 64 by default",
                     "type": "integer"
                 },
-                "used_human": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.DnsSearchDomainInput": {
-            "type": "object",
-            "properties": {
-                "dns_search_domain": {
-                    "type": "string"
+                "vnc_max_viewers": {
+                    "description": "maximum number of concurrent VNC proxy viewers per VM, 0 (unlimited) by default",
+                    "type": "integer"
+                },
+                "vnc_token_ttl": {
+                    "description": "VNC proxy session token lifetime (in seconds), 60 by default",
+                    "type": "integer"
                 }
             }
         },
-        "handlers.HostAuthSshKeyInput": {
+        "RestApiConfig.SettingChange": {
             "type": "object",
             "properties": {
-                "key_value": {
+                "restart_required": {
+                    "description": "the new value will only be applied after the REST API service restart",
+                    "type": "boolean"
+                },
+                "setting": {
                     "type": "string"
                 }
             }
         },
-        "handlers.JailCloneInput": {
+        "SchedulerUtils.Job": {
             "type": "object",
             "properties": {
-                "jail_name": {
-                    "type": "string"
+                "job_done": {
+                    "type": "boolean"
                 },
-                "new_jail_name": {
+                "job_done_logged": {
+                    "type": "boolean"
+                },
+                "job_error": {
                     "type": "string"
                 },
-                "snapshot_name": {
+                "job_failed": {
+                    "type": "boolean"
+                },
+                "job_failed_logged": {
+                    "type": "boolean"
+                },
+                "job_id": {
                     "type": "string"
-                }
-            }
-        },
-        "handlers.JailDnsInput": {
-            "type": "object",
-            "properties": {
-                "dns_server": {
+                },
+                "job_in_progress": {
+                    "type": "boolean"
+                },
+                "job_next": {
+                    "type": "boolean"
+                },
+                "job_type": {
                     "type": "string"
                 },
-                "search_domain": {
+                "replication": {
+                    "$ref": "#/definitions/SchedulerUtils.ReplicationJob"
+                },
+                "res_type": {
                     "type": "string"
+                },
+                "snapshot": {
+                    "$ref": "#/definitions/SchedulerUtils.SnapshotJob"
+                },
+                "time_added": {
+                    "type": "integer"
+                },
+                "time_finished": {
+                    "type": "integer"
                 }
             }
         },
-        "handlers.JailNetworkInput": {
+        "SchedulerUtils.ReplicationJob": {
             "type": "object",
             "properties": {
-                "ip_address": {
-                    "type": "string"
+                "done_snaps": {
+                    "type": "integer"
                 },
-                "network_bridge": {
+                "progress_bytes_done": {
+                    "type": "integer"
+                },
+                "progress_bytes_total": {
+                    "type": "integer"
+                },
+                "res_name": {
                     "type": "string"
-                }
-            }
-        },
-        "handlers.JailShells": {
-            "type": "object",
-            "properties": {
-                "available_shells": {
+                },
+                "scripts_remove": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
-                }
-            }
-        },
-        "handlers.ResourceDescription": {
-            "type": "object",
-            "properties": {
-                "description": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.SnapshotInput": {
-            "type": "object",
-            "properties": {
-                "new_res_name": {
-                    "description": "Used in clone operation, e.g. newVmName, the internal call will automatically append the dataset name",
-                    "type": "string"
                 },
-                "res_name": {
-                    "description": "VM or Jail name",
-                    "type": "string"
+                "scripts_replicate": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "snapshot_description": {
-                    "description": "Description of the snapshot",
-                    "type": "string"
+                "speed_limit": {
+                    "type": "integer"
                 },
-                "snapshot_name": {
-                    "description": "Full snapshot name, including the whole path, e.g. \"tank/vm-encrypted/vmTest1@snap1\"",
+                "ssh_endpoint": {
                     "type": "string"
                 },
-                "snapshot_type": {
-                    "description": "\"hourly\", \"daily\", \"weekly\", \"monthly\", \"frequent\"",
+                "ssh_key": {
                     "type": "string"
                 },
-                "snapshots_to_keep": {
-                    "description": "How many snapshots to keep, e.g. 5",
+                "ssh_port": {
                     "type": "integer"
-                }
-            }
-        },
-        "handlers.SnapshotName": {
-            "type": "object",
-            "properties": {
-                "resource_name": {
-                    "description": "VM or Jail name",
-                    "type": "string"
-                },
-                "snapshot_name": {
-                    "description": "Full snapshot name, including the whole path, e.g. \"tank/vm-encrypted/vmTest1@snap1\"",
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.SshKeyInput": {
-            "type": "object",
-            "properties": {
-                "key_comment": {
-                    "type": "string"
                 },
-                "key_value": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.SwaggerError": {
-            "type": "object",
-            "properties": {
-                "id": {
+                "total_snaps": {
                     "type": "integer"
                 },
-                "message": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.SwaggerSuccess": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "description": "success",
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.TagInput": {
-            "type": "object",
-            "properties": {
-                "tag": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.UpstreamDnsInput": {
-            "type": "object",
-            "properties": {
-                "dns_server": {
+                "zfs_dataset": {
                     "type": "string"
                 }
             }
         },
-        "handlers.VmCloneInput": {
+        "SchedulerUtils.SnapshotJob": {
             "type": "object",
             "properties": {
-                "new_vm_name": {
+                "res_name": {
                     "type": "string"
                 },
                 "snapshot_name": {
+                    "description": "only used in the snapshot destroy jobs",
                     "type": "string"
                 },
-                "vm_name": {
+                "snapshot_type": {
                     "type": "string"
-                }
-            }
-        },
-        "handlers.VmCpuInput": {
-            "type": "object",
-            "properties": {
-                "cpu_cores": {
-                    "type": "integer"
                 },
-                "cpu_sockets": {
+                "snapshots_to_keep": {
                     "type": "integer"
                 },
-                "cpu_threads": {
-                    "type": "integer"
+                "take_immediately": {
+                    "type": "boolean"
+                },
+                "zfs_dataset": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.VmDiskExpandInput": {
+        "VncProxy.Session": {
             "type": "object",
             "properties": {
-                "disk_image": {
+                "remote_address": {
                     "type": "string"
                 },
-                "expansion_size": {
-                    "type": "integer"
-                }
-            }
-        },
-        "handlers.VmOsSettings": {
-            "type": "object",
-            "properties": {
-                "os_comment": {
+                "started_at": {
                     "type": "string"
                 },
-                "os_type": {
+                "user": {
+                    "type": "string"
+                },
+                "vm_name": {
                     "type": "string"
                 }
             }
         },
-        "handlers.VmRamInput": {
+        "handlers.SwaggerError": {
             "type": "object",
             "properties": {
-                "bytes_value": {
-                    "type": "string"
+                "code": {
+                    "type": "string",
+                    "enum": [
+                        "INTERNAL_ERROR",
+                        "BAD_REQUEST",
+                        "INVALID_INPUT",
+                        "UNAUTHORIZED",
+                        "ROUTE_NOT_FOUND",
+                        "RESOURCE_NOT_FOUND",
+                        "VM_NOT_FOUND",
+                        "VM_RUNNING",
+                        "VM_NOT_RUNNING",
+                        "JAIL_NOT_FOUND",
+                        "JAIL_RUNNING",
+                        "JAIL_NOT_RUNNING",
+                        "RESOURCE_IS_BACKUP",
+                        "SNAPSHOT_NOT_FOUND",
+                        "SNAPSHOT_TYPE_INVALID",
+                        "SNAPSHOT_HAS_CLONES",
+                        "DATASET_LOCKED",
+                        "DATASET_BUSY",
+                        "NETWORK_NOT_FOUND",
+                        "HOST_NOT_FOUND",
+                        "HOST_DISABLED",
+                        "CONFIG_CONFLICT",
+                        "PRECONDITION_REQUIRED",
+                        "CONSOLE_WRITER_TAKEN",
+                        "VNC_TOKEN_INVALID",
+                        "TOO_MANY_VIEWERS",
+                        "BULK_OPERATION_NOT_FOUND",
+                        "ADMISSION_DENIED",
+                        "NAME_CONFLICT",
+                        "DATASET_NOT_ACTIVE",
+                        "ARCHIVE_INVALID",
+                        "IMPORT_NETWORK_MISSING",
+                        "AUTH_LOCKED_OUT"
+                    ]
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 },
-                "ram_amount": {
+                "id": {
+                    "description": "legacy numeric error ID, use \"code\" instead",
                     "type": "integer"
-                }
-            }
-        },
-        "handlers.VmTemplateLink": {
-            "type": "object",
-            "properties": {
-                "vm_template_link": {
+                },
+                "message": {
                     "type": "string"
                 }
             }
         },
-        "handlers.ZfsDatasetInput": {
+        "handlers.SwaggerSuccess": {
             "type": "object",
             "properties": {
-                "dataset": {
+                "message": {
+                    "description": "success",
                     "type": "string"
                 }
             }
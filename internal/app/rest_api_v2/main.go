@@ -4,13 +4,18 @@
 package main
 
 import (
+	ApiAudit "HosterCore/internal/app/rest_api_v2/pkg/audit"
+	RestApiConfig "HosterCore/internal/app/rest_api_v2/pkg/config"
 	"HosterCore/internal/app/rest_api_v2/pkg/handlers"
 	HandlersHA "HosterCore/internal/app/rest_api_v2/pkg/handlers_ha"
+	MiddlewareConcurrency "HosterCore/internal/app/rest_api_v2/pkg/middleware/concurrency"
 	MiddlewareLogging "HosterCore/internal/app/rest_api_v2/pkg/middleware/logging"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -40,6 +45,9 @@ func main() {
 		}
 	}
 
+	// Reload the API config on SIGHUP, or when the config file changes
+	go watchApiConfig()
+
 	r := mux.NewRouter()
 	// log = MiddlewareLogging.Configure(logrus.DebugLevel)
 
@@ -47,6 +55,10 @@ func main() {
 	log = MiddlewareLogging.Configure(logrus.DebugLevel)
 	handlers.SetLogConfig(log)
 	r.Use(log.LogResponses)
+	// Middleware -> Audit log of all mutating requests
+	r.Use(ApiAudit.Middleware)
+	// Middleware -> If-Match (optimistic concurrency) checks for the VM and jail settings changes
+	r.Use(MiddlewareConcurrency.IfMatch)
 
 	// Health checks
 	// r.HandleFunc("/api/v2/health", handlers.HealthCheck).Methods("GET")
@@ -54,6 +66,7 @@ func main() {
 	r.HandleFunc("/api/v2/health/auth/ha", handlers.HealthCheckHaAuth).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/health/auth/any", handlers.HealthCheckAnyAuth).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/health/auth/regular", handlers.HealthCheckRegularAuth).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/errors", handlers.ErrorCatalog).Methods(http.MethodGet)
 	// Host
 	r.HandleFunc("/api/v2/host/info", handlers.HostInfo).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/host/readme", handlers.GetHostReadme).Methods(http.MethodGet)
@@ -62,6 +75,7 @@ func main() {
 	r.HandleFunc("/api/v2/host/settings/delete-tag/{tag}", handlers.DeleteHostTag).Methods(http.MethodDelete)
 	r.HandleFunc("/api/v2/host/settings/delete-tag/{tag}", handlers.DeleteHostTag).Methods(http.MethodPost) // additional POST method for the clients that do not support DELETE
 	r.HandleFunc("/api/v2/host/settings/api", handlers.HostRestApiSettings).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/host/settings/api/reload", handlers.HostRestApiSettingsReload).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/host/settings/dns-search-domain", handlers.PostHostSettingsDnsSearchDomain).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/host/settings/vm-templates", handlers.PostHostSettingsVmTemplateLink).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/host/settings/add-upstream-dns", handlers.PostHostSettingsAddUpstreamDns).Methods(http.MethodPost)
@@ -71,6 +85,8 @@ func main() {
 	r.HandleFunc("/api/v2/host/settings/add-ssh-key", handlers.PostHostSettingsSshKey).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/host/settings/delete-ssh-key", handlers.DeleteHostSettingsSshKey).Methods(http.MethodDelete)
 	r.HandleFunc("/api/v2/host/settings/delete-ssh-key", handlers.DeleteHostSettingsSshKey).Methods(http.MethodPost) // additional POST method for the clients that do not support DELETE
+	r.HandleFunc("/api/v2/host/security/lockouts", handlers.HostSecurityLockouts).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/host/security/lockouts/clear", handlers.HostSecurityLockoutsClear).Methods(http.MethodDelete, http.MethodPost)
 	// Datasets
 	r.HandleFunc("/api/v2/dataset/all", handlers.DatasetList).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/dataset/unlock", handlers.UnlockEncryptedDataset).Methods(http.MethodPost)
@@ -82,6 +98,7 @@ func main() {
 	r.HandleFunc("/api/v2/vm/all/cache", handlers.VmListCache).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/vm/info/{vm_name}", handlers.VmInfo).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/vm/settings/{vm_name}", handlers.VmGetSettings).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/vm/settings/{vm_name}", handlers.VmPatchSettings).Methods(http.MethodPatch)
 	r.HandleFunc("/api/v2/vm/settings/cpu/{vm_name}", handlers.VmPostCpuInfo).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/vm/settings/ram/{vm_name}", handlers.VmPostRamInfo).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/vm/settings/os-info/{vm_name}", handlers.VmPostOsSettings).Methods(http.MethodPost)
@@ -94,7 +111,12 @@ func main() {
 	r.HandleFunc("/api/v2/vm/settings/unmount-iso/{vm_name}", handlers.VmPostUnmountIso).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/vm/settings/disk/add-new/{vm_name}", handlers.VmPostAddNewDisk).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/vm/settings/disk/expand/{vm_name}", handlers.VmPostExpandDisk).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/vm/settings/disk/detach/{vm_name}", handlers.VmPostDetachDisk).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/vm/settings/disk/remove/{vm_name}", handlers.VmPostRemoveDisk).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/vm/settings/disk/reorder/{vm_name}", handlers.VmPostReorderDisks).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/vm/settings/network/add/{vm_name}", handlers.VmPostAddNewNetwork).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/vm/settings/network/remove/{vm_name}/{nic_index}", handlers.VmPostRemoveNetwork).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/vm/settings/network/update/{vm_name}/{nic_index}", handlers.VmPostUpdateNetwork).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/vm/settings/description/{vm_name}", handlers.VmPostDescription).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/vm/templates", handlers.VmGetTemplates).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/vm/cloud-init/mount-iso/{vm_name}", handlers.VmPostMountCiIso).Methods(http.MethodPost)
@@ -109,7 +131,15 @@ func main() {
 	r.HandleFunc("/api/v2/vm/stop/force/{vm_name}", handlers.VmPostStopForce).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/vm/clone", handlers.VmClone).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/vm/deploy", handlers.VmPostDeploy).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/vm/apply", handlers.VmApply).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/vm/destroy/{vm_name}", handlers.VmDestroy).Methods(http.MethodDelete, http.MethodPost)
+	r.HandleFunc("/api/v2/vm/rename/{vm_name}", handlers.VmPostRename).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/vm/export/{vm_name}", handlers.VmGetExport).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/vm/import", handlers.VmPostImport).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/vm/console/serial/{vm_name}", handlers.VmSerialConsole).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/vm/console/vnc/token/{vm_name}", handlers.VmPostVncToken).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/vm/console/vnc/sessions", handlers.VmVncSessions).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/vm/console/vnc/{token}", handlers.VmVncProxy).Methods(http.MethodGet)
 	// Jails
 	r.HandleFunc("/api/v2/jail/all", handlers.JailList).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/jail/all/cache", handlers.JailListCache).Methods(http.MethodGet)
@@ -148,6 +178,12 @@ func main() {
 	r.HandleFunc("/api/v2/prometheus/autodiscovery/vms/use-ips", handlers.PrometheusAutoDiscoveryVmsIps).Methods(http.MethodGet)
 	// WireGuard
 	r.HandleFunc("/api/v2/wireguard/script", handlers.WireGuardScript).Methods(http.MethodPost)
+	// Audit
+	r.HandleFunc("/api/v2/audit", handlers.AuditLogList).Methods(http.MethodGet)
+	// Bulk operations
+	r.HandleFunc("/api/v2/bulk/operations", handlers.BulkListOperations).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/bulk/operations/{id}", handlers.BulkGetOperation).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/bulk/{action}", handlers.BulkPostAction).Methods(http.MethodPost)
 	// Scheduler
 	r.HandleFunc("/api/v2/scheduler/jobs", handlers.SchedulerGetJobs).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/scheduler/cron", handlers.SchedulerGetCron).Methods(http.MethodGet)
@@ -173,7 +209,6 @@ func main() {
 	)).Methods("GET")
 	// Define a route to serve the static file
 	r.HandleFunc("/api/v2/swagger.json", func(w http.ResponseWriter, r *http.Request) {
-		log.SetStatusCode(http.StatusOK)
 		ex, err := os.Executable()
 		if err != nil {
 			log.Error("could not get the executable path: " + err.Error())
@@ -183,7 +218,8 @@ func main() {
 		http.ServeFile(w, r, binPath+"/docs/swagger.json")
 	})
 	// Catch-all route for 404 errors
-	r.NotFoundHandler = r.NewRoute().HandlerFunc(handlers.NotFoundHandler).GetHandler()
+	// (router middlewares are not applied to the NotFoundHandler, so it has to be wrapped separately)
+	r.NotFoundHandler = log.LogResponses(http.HandlerFunc(handlers.NotFoundHandler))
 
 	bindAddress := fmt.Sprintf("%s:%d", restConf.BindToAddress, restConf.Port)
 	logInternal.Info("The REST APIv2 is bound to " + bindAddress)
@@ -199,3 +235,31 @@ func main() {
 		logInternal.Fatal("could not start the REST API server: " + err.Error())
 	}
 }
+
+func watchApiConfig() {
+	onReload := func(changes []RestApiConfig.SettingChange, err error) {
+		if err != nil {
+			logInternal.Errorf("could not reload the API config: %s", err.Error())
+			return
+		}
+		for _, v := range changes {
+			if v.RestartRequired {
+				logInternal.Warnf("API setting '%s' has been changed, but it will only be applied after the service restart", v.Setting)
+				continue
+			}
+			logInternal.Infof("API setting '%s' has been changed", v.Setting)
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			logInternal.Info("received SIGHUP, reloading the API config")
+			changes, err := RestApiConfig.ReloadApiConfig()
+			onReload(changes, err)
+		}
+	}()
+
+	RestApiConfig.WatchApiConfig(5*time.Second, onReload)
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package ApiAudit
+
+import (
+	FreeBSDLogger "HosterCore/internal/pkg/freebsd/logger"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+const DEFAULT_AUDIT_LOG_LOCATION = "/var/log/hoster_api_audit.jsonl"
+
+// A single audit log entry, written as one JSON line per mutating API request.
+type Entry struct {
+	Time          time.Time      `json:"time"`
+	RequestID     string         `json:"request_id,omitempty"`
+	User          string         `json:"user"`
+	Authenticated bool           `json:"authenticated"`
+	SourceIP      string         `json:"source_ip"`
+	Method        string         `json:"method"`
+	Route         string         `json:"route"`
+	Path          string         `json:"path"`
+	ResourceType  string         `json:"resource_type,omitempty"` // vm, jail, host, snapshot, etc
+	Resource      string         `json:"resource,omitempty"`
+	Payload       any            `json:"payload,omitempty"` // sanitized request payload
+	StatusCode    int            `json:"status_code"`
+	Success       bool           `json:"success"`
+	Error         string         `json:"error,omitempty"`
+	LatencyMs     int64          `json:"latency_ms"`
+	Changes       []ConfigChange `json:"changes,omitempty"` // before/after diff for the config changing requests
+}
+
+var writeLock sync.Mutex
+
+// Appends the entry to the audit log file, and optionally forwards it to syslog.
+func Write(entry Entry, logFile string, forwardToSyslog bool) error {
+	if len(logFile) < 1 {
+		logFile = DEFAULT_AUDIT_LOG_LOCATION
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if forwardToSyslog {
+		go FreeBSDLogger.LoggerToSyslog(FreeBSDLogger.LOGGER_SRV_API_AUDIT, FreeBSDLogger.LOGGER_LEVEL_CHANGE, string(line))
+	}
+
+	writeLock.Lock()
+	defer writeLock.Unlock()
+
+	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package ApiAudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type ConfigChange struct {
+	Path   string `json:"path"` // e.g. cpu_cores, or disks[1].disk_image
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+const redactedValue = "<redacted>"
+
+// Keys (or parts of keys) that are considered to be secret, and must never reach the audit log
+var secretKeys = []string{"password", "pass", "secret", "token", "private_key", "key_value", "script"}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, v := range secretKeys {
+		if strings.Contains(key, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Replaces all secret values in the decoded JSON object with a placeholder.
+func Sanitize(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		r := make(map[string]any, len(v))
+		for key, val := range v {
+			if isSecretKey(key) {
+				r[key] = redactedValue
+				continue
+			}
+			r[key] = Sanitize(val)
+		}
+		return r
+	case []any:
+		r := make([]any, len(v))
+		for i, val := range v {
+			r[i] = Sanitize(val)
+		}
+		return r
+	default:
+		return v
+	}
+}
+
+// Reads a JSON config file as a generic (and sanitized) object.
+// Returns nil if the file doesn't exist or can't be parsed.
+func readConfigSnapshot(location string) any {
+	if len(location) < 1 {
+		return nil
+	}
+
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return nil
+	}
+
+	var r any
+	err = json.Unmarshal(data, &r)
+	if err != nil {
+		return nil
+	}
+
+	return Sanitize(r)
+}
+
+// Compares two decoded JSON objects, and returns the list of changed values (sorted by the path).
+func Diff(before any, after any) (r []ConfigChange) {
+	b := make(map[string]any)
+	a := make(map[string]any)
+	flatten("", before, b)
+	flatten("", after, a)
+
+	for k, v := range b {
+		av, ok := a[k]
+		if !ok {
+			r = append(r, ConfigChange{Path: k, Before: v, After: nil})
+			continue
+		}
+		if !reflect.DeepEqual(v, av) {
+			r = append(r, ConfigChange{Path: k, Before: v, After: av})
+		}
+	}
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			r = append(r, ConfigChange{Path: k, Before: nil, After: v})
+		}
+	}
+
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].Path < r[j].Path
+	})
+
+	return
+}
+
+func flatten(prefix string, value any, r map[string]any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, val := range v {
+			path := key
+			if len(prefix) > 0 {
+				path = prefix + "." + key
+			}
+			flatten(path, val, r)
+		}
+	case []any:
+		if len(v) < 1 && len(prefix) > 0 {
+			r[prefix] = v
+		}
+		for i, val := range v {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), val, r)
+		}
+	default:
+		if len(prefix) > 0 {
+			r[prefix] = v
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package ApiAudit
+
+import (
+	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	RestApiConfig "HosterCore/internal/app/rest_api_v2/pkg/config"
+	MiddlewareLogging "HosterCore/internal/app/rest_api_v2/pkg/middleware/logging"
+	MiddlewareResources "HosterCore/internal/app/rest_api_v2/pkg/middleware/resources"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Request payloads larger than this are not recorded (e.g. file uploads)
+const maxPayloadSize = 1024 * 1024
+
+// Response bodies are only captured for the failed requests, to record the error message
+const maxErrorBodySize = 4096
+
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *responseRecorder) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	if w.statusCode >= 400 && w.body.Len() < maxErrorBodySize {
+		w.body.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer doesn't support hijacking")
+	}
+	return h.Hijack()
+}
+
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Request body, which is partially (or fully) buffered by the middleware, and still closes the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func isMutating(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete
+}
+
+// Middleware which writes an audit log entry for every mutating (POST, PUT, PATCH, DELETE) API request.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		timeStart := time.Now()
+		entry := Entry{Time: timeStart.UTC(), RequestID: MiddlewareLogging.RequestID(r.Context()), Method: r.Method, Path: r.URL.Path}
+		entry.User = ApiAuth.RequestUser(r)
+		entry.SourceIP = r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			entry.SourceIP = host
+		}
+		entry.Route = r.URL.Path
+		if route := mux.CurrentRoute(r); route != nil {
+			if tmpl, err := route.GetPathTemplate(); err == nil {
+				entry.Route = tmpl
+			}
+		}
+
+		// Read the payload, and put it back for the handler.
+		// Only the payloads of a known (small) size are buffered, chunked requests (ContentLength -1) are passed through as is.
+		var payload map[string]any
+		contentType := r.Header.Get("Content-Type")
+		if r.Body != nil && 0 <= r.ContentLength && r.ContentLength <= maxPayloadSize && !strings.HasPrefix(contentType, "application/octet-stream") && !strings.HasPrefix(contentType, "application/zstd") && !strings.HasPrefix(contentType, "multipart/") {
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+			// Whatever has been read already goes back in front of the rest of the body (if the read failed half way through)
+			r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+			if err == nil {
+				if json.Unmarshal(body, &payload) == nil {
+					entry.Payload = Sanitize(payload)
+				} else if len(body) > 0 {
+					entry.Payload = fmt.Sprintf("<non-json payload, %d bytes>", len(body))
+				}
+			}
+		}
+
+		entry.ResourceType, entry.Resource = targetResource(entry.Route, mux.Vars(r), payload)
+		// Config file location of the resource, so a before/after diff can be generated
+		configLocation := MiddlewareResources.ConfigLocation(entry.ResourceType, entry.Resource)
+		before := readConfigSnapshot(configLocation)
+
+		r, authResult := ApiAuth.WithAuthResult(r)
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		if rec.statusCode == 0 {
+			rec.statusCode = http.StatusOK
+		}
+		entry.StatusCode = rec.statusCode
+		entry.Success = rec.statusCode < 400
+		// Requests which never reached an auth check (e.g. rejected by the router) are not authenticated
+		_, entry.Authenticated = authResult.Result()
+		entry.LatencyMs = time.Since(timeStart).Milliseconds()
+		if !entry.Success {
+			entry.Error = errorMessage(rec.body.Bytes())
+		}
+
+		if before != nil {
+			entry.Changes = Diff(before, readConfigSnapshot(configLocation))
+		}
+
+		conf, _ := RestApiConfig.GetApiConfig()
+		_ = Write(entry, conf.AuditLogFile, conf.AuditSyslog)
+	})
+}
+
+// Extracts the error message from the standard error response (or returns the raw body otherwise).
+func errorMessage(body []byte) string {
+	resp := struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}{}
+	if json.Unmarshal(body, &resp) == nil {
+		if len(resp.Message) > 0 {
+			return resp.Message
+		}
+		if len(resp.Error) > 0 {
+			return resp.Error
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// Figures out which resource the request is targeting, using the route variables first, and the request payload second.
+func targetResource(route string, vars map[string]string, payload map[string]any) (resType string, resName string) {
+	if strings.HasPrefix(route, "/api/v2/host/") {
+		return MiddlewareResources.RESOURCE_HOST, vars["tag"]
+	}
+
+	lookup := []struct {
+		key     string
+		resType string
+	}{
+		{"vm_name", MiddlewareResources.RESOURCE_VM},
+		{"jail_name", MiddlewareResources.RESOURCE_JAIL},
+		{"snapshot_name", "snapshot"},
+		{"res_name", "resource"},
+		{"dataset", "dataset"},
+	}
+
+	for _, v := range lookup {
+		if val, ok := vars[v.key]; ok && len(val) > 0 {
+			return v.resType, val
+		}
+	}
+	for _, v := range lookup {
+		if val, ok := payload[v.key].(string); ok && len(val) > 0 {
+			return v.resType, val
+		}
+	}
+
+	return
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package ApiAudit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+const DEFAULT_QUERY_LIMIT = 100
+
+type Filter struct {
+	User     string
+	SourceIP string
+	Resource string
+	Route    string // substring match
+	Method   string
+	Outcome  string // success or failure
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+func (f Filter) match(e Entry) bool {
+	if len(f.User) > 0 && e.User != f.User {
+		return false
+	}
+	if len(f.SourceIP) > 0 && e.SourceIP != f.SourceIP {
+		return false
+	}
+	if len(f.Resource) > 0 && e.Resource != f.Resource {
+		return false
+	}
+	if len(f.Route) > 0 && !strings.Contains(e.Route, f.Route) && !strings.Contains(e.Path, f.Route) {
+		return false
+	}
+	if len(f.Method) > 0 && !strings.EqualFold(e.Method, f.Method) {
+		return false
+	}
+	if f.Outcome == "success" && !e.Success {
+		return false
+	}
+	if f.Outcome == "failure" && e.Success {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Reads the audit log, and returns the entries matching the filter (newest first).
+func Query(logFile string, f Filter) (r []Entry, e error) {
+	if len(logFile) < 1 {
+		logFile = DEFAULT_AUDIT_LOG_LOCATION
+	}
+	if f.Limit < 1 {
+		f.Limit = DEFAULT_QUERY_LIMIT
+	}
+	if len(f.Outcome) > 0 && f.Outcome != "success" && f.Outcome != "failure" {
+		e = errors.New("outcome must be either 'success' or 'failure'")
+		return
+	}
+
+	r = []Entry{}
+	file, err := os.Open(logFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		e = err
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		entry := Entry{}
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		if !f.match(entry) {
+			continue
+		}
+		r = append(r, entry)
+		// Only keep the most recent entries
+		if len(r) > f.Limit {
+			r = r[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		e = err
+		return
+	}
+
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+
+	return
+}
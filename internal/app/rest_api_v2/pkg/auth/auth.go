@@ -2,58 +2,87 @@ package ApiAuth
 
 import (
 	RestApiConfig "HosterCore/internal/app/rest_api_v2/pkg/config"
+	"crypto/subtle"
 	"net/http"
+	"strings"
 )
 
 // Check if the user is the regular REST API User, and confirms user credentials.
 // Returns true if we were able to confirm both.
 func CheckRestUser(r *http.Request) bool {
+	return checkUsers(r, func(v RestApiConfig.HTTPAuthUser) bool { return v.AdminUser })
+}
+
+// Checks if the user is an HA User, and confirms user credentials.
+// Returns true if we were able to confirm both.
+func CheckHaUser(r *http.Request) bool {
+	return checkUsers(r, func(v RestApiConfig.HTTPAuthUser) bool { return v.HaUser })
+}
+
+// Checks if the user is the Prometheus User, and confirms user credentials.
+func CheckPrometheusUser(r *http.Request) bool {
+	return checkUsers(r, func(v RestApiConfig.HTTPAuthUser) bool { return v.PrometheusUser })
+}
+
+// Could be useful in some cases. Might delete later, after the initial testing.
+func CheckAnyUser(r *http.Request) bool {
+	return checkUsers(r,
+		func(v RestApiConfig.HTTPAuthUser) bool { return v.HaUser },
+		func(v RestApiConfig.HTTPAuthUser) bool { return v.AdminUser },
+		func(v RestApiConfig.HTTPAuthUser) bool { return v.PrometheusUser },
+	)
+}
+
+// Confirms the request credentials against the first user matching each of the user type filters.
+// Failed attempts are counted (per source IP, and per user name across all source IPs), and the lockout is applied once the limit is reached.
+// Allow-listed addresses (HA peers, "auth_allow_list") are never locked out.
+// The result is recorded in the request context, for the audit log.
+func checkUsers(r *http.Request, userTypes ...func(RestApiConfig.HTTPAuthUser) bool) bool {
+	return recordAuthResult(r, verifyUsers(r, userTypes...))
+}
+
+func verifyUsers(r *http.Request, userTypes ...func(RestApiConfig.HTTPAuthUser) bool) bool {
 	user, pass, _ := r.BasicAuth()
-	// fmt.Println(user, pass)
-	userCheck := ""
-	passCheck := ""
 
 	// Load the REST API Config
 	conf, err := RestApiConfig.GetApiConfig()
 	if err != nil {
 		return false
 	}
-	// Find the right user
-	for _, v := range conf.HTTPAuth {
-		if v.AdminUser {
-			userCheck = v.User
-			passCheck = v.Password
-			break
-		}
+
+	// Bearer tokens are counted against the token owner, or an empty user name if the token is unknown
+	token := bearerToken(r)
+	if len(token) > 0 {
+		user = tokenOwner(conf, token)
 	}
-	// Password cannot be empty
-	if len(userCheck) < 1 || len(passCheck) < 1 || len(user) < 1 || len(pass) < 1 {
+
+	sourceIp := requestSourceIp(r)
+	if !isAllowListed(conf, sourceIp) && IsLockedOut(sourceIp, user) {
 		return false
 	}
-	// Check user credentials
-	if userCheck == user && passCheck == pass {
-		return true
+
+	for _, userType := range userTypes {
+		if checkCredentials(conf, userType, user, pass) || checkToken(conf, userType, token) {
+			registerSuccess(sourceIp, user)
+			return true
+		}
+	}
+
+	// Requests without any credentials are not counted as failures (e.g. the first browser request before the auth prompt)
+	if len(user) > 0 || len(pass) > 0 || len(token) > 0 {
+		registerFailure(conf, sourceIp, user)
 	}
 
 	return false
 }
 
-// Checks if the user is an HA User, and confirms user credentials.
-// Returns true if we were able to confirm both.
-func CheckHaUser(r *http.Request) bool {
-	user, pass, _ := r.BasicAuth()
-	// fmt.Println(user, pass)
+func checkCredentials(conf RestApiConfig.RestApiConfig, userType func(RestApiConfig.HTTPAuthUser) bool, user string, pass string) bool {
 	userCheck := ""
 	passCheck := ""
 
-	// Load the REST API Config
-	conf, err := RestApiConfig.GetApiConfig()
-	if err != nil {
-		return false
-	}
 	// Find the right user
 	for _, v := range conf.HTTPAuth {
-		if v.HaUser {
+		if userType(v) {
 			userCheck = v.User
 			passCheck = v.Password
 			break
@@ -71,42 +100,48 @@ func CheckHaUser(r *http.Request) bool {
 	return false
 }
 
-// Checks if the user is the Prometheus User, and confirms user credentials.
-func CheckPrometheusUser(r *http.Request) bool {
-	user, pass, _ := r.BasicAuth()
-	// fmt.Println(user, pass)
-	userCheck := ""
-	passCheck := ""
-
-	// Load the REST API Config
-	conf, err := RestApiConfig.GetApiConfig()
-	if err != nil {
+func checkToken(conf RestApiConfig.RestApiConfig, userType func(RestApiConfig.HTTPAuthUser) bool, token string) bool {
+	if len(token) < 1 {
 		return false
 	}
-	// Find the right user
+
 	for _, v := range conf.HTTPAuth {
-		if v.PrometheusUser {
-			userCheck = v.User
-			passCheck = v.Password
-			break
+		if userType(v) {
+			return len(v.Token) > 0 && subtle.ConstantTimeCompare([]byte(v.Token), []byte(token)) == 1
 		}
 	}
-	// Password cannot be empty
-	if len(userCheck) < 1 || len(passCheck) < 1 || len(user) < 1 || len(pass) < 1 {
-		return false
-	}
-	// Check user credentials
-	if userCheck == user && passCheck == pass {
-		return true
-	}
 
 	return false
 }
 
-// Could be useful in some cases. Might delete later, after the initial testing.
-func CheckAnyUser(r *http.Request) bool {
-	if CheckHaUser(r) || CheckRestUser(r) || CheckPrometheusUser(r) {
-		return true
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return ""
 	}
-	return false
+	return strings.TrimSpace(auth[7:])
+}
+
+func tokenOwner(conf RestApiConfig.RestApiConfig, token string) string {
+	for _, v := range conf.HTTPAuth {
+		if len(v.Token) > 0 && subtle.ConstantTimeCompare([]byte(v.Token), []byte(token)) == 1 {
+			return v.User
+		}
+	}
+	return ""
+}
+
+// Returns the user name used to authenticate the request (either via the basic auth, or the bearer token).
+func RequestUser(r *http.Request) string {
+	token := bearerToken(r)
+	if len(token) < 1 {
+		user, _, _ := r.BasicAuth()
+		return user
+	}
+
+	conf, err := RestApiConfig.GetApiConfig()
+	if err != nil {
+		return ""
+	}
+	return tokenOwner(conf, token)
 }
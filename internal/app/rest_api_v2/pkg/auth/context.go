@@ -0,0 +1,48 @@
+package ApiAuth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type ctxKey int
+
+const authResultKey ctxKey = 0
+
+// Outcome of the credential check performed by the request handler.
+// Set by the auth checks, and read by the middlewares once the handler returns (e.g. the audit log).
+type AuthResult struct {
+	mu            sync.Mutex
+	checked       bool
+	authenticated bool
+}
+
+// Returns true if the handler checked the request credentials, and whether the check has passed.
+// If the handler called more than one check, the request counts as authenticated if any of them passed.
+func (a *AuthResult) Result() (checked bool, authenticated bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.checked, a.authenticated
+}
+
+func (a *AuthResult) record(authenticated bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checked = true
+	a.authenticated = a.authenticated || authenticated
+}
+
+// Attaches an empty AuthResult to the request context, which will be filled in by the auth checks down the chain.
+func WithAuthResult(r *http.Request) (*http.Request, *AuthResult) {
+	result := &AuthResult{}
+	return r.WithContext(context.WithValue(r.Context(), authResultKey, result)), result
+}
+
+// Records the auth check result in the request context (if the AuthResult has been attached to it).
+func recordAuthResult(r *http.Request, authenticated bool) bool {
+	if result, ok := r.Context().Value(authResultKey).(*AuthResult); ok {
+		result.record(authenticated)
+	}
+	return authenticated
+}
@@ -0,0 +1,237 @@
+package ApiAuth
+
+import (
+	RestApiConfig "HosterCore/internal/app/rest_api_v2/pkg/config"
+	FreeBSDLogger "HosterCore/internal/pkg/freebsd/logger"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	LOCKOUT_TYPE_IP   = "ip"
+	LOCKOUT_TYPE_USER = "user"
+)
+
+// Failures older than this are forgotten, and the lockout time starts from the beginning again
+const failureMemory = 24 * time.Hour
+
+type LockoutEntry struct {
+	Type        string    `json:"type"`                // ip or user
+	Key         string    `json:"key"`                 // source IP address or user name
+	SourceIp    string    `json:"source_ip,omitempty"` // user lockouts only: source IP of the last failure
+	Failures    int       `json:"failures"`
+	Lockouts    int       `json:"lockouts"`
+	LastFailure time.Time `json:"last_failure"`
+	LockedUntil time.Time `json:"locked_until"`
+	Locked      bool      `json:"locked"`
+}
+
+var lockoutMu sync.Mutex
+var lockouts = make(map[string]*LockoutEntry)
+
+func lockoutKey(lockoutType string, key string) string {
+	return lockoutType + "/" + key
+}
+
+// Returns the request's source IP address (without the port).
+func requestSourceIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Checks if the source IP, or the user name (coming from any source IP) is currently locked out.
+func IsLockedOut(sourceIp string, user string) bool {
+	return LockoutRemaining(sourceIp, user) > 0
+}
+
+// Returns the time left until the source IP and the user name lockouts expire (0 if neither is locked out).
+func LockoutRemaining(sourceIp string, user string) (r time.Duration) {
+	lockoutMu.Lock()
+	defer lockoutMu.Unlock()
+
+	keys := []string{lockoutKey(LOCKOUT_TYPE_IP, sourceIp)}
+	if len(user) > 0 {
+		keys = append(keys, lockoutKey(LOCKOUT_TYPE_USER, user))
+	}
+
+	now := time.Now()
+	for _, k := range keys {
+		v, ok := lockouts[k]
+		if ok && v.LockedUntil.Sub(now) > r {
+			r = v.LockedUntil.Sub(now)
+		}
+	}
+
+	return
+}
+
+// Same as LockoutRemaining, but for the source IP and the user name of the request (allow-listed addresses are never locked out).
+func RequestLockoutRemaining(r *http.Request) time.Duration {
+	conf, err := RestApiConfig.GetApiConfig()
+	if err != nil {
+		return 0
+	}
+
+	sourceIp := requestSourceIp(r)
+	if isAllowListed(conf, sourceIp) {
+		return 0
+	}
+	return LockoutRemaining(sourceIp, RequestUser(r))
+}
+
+func registerSuccess(sourceIp string, user string) {
+	lockoutMu.Lock()
+	defer lockoutMu.Unlock()
+
+	delete(lockouts, lockoutKey(LOCKOUT_TYPE_IP, sourceIp))
+	if len(user) > 0 {
+		delete(lockouts, lockoutKey(LOCKOUT_TYPE_USER, user))
+	}
+}
+
+func registerFailure(conf RestApiConfig.RestApiConfig, sourceIp string, user string) {
+	if isAllowListed(conf, sourceIp) {
+		return
+	}
+
+	lockoutMu.Lock()
+	defer lockoutMu.Unlock()
+
+	countFailure(conf, lockoutKey(LOCKOUT_TYPE_IP, sourceIp), LockoutEntry{Type: LOCKOUT_TYPE_IP, Key: sourceIp})
+	// User name failures are counted across all source IPs, so rotating the source address doesn't avoid the lockout
+	if len(user) > 0 {
+		countFailure(conf, lockoutKey(LOCKOUT_TYPE_USER, user), LockoutEntry{Type: LOCKOUT_TYPE_USER, Key: user, SourceIp: sourceIp})
+	}
+}
+
+// Must be called with the lockoutMu held
+func countFailure(conf RestApiConfig.RestApiConfig, k string, entry LockoutEntry) {
+	now := time.Now()
+
+	v, ok := lockouts[k]
+	if !ok || now.Sub(v.LastFailure) > failureMemory {
+		v = &entry
+		lockouts[k] = v
+	}
+
+	v.Failures += 1
+	v.LastFailure = now
+	v.SourceIp = entry.SourceIp
+	if v.Failures < conf.AuthMaxFailures {
+		return
+	}
+
+	// Exponential lockout: initial lockout time, doubled on every subsequent lockout
+	lockoutTime := time.Duration(conf.AuthLockoutTime) * time.Second
+	maxLockoutTime := time.Duration(conf.AuthLockoutMaxTime) * time.Second
+	for i := 0; i < v.Lockouts && lockoutTime < maxLockoutTime; i++ {
+		lockoutTime = lockoutTime * 2
+	}
+	if lockoutTime > maxLockoutTime {
+		lockoutTime = maxLockoutTime
+	}
+
+	v.Lockouts += 1
+	v.Failures = 0
+	v.LockedUntil = now.Add(lockoutTime)
+
+	subject := fmt.Sprintf("%s '%s'", v.Type, v.Key)
+	if len(v.SourceIp) > 0 {
+		subject = subject + " (last failure from " + v.SourceIp + ")"
+	}
+	message := fmt.Sprintf("REST API: %s has been locked out for %s (lockout #%d) due to repeated authentication failures", subject, lockoutTime, v.Lockouts)
+	go FreeBSDLogger.LoggerToSyslog(FreeBSDLogger.LOGGER_SRV_REST_API, FreeBSDLogger.LOGGER_LEVEL_WARNING, message)
+}
+
+// HA peers, and the IPs/networks from the "auth_allow_list" are never locked out.
+func isAllowListed(conf RestApiConfig.RestApiConfig, sourceIp string) bool {
+	ip := net.ParseIP(sourceIp)
+	if ip == nil {
+		return false
+	}
+
+	allowList := conf.AuthAllowList
+	haConf, err := RestApiConfig.GetHaConfig()
+	if err == nil {
+		for _, v := range haConf.Candidates {
+			allowList = append(allowList, v.Address)
+		}
+	}
+
+	for _, v := range allowList {
+		_, network, err := net.ParseCIDR(v)
+		if err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if allowedIp := net.ParseIP(v); allowedIp != nil && allowedIp.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the list of all tracked authentication failures and lockouts.
+func ListLockouts() (r []LockoutEntry) {
+	lockoutMu.Lock()
+	defer lockoutMu.Unlock()
+
+	r = []LockoutEntry{}
+	now := time.Now()
+	for k, v := range lockouts {
+		if now.Sub(v.LastFailure) > failureMemory {
+			delete(lockouts, k)
+			continue
+		}
+		entry := *v
+		entry.Locked = now.Before(v.LockedUntil)
+		r = append(r, entry)
+	}
+
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].LastFailure.After(r[j].LastFailure)
+	})
+
+	return
+}
+
+// Clears the lockout for a specific source IP or user name.
+// If both lockoutType and key are empty, all lockouts are cleared.
+func ClearLockout(lockoutType string, key string) error {
+	lockoutMu.Lock()
+	defer lockoutMu.Unlock()
+
+	if len(lockoutType) < 1 && len(key) < 1 {
+		lockouts = make(map[string]*LockoutEntry)
+		go FreeBSDLogger.LoggerToSyslog(FreeBSDLogger.LOGGER_SRV_REST_API, FreeBSDLogger.LOGGER_LEVEL_CHANGE, "REST API: all authentication lockouts have been cleared")
+		return nil
+	}
+
+	if lockoutType != LOCKOUT_TYPE_IP && lockoutType != LOCKOUT_TYPE_USER {
+		return fmt.Errorf("lockout type must be either '%s' or '%s'", LOCKOUT_TYPE_IP, LOCKOUT_TYPE_USER)
+	}
+
+	found := false
+	for k, v := range lockouts {
+		if v.Type == lockoutType && v.Key == key {
+			delete(lockouts, k)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("lockout could not be found: %s '%s'", lockoutType, key)
+	}
+
+	go FreeBSDLogger.LoggerToSyslog(FreeBSDLogger.LOGGER_SRV_REST_API, FreeBSDLogger.LOGGER_LEVEL_CHANGE, fmt.Sprintf("REST API: authentication lockout has been cleared for %s '%s'", lockoutType, key))
+	return nil
+}
@@ -9,19 +9,32 @@ import (
 )
 
 type RestApiConfig struct {
-	BindToAddress string `json:"bind"`      // can be empty, 0.0.0.0 used by default
-	Port          int    `json:"port"`      // port to bind the HTTP server to
-	Protocol      string `json:"protocol"`  // http or https -> not implemented yet, will require another parameter: key_location
-	HaMode        bool   `json:"ha_mode"`   // whether to start the API server in an HA cluster mode
-	HaDebug       bool   `json:"ha_debug"`  // ha_debug allows you to test the HA Mode, because instead of applying the real actions, ha_debug will only log them instead
-	LogLevel      string `json:"log_level"` // DEBUG, INFO, WARN, or ERROR
-	HTTPAuth      []struct {
-		User           string `json:"user"`            // user name for the basic HTTP auth
-		Password       string `json:"password"`        // password for the basic HTTP auth
-		HaUser         bool   `json:"ha_user"`         // HA User has access to a different set of routes than the regular REST API user, and vise versa. Has been implemented to limit per-user API exposure, aka normal user is not authorized to call HA related routes.
-		PrometheusUser bool   `json:"prometheus_user"` // Prometheus User has access to the Prometheus metrics endpoint
-		AdminUser      bool   `json:"admin_user"`      // Admin User has access to the Admin API routes
-	} `json:"http_auth"`
+	BindToAddress         string         `json:"bind"`                               // can be empty, 0.0.0.0 used by default
+	Port                  int            `json:"port"`                               // port to bind the HTTP server to
+	Protocol              string         `json:"protocol"`                           // http or https -> not implemented yet, will require another parameter: key_location
+	HaMode                bool           `json:"ha_mode"`                            // whether to start the API server in an HA cluster mode
+	HaDebug               bool           `json:"ha_debug"`                           // ha_debug allows you to test the HA Mode, because instead of applying the real actions, ha_debug will only log them instead
+	LogLevel              string         `json:"log_level"`                          // DEBUG, INFO, WARN, or ERROR
+	SerialConsoleBufferKb int            `json:"serial_console_buffer_kb,omitempty"` // how much of the VM serial console output (in KB) is kept in memory and replayed to the new clients, 64 by default
+	VncTokenTtl           int            `json:"vnc_token_ttl,omitempty"`            // VNC proxy session token lifetime (in seconds), 60 by default
+	VncMaxViewers         int            `json:"vnc_max_viewers,omitempty"`          // maximum number of concurrent VNC proxy viewers per VM, 0 (unlimited) by default
+	AuthMaxFailures       int            `json:"auth_max_failures,omitempty"`        // number of failed authentication attempts (per source IP or user name) before the lockout is applied, 5 by default
+	AuthLockoutTime       int            `json:"auth_lockout_time,omitempty"`        // initial lockout time in seconds, doubled on every subsequent lockout, 30 by default
+	AuthLockoutMaxTime    int            `json:"auth_lockout_max_time,omitempty"`    // maximum lockout time in seconds, 3600 by default
+	AuthAllowList         []string       `json:"auth_allow_list,omitempty"`          // IP addresses or CIDR ranges that are never locked out (HA peers are always included)
+	AuditLogFile          string         `json:"audit_log_file,omitempty"`           // audit log location (JSON lines), /var/log/hoster_api_audit.jsonl by default
+	AuditSyslog           bool           `json:"audit_syslog,omitempty"`             // forward the audit log entries to syslog
+	AllowMissingIfMatch   bool           `json:"allow_missing_if_match,omitempty"`   // accept the VM and jail settings changes without the If-Match header (ETag from the GET settings response), rejected with 428 by default
+	HTTPAuth              []HTTPAuthUser `json:"http_auth"`
+}
+
+type HTTPAuthUser struct {
+	User           string `json:"user"`            // user name for the basic HTTP auth
+	Password       string `json:"password"`        // password for the basic HTTP auth
+	HaUser         bool   `json:"ha_user"`         // HA User has access to a different set of routes than the regular REST API user, and vise versa. Has been implemented to limit per-user API exposure, aka normal user is not authorized to call HA related routes.
+	PrometheusUser bool   `json:"prometheus_user"` // Prometheus User has access to the Prometheus metrics endpoint
+	AdminUser      bool   `json:"admin_user"`      // Admin User has access to the Admin API routes
+	Token          string `json:"token,omitempty"` // optional API token, accepted in the "Authorization: Bearer <token>" header instead of the user name and password
 }
 
 const confFileName = "restapi_config.json"
@@ -41,8 +54,31 @@ func GetApiConfigLocation() (r string, e error) {
 	return
 }
 
-// Parses the host_config.json, and returns the underlying struct or an error
+// Returns the currently loaded REST API config.
+// The config is read from disk only once (on the first call), and is then kept in memory until it's reloaded using ReloadApiConfig().
 func GetApiConfig() (r RestApiConfig, e error) {
+	if c := currentConfig.Load(); c != nil {
+		r = *c
+		return
+	}
+
+	_, e = ReloadApiConfig()
+	if e != nil {
+		return
+	}
+
+	r = *currentConfig.Load()
+	return
+}
+
+// Reads the REST API config from disk on every call, bypassing the in-memory copy used by GetApiConfig().
+// Meant for the long running services other than the REST API itself (e.g. ha_carp), which don't reload the config.
+func LoadApiConfig() (r RestApiConfig, e error) {
+	return readApiConfig()
+}
+
+// Parses the restapi_config.json, and returns the underlying struct (with the defaults applied) or an error
+func readApiConfig() (r RestApiConfig, e error) {
 	apiConfigFile, err := GetApiConfigLocation()
 	if err != nil {
 		e = err
@@ -64,6 +100,15 @@ func GetApiConfig() (r RestApiConfig, e error) {
 	if len(r.LogLevel) < 1 {
 		r.LogLevel = "DEBUG"
 	}
+	if r.AuthMaxFailures < 1 {
+		r.AuthMaxFailures = 5
+	}
+	if r.AuthLockoutTime < 1 {
+		r.AuthLockoutTime = 30
+	}
+	if r.AuthLockoutMaxTime < 1 {
+		r.AuthLockoutMaxTime = 3600
+	}
 
 	return
 }
@@ -0,0 +1,195 @@
+package RestApiConfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A single top level setting, that has been changed by the config reload.
+// Setting values are not included, because some of them (e.g. http_auth) contain credentials.
+type SettingChange struct {
+	Setting         string `json:"setting"`
+	RestartRequired bool   `json:"restart_required"` // the new value will only be applied after the REST API service restart
+}
+
+// Settings that are only used during the REST API service startup
+var restartRequiredSettings = []string{"bind", "port", "protocol", "ha_mode", "ha_debug", "log_level"}
+
+var currentConfig atomic.Pointer[RestApiConfig]
+var reloadMu sync.Mutex
+var loadedModTime time.Time
+var loadedSize int64
+
+// Reads, validates, and atomically replaces the currently loaded REST API config.
+// If the new config is invalid, an error is returned, and the last good config stays in use.
+func ReloadApiConfig() (r []SettingChange, e error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	r = []SettingChange{}
+	modTime, size := configFileStat()
+
+	newConf, err := readApiConfig()
+	if err != nil {
+		e = err
+		return
+	}
+	err = ValidateApiConfig(newConf)
+	if err != nil {
+		e = fmt.Errorf("config has been rejected: %s", err.Error())
+		return
+	}
+
+	oldConf := currentConfig.Load()
+	if oldConf != nil {
+		r, err = changedSettings(*oldConf, newConf)
+		if err != nil {
+			e = err
+			return
+		}
+	}
+
+	currentConfig.Store(&newConf)
+	loadedModTime = modTime
+	loadedSize = size
+
+	return
+}
+
+// Checks the config file for changes every interval, and reloads it if the file has been modified.
+// onReload is called after every reload attempt (successful or not). Never returns, so it must be started in a separate goroutine.
+func WatchApiConfig(interval time.Duration, onReload func(changes []SettingChange, e error)) {
+	for {
+		time.Sleep(interval)
+
+		modTime, size := configFileStat()
+		reloadMu.Lock()
+		modified := !modTime.Equal(loadedModTime) || size != loadedSize
+		reloadMu.Unlock()
+		if !modified {
+			continue
+		}
+
+		changes, err := ReloadApiConfig()
+		if err != nil {
+			// Remember the rejected file, to avoid reporting the same error every interval
+			reloadMu.Lock()
+			loadedModTime = modTime
+			loadedSize = size
+			reloadMu.Unlock()
+		}
+		if onReload != nil {
+			onReload(changes, err)
+		}
+	}
+}
+
+func configFileStat() (modTime time.Time, size int64) {
+	location, err := GetApiConfigLocation()
+	if err != nil {
+		return
+	}
+	info, err := os.Stat(location)
+	if err != nil {
+		return
+	}
+
+	return info.ModTime(), info.Size()
+}
+
+// Makes sure the config values are sane, before the config is put into use.
+func ValidateApiConfig(conf RestApiConfig) error {
+	if conf.Port < 1 || conf.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, got %d", conf.Port)
+	}
+	if len(conf.BindToAddress) > 0 && net.ParseIP(conf.BindToAddress) == nil {
+		return fmt.Errorf("bind address is not a valid IP: %s", conf.BindToAddress)
+	}
+	if len(conf.Protocol) > 0 && conf.Protocol != "http" && conf.Protocol != "https" {
+		return fmt.Errorf("protocol must be either http or https, got %s", conf.Protocol)
+	}
+	if !slices.Contains([]string{"DEBUG", "INFO", "WARN", "ERROR"}, strings.ToUpper(conf.LogLevel)) {
+		return fmt.Errorf("log_level must be one of DEBUG, INFO, WARN or ERROR, got %s", conf.LogLevel)
+	}
+	if conf.SerialConsoleBufferKb < 0 || conf.VncTokenTtl < 0 || conf.VncMaxViewers < 0 {
+		return errors.New("serial_console_buffer_kb, vnc_token_ttl and vnc_max_viewers can't be negative")
+	}
+	if conf.AuthLockoutMaxTime < conf.AuthLockoutTime {
+		return errors.New("auth_lockout_max_time can't be lower than auth_lockout_time")
+	}
+	for _, v := range conf.AuthAllowList {
+		_, _, err := net.ParseCIDR(v)
+		if err != nil && net.ParseIP(v) == nil {
+			return fmt.Errorf("auth_allow_list entry is not a valid IP or CIDR: %s", v)
+		}
+	}
+
+	if len(conf.HTTPAuth) < 1 {
+		return errors.New("at least one http_auth user must be configured")
+	}
+	for i, v := range conf.HTTPAuth {
+		if len(v.User) < 1 || len(v.Password) < 1 {
+			return fmt.Errorf("http_auth user #%d has an empty user name or password", i)
+		}
+		if len(v.Token) > 0 && len(v.Token) < 32 {
+			return fmt.Errorf("http_auth user #%d has a token shorter than 32 characters", i)
+		}
+	}
+
+	return nil
+}
+
+// Compares the configs setting by setting (using the JSON field names).
+func changedSettings(oldConf RestApiConfig, newConf RestApiConfig) (r []SettingChange, e error) {
+	r = []SettingChange{}
+
+	oldMap, err := settingsMap(oldConf)
+	if err != nil {
+		e = err
+		return
+	}
+	newMap, err := settingsMap(newConf)
+	if err != nil {
+		e = err
+		return
+	}
+
+	keys := []string{}
+	for k := range oldMap {
+		keys = append(keys, k)
+	}
+	for k := range newMap {
+		if _, ok := oldMap[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if string(oldMap[k]) != string(newMap[k]) {
+			r = append(r, SettingChange{Setting: k, RestartRequired: slices.Contains(restartRequiredSettings, k)})
+		}
+	}
+
+	return
+}
+
+func settingsMap(conf RestApiConfig) (r map[string]json.RawMessage, e error) {
+	data, err := json.Marshal(conf)
+	if err != nil {
+		e = err
+		return
+	}
+
+	e = json.Unmarshal(data, &r)
+	return
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	ApiAudit "HosterCore/internal/app/rest_api_v2/pkg/audit"
+	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	RestApiConfig "HosterCore/internal/app/rest_api_v2/pkg/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// @Tags Audit
+// @Summary Query the API audit log.
+// @Description Query the audit log of all mutating API requests (newest first).<br>`AUTH`: only REST user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} []ApiAudit.Entry
+// @Failure 500 {object} SwaggerError
+// @Param user query string false "Filter by user name"
+// @Param source_ip query string false "Filter by source IP address"
+// @Param resource query string false "Filter by the target resource name (e.g. VM or Jail name)"
+// @Param route query string false "Filter by route (substring match), e.g. /vm/destroy"
+// @Param method query string false "Filter by HTTP method"
+// @Param outcome query string false "Filter by outcome: success or failure"
+// @Param since query string false "Only show the entries after this time (RFC3339)"
+// @Param until query string false "Only show the entries before this time (RFC3339)"
+// @Param limit query int false "Maximum number of entries to return (100 by default)"
+// @Router /audit [get]
+func AuditLogList(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := ApiAudit.Filter{
+		User:     q.Get("user"),
+		SourceIP: q.Get("source_ip"),
+		Resource: q.Get("resource"),
+		Route:    q.Get("route"),
+		Method:   q.Get("method"),
+		Outcome:  q.Get("outcome"),
+	}
+
+	var err error
+	if v := q.Get("since"); len(v) > 0 {
+		filter.Since, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			ReportError(w, http.StatusBadRequest, "could not parse 'since': "+err.Error())
+			return
+		}
+	}
+	if v := q.Get("until"); len(v) > 0 {
+		filter.Until, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			ReportError(w, http.StatusBadRequest, "could not parse 'until': "+err.Error())
+			return
+		}
+	}
+	if v := q.Get("limit"); len(v) > 0 {
+		filter.Limit, err = strconv.Atoi(v)
+		if err != nil {
+			ReportError(w, http.StatusBadRequest, "limit must be an integer")
+			return
+		}
+	}
+
+	apiConf, err := RestApiConfig.GetApiConfig()
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	entries, err := ApiAudit.Query(apiConf.AuditLogFile, filter)
+	if err != nil {
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	payload, err := json.Marshal(entries)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
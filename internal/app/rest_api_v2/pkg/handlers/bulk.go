@@ -0,0 +1,119 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build freebsd
+// +build freebsd
+
+package handlers
+
+import (
+	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	HosterBulk "HosterCore/internal/pkg/hoster/bulk"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// @Tags Bulk
+// @Summary Start a bulk operation.
+// @Description Apply the action to every VM and/or Jail matching the selector, e.g. stop everything tagged `web`.<br>Available actions: `start`, `stop`, `snapshot`, `replicate`, `add-tag`, `remove-tag`.<br>The operation runs in the background, use the returned ID to check the per-resource results.<br>`AUTH`: Only `rest` user is allowed.
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Success 202 {object} HosterBulk.Operation
+// @Failure 500 {object} SwaggerError
+// @Param action path string true "Bulk action, e.g. stop"
+// @Param Input body ApiV2Types.BulkInput true "Request payload"
+// @Router /bulk/{action} [post]
+func BulkPostAction(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	action := vars["action"]
+
+	input := ApiV2Types.BulkInput{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&input)
+	if err != nil {
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	op, err := HosterBulk.StartOperation(action, input.Selector, input.Params)
+	if err != nil {
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	payload, err := json.Marshal(op)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v2/bulk/operations/"+op.ID)
+	SetStatusCode(w, http.StatusAccepted)
+	w.Write(payload)
+}
+
+// @Tags Bulk
+// @Summary List bulk operations.
+// @Description List the recent bulk operations (newest first).<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} []HosterBulk.Operation
+// @Failure 500 {object} SwaggerError
+// @Router /bulk/operations [get]
+func BulkListOperations(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	payload, err := json.Marshal(HosterBulk.ListOperations())
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
+
+// @Tags Bulk
+// @Summary Get the bulk operation status.
+// @Description Get the bulk operation status, including the per-resource results.<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} HosterBulk.Operation
+// @Failure 500 {object} SwaggerError
+// @Param id path string true "Operation ID"
+// @Router /bulk/operations/{id} [get]
+func BulkGetOperation(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	op, found := HosterBulk.GetOperation(vars["id"])
+	if !found {
+		ReportError(w, http.StatusNotFound, "bulk operation could not be found: "+vars["id"])
+		return
+	}
+
+	payload, err := json.Marshal(op)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
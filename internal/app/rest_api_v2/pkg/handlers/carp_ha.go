@@ -25,8 +25,7 @@ import (
 // @Router /carp-ha/ping [post]
 func CarpPing(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckHaUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -37,19 +36,19 @@ func CarpPing(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	err = CarpClient.ReceiveHostAdd(input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	hostname, err := FreeBSDsysctls.SysctlKernHostname()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -60,7 +59,7 @@ func CarpPing(w http.ResponseWriter, r *http.Request) {
 
 	payload, err := json.Marshal(res)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -80,8 +79,7 @@ func CarpPing(w http.ResponseWriter, r *http.Request) {
 // @Router /carp-ha/receive-state/{master_hostname} [post]
 func CarpReceiveHostState(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckHaUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -89,13 +87,13 @@ func CarpReceiveHostState(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	err = CarpClient.ReceiveRemoteState(input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -114,14 +112,13 @@ func CarpReceiveHostState(w http.ResponseWriter, r *http.Request) {
 // @Router /carp-ha/backups [get]
 func CarpReturnListOfBackups(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckHaUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	haConf, err := CarpUtils.ParseCarpConfigFile()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -129,19 +126,19 @@ func CarpReturnListOfBackups(w http.ResponseWriter, r *http.Request) {
 	if haConf.ParticipateInFailover {
 		snaps, err := zfsutils.SnapshotListAll()
 		if err != nil {
-			ReportError(w, http.StatusInternalServerError, err.Error())
+			ReportApiError(w, http.StatusInternalServerError, err)
 			return
 		}
 
 		vms, err := HosterVmUtils.ReadCache()
 		if err != nil {
-			ReportError(w, http.StatusInternalServerError, err.Error())
+			ReportApiError(w, http.StatusInternalServerError, err)
 			return
 		}
 
 		jails, err := HosterJailUtils.ReadCache()
 		if err != nil {
-			ReportError(w, http.StatusInternalServerError, err.Error())
+			ReportApiError(w, http.StatusInternalServerError, err)
 			return
 		}
 
@@ -186,7 +183,7 @@ func CarpReturnListOfBackups(w http.ResponseWriter, r *http.Request) {
 
 	payload, err := json.Marshal(backups)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
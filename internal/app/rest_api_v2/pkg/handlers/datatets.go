@@ -6,6 +6,7 @@ import (
 	"HosterCore/internal/pkg/byteconversion"
 	HosterHost "HosterCore/internal/pkg/hoster/host"
 	HosterHostUtils "HosterCore/internal/pkg/hoster/host/utils"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -21,23 +22,22 @@ import (
 // @Description Get active dataset list.<br>`AUTH`: Only REST user is allowed.
 // @Produce json
 // @Security BasicAuth
-// @Success 200 {object} []DatasetInfo
+// @Success 200 {object} []ApiV2Types.DatasetInfo
 // @Failure 500 {object} SwaggerError
 // @Router /dataset/all [get]
 func DatasetList(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	hostConf, err := HosterHost.GetHostConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	info := []DatasetInfo{}
+	info := []ApiV2Types.DatasetInfo{}
 	for _, v := range hostConf.ActiveZfsDatasets {
 		dsInfo, err := getDsInfo(v)
 		if err != nil {
@@ -49,7 +49,7 @@ func DatasetList(w http.ResponseWriter, r *http.Request) {
 
 	payload, err := json.Marshal(info)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -57,19 +57,7 @@ func DatasetList(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
-type DatasetInfo struct {
-	Encrypted      bool   `json:"encrypted"`
-	Mounted        bool   `json:"mounted"`
-	Used           uint64 `json:"used"`
-	Available      uint64 `json:"available"`
-	Total          uint64 `json:"total"`
-	UsedHuman      string `json:"used_human"`
-	AvailableHuman string `json:"available_human"`
-	TotalHuman     string `json:"total_human"`
-	Name           string `json:"name"`
-}
-
-func getDsInfo(dsName string) (r DatasetInfo, e error) {
+func getDsInfo(dsName string) (r ApiV2Types.DatasetInfo, e error) {
 	reSpace := regexp.MustCompile(`\s+`)
 
 	pool := strings.Split(dsName, "/")[0]
@@ -154,11 +142,6 @@ func getDsInfo(dsName string) (r DatasetInfo, e error) {
 	return
 }
 
-type DatasetEncryptionInput struct {
-	Dataset  string `json:"dataset"`
-	Password string `json:"password"`
-}
-
 // @Tags Datasets
 // @Summary Unlock an encrypted dataset.
 // @Description Unlock an encrypted dataset.<br>`AUTH`: Only REST user is allowed.
@@ -166,20 +149,19 @@ type DatasetEncryptionInput struct {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body DatasetEncryptionInput true "Request Payload"
+// @Param Input body ApiV2Types.DatasetEncryptionInput true "Request Payload"
 // @Router /dataset/unlock [post]
 func UnlockEncryptedDataset(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := DatasetEncryptionInput{}
+	input := ApiV2Types.DatasetEncryptionInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -194,19 +176,19 @@ func UnlockEncryptedDataset(w http.ResponseWriter, r *http.Request) {
 
 	err = HosterHostUtils.UnlockEncryptedDataset(input.Dataset, input.Password)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	err = HosterHostUtils.ReloadDns()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := JSONResponse.GenerateJson(w, "message", "success")
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+	"encoding/json"
+	"net/http"
+)
+
+// @Tags Health
+// @Summary List the API error codes.
+// @Description List the stable error codes returned in the `code` field of the error responses, together with their HTTP statuses and `details` parameters.<br>`AUTH`: Both users are allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} []ErrorMappings.CatalogEntry
+// @Failure 500 {object} SwaggerError
+// @Router /errors [get]
+func ErrorCatalog(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckAnyUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	payload, err := json.Marshal(ErrorMappings.Catalog())
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
@@ -28,8 +28,7 @@ func HealthCheck(w http.ResponseWriter, r *http.Request) {
 // @Router /health/auth/regular [get]
 func HealthCheckRegularAuth(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -48,8 +47,7 @@ func HealthCheckRegularAuth(w http.ResponseWriter, r *http.Request) {
 // @Router /health/auth/ha [get]
 func HealthCheckHaAuth(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckHaUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -68,8 +66,7 @@ func HealthCheckHaAuth(w http.ResponseWriter, r *http.Request) {
 // @Router /health/auth/any [get]
 func HealthCheckAnyAuth(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckHaUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -7,6 +7,7 @@ import (
 	FileExists "HosterCore/internal/pkg/file_exists"
 	HosterHost "HosterCore/internal/pkg/hoster/host"
 	HosterHostUtils "HosterCore/internal/pkg/hoster/host/utils"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
 	"encoding/json"
 	"net/http"
 	"os"
@@ -25,20 +26,19 @@ import (
 // @Router /host/info [get]
 func HostInfo(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	info, err := HosterHostUtils.GetHostInfo()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := json.Marshal(info)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -56,20 +56,19 @@ func HostInfo(w http.ResponseWriter, r *http.Request) {
 // @Router /host/settings [get]
 func HostSettings(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	info, err := HosterHost.GetHostConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := json.Marshal(info)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -87,20 +86,19 @@ func HostSettings(w http.ResponseWriter, r *http.Request) {
 // @Router /host/settings/api [get]
 func HostRestApiSettings(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	info, err := RestApiConfig.GetApiConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := json.Marshal(info)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -108,8 +106,34 @@ func HostRestApiSettings(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
-type DnsSearchDomainInput struct {
-	DnsSearchDomain string `json:"dns_search_domain"`
+// @Tags Host
+// @Summary Reload RestAPI Settings.
+// @Description Re-read and validate the RestAPI config file, and put it into use without restarting the service.<br>Invalid config is rejected, and the last good config stays in use.<br>Returns the list of changed settings (some of them only take effect after the service restart).<br>`AUTH`: only REST user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} []RestApiConfig.SettingChange
+// @Failure 500 {object} SwaggerError
+// @Router /host/settings/api/reload [post]
+func HostRestApiSettingsReload(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	changes, err := RestApiConfig.ReloadApiConfig()
+	if err != nil {
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	payload, err := json.Marshal(changes)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
 }
 
 // @Tags Host
@@ -119,20 +143,19 @@ type DnsSearchDomainInput struct {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body DnsSearchDomainInput true "Request Payload"
+// @Param Input body ApiV2Types.DnsSearchDomainInput true "Request Payload"
 // @Router /host/settings/dns-search-domain [post]
 func PostHostSettingsDnsSearchDomain(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := DnsSearchDomainInput{}
+	input := ApiV2Types.DnsSearchDomainInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -143,14 +166,14 @@ func PostHostSettingsDnsSearchDomain(w http.ResponseWriter, r *http.Request) {
 
 	hostConf, err := HosterHost.GetHostConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	hostConf.DnsSearchDomain = input.DnsSearchDomain
 	err = HosterHost.SaveHostConfig(hostConf)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -159,10 +182,6 @@ func PostHostSettingsDnsSearchDomain(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
-type VmTemplateLink struct {
-	Link string `json:"vm_template_link"`
-}
-
 // @Tags Host
 // @Summary Post an updated VM template site.
 // @Description Post an updated VM template site.
@@ -170,20 +189,19 @@ type VmTemplateLink struct {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body VmTemplateLink true "Request Payload"
+// @Param Input body ApiV2Types.VmTemplateLink true "Request Payload"
 // @Router /host/settings/vm-templates [post]
 func PostHostSettingsVmTemplateLink(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := VmTemplateLink{}
+	input := ApiV2Types.VmTemplateLink{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -194,14 +212,14 @@ func PostHostSettingsVmTemplateLink(w http.ResponseWriter, r *http.Request) {
 
 	hostConf, err := HosterHost.GetHostConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	hostConf.ImageServer = input.Link
 	err = HosterHost.SaveHostConfig(hostConf)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -210,10 +228,6 @@ func PostHostSettingsVmTemplateLink(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
-type UpstreamDnsInput struct {
-	DnsServer string `json:"dns_server"`
-}
-
 // @Tags Host
 // @Summary Add a new upstream DNS server.
 // @Description Add a new upstream DNS server.
@@ -221,20 +235,19 @@ type UpstreamDnsInput struct {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body UpstreamDnsInput true "Request Payload"
+// @Param Input body ApiV2Types.UpstreamDnsInput true "Request Payload"
 // @Router /host/settings/add-upstream-dns [post]
 func PostHostSettingsAddUpstreamDns(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := UpstreamDnsInput{}
+	input := ApiV2Types.UpstreamDnsInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -245,20 +258,20 @@ func PostHostSettingsAddUpstreamDns(w http.ResponseWriter, r *http.Request) {
 
 	hostConf, err := HosterHost.GetHostConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	hostConf.DnsServers = append(hostConf.DnsServers, input.DnsServer)
 	err = HosterHost.SaveHostConfig(hostConf)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := JSONResponse.GenerateJson(w, "message", "success")
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -273,20 +286,19 @@ func PostHostSettingsAddUpstreamDns(w http.ResponseWriter, r *http.Request) {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body UpstreamDnsInput true "Request Payload"
+// @Param Input body ApiV2Types.UpstreamDnsInput true "Request Payload"
 // @Router /host/settings/delete-upstream-dns [delete]
 func DeleteHostSettingsUpstreamDns(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := UpstreamDnsInput{}
+	input := ApiV2Types.UpstreamDnsInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -297,7 +309,7 @@ func DeleteHostSettingsUpstreamDns(w http.ResponseWriter, r *http.Request) {
 
 	hostConf, err := HosterHost.GetHostConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -311,13 +323,13 @@ func DeleteHostSettingsUpstreamDns(w http.ResponseWriter, r *http.Request) {
 
 	err = HosterHost.SaveHostConfig(hostConf)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := JSONResponse.GenerateJson(w, "message", "success")
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -325,11 +337,6 @@ func DeleteHostSettingsUpstreamDns(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
-type SshKeyInput struct {
-	KeyComment string `json:"key_comment"`
-	KeyValue   string `json:"key_value"`
-}
-
 // @Tags Host
 // @Summary Add a new VM SSH access key.
 // @Description Add a new VM SSH access key.
@@ -337,20 +344,19 @@ type SshKeyInput struct {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body SshKeyInput true "Request Payload"
+// @Param Input body ApiV2Types.SshKeyInput true "Request Payload"
 // @Router /host/settings/add-ssh-key [post]
 func PostHostSettingsSshKey(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := SshKeyInput{}
+	input := ApiV2Types.SshKeyInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -365,20 +371,20 @@ func PostHostSettingsSshKey(w http.ResponseWriter, r *http.Request) {
 
 	hostConf, err := HosterHost.GetHostConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	hostConf.HostSSHKeys = append(hostConf.HostSSHKeys, HosterHost.HostConfigKey{KeyValue: input.KeyValue, Comment: input.KeyComment})
 	err = HosterHost.SaveHostConfig(hostConf)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := JSONResponse.GenerateJson(w, "message", "success")
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -393,20 +399,19 @@ func PostHostSettingsSshKey(w http.ResponseWriter, r *http.Request) {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body SshKeyInput true "Request Payload"
+// @Param Input body ApiV2Types.SshKeyInput true "Request Payload"
 // @Router /host/settings/delete-ssh-key [delete]
 func DeleteHostSettingsSshKey(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := SshKeyInput{}
+	input := ApiV2Types.SshKeyInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -417,7 +422,7 @@ func DeleteHostSettingsSshKey(w http.ResponseWriter, r *http.Request) {
 
 	hostConf, err := HosterHost.GetHostConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -431,13 +436,13 @@ func DeleteHostSettingsSshKey(w http.ResponseWriter, r *http.Request) {
 
 	err = HosterHost.SaveHostConfig(hostConf)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := JSONResponse.GenerateJson(w, "message", "success")
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -445,10 +450,6 @@ func DeleteHostSettingsSshKey(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
-type HostAuthSshKeyInput struct {
-	KeyValue string `json:"key_value"`
-}
-
 // @Tags Host
 // @Summary Add a new host-level authorized SSH key.
 // @Description Add a new host-level authorized SSH key.
@@ -456,29 +457,28 @@ type HostAuthSshKeyInput struct {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body HostAuthSshKeyInput{} true "Request Payload"
+// @Param Input body ApiV2Types.HostAuthSshKeyInput{} true "Request Payload"
 // @Router /host/settings/ssh-auth-key [post]
 func PostHostSshAuthKey(w http.ResponseWriter, r *http.Request) {
 	authKeyLocation := "/root/.ssh/authorized_keys"
 	if !FileExists.CheckUsingOsStat(authKeyLocation) {
 		err := os.WriteFile(authKeyLocation, []byte(""), 0600)
 		if err != nil {
-			ReportError(w, http.StatusInternalServerError, err.Error())
+			ReportApiError(w, http.StatusInternalServerError, err)
 			return
 		}
 	}
 
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := HostAuthSshKeyInput{}
+	input := ApiV2Types.HostAuthSshKeyInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -489,13 +489,13 @@ func PostHostSshAuthKey(w http.ResponseWriter, r *http.Request) {
 
 	keyFile, err := os.ReadFile(authKeyLocation)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := JSONResponse.GenerateJson(w, "message", "success")
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -524,7 +524,7 @@ func PostHostSshAuthKey(w http.ResponseWriter, r *http.Request) {
 		keys = append(keys, input.KeyValue)
 		err = os.WriteFile(authKeyLocation, []byte(strings.Join(keys, "\n")), 0600)
 		if err != nil {
-			ReportError(w, http.StatusInternalServerError, err.Error())
+			ReportApiError(w, http.StatusInternalServerError, err)
 			return
 		}
 
@@ -544,8 +544,7 @@ func PostHostSshAuthKey(w http.ResponseWriter, r *http.Request) {
 // @Router /host/settings/add-tag/{tag} [post]
 func PostHostTag(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -562,20 +561,20 @@ func PostHostTag(w http.ResponseWriter, r *http.Request) {
 
 	hostConf, err := HosterHost.GetHostConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	hostConf.Tags = append(hostConf.Tags, input)
 	err = HosterHost.SaveHostConfig(hostConf)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := JSONResponse.GenerateJson(w, "message", "success")
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -594,8 +593,7 @@ func PostHostTag(w http.ResponseWriter, r *http.Request) {
 // @Router /host/settings/delete-tag/{tag} [delete]
 func DeleteHostTag(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -612,7 +610,7 @@ func DeleteHostTag(w http.ResponseWriter, r *http.Request) {
 
 	hostConf, err := HosterHost.GetHostConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -626,13 +624,13 @@ func DeleteHostTag(w http.ResponseWriter, r *http.Request) {
 
 	err = HosterHost.SaveHostConfig(hostConf)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := JSONResponse.GenerateJson(w, "message", "success")
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -649,14 +647,13 @@ func DeleteHostTag(w http.ResponseWriter, r *http.Request) {
 // @Router /host/readme [get]
 func GetHostReadme(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	readme, err := HosterHost.GetReadme()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	JSONResponse "HosterCore/internal/app/rest_api_v2/pkg/json_response"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
+	"encoding/json"
+	"net/http"
+)
+
+// @Tags Host
+// @Summary List authentication failures and lockouts.
+// @Description List the source IPs and user names with recent authentication failures, including the active lockouts. User name failures are counted across all source IPs. Locked out requests get `429` (`AUTH_LOCKED_OUT`) with the `Retry-After` header.<br>`AUTH`: only REST user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} []ApiAuth.LockoutEntry
+// @Failure 500 {object} SwaggerError
+// @Router /host/security/lockouts [get]
+func HostSecurityLockouts(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	payload, err := json.Marshal(ApiAuth.ListLockouts())
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
+
+// @Tags Host
+// @Summary Clear authentication lockouts.
+// @Description Clear the lockout for a specific source IP (`type: ip`) or user name (`type: user`). Send an empty payload to clear all lockouts.<br>`AUTH`: only REST user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} SwaggerSuccess
+// @Failure 500 {object} SwaggerError
+// @Param Input body ApiV2Types.LockoutClearInput true "Request payload"
+// @Router /host/security/lockouts/clear [delete]
+func HostSecurityLockoutsClear(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	input := ApiV2Types.LockoutClearInput{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&input)
+	if err != nil {
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = ApiAuth.ClearLockout(input.Type, input.Key)
+	if err != nil {
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	payload, err := JSONResponse.GenerateJson(w, "message", "success")
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
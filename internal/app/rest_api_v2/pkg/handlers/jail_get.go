@@ -2,6 +2,8 @@ package handlers
 
 import (
 	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	MiddlewareConcurrency "HosterCore/internal/app/rest_api_v2/pkg/middleware/concurrency"
+	AtomicFile "HosterCore/internal/pkg/atomic_file"
 	HosterHost "HosterCore/internal/pkg/hoster/host"
 	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
 	"encoding/json"
@@ -18,11 +20,11 @@ import (
 // @Success 200 {object} HosterJailUtils.JailConfig{}
 // @Failure 500 {object} SwaggerError
 // @Param jail_name path string true "Jail Name"
+// @Header 200 {string} ETag "Config revision, send it back in the If-Match header to avoid overwriting someone else's changes"
 // @Router /jail/settings/{jail_name} [get]
 func JailGetSettings(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -31,13 +33,20 @@ func JailGetSettings(w http.ResponseWriter, r *http.Request) {
 
 	info, err := HosterJailUtils.InfoJsonApi(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Revision is read before the config, so the ETag can never be newer than the returned config
+	revision, err := AtomicFile.FileRevision(info.Simple.Mountpoint + "/" + info.Name + "/" + HosterJailUtils.JAIL_CONFIG_NAME)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	conf, err := HosterJailUtils.GetJailConfig(info.Simple.Mountpoint + "/" + info.Name)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -45,7 +54,7 @@ func JailGetSettings(w http.ResponseWriter, r *http.Request) {
 	if len(conf.DnsSearchDomain) < 1 {
 		hostConf, err := HosterHost.GetHostConfig()
 		if err != nil {
-			ReportError(w, http.StatusInternalServerError, err.Error())
+			ReportApiError(w, http.StatusInternalServerError, err)
 			return
 		}
 		conf.DnsSearchDomain = hostConf.DnsSearchDomain
@@ -53,11 +62,12 @@ func JailGetSettings(w http.ResponseWriter, r *http.Request) {
 
 	payload, err := json.Marshal(conf)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", MiddlewareConcurrency.ETag(revision))
 	SetStatusCode(w, http.StatusOK)
 	w.Write(payload)
 }
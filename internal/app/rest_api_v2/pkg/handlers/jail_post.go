@@ -3,8 +3,10 @@ package handlers
 import (
 	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
 	JSONResponse "HosterCore/internal/app/rest_api_v2/pkg/json_response"
+	HosterAdmission "HosterCore/internal/pkg/hoster/admission"
 	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
 	HosterNetwork "HosterCore/internal/pkg/hoster/network"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,29 +25,29 @@ import (
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param jail_name path string true "Name of the Jail"
-// @Param Input body ResourceDescription{} true "Request payload"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.ResourceDescription{} true "Request payload"
 // @Router /jail/settings/description/{jail_name} [post]
 func JailPostDescription(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	jailName := vars["jail_name"]
 
-	input := ResourceDescription{}
+	input := ApiV2Types.ResourceDescription{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	err = HosterJailUtils.UpdateDescription(jailName, input.Description)
+	err = HosterJailUtils.UpdateDescription(r.Context(), jailName, input.Description)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -63,23 +65,23 @@ func JailPostDescription(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} SwaggerError
 // @Param jail_name path string true "Jail Name"
 // @Param new_tag path string true "New Tag"
-// @Param Input body TagInput true "Request payload"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.TagInput true "Request payload"
 // @Router /jail/settings/add-tag/{jail_name} [post]
 func JailPostNewTag(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	jailName := vars["jail_name"]
 
-	input := TagInput{}
+	input := ApiV2Types.TagInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -95,7 +97,7 @@ func JailPostNewTag(w http.ResponseWriter, r *http.Request) {
 
 	jailInfo, err := HosterJailUtils.InfoJsonApi(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 	location := jailInfo.Simple.Mountpoint + "/" + jailInfo.Name + "/" + HosterJailUtils.JAIL_CONFIG_NAME
@@ -111,9 +113,9 @@ func JailPostNewTag(w http.ResponseWriter, r *http.Request) {
 		jailInfo.JailConfig.Tags = append(jailInfo.JailConfig.Tags, input.Tag)
 	}
 
-	err = HosterJailUtils.ConfigFileWriter(jailInfo.JailConfig, location)
+	err = HosterJailUtils.ConfigFileWriterContext(r.Context(), jailInfo.JailConfig, location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -134,11 +136,11 @@ func JailPostNewTag(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} SwaggerError
 // @Param jail_name path string true "Jail Name"
 // @Param production path string true "Workload type (is this a production Jail?), e.g. true or false"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
 // @Router /jail/settings/production/{jail_name}/{production} [post]
 func JailPostProductionSetting(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -157,7 +159,7 @@ func JailPostProductionSetting(w http.ResponseWriter, r *http.Request) {
 
 	jailInfo, err := HosterJailUtils.InfoJsonApi(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 	location := jailInfo.Simple.Mountpoint + "/" + jailName + "/" + HosterJailUtils.JAIL_CONFIG_NAME
@@ -167,9 +169,9 @@ func JailPostProductionSetting(w http.ResponseWriter, r *http.Request) {
 	} else {
 		jailInfo.JailConfig.Production = false
 	}
-	err = HosterJailUtils.ConfigFileWriter(jailInfo.JailConfig, location)
+	err = HosterJailUtils.ConfigFileWriterContext(r.Context(), jailInfo.JailConfig, location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -187,11 +189,11 @@ func JailPostProductionSetting(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} SwaggerError
 // @Param jail_name path string true "Jail Name"
 // @Param limit path int true "Percentage limit (1-100)"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
 // @Router /jail/settings/cpu/{jail_name}/{limit} [post]
 func JailPostCpuPercentageLimit(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -213,15 +215,15 @@ func JailPostCpuPercentageLimit(w http.ResponseWriter, r *http.Request) {
 
 	jailInfo, err := HosterJailUtils.InfoJsonApi(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	jailInfo.JailConfig.CPULimitPercent = limitInt
 	location := jailInfo.Simple.Mountpoint + "/" + jailName + "/" + HosterJailUtils.JAIL_CONFIG_NAME
-	err = HosterJailUtils.ConfigFileWriter(jailInfo.JailConfig, location)
+	err = HosterJailUtils.ConfigFileWriterContext(r.Context(), jailInfo.JailConfig, location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -239,11 +241,11 @@ func JailPostCpuPercentageLimit(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} SwaggerError
 // @Param jail_name path string true "Jail Name"
 // @Param limit path string true "Memory limit (in MB or GB, e.g. 2GB, or 2048MB)"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
 // @Router /jail/settings/ram/{jail_name}/{limit} [post]
 func JailPostRamLimit(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -282,15 +284,26 @@ func JailPostRamLimit(w http.ResponseWriter, r *http.Request) {
 
 	jailInfo, err := HosterJailUtils.InfoJsonApi(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = HosterAdmission.Check(HosterAdmission.Request{
+		Action:       HosterAdmission.ACTION_UPDATE,
+		ResourceType: HosterAdmission.RESOURCE_JAIL,
+		ResourceName: jailName,
+		Ram:          fmt.Sprintf("%d%s", limitInt, limitType),
+	})
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	jailInfo.JailConfig.RAMLimit = fmt.Sprintf("%d%s", limitInt, limitType)
 	location := jailInfo.Simple.Mountpoint + "/" + jailName + "/" + HosterJailUtils.JAIL_CONFIG_NAME
-	err = HosterJailUtils.ConfigFileWriter(jailInfo.JailConfig, location)
+	err = HosterJailUtils.ConfigFileWriterContext(r.Context(), jailInfo.JailConfig, location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -307,23 +320,23 @@ func JailPostRamLimit(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param jail_name path string true "Jail Name"
-// @Param Input body JailDnsInput{} true "Request payload"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.JailDnsInput{} true "Request payload"
 // @Router /jail/settings/dns/{jail_name} [post]
 func JailPostSettingsDns(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	jailName := vars["jail_name"]
 
-	input := JailDnsInput{}
+	input := ApiV2Types.JailDnsInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -344,16 +357,16 @@ func JailPostSettingsDns(w http.ResponseWriter, r *http.Request) {
 
 	jailInfo, err := HosterJailUtils.InfoJsonApi(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 	location := jailInfo.Simple.Mountpoint + "/" + jailInfo.Name + "/" + HosterJailUtils.JAIL_CONFIG_NAME
 	jailInfo.JailConfig.DnsServer = input.DnsServer
 	jailInfo.JailConfig.DnsSearchDomain = input.SearchDomain
 
-	err = HosterJailUtils.ConfigFileWriter(jailInfo.JailConfig, location)
+	err = HosterJailUtils.ConfigFileWriterContext(r.Context(), jailInfo.JailConfig, location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -373,23 +386,23 @@ func JailPostSettingsDns(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param jail_name path string true "Jail Name"
-// @Param Input body JailNetworkInput{} true "Request payload"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.JailNetworkInput{} true "Request payload"
 // @Router /jail/settings/network/{jail_name} [post]
 func JailPostSettingsNetwork(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	jailName := vars["jail_name"]
 
-	input := JailNetworkInput{}
+	input := ApiV2Types.JailNetworkInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -404,7 +417,7 @@ func JailPostSettingsNetwork(w http.ResponseWriter, r *http.Request) {
 
 	networks, err := HosterNetwork.GetNetworkConfig()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -423,16 +436,16 @@ func JailPostSettingsNetwork(w http.ResponseWriter, r *http.Request) {
 
 	jailInfo, err := HosterJailUtils.InfoJsonApi(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 	location := jailInfo.Simple.Mountpoint + "/" + jailInfo.Name + "/" + HosterJailUtils.JAIL_CONFIG_NAME
 	jailInfo.JailConfig.IPAddress = input.IpAddress
 	jailInfo.JailConfig.Network = input.NetworkBridge
 
-	err = HosterJailUtils.ConfigFileWriter(jailInfo.JailConfig, location)
+	err = HosterJailUtils.ConfigFileWriterContext(r.Context(), jailInfo.JailConfig, location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -5,6 +5,7 @@ import (
 	JSONResponse "HosterCore/internal/app/rest_api_v2/pkg/json_response"
 	HosterJail "HosterCore/internal/pkg/hoster/jail"
 	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -20,28 +21,32 @@ import (
 // @Security BasicAuth
 // @Success 200 {object} []HosterJailUtils.JailApi
 // @Failure 500 {object} SwaggerError
+// @Param tag query string false "Filter by tag (comma separated list, all tags must match)"
+// @Param running query bool false "Filter by the running state"
+// @Param production query bool false "Filter by the production flag"
+// @Param backup query bool false "Filter by the backup status"
+// @Param owner query string false "Filter by owner"
+// @Param dataset query string false "Filter by ZFS dataset, e.g. zroot/vm-encrypted"
+// @Param name query string false "Filter by name (glob pattern), e.g. web-*"
+// @Param sort query string false "Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name"
+// @Param offset query int false "Pagination offset"
+// @Param limit query int false "Pagination limit"
+// @Param fields query string false "Comma separated list of fields to return, e.g. name,running"
+// @Header 200 {integer} X-Total-Count "Number of items matching the filters"
 // @Router /jail/all [get]
 func JailList(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	jails, err := HosterJailUtils.ListJsonApi()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	payload, err := json.Marshal(jails)
-	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	SetStatusCode(w, http.StatusOK)
-	w.Write(payload)
+	writeListResponse(w, r, jails)
 }
 
 // @Tags Jails
@@ -51,28 +56,32 @@ func JailList(w http.ResponseWriter, r *http.Request) {
 // @Security BasicAuth
 // @Success 200 {object} []HosterJailUtils.JailApi
 // @Failure 500 {object} SwaggerError
+// @Param tag query string false "Filter by tag (comma separated list, all tags must match)"
+// @Param running query bool false "Filter by the running state"
+// @Param production query bool false "Filter by the production flag"
+// @Param backup query bool false "Filter by the backup status"
+// @Param owner query string false "Filter by owner"
+// @Param dataset query string false "Filter by ZFS dataset, e.g. zroot/vm-encrypted"
+// @Param name query string false "Filter by name (glob pattern), e.g. web-*"
+// @Param sort query string false "Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name"
+// @Param offset query int false "Pagination offset"
+// @Param limit query int false "Pagination limit"
+// @Param fields query string false "Comma separated list of fields to return, e.g. name,running"
+// @Header 200 {integer} X-Total-Count "Number of items matching the filters"
 // @Router /jail/all/cache [get]
 func JailListCache(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	jails, err := HosterJailUtils.ReadCache()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	payload, err := json.Marshal(jails)
-	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	SetStatusCode(w, http.StatusOK)
-	w.Write(payload)
+	writeListResponse(w, r, jails)
 }
 
 // @Tags Jails
@@ -85,21 +94,20 @@ func JailListCache(w http.ResponseWriter, r *http.Request) {
 // @Router /jail/template/list [get]
 func JailListTemplates(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	templates, err := HosterJailUtils.ListTemplates()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	// payload, _ := JSONResponse.GenerateJson(w, "message", templates)
 	payload, err := json.Marshal(templates)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -118,8 +126,7 @@ func JailListTemplates(w http.ResponseWriter, r *http.Request) {
 // @Router /jail/info/{jail_name} [get]
 func JailInfo(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -128,13 +135,13 @@ func JailInfo(w http.ResponseWriter, r *http.Request) {
 
 	jails, err := HosterJailUtils.InfoJsonApi(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := json.Marshal(jails)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -154,8 +161,7 @@ func JailInfo(w http.ResponseWriter, r *http.Request) {
 // @Router /jail/start/{jail_name} [post]
 func JailStart(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -164,7 +170,7 @@ func JailStart(w http.ResponseWriter, r *http.Request) {
 
 	err := HosterJail.Start(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -184,8 +190,7 @@ func JailStart(w http.ResponseWriter, r *http.Request) {
 // @Router /jail/start-all/{production} [post]
 func JailPostStartAll(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -221,8 +226,7 @@ func JailPostStartAll(w http.ResponseWriter, r *http.Request) {
 // @Router /jail/stop-all [post]
 func JailPostStopAll(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -250,8 +254,7 @@ func JailPostStopAll(w http.ResponseWriter, r *http.Request) {
 // @Router /jail/stop/{jail_name} [post]
 func JailStop(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -260,7 +263,7 @@ func JailStop(w http.ResponseWriter, r *http.Request) {
 
 	err := HosterJail.Stop(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -280,8 +283,7 @@ func JailStop(w http.ResponseWriter, r *http.Request) {
 // @Router /jail/destroy/{jail_name} [delete]
 func JailDestroy(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -290,7 +292,7 @@ func JailDestroy(w http.ResponseWriter, r *http.Request) {
 
 	err := HosterJail.Destroy(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -310,8 +312,7 @@ func JailDestroy(w http.ResponseWriter, r *http.Request) {
 // @Router /jail/deploy [post]
 func JailDeploy(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -319,13 +320,13 @@ func JailDeploy(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	err = HosterJail.Deploy(input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -341,26 +342,25 @@ func JailDeploy(w http.ResponseWriter, r *http.Request) {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body JailCloneInput true "Request payload"
+// @Param Input body ApiV2Types.JailCloneInput true "Request payload"
 // @Router /jail/clone [post]
 func JailClone(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := JailCloneInput{}
+	input := ApiV2Types.JailCloneInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	err = HosterJail.Clone(input.JailName, input.NewJailName, input.SnapshotName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -380,8 +380,7 @@ func JailClone(w http.ResponseWriter, r *http.Request) {
 // @Router /jail/readme/{jail_name} [get]
 func JailGetReadme(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -390,7 +389,7 @@ func JailGetReadme(w http.ResponseWriter, r *http.Request) {
 
 	readme, err := HosterJail.GetReadme(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -404,14 +403,13 @@ func JailGetReadme(w http.ResponseWriter, r *http.Request) {
 // @Description Get a list of active shells for a specific Jail.<br>`AUTH`: Only `rest` user is allowed.
 // @Produce json
 // @Security BasicAuth
-// @Success 200 {object} JailShells{}
+// @Success 200 {object} ApiV2Types.JailShells{}
 // @Failure 500 {object} SwaggerError
 // @Param jail_name path string true "Jail Name"
 // @Router /jail/get/shells/{jail_name} [get]
 func JailGetShells(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -419,16 +417,16 @@ func JailGetShells(w http.ResponseWriter, r *http.Request) {
 	jailName := vars["jail_name"]
 
 	var err error
-	output := JailShells{}
+	output := ApiV2Types.JailShells{}
 	output.AvailableShells, err = HosterJailUtils.GetJailShells(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := json.Marshal(output)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// Applies the list query parameters (filters, sorting, pagination and sparse fieldsets) to the list, and writes the response.
+// The number of items that matched the filters (before the pagination) is returned in the X-Total-Count header.
+func writeListResponse[T HosterListQuery.Listable](w http.ResponseWriter, r *http.Request, items []T) {
+	query, err := HosterListQuery.ParseValues(r.URL.Query())
+	if err != nil {
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, total, err := HosterListQuery.Apply(items, query)
+	if err != nil {
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var payload []byte
+	if len(query.Fields) > 0 {
+		selected, err := HosterListQuery.SelectFields(page, query.Fields)
+		if err != nil {
+			ReportApiError(w, http.StatusBadRequest, err)
+			return
+		}
+		payload, err = json.Marshal(selected)
+		if err != nil {
+			ReportApiError(w, http.StatusInternalServerError, err)
+			return
+		}
+	} else {
+		payload, err = json.Marshal(page)
+		if err != nil {
+			ReportApiError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
@@ -1,9 +1,12 @@
 package handlers
 
 // Standard error response. Used in Swagger docs and inside the main error handler.
+// Clients should rely on the stable "code" field, the full error catalog is served at GET /errors.
 type SwaggerError struct {
-	ErrorID    int    `json:"id"`
-	ErrorValue string `json:"message"`
+	ErrorID    int               `json:"id"` // legacy numeric error ID, use "code" instead
+	ErrorCode  string            `json:"code" enums:"INTERNAL_ERROR,BAD_REQUEST,INVALID_INPUT,UNAUTHORIZED,ROUTE_NOT_FOUND,RESOURCE_NOT_FOUND,VM_NOT_FOUND,VM_RUNNING,VM_NOT_RUNNING,JAIL_NOT_FOUND,JAIL_RUNNING,JAIL_NOT_RUNNING,RESOURCE_IS_BACKUP,SNAPSHOT_NOT_FOUND,SNAPSHOT_TYPE_INVALID,SNAPSHOT_HAS_CLONES,DATASET_LOCKED,DATASET_BUSY,NETWORK_NOT_FOUND,HOST_NOT_FOUND,HOST_DISABLED,CONFIG_CONFLICT,PRECONDITION_REQUIRED,CONSOLE_WRITER_TAKEN,VNC_TOKEN_INVALID,TOO_MANY_VIEWERS,BULK_OPERATION_NOT_FOUND,ADMISSION_DENIED,NAME_CONFLICT,DATASET_NOT_ACTIVE,ARCHIVE_INVALID,IMPORT_NETWORK_MISSING,AUTH_LOCKED_OUT"`
+	ErrorValue string            `json:"message"`
+	Details    map[string]string `json:"details,omitempty"`
 }
 
 // Purely Swagger related object, not used anywhere else in the codebase
@@ -15,71 +18,3 @@ type SwaggerSuccess struct {
 type SwaggerStringList struct {
 	Message []string `json:"message"` // success
 }
-
-type JailCloneInput struct {
-	JailName     string `json:"jail_name"`
-	NewJailName  string `json:"new_jail_name"`
-	SnapshotName string `json:"snapshot_name"`
-}
-
-type VmCloneInput struct {
-	VmName       string `json:"vm_name"`
-	NewVmName    string `json:"new_vm_name"`
-	SnapshotName string `json:"snapshot_name"`
-}
-
-type VmCpuInput struct {
-	CpuSockets int `json:"cpu_sockets"`
-	CpuCores   int `json:"cpu_cores"`
-	CpuThreads int `json:"cpu_threads"`
-}
-
-type VmRamInput struct {
-	RamAmount  int    `json:"ram_amount"`
-	BytesValue string `json:"bytes_value"`
-}
-
-type VmOsSettings struct {
-	OsType    string `json:"os_type"`
-	OsComment string `json:"os_comment"`
-}
-
-type TagInput struct {
-	Tag string `json:"tag"`
-}
-
-type VmMountIsoInput struct {
-	IsoPath    string `json:"iso_path"`
-	IsoComment string `json:"iso_comment"`
-}
-
-type VmDiskExpandInput struct {
-	DiskImage     string `json:"disk_image"`
-	ExpansionSize int    `json:"expansion_size"`
-}
-
-type JailShells struct {
-	AvailableShells []string `json:"available_shells"`
-}
-
-type ResourceDescription struct {
-	Description string `json:"description"`
-}
-
-type JailDnsInput struct {
-	DnsServer    string `json:"dns_server"`
-	SearchDomain string `json:"search_domain"`
-}
-
-type JailNetworkInput struct {
-	NetworkBridge string `json:"network_bridge"`
-	IpAddress     string `json:"ip_address"`
-}
-
-type ZfsDatasetInput struct {
-	Dataset string `json:"dataset"`
-}
-
-type WireGuardScriptInput struct {
-	Script string `json:"script"`
-}
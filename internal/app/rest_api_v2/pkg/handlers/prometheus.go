@@ -17,21 +17,20 @@ import (
 // @Router /prometheus/autodiscovery/vms [get]
 func PrometheusAutoDiscoveryVms(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckPrometheusUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	prometheusTargets, err := HosterPrometheus.GenerateVmTargets(false)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	w.Header().Add("content-type", "application/json")
 	payload, err := json.Marshal(prometheusTargets)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -49,21 +48,20 @@ func PrometheusAutoDiscoveryVms(w http.ResponseWriter, r *http.Request) {
 // @Router /prometheus/autodiscovery/vms/use-ips [get]
 func PrometheusAutoDiscoveryVmsIps(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckPrometheusUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	prometheusTargets, err := HosterPrometheus.GenerateVmTargets(true)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	w.Header().Add("content-type", "application/json")
 	payload, err := json.Marshal(prometheusTargets)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -6,10 +6,10 @@ package handlers
 
 import (
 	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
-	ErrorMappings "HosterCore/internal/app/rest_api_v2/pkg/error_mappings"
 	FreeBSDPgrep "HosterCore/internal/pkg/freebsd/pgrep"
 	"HosterCore/internal/pkg/freebsd/rctl"
 	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
 	"encoding/json"
 	"net/http"
 	"regexp"
@@ -28,8 +28,7 @@ import (
 // @Router /metrics/vm/{vm_name} [get]
 func VmMetrics(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -38,7 +37,7 @@ func VmMetrics(w http.ResponseWriter, r *http.Request) {
 
 	pids, err := FreeBSDPgrep.Pgrep(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -56,13 +55,13 @@ func VmMetrics(w http.ResponseWriter, r *http.Request) {
 
 	rctl, err := rctl.MetricsProcess(pid)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := json.Marshal(rctl)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -81,8 +80,7 @@ func VmMetrics(w http.ResponseWriter, r *http.Request) {
 // @Router /metrics/jail/{jail_name} [get]
 func JailMetrics(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -91,7 +89,7 @@ func JailMetrics(w http.ResponseWriter, r *http.Request) {
 
 	jlist, err := HosterJailUtils.GetRunningJails()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -108,13 +106,13 @@ func JailMetrics(w http.ResponseWriter, r *http.Request) {
 
 	rctl, err := rctl.MetricsJail(jailName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := json.Marshal(rctl)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
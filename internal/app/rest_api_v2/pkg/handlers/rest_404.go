@@ -1,14 +1,10 @@
 package handlers
 
 import (
-	JSONResponse "HosterCore/internal/app/rest_api_v2/pkg/json_response"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
 	"net/http"
 )
 
 func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
-	payload, _ := JSONResponse.GenerateJson(w, "message", "404")
-	log.SetErrorMessage("404")
-
-	SetStatusCode(w, http.StatusNotFound)
-	w.Write(payload)
+	ReportErrorCode(w, http.StatusNotFound, ErrorMappings.CODE_ROUTE_NOT_FOUND, "route not found: "+r.Method+" "+r.URL.Path)
 }
@@ -1,42 +1,79 @@
 package handlers
 
 import (
-	ErrorMappings "HosterCore/internal/app/rest_api_v2/pkg/error_mappings"
-	JSONResponse "HosterCore/internal/app/rest_api_v2/pkg/json_response"
+	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	MiddlewareLogging "HosterCore/internal/app/rest_api_v2/pkg/middleware/logging"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 func SetStatusCode(w http.ResponseWriter, httpStatusCode int) {
-	log.SetStatusCode(httpStatusCode)
-
 	w.Header().Add("Access-Control-Allow-Methods", "*")
 	w.Header().Add("Access-Control-Allow-Origin", "*")
 	w.WriteHeader(httpStatusCode)
 }
 
 func ReportError(w http.ResponseWriter, httpStatusCode int, errorValue string) {
-	log.SetErrorMessage(errorValue)
+	writeApiError(w, ErrorMappings.Resolve(httpStatusCode, errorValue))
+}
+
+// Same as ReportError, but the catalog code attached to the error where it was raised takes priority over the message matching.
+func ReportApiError(w http.ResponseWriter, httpStatusCode int, err error) {
+	writeApiError(w, ErrorMappings.ResolveError(httpStatusCode, err))
+}
+
+// Same as ReportError, but uses the error code given, instead of looking it up in the error catalog.
+func ReportErrorCode(w http.ResponseWriter, httpStatusCode int, code string, errorValue string) {
+	apiErr := ErrorMappings.Resolve(httpStatusCode, errorValue)
+	apiErr.Code = code
+	apiErr.HttpStatus = httpStatusCode
+	writeApiError(w, apiErr)
+}
 
-	swaggerErr := SwaggerError{}
-	errID := ErrorMappings.ValueLookup(errorValue)
-	swaggerErr.ErrorID = int(errID)
-	swaggerErr.ErrorValue = errorValue
+func writeApiError(w http.ResponseWriter, apiErr ErrorMappings.ApiError) {
+	MiddlewareLogging.SetErrorMessage(w, apiErr.Message)
+
+	swaggerErr := SwaggerError{
+		ErrorID:    apiErr.ID,
+		ErrorCode:  apiErr.Code,
+		ErrorValue: apiErr.Message,
+		Details:    apiErr.Details,
+	}
 
 	payload, _ := json.Marshal(swaggerErr)
 	w.Header().Add("Content-Type", "application/json")
-	SetStatusCode(w, httpStatusCode)
+	SetStatusCode(w, apiErr.HttpStatus)
 	w.Write(payload)
 }
 
-func UnauthenticatedResponse(w http.ResponseWriter, user string, pass string) {
-	payload, _ := JSONResponse.GenerateJson(w, "error", "unauthorized")
+// Responds with 401, or with 429 (and the Retry-After header) if the source IP or the user name is locked out.
+// The password is never logged.
+func UnauthenticatedResponse(w http.ResponseWriter, r *http.Request) {
+	user := ApiAuth.RequestUser(r)
+
+	lockout := ApiAuth.RequestLockoutRemaining(r)
+	if lockout > 0 {
+		retryAfter := int(math.Ceil(lockout.Seconds()))
+		w.Header().Add("Retry-After", strconv.Itoa(retryAfter))
+		payload, _ := json.Marshal(SwaggerError{ErrorCode: ErrorMappings.CODE_AUTH_LOCKED_OUT, ErrorValue: "too many failed authentication attempts, try again later"})
+		MiddlewareLogging.SetErrorMessage(w, fmt.Sprintf("could not authenticate '%s': locked out for another %ds", user, retryAfter))
+
+		w.Header().Add("Content-Type", "application/json")
+		SetStatusCode(w, http.StatusTooManyRequests)
+		w.Write(payload)
+		return
+	}
+
 	w.Header().Add("WWW-Authenticate", `Basic realm="Restricted"`)
 
-	message := fmt.Sprintf("could not authenticate '%s' using '%s'", user, pass)
-	log.SetErrorMessage(message)
+	payload, _ := json.Marshal(SwaggerError{ErrorCode: ErrorMappings.CODE_UNAUTHORIZED, ErrorValue: "unauthorized"})
+	MiddlewareLogging.SetErrorMessage(w, fmt.Sprintf("could not authenticate '%s'", user))
 
+	w.Header().Add("Content-Type", "application/json")
 	SetStatusCode(w, http.StatusUnauthorized)
 	w.Write(payload)
 }
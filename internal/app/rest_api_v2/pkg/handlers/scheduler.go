@@ -3,6 +3,7 @@ package handlers
 import (
 	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
 	SchedulerClient "HosterCore/internal/app/scheduler/client"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -22,20 +23,19 @@ import (
 // @Router /scheduler/jobs [get]
 func SchedulerGetJobs(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	jobs, err := SchedulerClient.GetJobList()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := json.Marshal(jobs)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 	w.Header().Add("Content-Type", "application/json")
@@ -44,32 +44,17 @@ func SchedulerGetJobs(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
-type CronJob struct {
-	Disabled bool   `json:"disabled"`
-	Time     string `json:"time"`
-	User     string `json:"user"`
-	Command  string `json:"command"`
-	Comment  string `json:"comment"`
-}
-
-type CronFile struct {
-	FileName      string    `json:"file_name"`
-	CronVariables []string  `json:"cron_variables"`
-	CronJobs      []CronJob `json:"cron_jobs"`
-}
-
 // @Tags Scheduler
 // @Summary Get the list of scheduled cron jobs.
 // @Description Get the list of scheduled cron jobs.<br>`AUTH`: Only REST user is allowed.
 // @Produce json
 // @Security BasicAuth
-// @Success 200 {object} []CronFile{}
+// @Success 200 {object} []ApiV2Types.CronFile{}
 // @Failure 500 {object} SwaggerError
 // @Router /scheduler/cron [get]
 func SchedulerGetCron(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -81,7 +66,7 @@ func SchedulerGetCron(w http.ResponseWriter, r *http.Request) {
 	}
 
 	files := strings.Split(strings.TrimSpace(string(out)), "\n")
-	cronFiles := []CronFile{}
+	cronFiles := []ApiV2Types.CronFile{}
 
 	for _, v := range files {
 		if strings.HasPrefix(v, "hoster_") {
@@ -98,7 +83,7 @@ func SchedulerGetCron(w http.ResponseWriter, r *http.Request) {
 
 	payload, err := json.Marshal(cronFiles)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 	w.Header().Add("Content-Type", "application/json")
@@ -107,7 +92,7 @@ func SchedulerGetCron(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
-func parseCronJob(filePath string) (r CronFile, e error) {
+func parseCronJob(filePath string) (r ApiV2Types.CronFile, e error) {
 	file, err := os.ReadFile(filePath)
 	if err != nil {
 		e = err
@@ -138,7 +123,7 @@ func parseCronJob(filePath string) (r CronFile, e error) {
 			continue
 		}
 
-		job := CronJob{}
+		job := ApiV2Types.CronJob{}
 		if strings.HasPrefix(v, "#DISABLED") || strings.HasPrefix(v, "# DISABLED") {
 			job.Disabled = true
 			v = strings.TrimPrefix(v, "#DISABLED")
@@ -165,7 +150,7 @@ func parseCronJob(filePath string) (r CronFile, e error) {
 			job.Command = strings.TrimSpace(job.Command)
 		} else {
 			if len(split) < 6 {
-				log.Debugf("could not parse CronJob (less than 5 split values): %s", v)
+				log.Debugf("could not parse ApiV2Types.CronJob (less than 5 split values): %s", v)
 				continue
 			}
 
@@ -6,13 +6,14 @@ package handlers
 
 import (
 	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
-	ErrorMappings "HosterCore/internal/app/rest_api_v2/pkg/error_mappings"
 	JSONResponse "HosterCore/internal/app/rest_api_v2/pkg/json_response"
 	SchedulerClient "HosterCore/internal/app/scheduler/client"
 	SchedulerUtils "HosterCore/internal/app/scheduler/utils"
 	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
 	zfsutils "HosterCore/internal/pkg/zfs_utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
 	"encoding/json"
 	"net/http"
 	"time"
@@ -20,16 +21,6 @@ import (
 	"github.com/gorilla/mux"
 )
 
-type SnapshotInput struct {
-	SnapshotsToKeep     int    `json:"snapshots_to_keep"`    // How many snapshots to keep, e.g. 5
-	SnapshotName        string `json:"snapshot_name"`        // Full snapshot name, including the whole path, e.g. "tank/vm-encrypted/vmTest1@snap1"
-	ResourceName        string `json:"res_name"`             // VM or Jail name
-	NewResourceName     string `json:"new_res_name"`         // Used in clone operation, e.g. newVmName, the internal call will automatically append the dataset name
-	SnapshotType        string `json:"snapshot_type"`        // "hourly", "daily", "weekly", "monthly", "frequent"
-	SnapshotDescription string `json:"snapshot_description"` // Description of the snapshot
-	SnapshotDataset     string `json:"-"`
-}
-
 // @Tags Snapshots
 // @Summary Take a new snapshot.
 // @Description Take a new VM or Jail snapshot, using the resource name (Jail name or a VM name).<br>`AUTH`: Only `rest` user is allowed.
@@ -37,26 +28,25 @@ type SnapshotInput struct {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body SnapshotInput true "Request payload"
+// @Param Input body ApiV2Types.SnapshotInput true "Request payload"
 // @Router /snapshot/take/immediate [post]
 func SnapshotTakeImmediate(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := SnapshotInput{}
+	input := ApiV2Types.SnapshotInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	jails, err := HosterJailUtils.ListAllSimple()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 	for _, v := range jails {
@@ -67,7 +57,7 @@ func SnapshotTakeImmediate(w http.ResponseWriter, r *http.Request) {
 
 	vms, err := HosterVmUtils.ListAllSimple()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 	for _, v := range vms {
@@ -81,9 +71,17 @@ func SnapshotTakeImmediate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobId, err := SchedulerClient.AddSnapshotJob(input.ResourceName, 9000, zfsutils.TYPE_CUSTOM, true)
+	// Type and the number of snapshots to keep are optional, the custom snapshots are kept (almost) forever by default
+	if len(input.SnapshotType) < 1 {
+		input.SnapshotType = zfsutils.TYPE_CUSTOM
+	}
+	if input.SnapshotsToKeep < 1 {
+		input.SnapshotsToKeep = 9000
+	}
+
+	jobId, err := SchedulerClient.AddSnapshotJob(input.ResourceName, input.SnapshotsToKeep, input.SnapshotType, true)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -100,7 +98,7 @@ func SnapshotTakeImmediate(w http.ResponseWriter, r *http.Request) {
 
 		job, err = SchedulerClient.GetJobInfo(jobId)
 		if err != nil {
-			ReportError(w, http.StatusInternalServerError, err.Error())
+			ReportApiError(w, http.StatusInternalServerError, err)
 			return
 		}
 
@@ -111,7 +109,7 @@ func SnapshotTakeImmediate(w http.ResponseWriter, r *http.Request) {
 
 	_, err = zfsutils.WriteSnapshotCache()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -131,8 +129,7 @@ func SnapshotTakeImmediate(w http.ResponseWriter, r *http.Request) {
 // @Router /snapshot/all/{res_name} [get]
 func SnapshotList(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -142,12 +139,12 @@ func SnapshotList(w http.ResponseWriter, r *http.Request) {
 
 	jails, err := HosterJailUtils.ListAllSimple()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 	vms, err := HosterVmUtils.ListAllSimple()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -168,7 +165,7 @@ func SnapshotList(w http.ResponseWriter, r *http.Request) {
 
 	snaps, err := zfsutils.SnapshotListWithDescriptions()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -181,7 +178,7 @@ func SnapshotList(w http.ResponseWriter, r *http.Request) {
 
 	payload, err := json.Marshal(result)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -200,8 +197,7 @@ func SnapshotList(w http.ResponseWriter, r *http.Request) {
 // @Router /snapshot/all/{res_name}/cache [get]
 func SnapshotListCache(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -211,12 +207,12 @@ func SnapshotListCache(w http.ResponseWriter, r *http.Request) {
 
 	jails, err := HosterJailUtils.ReadCache()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 	vms, err := HosterVmUtils.ReadCache()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -239,7 +235,7 @@ func SnapshotListCache(w http.ResponseWriter, r *http.Request) {
 
 	snaps, err := zfsutils.ReadSnapshotCache()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -257,7 +253,7 @@ func SnapshotListCache(w http.ResponseWriter, r *http.Request) {
 
 	payload, err := json.Marshal(result)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -265,11 +261,6 @@ func SnapshotListCache(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
-type SnapshotName struct {
-	ResourceName string `json:"resource_name"` // VM or Jail name
-	SnapshotName string `json:"snapshot_name"` // Full snapshot name, including the whole path, e.g. "tank/vm-encrypted/vmTest1@snap1"
-}
-
 // @Tags Snapshots
 // @Summary Destroy a snapshot for any given VM or a Jail.
 // @Description Destroy a snapshot for any given VM or a Jail.<br>`AUTH`: Only `rest` user is allowed.
@@ -277,16 +268,15 @@ type SnapshotName struct {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body SnapshotName true "Request payload"
+// @Param Input body ApiV2Types.SnapshotName true "Request payload"
 // @Router /snapshot/destroy [delete]
 func SnapshotDestroy(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := SnapshotName{}
+	input := ApiV2Types.SnapshotName{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
@@ -296,7 +286,7 @@ func SnapshotDestroy(w http.ResponseWriter, r *http.Request) {
 
 	jobID, err := SchedulerClient.AddSnapshotDestroyJob(input.ResourceName, input.SnapshotName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -312,7 +302,7 @@ func SnapshotDestroy(w http.ResponseWriter, r *http.Request) {
 
 		jobStatus, err := SchedulerClient.GetJobInfo(jobID)
 		if err != nil {
-			ReportError(w, http.StatusInternalServerError, err.Error())
+			ReportApiError(w, http.StatusInternalServerError, err)
 			return
 		}
 
@@ -337,16 +327,15 @@ func SnapshotDestroy(w http.ResponseWriter, r *http.Request) {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body SnapshotName true "Request payload"
+// @Param Input body ApiV2Types.SnapshotName true "Request payload"
 // @Router /snapshot/rollback [post]
 func SnapshotRollback(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := SnapshotName{}
+	input := ApiV2Types.SnapshotName{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
@@ -356,7 +345,7 @@ func SnapshotRollback(w http.ResponseWriter, r *http.Request) {
 
 	jobID, err := SchedulerClient.AddSnapshotRollbackJob(input.ResourceName, input.SnapshotName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -372,7 +361,7 @@ func SnapshotRollback(w http.ResponseWriter, r *http.Request) {
 
 		jobStatus, err := SchedulerClient.GetJobInfo(jobID)
 		if err != nil {
-			ReportError(w, http.StatusInternalServerError, err.Error())
+			ReportApiError(w, http.StatusInternalServerError, err)
 			return
 		}
 
@@ -397,33 +386,32 @@ func SnapshotRollback(w http.ResponseWriter, r *http.Request) {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body SnapshotInput true "Request payload"
+// @Param Input body ApiV2Types.SnapshotInput true "Request payload"
 // @Router /snapshot/clone [post]
 func SnapshotClone(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := SnapshotInput{}
+	input := ApiV2Types.SnapshotInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
 		// ReportError(w, http.StatusInternalServerError, ErrorMappings.CouldNotParseYourInput.String())
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	vms, err := HosterVmUtils.ListAllSimple()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	jails, err := HosterJailUtils.ListAllSimple()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -453,12 +441,12 @@ func SnapshotClone(w http.ResponseWriter, r *http.Request) {
 
 	_, err = HosterVmUtils.WriteCache()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 	_, err = HosterJailUtils.WriteCache()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -0,0 +1,245 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build freebsd
+// +build freebsd
+
+package handlers
+
+import (
+	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	RestApiConfig "HosterCore/internal/app/rest_api_v2/pkg/config"
+	MiddlewareLogging "HosterCore/internal/app/rest_api_v2/pkg/middleware/logging"
+	SerialConsole "HosterCore/internal/app/rest_api_v2/pkg/serial_console"
+	VncProxy "HosterCore/internal/app/rest_api_v2/pkg/vnc_proxy"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	// The API is protected by the HTTP auth, and it's meant to be used from other origins (e.g. the web UI)
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// @Tags VMs
+// @Summary Connect to the VM's serial console (WebSocket).
+// @Description Attach to the VM's serial console (nmdm device) using a WebSocket connection. Console output is sent as binary messages, and the last N KB of output are replayed on connect.<br>Only one client can hold the write access at a time (`?write=true`), any number of clients can connect in the read-only mode.<br>The console device is released once the last client disconnects, and `409` is returned while it's used by the CLI (`hoster vm serial-console`).<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 101
+// @Failure 500 {object} SwaggerError
+// @Failure 409 {object} SwaggerError
+// @Param vm_name path string true "VM Name"
+// @Param write query bool false "Request the write access to the console"
+// @Router /vm/console/serial/{vm_name} [get]
+func VmSerialConsole(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	vmName := vars["vm_name"]
+	write := strings.ToLower(r.URL.Query().Get("write")) == "true"
+
+	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !vmInfo.Running {
+		ReportError(w, http.StatusBadRequest, ErrorMappings.VmIsNotRunning.String())
+		return
+	}
+
+	apiConf, err := RestApiConfig.GetApiConfig()
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	client, replay, err := SerialConsole.Attach(vmName, write, apiConf.SerialConsoleBufferKb)
+	if errors.Is(err, SerialConsole.ErrWriterTaken) || errors.Is(err, SerialConsole.ErrDeviceLocked) {
+		ReportApiError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		client.Detach()
+		MiddlewareLogging.SetErrorMessage(w, "could not upgrade the serial console connection: "+err.Error())
+		return
+	}
+
+	go func() {
+		defer conn.Close()
+
+		if len(replay) > 0 {
+			err := conn.WriteMessage(websocket.BinaryMessage, replay)
+			if err != nil {
+				client.Detach()
+				return
+			}
+		}
+		for v := range client.Output {
+			err := conn.WriteMessage(websocket.BinaryMessage, v)
+			if err != nil {
+				client.Detach()
+				return
+			}
+		}
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "serial console closed"))
+	}()
+
+	conn.SetReadLimit(64 * 1024)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			client.Detach()
+			return
+		}
+		// Input from the read-only clients is silently discarded
+		if client.CanWrite {
+			_, _ = client.Write(data)
+		}
+	}
+}
+
+// @Tags VMs
+// @Summary Issue a VNC proxy session token.
+// @Description Issue a short-lived, single-use token, which can be used to connect to the VM's VNC console over the WebSocket proxy (e.g. using noVNC).<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} ApiV2Types.VncTokenResponse
+// @Failure 500 {object} SwaggerError
+// @Param vm_name path string true "VM Name"
+// @Router /vm/console/vnc/token/{vm_name} [post]
+func VmPostVncToken(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	vmName := vars["vm_name"]
+	user := ApiAuth.RequestUser(r)
+
+	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !vmInfo.Running {
+		ReportError(w, http.StatusBadRequest, ErrorMappings.VmIsNotRunning.String())
+		return
+	}
+
+	apiConf, err := RestApiConfig.GetApiConfig()
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	token, err := VncProxy.IssueToken(vmName, vmInfo.VncPort, user, r.RemoteAddr, time.Duration(apiConf.VncTokenTtl)*time.Second)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := ApiV2Types.VncTokenResponse{Token: token, VncPassword: vmInfo.VncPassword}
+	resp.WsPath = "/api/v2/vm/console/vnc/" + token.Token
+
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
+
+// @Tags VMs
+// @Summary Connect to the VM's VNC console (WebSocket).
+// @Description WebSocket-to-TCP VNC proxy, compatible with noVNC. Use the token issued by `/vm/console/vnc/token/{vm_name}`, each token can only be used once, and only from the IP address it was issued to.<br>`AUTH`: session token.
+// @Success 101
+// @Failure 401 {object} SwaggerError
+// @Failure 429 {object} SwaggerError
+// @Param token path string true "VNC session token"
+// @Router /vm/console/vnc/{token} [get]
+func VmVncProxy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	token, err := VncProxy.RedeemToken(vars["token"], r.RemoteAddr)
+	if err != nil {
+		ReportApiError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	apiConf, err := RestApiConfig.GetApiConfig()
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	release, err := VncProxy.AcquireViewer(token, r.RemoteAddr, apiConf.VncMaxViewers)
+	if err != nil {
+		ReportApiError(w, http.StatusTooManyRequests, err)
+		return
+	}
+	defer release()
+
+	upgrader := wsUpgrader
+	upgrader.Subprotocols = []string{"binary"}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		MiddlewareLogging.SetErrorMessage(w, "could not upgrade the vnc proxy connection: "+err.Error())
+		return
+	}
+	defer conn.Close()
+
+	err = VncProxy.Proxy(conn, token.VncPort)
+	if err != nil {
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()))
+	}
+}
+
+// @Tags VMs
+// @Summary List active VNC proxy sessions.
+// @Description List active VNC proxy sessions.<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} []VncProxy.Session
+// @Failure 500 {object} SwaggerError
+// @Router /vm/console/vnc/sessions [get]
+func VmVncSessions(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	payload, err := json.Marshal(VncProxy.ActiveSessions())
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
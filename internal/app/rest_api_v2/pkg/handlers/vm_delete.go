@@ -10,8 +10,10 @@ package handlers
 import (
 	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
 	JSONResponse "HosterCore/internal/app/rest_api_v2/pkg/json_response"
+	SerialConsole "HosterCore/internal/app/rest_api_v2/pkg/serial_console"
 	HosterVm "HosterCore/internal/pkg/hoster/vm"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
 	"encoding/json"
 	"net/http"
 
@@ -29,8 +31,7 @@ import (
 // @Router /vm/destroy/{vm_name} [delete]
 func VmDestroy(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -39,13 +40,14 @@ func VmDestroy(w http.ResponseWriter, r *http.Request) {
 
 	err := HosterVm.Destroy(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
+	SerialConsole.Close(vmName)
 
 	_, err = HosterVmUtils.WriteCache()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -62,36 +64,36 @@ func VmDestroy(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "VM Name"
-// @Param Input body TagInput true "Request payload"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.TagInput true "Request payload"
 // @Router /vm/settings/delete-tag/{vm_name} [delete]
 func VmDeleteExistingTag(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	vmName := vars["vm_name"]
 
-	input := TagInput{}
+	input := ApiV2Types.TagInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	location := vmInfo.Simple.MountPoint.Mountpoint + "/" + vmName
 	config, err := HosterVmUtils.GetVmConfig(location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -105,9 +107,9 @@ func VmDeleteExistingTag(w http.ResponseWriter, r *http.Request) {
 	config.Tags = []string{}
 	config.Tags = append(config.Tags, tags...)
 
-	err = HosterVmUtils.ConfigFileWriter(config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	err = HosterVmUtils.ConfigFileWriterContext(r.Context(), config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -0,0 +1,119 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build freebsd
+// +build freebsd
+
+package handlers
+
+import (
+	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	MiddlewareLogging "HosterCore/internal/app/rest_api_v2/pkg/middleware/logging"
+	HosterVm "HosterCore/internal/pkg/hoster/vm"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// @Tags VMs
+// @Summary Export the VM into a portable archive (streamed).
+// @Description Stream the VM export archive (tar.zst): VM config, disk images and zvols (holes are skipped), cloud-init seed and files, README and a checksum manifest.<br>External disks are not exported. The VM has to be offline.<br>`AUTH`: Only `rest` user is allowed.
+// @Produce application/zstd
+// @Security BasicAuth
+// @Success 200 {file} file
+// @Failure 500 {object} SwaggerError
+// @Param vm_name path string true "Name of the VM"
+// @Router /vm/export/{vm_name} [get]
+func VmGetExport(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	vmName := vars["vm_name"]
+
+	export, err := HosterVm.NewVmExport(vmName)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The export can take much longer than the server write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/zstd")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+vmName+`.tar.zst"`)
+	SetStatusCode(w, http.StatusOK)
+
+	err = export.Write(w)
+	if err != nil {
+		// The status code was already sent, abort the connection so the client doesn't get a truncated archive
+		MiddlewareLogging.SetErrorMessage(w, err.Error())
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// @Tags VMs
+// @Summary Import the VM from an export archive (streamed).
+// @Description Recreate the VM from the export archive sent as the request body (`Content-Type: application/octet-stream`). All files are verified against the checksum manifest.<br>Parent host and VNC port are reset, network interfaces are moved to the target network, and get new MAC/IP addresses if they conflict with this host.<br>`AUTH`: Only `rest` user is allowed.
+// @Accept octet-stream
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} HosterVm.VmImportResult
+// @Failure 400 {object} SwaggerError "ARCHIVE_INVALID (bad archive, manifest or checksum mismatch), DATASET_NOT_ACTIVE"
+// @Failure 409 {object} SwaggerError "NAME_CONFLICT, IMPORT_NETWORK_MISSING, ADMISSION_DENIED"
+// @Failure 500 {object} SwaggerError
+// @Param vm_name query string false "New VM name (the name stored in the archive is used by default)"
+// @Param dataset query string false "Parent dataset, e.g. zroot/vm-encrypted (the first active dataset is used by default)"
+// @Param network query string false "Move all network interfaces to this network"
+// @Param regenerate_mac query bool false "Always generate new MAC addresses"
+// @Param reset_instance_id query bool false "Reset the cloud-init instance-id if the primary NIC address changes (regenerates the SSH host keys, and applies the user-data again)"
+// @Param Input body string true "VM export archive (tar.zst)"
+// @Router /vm/import [post]
+func VmPostImport(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "application/octet-stream") && !strings.HasPrefix(contentType, "application/zstd") {
+		ReportError(w, http.StatusBadRequest, "archive must be sent as application/octet-stream")
+		return
+	}
+
+	query := r.URL.Query()
+	input := HosterVm.VmImportInput{
+		VmName:        query.Get("vm_name"),
+		Dataset:       query.Get("dataset"),
+		Network:       query.Get("network"),
+		RegenerateMac: strings.ToLower(query.Get("regenerate_mac")) == "true",
+		// Opt-in only, cloud-init re-runs all per-instance modules for a new instance-id
+		ResetInstanceId: strings.ToLower(query.Get("reset_instance_id")) == "true",
+	}
+
+	// The upload can take much longer than the server read/write timeouts
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	result, err := HosterVm.ImportVm(r.Body, input)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	payload, err := json.Marshal(result)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
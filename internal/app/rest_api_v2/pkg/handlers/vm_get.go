@@ -9,20 +9,17 @@ package handlers
 
 import (
 	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	MiddlewareConcurrency "HosterCore/internal/app/rest_api_v2/pkg/middleware/concurrency"
+	AtomicFile "HosterCore/internal/pkg/atomic_file"
 	HosterVm "HosterCore/internal/pkg/hoster/vm"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
 	"encoding/json"
 	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
-type VmStopInput struct {
-	ForceCleanup bool   `json:"force_cleanup"` // Kill the VM supervisor directly (useful in the situations where you want to destroy the VM, or roll it back to a previous snapshot)
-	ForceStop    bool   `json:"force_stop"`    // Send a SIGKILL instead of a graceful SIGTERM
-	VmName       string `json:"vm_name"`
-}
-
 // @Tags VMs
 // @Summary List all VMs.
 // @Description Get the list of all VMs, including the information about them.<br>`AUTH`: Both users are allowed.
@@ -30,28 +27,32 @@ type VmStopInput struct {
 // @Security BasicAuth
 // @Success 200 {object} []HosterVmUtils.VmApi
 // @Failure 500 {object} SwaggerError
+// @Param tag query string false "Filter by tag (comma separated list, all tags must match)"
+// @Param running query bool false "Filter by the running state"
+// @Param production query bool false "Filter by the production flag"
+// @Param backup query bool false "Filter by the backup status"
+// @Param owner query string false "Filter by owner"
+// @Param dataset query string false "Filter by ZFS dataset, e.g. zroot/vm-encrypted"
+// @Param name query string false "Filter by name (glob pattern), e.g. web-*"
+// @Param sort query string false "Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name"
+// @Param offset query int false "Pagination offset"
+// @Param limit query int false "Pagination limit"
+// @Param fields query string false "Comma separated list of fields to return, e.g. name,running"
+// @Header 200 {integer} X-Total-Count "Number of items matching the filters"
 // @Router /vm/all [get]
 func VmList(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vms, err := HosterVmUtils.ListJsonApi()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	payload, err := json.Marshal(vms)
-	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	SetStatusCode(w, http.StatusOK)
-	w.Write(payload)
+	writeListResponse(w, r, vms)
 }
 
 // @Tags VMs
@@ -61,28 +62,32 @@ func VmList(w http.ResponseWriter, r *http.Request) {
 // @Security BasicAuth
 // @Success 200 {object} []HosterVmUtils.VmApi
 // @Failure 500 {object} SwaggerError
+// @Param tag query string false "Filter by tag (comma separated list, all tags must match)"
+// @Param running query bool false "Filter by the running state"
+// @Param production query bool false "Filter by the production flag"
+// @Param backup query bool false "Filter by the backup status"
+// @Param owner query string false "Filter by owner"
+// @Param dataset query string false "Filter by ZFS dataset, e.g. zroot/vm-encrypted"
+// @Param name query string false "Filter by name (glob pattern), e.g. web-*"
+// @Param sort query string false "Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name"
+// @Param offset query int false "Pagination offset"
+// @Param limit query int false "Pagination limit"
+// @Param fields query string false "Comma separated list of fields to return, e.g. name,running"
+// @Header 200 {integer} X-Total-Count "Number of items matching the filters"
 // @Router /vm/all/cache [get]
 func VmListCache(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vms, err := HosterVmUtils.ReadCache()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	payload, err := json.Marshal(vms)
-	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	SetStatusCode(w, http.StatusOK)
-	w.Write(payload)
+	writeListResponse(w, r, vms)
 }
 
 // @Tags VMs
@@ -96,8 +101,7 @@ func VmListCache(w http.ResponseWriter, r *http.Request) {
 // @Router /vm/info/{vm_name} [get]
 func VmInfo(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -106,13 +110,13 @@ func VmInfo(w http.ResponseWriter, r *http.Request) {
 
 	info, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := json.Marshal(info)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -131,8 +135,7 @@ func VmInfo(w http.ResponseWriter, r *http.Request) {
 // @Router /vm/readme/{vm_name} [get]
 func VmGetReadme(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -141,7 +144,7 @@ func VmGetReadme(w http.ResponseWriter, r *http.Request) {
 
 	readme, err := HosterVm.GetReadme(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -158,11 +161,11 @@ func VmGetReadme(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} HosterVmUtils.VmConfig
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "VM Name"
+// @Header 200 {string} ETag "Config revision, send it back in the If-Match header to avoid overwriting someone else's changes"
 // @Router /vm/settings/{vm_name} [get]
 func VmGetSettings(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -171,23 +174,31 @@ func VmGetSettings(w http.ResponseWriter, r *http.Request) {
 
 	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	location := vmInfo.Simple.MountPoint.Mountpoint + "/" + vmName
+	// Revision is read before the config, so the ETag can never be newer than the returned config
+	revision, err := AtomicFile.FileRevision(location + "/" + HosterVmUtils.VM_CONFIG_NAME)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	config, err := HosterVmUtils.GetVmConfig(location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := json.Marshal(config)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	w.Header().Set("ETag", MiddlewareConcurrency.ETag(revision))
 	SetStatusCode(w, http.StatusOK)
 	w.Write(payload)
 }
@@ -199,32 +210,31 @@ func VmGetSettings(w http.ResponseWriter, r *http.Request) {
 // @Security BasicAuth
 // @Success 200 {object} HosterVmUtils.VmTemplate{}
 // @Failure 500 {object} SwaggerError
-// @Param Input body ZfsDatasetInput{} true "Request payload"
+// @Param Input body ApiV2Types.ZfsDatasetInput{} true "Request payload"
 // @Router /vm/templates [post]
 func VmGetTemplates(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := ZfsDatasetInput{}
+	input := ApiV2Types.ZfsDatasetInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	templates, err := HosterVmUtils.GetTemplates(input.Dataset)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	payload, err := json.Marshal(templates)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
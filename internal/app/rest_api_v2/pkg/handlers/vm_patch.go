@@ -0,0 +1,115 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build freebsd
+// +build freebsd
+
+package handlers
+
+import (
+	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	MiddlewareConcurrency "HosterCore/internal/app/rest_api_v2/pkg/middleware/concurrency"
+	AtomicFile "HosterCore/internal/pkg/atomic_file"
+	HosterAdmission "HosterCore/internal/pkg/hoster/admission"
+	HosterVm "HosterCore/internal/pkg/hoster/vm"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+const maxVmPatchSize = 1 << 20
+
+// @Tags VMs
+// @Summary Modify VM settings using a JSON Merge Patch.
+// @Description Apply the JSON Merge Patch (RFC 7396) to the VM config, e.g. `{"cpu_cores": 4, "memory": "8G", "description": null}`.<br>The resulting config is validated as a whole, and is only written if it's valid.<br>Returns the list of changed settings, and whether the VM restart is required for them to take effect.<br>`AUTH`: Only `rest` user is allowed.
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} []HosterVmUtils.VmConfigChange
+// @Failure 409 {object} SwaggerError
+// @Failure 500 {object} SwaggerError
+// @Param vm_name path string true "Name of the VM"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body object true "JSON Merge Patch"
+// @Router /vm/settings/{vm_name} [patch]
+func VmPatchSettings(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	vmName := vars["vm_name"]
+
+	patch, err := io.ReadAll(io.LimitReader(r.Body, maxVmPatchSize))
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	location := vmInfo.Simple.MountPoint.Mountpoint + "/" + vmName
+	config, err := HosterVmUtils.GetVmConfig(location)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	newConfig, err := HosterVmUtils.PatchVmConfig(config, patch)
+	if err != nil {
+		ReportError(w, http.StatusBadRequest, "could not apply the merge patch: "+err.Error())
+		return
+	}
+
+	err = HosterVm.ValidateVmUpdate(vmName, location, config, newConfig)
+	var issues HosterVmUtils.VmConfigIssues
+	if errors.As(err, &issues) || errors.Is(err, HosterVm.ErrHostCapacityExceeded) {
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+	if errors.Is(err, HosterAdmission.ErrAdmissionDenied) {
+		ReportApiError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	changes, err := HosterVmUtils.DiffVmConfig(config, newConfig, vmInfo.Running)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(changes) > 0 {
+		err = HosterVmUtils.ConfigFileWriterContext(r.Context(), newConfig, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+		if err != nil {
+			ReportApiError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	payload, err := json.Marshal(changes)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	revision, err := AtomicFile.FileRevision(location + "/" + HosterVmUtils.VM_CONFIG_NAME)
+	if err == nil {
+		w.Header().Set("ETag", MiddlewareConcurrency.ETag(revision))
+	}
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
@@ -10,12 +10,16 @@ package handlers
 import (
 	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
 	JSONResponse "HosterCore/internal/app/rest_api_v2/pkg/json_response"
+	SerialConsole "HosterCore/internal/app/rest_api_v2/pkg/serial_console"
 	"HosterCore/internal/pkg/byteconversion"
+	HosterAdmission "HosterCore/internal/pkg/hoster/admission"
 	HosterHostUtils "HosterCore/internal/pkg/hoster/host/utils"
 	HosterVm "HosterCore/internal/pkg/hoster/vm"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -32,23 +36,23 @@ import (
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "VM Name"
 // @Param new_tag path string true "New Tag"
-// @Param Input body TagInput true "Request payload"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.TagInput true "Request payload"
 // @Router /vm/settings/add-tag/{vm_name} [post]
 func VmPostNewTag(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	vmName := vars["vm_name"]
 
-	input := TagInput{}
+	input := ApiV2Types.TagInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -64,14 +68,14 @@ func VmPostNewTag(w http.ResponseWriter, r *http.Request) {
 
 	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	location := vmInfo.Simple.MountPoint.Mountpoint + "/" + vmName
 	config, err := HosterVmUtils.GetVmConfig(location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -86,9 +90,9 @@ func VmPostNewTag(w http.ResponseWriter, r *http.Request) {
 		config.Tags = append(config.Tags, input.Tag)
 	}
 
-	err = HosterVmUtils.ConfigFileWriter(config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	err = HosterVmUtils.ConfigFileWriterContext(r.Context(), config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -111,8 +115,7 @@ func VmPostNewTag(w http.ResponseWriter, r *http.Request) {
 // @Router /vm/deploy [post]
 func VmPostDeploy(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -120,19 +123,19 @@ func VmPostDeploy(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	err = HosterVm.Deploy(input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	_, err = HosterVmUtils.WriteCache()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -141,6 +144,59 @@ func VmPostDeploy(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
+// @Tags VMs
+// @Summary Apply the declarative VM spec.
+// @Description Compare the VM spec with the current VM state, and apply the minimal set of changes needed (deploy, CPU/RAM and other settings, new disks, disk expansion, new network interfaces).<br>Re-applying an unchanged spec is a no-op.<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} HosterVm.VmApplyResult
+// @Failure 500 {object} SwaggerError
+// @Param dry_run query bool false "Only return the plan, without applying it"
+// @Param Input body HosterVm.VmSpec{} true "Request payload"
+// @Router /vm/apply [post]
+func VmApply(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	dryRun := strings.ToLower(r.URL.Query().Get("dry_run")) == "true"
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxVmPatchSize))
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+	spec, err := HosterVm.ParseVmSpec(data)
+	if err != nil {
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := HosterVm.Apply(spec, dryRun)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !dryRun && len(result.Changes) > 0 {
+		_, err = HosterVmUtils.WriteCache()
+		if err != nil {
+			ReportApiError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	payload, err := json.Marshal(result)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
+
 // @Tags VMs
 // @Summary Start a specific VM.
 // @Description Start a specific VM using it's name as a parameter.<br>`AUTH`: Both users are allowed.
@@ -152,8 +208,7 @@ func VmPostDeploy(w http.ResponseWriter, r *http.Request) {
 // @Router /vm/start/{vm_name} [post]
 func VmPostStart(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -162,7 +217,7 @@ func VmPostStart(w http.ResponseWriter, r *http.Request) {
 
 	err := HosterVm.Start(vmName, false, false)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -182,8 +237,7 @@ func VmPostStart(w http.ResponseWriter, r *http.Request) {
 // @Router /vm/start-all/{production} [post]
 func VmPostStartAll(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -221,8 +275,7 @@ func VmPostStartAll(w http.ResponseWriter, r *http.Request) {
 // @Router /vm/stop-all/{force} [post]
 func VmPostStopAll(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -260,8 +313,7 @@ func VmPostStopAll(w http.ResponseWriter, r *http.Request) {
 // @Router /vm/start/wait-vnc/{vm_name} [post]
 func VmPostStartAndWaitVnc(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -270,7 +322,7 @@ func VmPostStartAndWaitVnc(w http.ResponseWriter, r *http.Request) {
 
 	err := HosterVm.Start(vmName, true, false)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -286,26 +338,25 @@ func VmPostStartAndWaitVnc(w http.ResponseWriter, r *http.Request) {
 // @Security BasicAuth
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
-// @Param Input body VmCloneInput true "Request payload"
+// @Param Input body ApiV2Types.VmCloneInput true "Request payload"
 // @Router /vm/clone [post]
 func VmClone(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
-	input := VmCloneInput{}
+	input := ApiV2Types.VmCloneInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	err = HosterVm.Clone(input.VmName, input.NewVmName, input.SnapshotName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -322,29 +373,29 @@ func VmClone(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "Name of the VM"
-// @Param Input body VmCpuInput true "Request payload"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.VmCpuInput true "Request payload"
 // @Router /vm/settings/cpu/{vm_name} [post]
 func VmPostCpuInfo(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	vmName := vars["vm_name"]
 
-	input := VmCpuInput{}
+	input := ApiV2Types.VmCpuInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	hostInfo, err := HosterHostUtils.GetHostInfo()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -369,24 +420,44 @@ func VmPostCpuInfo(w http.ResponseWriter, r *http.Request) {
 
 	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	location := vmInfo.Simple.MountPoint.Mountpoint + "/" + vmName
 	config, err := HosterVmUtils.GetVmConfig(location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = HosterAdmission.Check(HosterAdmission.Request{
+		Action:       HosterAdmission.ACTION_UPDATE,
+		ResourceType: HosterAdmission.RESOURCE_VM,
+		ResourceName: vmName,
+		Owner:        config.Owner,
+		VCpus:        overallCpus,
+		Ram:          config.Memory,
+	})
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	oldConfig := config
 	config.CPUCores = input.CpuCores
 	config.CPUThreads = input.CpuThreads
 	config.CPUSockets = input.CpuSockets
 
-	err = HosterVmUtils.ConfigFileWriter(config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	err = HosterVmUtils.ValidateVmConfigChange(vmName, location, oldConfig, config)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = HosterVmUtils.ConfigFileWriterContext(r.Context(), config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -403,36 +474,36 @@ func VmPostCpuInfo(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "Name of the VM"
-// @Param Input body VmRamInput true "Request payload"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.VmRamInput true "Request payload"
 // @Router /vm/settings/ram/{vm_name} [post]
 func VmPostRamInfo(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	vmName := vars["vm_name"]
 
-	input := VmRamInput{}
+	input := ApiV2Types.VmRamInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	hostInfo, err := HosterHostUtils.GetHostInfo()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	overallRamHuman := fmt.Sprintf("%d%s", input.RamAmount, input.BytesValue)
 	overallRamBytes, err := byteconversion.HumanToBytes(overallRamHuman)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -458,24 +529,48 @@ func VmPostRamInfo(w http.ResponseWriter, r *http.Request) {
 
 	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	location := vmInfo.Simple.MountPoint.Mountpoint + "/" + vmName
 	config, err := HosterVmUtils.GetVmConfig(location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	vCpus := config.CPUSockets * config.CPUCores
+	if config.CPUThreads > 0 {
+		vCpus = vCpus * config.CPUThreads
+	}
+	err = HosterAdmission.Check(HosterAdmission.Request{
+		Action:       HosterAdmission.ACTION_UPDATE,
+		ResourceType: HosterAdmission.RESOURCE_VM,
+		ResourceName: vmName,
+		Owner:        config.Owner,
+		VCpus:        vCpus,
+		Ram:          overallRamHuman,
+	})
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	oldConfig := config
 	config.Memory = overallRamHuman
 	// log.Debug("Setting RAM to: " + overallRamHuman)
 	// log.Debug(config)
 
-	err = HosterVmUtils.ConfigFileWriter(config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	err = HosterVmUtils.ValidateVmConfigChange(vmName, location, oldConfig, config)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = HosterVmUtils.ConfigFileWriterContext(r.Context(), config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -493,11 +588,11 @@ func VmPostRamInfo(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "Name of the VM"
 // @Param resolution path int true "Resolution code, e.g. 3 for 1024x768"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
 // @Router /vm/settings/vnc-resolution/{vm_name}/{resolution} [post]
 func VmPostVncResolution(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -528,21 +623,28 @@ func VmPostVncResolution(w http.ResponseWriter, r *http.Request) {
 
 	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	location := vmInfo.Simple.MountPoint.Mountpoint + "/" + vmName
 	config, err := HosterVmUtils.GetVmConfig(location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	oldConfig := config
 	config.VncResolution = resolutionInt
-	err = HosterVmUtils.ConfigFileWriter(config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	err = HosterVmUtils.ValidateVmConfigChange(vmName, location, oldConfig, config)
+	if err != nil {
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = HosterVmUtils.ConfigFileWriterContext(r.Context(), config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -560,11 +662,11 @@ func VmPostVncResolution(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "Name of the VM"
 // @Param firmware path string true "Firmware type (bootloader type), e.g. bios or uefi"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
 // @Router /vm/settings/firmware/{vm_name}/{firmware} [post]
 func VmPostFirmwareType(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -582,21 +684,28 @@ func VmPostFirmwareType(w http.ResponseWriter, r *http.Request) {
 
 	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	location := vmInfo.Simple.MountPoint.Mountpoint + "/" + vmName
 	config, err := HosterVmUtils.GetVmConfig(location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	oldConfig := config
 	config.Loader = firmware
-	err = HosterVmUtils.ConfigFileWriter(config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	err = HosterVmUtils.ValidateVmConfigChange(vmName, location, oldConfig, config)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = HosterVmUtils.ConfigFileWriterContext(r.Context(), config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -614,11 +723,11 @@ func VmPostFirmwareType(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "Name of the VM"
 // @Param production path string true "Workload type (is this a production VM), e.g. true or false"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
 // @Router /vm/settings/production/{vm_name}/{production} [post]
 func VmPostProductionSetting(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -637,14 +746,14 @@ func VmPostProductionSetting(w http.ResponseWriter, r *http.Request) {
 
 	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	location := vmInfo.Simple.MountPoint.Mountpoint + "/" + vmName
 	config, err := HosterVmUtils.GetVmConfig(location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -653,9 +762,9 @@ func VmPostProductionSetting(w http.ResponseWriter, r *http.Request) {
 	} else {
 		config.Production = false
 	}
-	err = HosterVmUtils.ConfigFileWriter(config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	err = HosterVmUtils.ConfigFileWriterContext(r.Context(), config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -672,36 +781,36 @@ func VmPostProductionSetting(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "Name of the VM"
-// @Param Input body VmOsSettings true "Request payload"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.VmOsSettings true "Request payload"
 // @Router /vm/settings/os-info/{vm_name} [post]
 func VmPostOsSettings(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	vmName := vars["vm_name"]
 
-	input := VmOsSettings{}
+	input := ApiV2Types.VmOsSettings{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	vmInfo, err := HosterVmUtils.InfoJsonApi(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	location := vmInfo.Simple.MountPoint.Mountpoint + "/" + vmName
 	config, err := HosterVmUtils.GetVmConfig(location)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -711,9 +820,9 @@ func VmPostOsSettings(w http.ResponseWriter, r *http.Request) {
 	config.OsType = input.OsType
 	config.OsComment = input.OsComment
 
-	err = HosterVmUtils.ConfigFileWriter(config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	err = HosterVmUtils.ConfigFileWriterContext(r.Context(), config, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -721,7 +830,7 @@ func VmPostOsSettings(w http.ResponseWriter, r *http.Request) {
 	// (otherwise the icon won't change in the UI)
 	_, err = HosterVmUtils.WriteCache()
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -741,8 +850,7 @@ func VmPostOsSettings(w http.ResponseWriter, r *http.Request) {
 // @Router /vm/stop/{vm_name} [post]
 func VmPostStop(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -751,7 +859,7 @@ func VmPostStop(w http.ResponseWriter, r *http.Request) {
 
 	err := HosterVm.Stop(vmName, false, false)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -771,8 +879,7 @@ func VmPostStop(w http.ResponseWriter, r *http.Request) {
 // @Router /vm/stop/force/{vm_name} [post]
 func VmPostStopForce(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckAnyUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -781,7 +888,7 @@ func VmPostStopForce(w http.ResponseWriter, r *http.Request) {
 
 	err := HosterVm.Stop(vmName, true, false)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -801,8 +908,7 @@ func VmPostStopForce(w http.ResponseWriter, r *http.Request) {
 // @Router /vm/cloud-init/unmount-iso/{vm_name} [post]
 func VmPostUnmountCiIso(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -811,7 +917,7 @@ func VmPostUnmountCiIso(w http.ResponseWriter, r *http.Request) {
 
 	err := HosterVmUtils.UnmountCiIso(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -831,8 +937,7 @@ func VmPostUnmountCiIso(w http.ResponseWriter, r *http.Request) {
 // @Router /vm/cloud-init/mount-iso/{vm_name} [post]
 func VmPostMountCiIso(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -841,7 +946,7 @@ func VmPostMountCiIso(w http.ResponseWriter, r *http.Request) {
 
 	err := HosterVmUtils.MountCiIso(vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -858,29 +963,29 @@ func VmPostMountCiIso(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "VM Name"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
 // @Router /vm/settings/mount-iso/{vm_name} [post]
 func VmPostMountIso(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	vmName := vars["vm_name"]
 
-	input := VmMountIsoInput{}
+	input := ApiV2Types.VmMountIsoInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	// log.Debug(input)
-	err = HosterVmUtils.MountInstallationIso(vmName, input.IsoPath, input.IsoComment)
+	err = HosterVmUtils.MountInstallationIso(r.Context(), vmName, input.IsoPath, input.IsoComment)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -897,28 +1002,28 @@ func VmPostMountIso(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "VM Name"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
 // @Router /vm/settings/unmount-iso/{vm_name} [post]
 func VmPostUnmountIso(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	vmName := vars["vm_name"]
 
-	input := VmMountIsoInput{}
+	input := ApiV2Types.VmMountIsoInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	err = HosterVmUtils.UnmountInstallationIso(vmName, input.IsoPath)
+	err = HosterVmUtils.UnmountInstallationIso(r.Context(), vmName, input.IsoPath)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -935,12 +1040,12 @@ func VmPostUnmountIso(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "Name of the VM"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
 // @Param Input body HosterVmUtils.VmDisk{} true "Request payload"
 // @Router /vm/settings/disk/add-new/{vm_name} [post]
 func VmPostAddNewDisk(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -951,13 +1056,13 @@ func VmPostAddNewDisk(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	err = HosterVmUtils.AddNewVmDisk(vmName, input)
+	err = HosterVmUtils.AddNewVmDisk(r.Context(), vmName, input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -965,7 +1070,7 @@ func VmPostAddNewDisk(w http.ResponseWriter, r *http.Request) {
 	// (otherwise the icon won't change in the UI)
 	// _, err = HosterVmUtils.WriteCache()
 	// if err != nil {
-	// 	ReportError(w, http.StatusInternalServerError, err.Error())
+	// 	ReportApiError(w, http.StatusInternalServerError, err)
 	// 	return
 	// }
 
@@ -982,29 +1087,29 @@ func VmPostAddNewDisk(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "Name of the VM"
-// @Param Input body VmDiskExpandInput{} true "Request payload"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.VmDiskExpandInput{} true "Request payload"
 // @Router /vm/settings/disk/expand/{vm_name} [post]
 func VmPostExpandDisk(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	vmName := vars["vm_name"]
 
-	input := VmDiskExpandInput{}
+	input := ApiV2Types.VmDiskExpandInput{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	err = HosterVmUtils.DiskExpandOffline(input.DiskImage, input.ExpansionSize, vmName)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -1012,7 +1117,7 @@ func VmPostExpandDisk(w http.ResponseWriter, r *http.Request) {
 	// (otherwise the icon won't change in the UI)
 	// _, err = HosterVmUtils.WriteCache()
 	// if err != nil {
-	// 	ReportError(w, http.StatusInternalServerError, err.Error())
+	// 	ReportApiError(w, http.StatusInternalServerError, err)
 	// 	return
 	// }
 
@@ -1021,6 +1126,129 @@ func VmPostExpandDisk(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
+// @Tags VMs
+// @Summary Detach a VM disk.
+// @Description Detach a disk from the VM, the disk image (or zvol) itself is kept. VM has to be offline.<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} SwaggerSuccess
+// @Failure 500 {object} SwaggerError
+// @Param vm_name path string true "Name of the VM"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.VmDiskDetachInput{} true "Request payload"
+// @Router /vm/settings/disk/detach/{vm_name} [post]
+func VmPostDetachDisk(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	vmName := vars["vm_name"]
+
+	input := ApiV2Types.VmDiskDetachInput{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&input)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, err = HosterVmUtils.DetachVmDisk(r.Context(), vmName, input.DiskImage)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	payload, _ := JSONResponse.GenerateJson(w, "message", "success")
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
+
+// @Tags VMs
+// @Summary Remove a VM disk.
+// @Description Detach a disk from the VM, and permanently delete its image (or zvol). VM has to be offline.<br>Fails if any of the VM snapshots (or their clones) still reference the disk data. External disk images are never deleted, detach them instead.<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} SwaggerSuccess
+// @Failure 400 {object} SwaggerError
+// @Failure 500 {object} SwaggerError
+// @Param vm_name path string true "Name of the VM"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.VmDiskRemoveInput{} true "Request payload"
+// @Router /vm/settings/disk/remove/{vm_name} [post]
+func VmPostRemoveDisk(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	vmName := vars["vm_name"]
+
+	input := ApiV2Types.VmDiskRemoveInput{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&input)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !input.Confirm {
+		ReportError(w, http.StatusBadRequest, "disk image will be deleted permanently, set confirm to true to continue")
+		return
+	}
+
+	err = HosterVmUtils.RemoveVmDisk(r.Context(), vmName, input.DiskImage)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	payload, _ := JSONResponse.GenerateJson(w, "message", "success")
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
+
+// @Tags VMs
+// @Summary Change the VM disk order.
+// @Description Move the listed disks to the top of the disk list, in the given order. The first disk is the boot disk. Takes effect after the next VM start.<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} SwaggerSuccess
+// @Failure 500 {object} SwaggerError
+// @Param vm_name path string true "Name of the VM"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.VmDiskReorderInput{} true "Request payload"
+// @Router /vm/settings/disk/reorder/{vm_name} [post]
+func VmPostReorderDisks(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	vmName := vars["vm_name"]
+
+	input := ApiV2Types.VmDiskReorderInput{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&input)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = HosterVmUtils.ReorderVmDisks(r.Context(), vmName, input.Order)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	payload, _ := JSONResponse.GenerateJson(w, "message", "success")
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
+
 // @Tags VMs, Networks
 // @Summary Add a new VM network interface.
 // @Description Add a new VM network interface.<br>`AUTH`: Only `rest` user is allowed.
@@ -1029,12 +1257,12 @@ func VmPostExpandDisk(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "Name of the VM"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
 // @Param Input body HosterVmUtils.VmNetwork{} true "Request payload"
 // @Router /vm/settings/network/add/{vm_name} [post]
 func VmPostAddNewNetwork(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -1045,13 +1273,13 @@ func VmPostAddNewNetwork(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	err = HosterVm.AddNewVmNetwork(vmName, input)
+	err = HosterVm.AddNewVmNetwork(r.Context(), vmName, input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -1059,7 +1287,7 @@ func VmPostAddNewNetwork(w http.ResponseWriter, r *http.Request) {
 	// (otherwise the icon won't change in the UI)
 	// _, err = HosterVmUtils.WriteCache()
 	// if err != nil {
-	// 	ReportError(w, http.StatusInternalServerError, err.Error())
+	// 	ReportApiError(w, http.StatusInternalServerError, err)
 	// 	return
 	// }
 
@@ -1068,6 +1296,138 @@ func VmPostAddNewNetwork(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
+// @Tags VMs, Networks
+// @Summary Remove a VM network interface.
+// @Description Remove a VM network interface, and release its IP address. The VM must be stopped.<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} SwaggerSuccess
+// @Failure 409 {object} SwaggerError
+// @Failure 500 {object} SwaggerError
+// @Param vm_name path string true "Name of the VM"
+// @Param nic_index path int true "Network interface index (0 is the first interface)"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Router /vm/settings/network/remove/{vm_name}/{nic_index} [post]
+func VmPostRemoveNetwork(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	vmName := vars["vm_name"]
+
+	nicIndex, err := strconv.Atoi(vars["nic_index"])
+	if err != nil {
+		ReportError(w, http.StatusBadRequest, "nic_index must be an integer")
+		return
+	}
+
+	_, err = HosterVm.RemoveVmNetwork(r.Context(), vmName, nicIndex)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	payload, _ := JSONResponse.GenerateJson(w, "message", "success")
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
+
+// @Tags VMs, Networks
+// @Summary Change the VM network interface settings.
+// @Description Change the VM network interface settings: network, driver, MAC address (set or regenerate), IP address, VLAN tag or comment. Only the fields that are set are changed.<br>If the interface is moved to another network, a new IP address is allocated (unless it's set explicitly). Takes effect after the next VM start.<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} HosterVmUtils.VmNetwork
+// @Failure 500 {object} SwaggerError
+// @Param vm_name path string true "Name of the VM"
+// @Param nic_index path int true "Network interface index (0 is the first interface)"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body HosterVm.VmNetworkUpdate{} true "Request payload"
+// @Router /vm/settings/network/update/{vm_name}/{nic_index} [post]
+func VmPostUpdateNetwork(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	vmName := vars["vm_name"]
+
+	nicIndex, err := strconv.Atoi(vars["nic_index"])
+	if err != nil {
+		ReportError(w, http.StatusBadRequest, "nic_index must be an integer")
+		return
+	}
+
+	input := HosterVm.VmNetworkUpdate{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&input)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	nic, err := HosterVm.UpdateVmNetwork(r.Context(), vmName, nicIndex, input)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	payload, err := json.Marshal(nic)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
+
+// @Tags VMs
+// @Summary Rename the VM.
+// @Description Rename the VM: ZFS dataset, VM config paths, cloud-init hostname, cron jobs and DNS records are updated.<br>MAC and IP addresses, passwords and SSH keys are kept as is. The VM has to be offline, and it can't be renamed while it's being replicated.<br>`AUTH`: Only `rest` user is allowed.
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} SwaggerSuccess
+// @Failure 500 {object} SwaggerError
+// @Param vm_name path string true "Name of the VM"
+// @Param Input body ApiV2Types.VmRenameInput{} true "Request payload"
+// @Router /vm/rename/{vm_name} [post]
+func VmPostRename(w http.ResponseWriter, r *http.Request) {
+	if !ApiAuth.CheckRestUser(r) {
+		UnauthenticatedResponse(w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	vmName := vars["vm_name"]
+
+	input := ApiV2Types.VmRenameInput{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&input)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(input.NewName) < 1 {
+		ReportError(w, http.StatusBadRequest, "new_name must be set")
+		return
+	}
+
+	err = HosterVm.RenameVm(vmName, input.NewName)
+	if err != nil {
+		ReportApiError(w, http.StatusInternalServerError, err)
+		return
+	}
+	SerialConsole.Close(vmName)
+
+	payload, _ := JSONResponse.GenerateJson(w, "message", "success")
+	SetStatusCode(w, http.StatusOK)
+	w.Write(payload)
+}
+
 // @Tags VMs
 // @Summary Update VM's description.
 // @Description Update VM's description.<br>`AUTH`: Only `rest` user is allowed.
@@ -1076,29 +1436,29 @@ func VmPostAddNewNetwork(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} SwaggerSuccess
 // @Failure 500 {object} SwaggerError
 // @Param vm_name path string true "Name of the VM"
-// @Param Input body ResourceDescription{} true "Request payload"
+// @Param If-Match header string false "ETag from the GET settings response (required, unless allow_missing_if_match is enabled), 412 is returned if the config has been changed since"
+// @Param Input body ApiV2Types.ResourceDescription{} true "Request payload"
 // @Router /vm/settings/description/{vm_name} [post]
 func VmPostDescription(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vars := mux.Vars(r)
 	vmName := vars["vm_name"]
 
-	input := ResourceDescription{}
+	input := ApiV2Types.ResourceDescription{}
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&input)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	err = HosterVmUtils.UpdateDescription(vmName, input.Description)
+	err = HosterVmUtils.UpdateDescription(r.Context(), vmName, input.Description)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -23,8 +23,7 @@ import (
 // @Router /wireguard/script [post]
 func WireGuardScript(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckRestUser(r) {
-		user, pass, _ := r.BasicAuth()
-		UnauthenticatedResponse(w, user, pass)
+		UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -44,7 +43,7 @@ func WireGuardScript(w http.ResponseWriter, r *http.Request) {
 
 	err = os.WriteFile(scriptLoc, []byte(script), 0644)
 	if err != nil {
-		ReportError(w, http.StatusInternalServerError, err.Error())
+		ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -4,6 +4,7 @@
 package HandlersHA
 
 import (
+	ApiV2Types "HosterCore/pkg/api_v2_types"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -15,12 +16,12 @@ import (
 	"HosterCore/cmd"
 	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
 	RestApiConfig "HosterCore/internal/app/rest_api_v2/pkg/config"
-	ErrorMappings "HosterCore/internal/app/rest_api_v2/pkg/error_mappings"
 	"HosterCore/internal/app/rest_api_v2/pkg/handlers"
 	JSONResponse "HosterCore/internal/app/rest_api_v2/pkg/json_response"
 	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
 	zfsutils "HosterCore/internal/pkg/zfs_utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
 )
 
 // @Tags HA
@@ -42,8 +43,7 @@ func HandleRegistration(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	if !ApiAuth.CheckHaUser(r) {
-		user, pass, _ := r.BasicAuth()
-		handlers.UnauthenticatedResponse(w, user, pass)
+		handlers.UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -86,8 +86,7 @@ func HandlePing(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	if !ApiAuth.CheckHaUser(r) {
-		user, pass, _ := r.BasicAuth()
-		handlers.UnauthenticatedResponse(w, user, pass)
+		handlers.UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -121,8 +120,7 @@ func HandlePing(w http.ResponseWriter, r *http.Request) {
 // @Router /ha/terminate [post]
 func HandleTerminate(w http.ResponseWriter, r *http.Request) {
 	if !ApiAuth.CheckHaUser(r) {
-		user, pass, _ := r.BasicAuth()
-		handlers.UnauthenticatedResponse(w, user, pass)
+		handlers.UnauthenticatedResponse(w, r)
 		return
 	}
 
@@ -145,19 +143,11 @@ func HandleTerminate(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
-type HaVm struct {
-	VmName         string `json:"vm_name"`
-	Live           bool   `json:"live"`
-	LatestSnapshot string `json:"latest_snapshot"`
-	ParentHost     string `json:"parent_host"`
-	CurrentHost    string `json:"current_host"`
-}
-
 // @Tags HA
 // @Summary Handle the HA enabled VM list.
 // @Description Handle the HA enabled VM list.
 // @Produce json
-// @Success 200 {object} []HaVm
+// @Success 200 {object} []ApiV2Types.HaVm
 // @Failure 500 {object} handlers.SwaggerError
 // @Security BasicAuth
 // @Router /ha/vm-list [get]
@@ -171,26 +161,25 @@ func HandleVmList(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	if !ApiAuth.CheckHaUser(r) {
-		user, pass, _ := r.BasicAuth()
-		handlers.UnauthenticatedResponse(w, user, pass)
+		handlers.UnauthenticatedResponse(w, r)
 		return
 	}
 
 	vms, err := HosterVmUtils.ListJsonApi()
 	if err != nil {
-		handlers.ReportError(w, http.StatusInternalServerError, err.Error())
+		handlers.ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	snaps, err := zfsutils.SnapshotListWithDescriptions()
 	if err != nil {
-		handlers.ReportError(w, http.StatusInternalServerError, err.Error())
+		handlers.ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	haVms := []HaVm{}
+	haVms := []ApiV2Types.HaVm{}
 	for _, v := range vms {
-		temp := HaVm{}
+		temp := ApiV2Types.HaVm{}
 		if !v.Production {
 			continue
 		}
@@ -213,7 +202,7 @@ func HandleVmList(w http.ResponseWriter, r *http.Request) {
 
 	payload, err := json.Marshal(haVms)
 	if err != nil {
-		handlers.ReportError(w, http.StatusInternalServerError, err.Error())
+		handlers.ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -221,19 +210,11 @@ func HandleVmList(w http.ResponseWriter, r *http.Request) {
 	w.Write(payload)
 }
 
-type HaJail struct {
-	VmName         string `json:"jail_name"`
-	Live           bool   `json:"live"`
-	LatestSnapshot string `json:"latest_snapshot"`
-	ParentHost     string `json:"parent_host"`
-	CurrentHost    string `json:"current_host"`
-}
-
 // @Tags HA
 // @Summary Handle the HA enabled Jail list.
 // @Description Handle the HA enabled Jail list.
 // @Produce json
-// @Success 200 {object} []HaJail
+// @Success 200 {object} []ApiV2Types.HaJail
 // @Failure 500 {object} handlers.SwaggerError
 // @Security BasicAuth
 // @Router /ha/jail-list [get]
@@ -246,26 +227,25 @@ func HandleJailList(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	if !ApiAuth.CheckHaUser(r) {
-		user, pass, _ := r.BasicAuth()
-		handlers.UnauthenticatedResponse(w, user, pass)
+		handlers.UnauthenticatedResponse(w, r)
 		return
 	}
 
 	jails, err := HosterJailUtils.ListJsonApi()
 	if err != nil {
-		handlers.ReportError(w, http.StatusInternalServerError, err.Error())
+		handlers.ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	snaps, err := zfsutils.SnapshotListWithDescriptions()
 	if err != nil {
-		handlers.ReportError(w, http.StatusInternalServerError, err.Error())
+		handlers.ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	haJails := []HaJail{}
+	haJails := []ApiV2Types.HaJail{}
 	for _, v := range jails {
-		temp := HaJail{}
+		temp := ApiV2Types.HaJail{}
 		if !v.Production {
 			continue
 		}
@@ -288,7 +268,7 @@ func HandleJailList(w http.ResponseWriter, r *http.Request) {
 
 	payload, err := json.Marshal(haJails)
 	if err != nil {
-		handlers.ReportError(w, http.StatusInternalServerError, err.Error())
+		handlers.ReportApiError(w, http.StatusInternalServerError, err)
 		return
 	}
 
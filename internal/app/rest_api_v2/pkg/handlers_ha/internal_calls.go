@@ -6,10 +6,14 @@ package HandlersHA
 import (
 	RestApiConfig "HosterCore/internal/app/rest_api_v2/pkg/config"
 	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	ApiV2Client "HosterCore/pkg/api_v2_client"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"sort"
@@ -154,24 +158,14 @@ func registerNode() {
 			host.StartupTime = haConf.StartupTime
 			host.BackupNode = haConf.BackupNode
 
-			jsonPayload, _ := json.Marshal(host)
-			payload := strings.NewReader(string(jsonPayload))
-			url := v.Protocol + "://" + v.Address + ":" + v.Port + "/api/v2/ha/register"
-
-			req, err := http.NewRequest("POST", url, payload)
+			client, err := nodeApiClient(v)
 			if err != nil {
-				// _ = exec.Command("logger", "-t", "HOSTER_HA_REST", "ERROR: could not form the /register request: "+err.Error()).Run()
 				internalLog.Error("could not form the /register request: " + err.Error())
 				time.Sleep(time.Second * 10)
 				continue
 			}
 
-			auth := v.User + ":" + v.Password
-			authEncoded := base64.StdEncoding.EncodeToString([]byte(auth))
-			req.Header.Add("Content-Type", "application/json")
-			req.Header.Add("Authorization", "Basic "+authEncoded)
-
-			res, err := http.DefaultClient.Do(req)
+			_, err = client.HaRegistration(context.Background(), host)
 			if err != nil {
 				// _ = exec.Command("logger", "-t", "HOSTER_HA_REST", "WARN: could not join the candidate: "+err.Error()).Run()
 				internalLog.Error("could not join the other candidate: " + err.Error())
@@ -182,8 +176,6 @@ func registerNode() {
 				internalLog.Info("joined the candidate " + v.Hostname)
 				haConf.Candidates[i].Registered = true
 				haConf.Candidates[i].StartupTime = haConf.StartupTime
-				req.Body.Close()
-				res.Body.Close()
 			}
 		}
 	}
@@ -227,27 +219,13 @@ func sendPing() {
 				host.Hostname, _ = FreeBSDsysctls.SysctlKernHostname()
 				host.StartupTime = haConf.StartupTime
 
-				jsonPayload, err := json.Marshal(host)
+				client, err := nodeApiClient(v)
 				if err != nil {
-					// _ = exec.Command("logger", "-t", "HOSTER_HA_REST", "ERR: pingGoRoutineJSONPAYLOAD(): "+err.Error()).Run()
-					internalLog.Error("could not form a JSON payload pingGoRoutine(): " + err.Error())
-					return
-				}
-
-				payload := strings.NewReader(string(jsonPayload))
-				url := v.Protocol + "://" + v.Address + ":" + v.Port + "/api/v2/ha/ping"
-				req, err := http.NewRequest("POST", url, payload)
-				if err != nil {
-					// _ = exec.Command("logger", "-t", "HOSTER_HA_REST", "ERR: pingGoRoutineREQ(): "+err.Error()).Run()
 					internalLog.Error("pingGoRoutineRequest(): " + err.Error())
 					return
 				}
 
-				auth := v.User + ":" + v.Password
-				authEncoded := base64.StdEncoding.EncodeToString([]byte(auth))
-				req.Header.Add("Content-Type", "application/json")
-				req.Header.Add("Authorization", "Basic "+authEncoded)
-				resp, err := http.DefaultClient.Do(req)
+				_, err = client.HaPing(context.Background(), host)
 				if err != nil {
 					// _ = exec.Command("logger", "-t", "HOSTER_HA_REST", "WARN: failed to ping the candidate node: "+err.Error()).Run()
 					internalLog.Warn("failed to ping the candidate node: " + err.Error())
@@ -260,9 +238,6 @@ func sendPing() {
 						haConf.Candidates[i].TimesFailed -= 1
 					}
 					haConf.Candidates[i].Registered = true
-					// Close the request and response body to release resources
-					req.Body.Close()
-					resp.Body.Close()
 				}
 			}(i, v)
 		}
@@ -310,10 +285,10 @@ func failoverHostVms(haNode HosterHaNode) {
 		return
 	}
 
-	haVms := []HaVm{}
+	haVms := []ApiV2Types.HaVm{}
 	hostsDbCopy := readHostsDb(&hostsDbLock)
 	for _, v := range hostsDbCopy {
-		haVmsTemp := []HaVm{}
+		haVmsTemp := []ApiV2Types.HaVm{}
 
 		// Skip the failed node (passed via function parameters, and not offline-d yet)
 		if v.NodeInfo.Hostname == haNode.NodeInfo.Hostname {
@@ -357,7 +332,7 @@ func failoverHostVms(haNode HosterHaNode) {
 		return haVms[i].LatestSnapshot < haVms[j].LatestSnapshot
 	})
 
-	uniqueHaVms := []HaVm{}
+	uniqueHaVms := []ApiV2Types.HaVm{}
 	for _, v := range haVms {
 		vmExists := false
 		for _, vv := range uniqueHaVms {
@@ -550,19 +525,13 @@ func TerminateOtherMembers() {
 
 			// _ = exec.Command("logger", "-t", "HOSTER_HA_REST", "INFO: sending a shutdown signal to: "+node.NodeInfo.Hostname).Run()
 			internalLog.Infof("sending a shutdown signal to: %s", node.NodeInfo.Hostname)
-			url := node.NodeInfo.Protocol + "://" + node.NodeInfo.Address + ":" + node.NodeInfo.Port + "/api/v2/ha/terminate"
-
-			req, err := http.NewRequest("POST", url, nil)
+			client, err := nodeApiClient(node.NodeInfo)
 			if err != nil {
-				// _ = exec.Command("logger", "-t", "HOSTER_HA_REST", "ERROR: could not form the /terminate request: "+err.Error()).Run()
 				internalLog.Errorf("could not form the /terminate request: %s", err.Error())
 				return
 			}
 
-			auth := node.NodeInfo.User + ":" + node.NodeInfo.Password
-			authEncoded := base64.StdEncoding.EncodeToString([]byte(auth))
-			req.Header.Add("Authorization", "Basic "+authEncoded)
-			_, err = http.DefaultClient.Do(req)
+			_, err = client.HaTerminate(context.Background())
 
 			if err != nil {
 				// _ = exec.Command("logger", "-t", "HOSTER_HA_REST", "WARN: could not notify the member: "+node.NodeInfo.Hostname+". Error: "+err.Error()).Run()
@@ -572,3 +541,9 @@ func TerminateOtherMembers() {
 	}
 	wg.Wait()
 }
+
+// Returns the REST API client for the HA node, using the node's own HA user credentials.
+func nodeApiClient(node RestApiConfig.HaNode) (*ApiV2Client.Client, error) {
+	endpoint := node.Protocol + "://" + net.JoinHostPort(node.Address, node.Port)
+	return ApiV2Client.New(endpoint, ApiV2Client.WithBasicAuth(node.User, node.Password), ApiV2Client.WithRetries(0, 0))
+}
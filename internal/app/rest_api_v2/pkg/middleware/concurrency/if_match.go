@@ -0,0 +1,137 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package MiddlewareConcurrency
+
+import (
+	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	RestApiConfig "HosterCore/internal/app/rest_api_v2/pkg/config"
+	MiddlewareLogging "HosterCore/internal/app/rest_api_v2/pkg/middleware/logging"
+	MiddlewareResources "HosterCore/internal/app/rest_api_v2/pkg/middleware/resources"
+	AtomicFile "HosterCore/internal/pkg/atomic_file"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Per config file lock, which serializes the API settings changes to the same resource
+var resourceLocks sync.Map
+
+// Formats the config file revision as an HTTP ETag header value.
+func ETag(revision string) string {
+	return `"` + revision + `"`
+}
+
+// Optimistic concurrency control for the VM and jail settings changes.
+//
+// The client has to send the If-Match header (ETag returned by the GET settings routes), and the change is only applied
+// if the config file hasn't been modified since, otherwise 412 Precondition Failed is returned.
+// Requests without the If-Match header are rejected with 428, unless "allow_missing_if_match" is enabled in the REST API config.
+//
+// The revision is checked here for the early rejection, and then passed to the config writer using the request context,
+// where it's compared again under the config file lock (the config could be changed by the CLI in the meantime).
+// The credentials are checked first, so the unauthenticated callers can't use 412/428 to find out which resources exist.
+func IfMatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/api/v2/vm/settings/") && !strings.HasPrefix(r.URL.Path, "/api/v2/jail/settings/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// All settings routes are only available to the REST API user
+		if !ApiAuth.CheckRestUser(r) {
+			w.Header().Add("WWW-Authenticate", `Basic realm="Restricted"`)
+			reportError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		confLocation := configLocation(mux.Vars(r))
+		if len(confLocation) < 1 {
+			// Resource doesn't exist, let the handler report it
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		lock, _ := resourceLocks.LoadOrStore(confLocation, &sync.Mutex{})
+		lock.(*sync.Mutex).Lock()
+		defer lock.(*sync.Mutex).Unlock()
+
+		ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+		if len(ifMatch) < 1 {
+			conf, err := RestApiConfig.GetApiConfig()
+			if err != nil || !conf.AllowMissingIfMatch {
+				reportError(w, http.StatusPreconditionRequired, "If-Match header is required for the settings changes, use the ETag from the GET settings response")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		revision, err := AtomicFile.FileRevision(confLocation)
+		if err != nil {
+			reportError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !etagMatches(ifMatch, revision) {
+			reportError(w, http.StatusPreconditionFailed, AtomicFile.ErrRevisionMismatch.Error())
+			return
+		}
+		if ifMatch == "*" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(AtomicFile.WithExpectedRevision(r.Context(), confLocation, revision)))
+	})
+}
+
+// Supports the "*" wildcard, and a comma separated list of (optionally weak) ETags.
+func etagMatches(ifMatch string, revision string) bool {
+	for _, v := range strings.Split(ifMatch, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" {
+			return true
+		}
+		v = strings.TrimPrefix(v, "W/")
+		if strings.Trim(v, `"`) == revision {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Only the VM and jail settings have the revisions (the route variable tells which one it is)
+func configLocation(vars map[string]string) string {
+	if vmName, ok := vars["vm_name"]; ok {
+		return MiddlewareResources.ConfigLocation(MiddlewareResources.RESOURCE_VM, vmName)
+	}
+	if jailName, ok := vars["jail_name"]; ok {
+		return MiddlewareResources.ConfigLocation(MiddlewareResources.RESOURCE_JAIL, jailName)
+	}
+
+	return ""
+}
+
+// Same format as the handlers.ReportError()
+func reportError(w http.ResponseWriter, httpStatusCode int, errorValue string) {
+	MiddlewareLogging.SetErrorMessage(w, errorValue)
+
+	apiErr := ErrorMappings.Resolve(httpStatusCode, errorValue)
+	payload, _ := json.Marshal(apiErr)
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Header().Add("Access-Control-Allow-Methods", "*")
+	w.Header().Add("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(apiErr.HttpStatus)
+	w.Write(payload)
+}
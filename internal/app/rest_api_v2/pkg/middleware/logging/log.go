@@ -1,42 +1,36 @@
 package MiddlewareLogging
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
-	"sync"
 	"time"
 
+	"github.com/oklog/ulid/v2"
 	"github.com/sirupsen/logrus"
 )
 
-var lock sync.RWMutex
-
 type Log struct {
 	*logrus.Logger
-	RespError         bool
-	ErrorMessage      string
-	RespDebug         bool
-	DebugMessage      string
-	InfoMessage       string
-	HttpStatusCode    int
-	HttpStatusCodeSet bool
 }
 
-func Configure(level logrus.Level) *Log {
-	lock.Lock()
-	defer lock.Unlock()
+type ctxKey int
+
+const requestStateKey ctxKey = 0
 
+const REQUEST_ID_HEADER = "X-Request-ID"
+
+func Configure(level logrus.Level) *Log {
 	l := &Log{
-		Logger:    logrus.New(),
-		RespError: false,
+		Logger: logrus.New(),
 	}
 
 	// logStdOut := os.Getenv("LOG_STDOUT")
 	logFile := os.Getenv("LOG_FILE")
 
 	// Log as JSON instead of the default ASCII/text formatter.
-	// l.SetFormatter(&logrus.JSONFormatter{})
+	l.SetFormatter(&logrus.JSONFormatter{})
 
 	// Output to stdout instead of the default stderr
 	l.SetOutput(os.Stdout)
@@ -57,107 +51,54 @@ func Configure(level logrus.Level) *Log {
 	return l
 }
 
-func (log *Log) revertToNormalLevel() {
-	lock.Lock()
-	defer lock.Unlock()
-
-	log.RespError = false
-	log.RespDebug = false
-}
-
-func (log *Log) SetErrorTrue() {
-	lock.Lock()
-	defer lock.Unlock()
-
-	log.RespError = true
-}
-
-func (log *Log) SetErrorMessage(message string) {
-	lock.Lock()
-	defer lock.Unlock()
-
-	log.RespError = true
-	log.ErrorMessage = message
-}
-
-func (log *Log) SetDebugTrue() {
-	lock.Lock()
-	defer lock.Unlock()
-
-	log.RespDebug = true
-}
-
-func (log *Log) SetDebugMessage(message string) {
-	lock.Lock()
-	defer lock.Unlock()
-
-	log.RespDebug = true
-	log.DebugMessage = message
-}
+func (log *Log) LogResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeStart := time.Now()
 
-func (log *Log) SetStatusCode(status int) {
-	lock.Lock()
-	defer lock.Unlock()
+		// Reuse the client's request ID if it was set, otherwise generate a new one
+		requestId := r.Header.Get(REQUEST_ID_HEADER)
+		if len(requestId) < 1 || len(requestId) > 64 {
+			requestId = ulid.Make().String()
+		}
 
-	log.HttpStatusCode = status
-}
+		state := &RequestState{RequestID: requestId}
+		rw := &ResponseWriter{ResponseWriter: w, state: state}
+		rw.Header().Set(REQUEST_ID_HEADER, requestId)
 
-func (log *Log) UnsetStatusCode() {
-	lock.Lock()
-	defer lock.Unlock()
+		ctx := context.WithValue(r.Context(), requestStateKey, state)
+		next.ServeHTTP(rw, r.WithContext(ctx))
 
-	log.HttpStatusCode = 0
-}
+		state.mu.Lock()
+		defer state.mu.Unlock()
 
-func (log *Log) LogResponses(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Set default status OK, if the status is empty
-		if log.HttpStatusCode == 0 {
-			log.SetStatusCode(http.StatusOK)
+		statusCode := state.statusCode
+		if statusCode == 0 {
+			statusCode = http.StatusOK
 		}
 
-		timeStart := time.Now()
-		next.ServeHTTP(w, r)
-
-		if log.RespDebug {
-			log.WithFields(logrus.Fields{
-				"method":         r.Method,
-				"status_code":    log.HttpStatusCode,
-				"url":            r.URL.Path,
-				"client_address": r.RemoteAddr,
-				"latency":        fmt.Sprintf("%dms", time.Since(timeStart).Milliseconds()),
-			}).Debug(log.DebugMessage)
-			log.revertToNormalLevel()
+		entry := log.WithFields(logrus.Fields{
+			"request_id":     requestId,
+			"method":         r.Method,
+			"status_code":    statusCode,
+			"url":            r.URL.Path,
+			"client_address": r.RemoteAddr,
+			"latency":        fmt.Sprintf("%dms", time.Since(timeStart).Milliseconds()),
+		})
 
+		if state.debug {
+			entry.Debug(state.debugMessage)
 			return
 		}
-
-		if log.RespError {
-			log.WithFields(logrus.Fields{
-				"method":         r.Method,
-				"status_code":    log.HttpStatusCode,
-				"url":            r.URL.Path,
-				"client_address": r.RemoteAddr,
-				"latency":        fmt.Sprintf("%dms", time.Since(timeStart).Milliseconds()),
-			}).Error(log.ErrorMessage)
-			log.revertToNormalLevel()
-
+		if state.error {
+			entry.Error(state.errorMessage)
 			return
 		}
 
 		infoMessage := "success"
-		if len(log.InfoMessage) > 0 {
-			infoMessage = log.InfoMessage
+		if len(state.infoMessage) > 0 {
+			infoMessage = state.infoMessage
 		}
-		log.WithFields(logrus.Fields{
-			"method":         r.Method,
-			"status_code":    log.HttpStatusCode,
-			"url":            r.URL.Path,
-			"client_address": r.RemoteAddr,
-			"latency":        fmt.Sprintf("%dms", time.Since(timeStart).Milliseconds()),
-		}).Info(infoMessage)
-
-		// Clear out the HTTP status code
-		log.UnsetStatusCode()
+		entry.Info(infoMessage)
 	})
 }
@@ -0,0 +1,136 @@
+package MiddlewareLogging
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Logging state of a single HTTP request.
+// Each request gets its own state, so the status code or the error message never leaks into another request's log line.
+type RequestState struct {
+	mu           sync.Mutex
+	RequestID    string
+	statusCode   int
+	error        bool
+	errorMessage string
+	debug        bool
+	debugMessage string
+	infoMessage  string
+}
+
+// Response writer wrapper, which captures the status code into the request state.
+type ResponseWriter struct {
+	http.ResponseWriter
+	state *RequestState
+}
+
+func (w *ResponseWriter) WriteHeader(statusCode int) {
+	w.state.mu.Lock()
+	if w.state.statusCode == 0 {
+		w.state.statusCode = statusCode
+	}
+	w.state.mu.Unlock()
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *ResponseWriter) Write(p []byte) (int, error) {
+	w.state.mu.Lock()
+	if w.state.statusCode == 0 {
+		w.state.statusCode = http.StatusOK
+	}
+	w.state.mu.Unlock()
+
+	return w.ResponseWriter.Write(p)
+}
+
+// Required for the WebSocket connections (VM consoles).
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer doesn't support hijacking")
+	}
+
+	w.state.mu.Lock()
+	if w.state.statusCode == 0 {
+		w.state.statusCode = http.StatusSwitchingProtocols
+	}
+	w.state.mu.Unlock()
+
+	return h.Hijack()
+}
+
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Finds the request state by unwrapping the response writer chain.
+// Returns nil if the response writer was not wrapped by the LogResponses middleware.
+func stateFromWriter(w http.ResponseWriter) *RequestState {
+	for w != nil {
+		rw, ok := w.(*ResponseWriter)
+		if ok {
+			return rw.state
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+	return nil
+}
+
+// Returns the request ID assigned by the LogResponses middleware (or an empty string if it wasn't set).
+func RequestID(ctx context.Context) string {
+	state, ok := ctx.Value(requestStateKey).(*RequestState)
+	if !ok {
+		return ""
+	}
+	return state.RequestID
+}
+
+// Marks the request as failed, and sets the error message for the request's log line.
+func SetErrorMessage(w http.ResponseWriter, message string) {
+	state := stateFromWriter(w)
+	if state == nil {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.error = true
+	state.errorMessage = message
+}
+
+// Logs the request's line at the DEBUG level, using the message provided.
+func SetDebugMessage(w http.ResponseWriter, message string) {
+	state := stateFromWriter(w)
+	if state == nil {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.debug = true
+	state.debugMessage = message
+}
+
+// Overrides the default "success" message of the request's log line.
+func SetInfoMessage(w http.ResponseWriter, message string) {
+	state := stateFromWriter(w)
+	if state == nil {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.infoMessage = message
+}
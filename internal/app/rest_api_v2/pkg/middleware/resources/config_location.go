@@ -0,0 +1,51 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package MiddlewareResources
+
+import (
+	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	HosterLocations "HosterCore/internal/pkg/hoster/locations"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+)
+
+const (
+	RESOURCE_VM   = "vm"
+	RESOURCE_JAIL = "jail"
+	RESOURCE_HOST = "host"
+)
+
+// Returns the config file location for the API resource (VM, jail or host),
+// or an empty string if the resource doesn't exist (or it doesn't have a config file).
+func ConfigLocation(resType string, resName string) string {
+	switch resType {
+	case RESOURCE_VM:
+		vms, err := HosterVmUtils.ListAllSimple()
+		if err != nil {
+			return ""
+		}
+		for _, v := range vms {
+			if v.VmName == resName {
+				return v.Mountpoint + "/" + v.VmName + "/" + HosterVmUtils.VM_CONFIG_NAME
+			}
+		}
+	case RESOURCE_JAIL:
+		jails, err := HosterJailUtils.ListAllSimple()
+		if err != nil {
+			return ""
+		}
+		for _, v := range jails {
+			if v.JailName == resName {
+				return v.Mountpoint + "/" + v.JailName + "/" + HosterJailUtils.JAIL_CONFIG_NAME
+			}
+		}
+	case RESOURCE_HOST:
+		loc, err := HosterLocations.LocateConfig("host_config.json")
+		if err == nil {
+			return loc
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,283 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build freebsd
+// +build freebsd
+
+package SerialConsole
+
+import (
+	FileExists "HosterCore/internal/pkg/file_exists"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const DEFAULT_HISTORY_KB = 64
+const clientQueueSize = 256
+
+// How often the session checks if the VM is still running (the nmdm device doesn't report the other side going away)
+const vmCheckInterval = 5 * time.Second
+
+var ErrWriterTaken = errors.New("another client already holds the write access to this serial console")
+
+// The nmdm device is used by another process, usually cu(1) started by "hoster vm serial-console"
+var ErrDeviceLocked = ErrorMappings.NewError(ErrorMappings.CODE_CONSOLE_WRITER_TAKEN, "serial console is in use by another process")
+
+// A single serial console session, shared between all of the clients connected to the same VM.
+// Only one client can write to the console at a time, but any number of clients can read from it.
+//
+// The nmdm device is opened (and UUCP locked, see lockDevice) on the first attach, and closed once the last client detaches,
+// so the CLI console (cu) can use the device in the meantime. The console history is kept until the VM stops,
+// but it doesn't include the output printed while nobody was connected.
+type Session struct {
+	mu         sync.Mutex
+	vmName     string
+	device     *os.File
+	unlock     func()
+	history    []byte
+	historyMax int
+	clients    map[*Client]struct{}
+	writer     *Client
+	done       chan struct{}
+}
+
+// A client attached to the serial console session.
+// Console output is delivered using the Output channel, which gets closed once the client is detached.
+type Client struct {
+	Output   chan []byte
+	CanWrite bool
+	session  *Session
+	once     sync.Once
+}
+
+var sessionsMu sync.Mutex
+var sessions = make(map[string]*Session)
+
+// Returns the nmdm device path used by the Hoster to expose the VM's serial console,
+// e.g. /dev/nmdm-test-vm-1-1B (the "A" side is used by bhyve itself).
+func DevicePath(vmName string) string {
+	return "/dev/nmdm-" + vmName + "-1B"
+}
+
+// Attaches a new client to the VM's serial console, opening the nmdm device if required.
+//
+// Returns the client itself, and the console history (last N KB of console output) that should be replayed to the new client.
+func Attach(vmName string, write bool, historyKb int) (c *Client, replay []byte, e error) {
+	if historyKb < 1 {
+		historyKb = DEFAULT_HISTORY_KB
+	}
+
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	s, ok := sessions[vmName]
+	if !ok {
+		s = &Session{vmName: vmName, clients: make(map[*Client]struct{}), done: make(chan struct{})}
+		sessions[vmName] = s
+		go s.watchVm()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.historyMax = historyKb * 1024
+	if write && s.writer != nil {
+		e = ErrWriterTaken
+		return
+	}
+
+	if s.device == nil {
+		unlock, err := lockDevice(DevicePath(vmName))
+		if err != nil {
+			e = err
+			return
+		}
+		dev, err := os.OpenFile(DevicePath(vmName), os.O_RDWR|syscall.O_NOCTTY, 0)
+		if err != nil {
+			unlock()
+			e = err
+			return
+		}
+		err = makeRaw(dev)
+		if err != nil {
+			dev.Close()
+			unlock()
+			e = err
+			return
+		}
+		s.device = dev
+		s.unlock = unlock
+		go s.readLoop(dev)
+	}
+
+	c = &Client{Output: make(chan []byte, clientQueueSize), CanWrite: write, session: s}
+	s.clients[c] = struct{}{}
+	if write {
+		s.writer = c
+	}
+
+	replay = make([]byte, len(s.history))
+	copy(replay, s.history)
+	return
+}
+
+// Writes the client input into the serial console.
+// Returns an error if the client doesn't hold the write access.
+func (c *Client) Write(p []byte) (int, error) {
+	s := c.session
+	s.mu.Lock()
+	if s.writer != c {
+		s.mu.Unlock()
+		return 0, errors.New("this client has a read-only access to the serial console")
+	}
+	dev := s.device
+	s.mu.Unlock()
+
+	// The device write may block (e.g. the guest doesn't read its serial port), so it's done without holding the session lock
+	if dev == nil {
+		return 0, errors.New("serial console device is closed")
+	}
+	return dev.Write(p)
+}
+
+// Detaches the client from the session, and releases the write access (if it was held by this client).
+// The nmdm device is closed (and unlocked) once the last client detaches, the console history is kept for the future clients.
+func (c *Client) Detach() {
+	c.once.Do(func() {
+		s := c.session
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.clients, c)
+		if s.writer == c {
+			s.writer = nil
+		}
+		close(c.Output)
+
+		if len(s.clients) < 1 {
+			s.closeDevice()
+		}
+	})
+}
+
+// Closes the VM's serial console session (if there is one): disconnects all clients, closes the nmdm device
+// and drops the console history. Has to be called once the VM is stopped, destroyed or renamed.
+func Close(vmName string) {
+	sessionsMu.Lock()
+	s, ok := sessions[vmName]
+	if ok {
+		delete(sessions, vmName)
+	}
+	sessionsMu.Unlock()
+
+	if ok {
+		s.close()
+	}
+}
+
+// Removes the session once the VM is gone, /dev/vmm/<vm_name> only exists while the bhyve VM is running
+func (s *Session) watchVm() {
+	ticker := time.NewTicker(vmCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if !FileExists.CheckUsingOsStat("/dev/vmm/" + s.vmName) {
+				s.remove()
+				return
+			}
+		}
+	}
+}
+
+// Removes the session from the session list (unless it's been replaced already), and closes it
+func (s *Session) remove() {
+	sessionsMu.Lock()
+	if sessions[s.vmName] == s {
+		delete(sessions, s.vmName)
+	}
+	sessionsMu.Unlock()
+
+	s.close()
+}
+
+func (s *Session) close() {
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		return
+	default:
+		close(s.done)
+	}
+	s.closeDevice()
+	clients := []*Client{}
+	for c := range s.clients {
+		clients = append(clients, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range clients {
+		c.Detach()
+	}
+}
+
+// Must be called with the s.mu held
+func (s *Session) closeDevice() {
+	if s.device == nil {
+		return
+	}
+
+	s.device.Close()
+	s.device = nil
+	s.unlock()
+	s.unlock = nil
+}
+
+func (s *Session) readLoop(dev *os.File) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := dev.Read(buf)
+		if n > 0 {
+			s.broadcast(buf[:n])
+		}
+		if err != nil {
+			// The device was closed after the last client has detached, the session itself stays
+			s.mu.Lock()
+			closed := s.device != dev
+			s.mu.Unlock()
+			if !closed {
+				s.remove()
+			}
+			return
+		}
+	}
+}
+
+func (s *Session) broadcast(p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history = append(s.history, p...)
+	if len(s.history) > s.historyMax {
+		s.history = append([]byte{}, s.history[len(s.history)-s.historyMax:]...)
+	}
+
+	for c := range s.clients {
+		chunk := make([]byte, len(p))
+		copy(chunk, p)
+		select {
+		case c.Output <- chunk:
+		default:
+			// Slow client, drop this chunk instead of blocking the whole session
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build freebsd
+// +build freebsd
+
+package SerialConsole
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// UUCP lock files, the same ones cu(1) uses (uu_lock(3)), so the API session and "hoster vm serial-console" never read the same device at once
+const uucpLockDir = "/var/spool/lock"
+
+// Creates the UUCP lock file for the device (e.g. /var/spool/lock/LCK..nmdm-test-vm-1-1B).
+// Lock files left behind by the processes that no longer exist are removed.
+func lockDevice(devicePath string) (unlock func(), e error) {
+	lockFile := filepath.Join(uucpLockDir, "LCK.."+filepath.Base(devicePath))
+
+	for i := 0; i < 2; i++ {
+		f, err := os.OpenFile(lockFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%10d\n", os.Getpid())
+			f.Close()
+			if err != nil {
+				os.Remove(lockFile)
+				e = err
+				return
+			}
+			unlock = func() { os.Remove(lockFile) }
+			return
+		}
+		if !errors.Is(err, os.ErrExist) {
+			e = err
+			return
+		}
+
+		data, err := os.ReadFile(lockFile)
+		if err != nil {
+			e = err
+			return
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err == nil && pid > 0 && unix.Kill(pid, 0) != unix.ESRCH {
+			e = fmt.Errorf("%w (pid %d)", ErrDeviceLocked, pid)
+			return
+		}
+		// Stale lock
+		os.Remove(lockFile)
+	}
+
+	e = ErrDeviceLocked
+	return
+}
+
+// Puts the nmdm device into the raw mode (the same thing cfmakeraw(3) does),
+// otherwise the line discipline would echo and buffer the console input.
+func makeRaw(dev *os.File) error {
+	fd := int(dev.Fd())
+	termios, err := unix.IoctlGetTermios(fd, unix.TIOCGETA)
+	if err != nil {
+		return err
+	}
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+
+	return unix.IoctlSetTermios(fd, unix.TIOCSETA, termios)
+}
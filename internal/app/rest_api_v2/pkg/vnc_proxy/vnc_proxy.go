@@ -0,0 +1,222 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package VncProxy
+
+import (
+	HosterLogger "HosterCore/internal/pkg/logger"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const DEFAULT_TOKEN_TTL = 60 // seconds
+const VNC_AUDIT_LOG_LOCATION = "/var/log/hoster_audit_vnc.log"
+
+var ErrTokenInvalid = errors.New("vnc session token is invalid or has expired")
+var ErrTooManyViewers = errors.New("maximum number of concurrent VNC viewers has been reached for this VM")
+
+// A short-lived, single-use token, which allows a single VNC session to be established over the WebSocket proxy.
+type Token struct {
+	Token      string    `json:"token"`
+	VmName     string    `json:"vm_name"`
+	VncPort    int       `json:"-"`
+	User       string    `json:"-"`
+	RemoteAddr string    `json:"-"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// An active VNC proxy session.
+type Session struct {
+	VmName     string    `json:"vm_name"`
+	User       string    `json:"user"`
+	RemoteAddr string    `json:"remote_address"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+var mu sync.Mutex
+var tokens = make(map[string]Token)
+var viewers = make(map[string][]*Session)
+
+var audit = HosterLogger.New()
+var auditOnce sync.Once
+
+// The audit log file is only opened when the first VNC session token is issued
+func auditLog() *HosterLogger.Log {
+	auditOnce.Do(func() {
+		audit.SetFileLocation(VNC_AUDIT_LOG_LOCATION)
+	})
+	return audit
+}
+
+// Issues a new session token for the VM's VNC console.
+// The token can be redeemed only once, and only until it expires.
+func IssueToken(vmName string, vncPort int, user string, remoteAddr string, ttl time.Duration) (r Token, e error) {
+	if ttl <= 0 {
+		ttl = DEFAULT_TOKEN_TTL * time.Second
+	}
+
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		e = err
+		return
+	}
+
+	r.Token = hex.EncodeToString(b)
+	r.VmName = vmName
+	r.VncPort = vncPort
+	r.User = user
+	r.RemoteAddr = remoteAddr
+	r.ExpiresAt = time.Now().Add(ttl)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	removeExpiredTokens()
+	tokens[r.Token] = r
+	auditLog().InfoToFile(fmt.Sprintf("vnc token issued: vm=%s user=%s client=%s expires=%s", vmName, user, remoteAddr, r.ExpiresAt.Format(time.RFC3339)))
+
+	return
+}
+
+// Redeems (and invalidates) the session token.
+// The token can only be redeemed from the same IP address it was issued to, so a leaked URL is useless elsewhere.
+func RedeemToken(token string, remoteAddr string) (r Token, e error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	removeExpiredTokens()
+	r, ok := tokens[token]
+	if !ok {
+		e = ErrTokenInvalid
+		return
+	}
+	if remoteHost(r.RemoteAddr) != remoteHost(remoteAddr) {
+		auditLog().WarnToFile(fmt.Sprintf("vnc token rejected (issued to %s): vm=%s user=%s client=%s", r.RemoteAddr, r.VmName, r.User, remoteAddr))
+		r = Token{}
+		e = ErrTokenInvalid
+		return
+	}
+	delete(tokens, token)
+
+	return
+}
+
+// Strips the port from the remote address, every new connection uses a different source port
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+func removeExpiredTokens() {
+	now := time.Now()
+	for k, v := range tokens {
+		if now.After(v.ExpiresAt) {
+			delete(tokens, k)
+		}
+	}
+}
+
+// Registers a new viewer for the VM, making sure the concurrent viewer limit is respected (0 means unlimited).
+// Call the returned function once the viewer disconnects.
+func AcquireViewer(t Token, remoteAddr string, maxViewers int) (release func(), e error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if maxViewers > 0 && len(viewers[t.VmName]) >= maxViewers {
+		auditLog().WarnToFile(fmt.Sprintf("vnc connection rejected (viewer limit %d): vm=%s user=%s client=%s", maxViewers, t.VmName, t.User, remoteAddr))
+		e = ErrTooManyViewers
+		return
+	}
+
+	s := &Session{VmName: t.VmName, User: t.User, RemoteAddr: remoteAddr, StartedAt: time.Now()}
+	viewers[t.VmName] = append(viewers[t.VmName], s)
+	auditLog().InfoToFile(fmt.Sprintf("vnc connection opened: vm=%s user=%s client=%s", s.VmName, s.User, s.RemoteAddr))
+
+	release = func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		list := viewers[s.VmName]
+		for i, v := range list {
+			if v == s {
+				viewers[s.VmName] = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+		if len(viewers[s.VmName]) < 1 {
+			delete(viewers, s.VmName)
+		}
+		auditLog().InfoToFile(fmt.Sprintf("vnc connection closed: vm=%s user=%s client=%s duration=%s", s.VmName, s.User, s.RemoteAddr, time.Since(s.StartedAt).Round(time.Second)))
+	}
+
+	return
+}
+
+// Returns the list of currently active VNC sessions.
+func ActiveSessions() (r []Session) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	r = []Session{}
+	for _, v := range viewers {
+		for _, vv := range v {
+			r = append(r, *vv)
+		}
+	}
+
+	return
+}
+
+// Pipes the data between the WebSocket client (e.g. noVNC) and the VM's VNC server, until one of the sides disconnects.
+func Proxy(ws *websocket.Conn, vncPort int) error {
+	tcp, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", vncPort), 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer tcp.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		defer func() { done <- struct{}{} }()
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := tcp.Read(buf)
+			if n > 0 {
+				if ws.WriteMessage(websocket.BinaryMessage, buf[:n]) != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer func() { done <- struct{}{} }()
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			_, err = tcp.Write(data)
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	<-done
+	return nil
+}
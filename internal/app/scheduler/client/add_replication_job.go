@@ -193,6 +193,7 @@ func Replicate(job SchedulerUtils.ReplicationJob) (r SchedulerUtils.ReplicationJ
 	var localSnaps []string
 	var toReplicate []string
 	var commonSnaps []string
+	var remoteZvolList []string
 	for i, v := range strings.Split(string(out), "\n") {
 		if i == 0 {
 			continue
@@ -204,6 +205,8 @@ func Replicate(job SchedulerUtils.ReplicationJob) (r SchedulerUtils.ReplicationJ
 		split := reSplitSpace.Split(v, -1)
 		if split[0] == localDs || strings.Contains(split[0], localDs+"@") {
 			remoteDsList = append(remoteDsList, split[0])
+		} else if strings.HasPrefix(split[0], localDs+"/") {
+			remoteZvolList = append(remoteZvolList, split[0])
 		}
 	}
 
@@ -310,6 +313,27 @@ func Replicate(job SchedulerUtils.ReplicationJob) (r SchedulerUtils.ReplicationJ
 		}
 	}
 
+	// zvol disks live in the child datasets, which are replicated one by one after their parent dataset.
+	// Properties are sent along (-p), otherwise the remote zvols get the default volmode and GEOM tastes the guest partitions.
+	zvolSteps, zvolToRemove, err := zfsutils.ZvolReplicationSteps(localDs, remoteZvolList)
+	if err != nil {
+		e = err
+		return
+	}
+	for _, v := range zvolToRemove {
+		cmd := fmt.Sprintf("ssh -oStrictHostKeyChecking=accept-new -oBatchMode=yes -i %s -p%d %s zfs destroy %s", job.SshKey, job.SshPort, job.SshEndpoint, v)
+		removeCmds = append(removeCmds, cmd)
+	}
+	for _, v := range zvolSteps {
+		cmd := ""
+		if len(v.From) < 1 {
+			cmd = fmt.Sprintf("zfs send -P -v -p %s | %s | ssh -oStrictHostKeyChecking=accept-new -oBatchMode=yes -i %s -p%d %s zfs receive %s", v.To, mbufferBinary, job.SshKey, job.SshPort, job.SshEndpoint, v.Dataset)
+		} else {
+			cmd = fmt.Sprintf("zfs send -P -p -vi %s %s | %s | ssh -oStrictHostKeyChecking=accept-new -i %s -p%d %s zfs receive -F %s", v.From, v.To, mbufferBinary, job.SshKey, job.SshPort, job.SshEndpoint, v.Dataset)
+		}
+		replicateCmds = append(replicateCmds, cmd)
+	}
+
 	r.ScriptsRemove = append(r.ScriptsRemove, removeCmds...)
 	r.ScriptsReplicate = append(r.ScriptsReplicate, replicateCmds...)
 
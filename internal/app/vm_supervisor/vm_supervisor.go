@@ -12,6 +12,7 @@ import (
 	HosterVm "HosterCore/internal/pkg/hoster/vm"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -41,19 +42,23 @@ func main() {
 
 	// Get env vars passed from "hoster vm start"
 	vmName = os.Getenv("VM_NAME")
-	vmStartCommand := os.Getenv("VM_START")
+	vmStartArgs := []string{}
+	err := json.Unmarshal([]byte(os.Getenv("VM_START_ARGS")), &vmStartArgs)
+	if err != nil || len(vmStartArgs) < 1 {
+		log.WithFields(logrus.Fields{"type": LOG_SUPERVISOR}).Error("VM_START_ARGS is not set or is invalid, the VM can't be started")
+		os.Exit(103)
+	}
 
 	// Add the log crash detection strings
 	reSpace = regexp.MustCompile(`\s+`)
 	logCrashDetected = append(logCrashDetected, "read |0: file already closed")
 
 	// Start the process
-	// parts := strings.Fields(vmStartCommand)
+	// bhyve is executed directly using the argv, never through a shell: the settings are in the generated bhyve config file
 	for {
 		log.WithFields(logrus.Fields{"type": LOG_SUPERVISOR}).Info("SUPERVISED SESSION STARTED: VM boot process has been initiated")
-		log.WithFields(logrus.Fields{"type": LOG_SUPERVISOR}).Info("BHYVE START CMD: " + vmStartCommand)
-		// hupCmd := exec.Command(parts[0], parts[1:]...)
-		hupCmd := exec.Command("sh", "-c", vmStartCommand)
+		log.WithFields(logrus.Fields{"type": LOG_SUPERVISOR}).Info("BHYVE START CMD: " + strings.Join(vmStartArgs, " "))
+		hupCmd := exec.Command(vmStartArgs[0], vmStartArgs[1:]...)
 		stdout, err := hupCmd.StdoutPipe()
 		if err != nil {
 			log.WithFields(logrus.Fields{"type": LOG_SUPERVISOR}).Error("Failed to create stdout pipe: " + err.Error())
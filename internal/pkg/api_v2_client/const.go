@@ -1,3 +0,0 @@
-package ApiV2client
-
-const HTTP_CALL_TIMEOUT = 5
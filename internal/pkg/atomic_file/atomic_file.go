@@ -0,0 +1,135 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package AtomicFile
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrRevisionMismatch = errors.New("config file has been modified by someone else, re-read it and try again")
+
+// Writes the data to a temporary file in the same directory, and then renames it over the target file.
+// Readers will either see the old or the new version of the file, but never a partially written one.
+func WriteFile(filePath string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filePath)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op after a successful rename
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	closeErr := tmp.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+
+	err = os.Rename(tmpName, filePath)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the rename itself survives a crash
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	defer d.Close()
+	_ = d.Sync()
+
+	return nil
+}
+
+// Locks the directory, makes sure the file has not been changed since expectedRevision was read (skipped if expectedRevision is empty),
+// and atomically writes the new data.
+func WriteFileIfMatch(filePath string, data []byte, perm os.FileMode, expectedRevision string) error {
+	unlock, err := Lock(filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if len(expectedRevision) > 0 {
+		current, err := FileRevision(filePath)
+		if err != nil {
+			return err
+		}
+		if current != expectedRevision {
+			return ErrRevisionMismatch
+		}
+	}
+
+	return WriteFile(filePath, data, perm)
+}
+
+type expectedRevisionKey struct{}
+
+// File revision expected by the client (e.g. the If-Match header value), carried by the request context.
+// Updated after every successful write, so a request can write the same file more than once.
+type expectedRevision struct {
+	mu       sync.Mutex
+	filePath string
+	revision string
+}
+
+// Returns a copy of the context, carrying the revision the file is expected to have when it's written using WriteFileContext().
+func WithExpectedRevision(ctx context.Context, filePath string, revision string) context.Context {
+	return context.WithValue(ctx, expectedRevisionKey{}, &expectedRevision{filePath: filePath, revision: revision})
+}
+
+// Same as WriteFileIfMatch(), but the expected revision is taken from the context (see WithExpectedRevision()),
+// and compared while the directory lock is held, so the changes made after the client has read the file are never overwritten.
+// If the context doesn't carry a revision for this file, the write is unconditional.
+func WriteFileContext(ctx context.Context, filePath string, data []byte, perm os.FileMode) error {
+	expected, ok := ctx.Value(expectedRevisionKey{}).(*expectedRevision)
+	if !ok || expected.filePath != filePath {
+		return WriteFileIfMatch(filePath, data, perm, "")
+	}
+
+	expected.mu.Lock()
+	defer expected.mu.Unlock()
+
+	err := WriteFileIfMatch(filePath, data, perm, expected.revision)
+	if err != nil {
+		return err
+	}
+	expected.revision = Revision(data)
+
+	return nil
+}
+
+// Returns the revision (content hash) of the data.
+func Revision(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// Returns the revision (content hash) of the file on disk.
+func FileRevision(filePath string) (r string, e error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		e = err
+		return
+	}
+
+	r = Revision(data)
+	return
+}
@@ -0,0 +1,16 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package AtomicFile
+
+import "errors"
+
+// The config files are only ever written on the Hoster nodes (FreeBSD), this only keeps the shared
+// packages (e.g. the API types used by HosterCore/pkg/api_v2_client) building on the other platforms.
+func Lock(filePath string) (unlock func(), e error) {
+	e = errors.New("file locking is not supported on this platform")
+	return
+}
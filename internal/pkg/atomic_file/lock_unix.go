@@ -0,0 +1,37 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package AtomicFile
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Takes an exclusive lock on the file's parent directory, which is shared between all Hoster processes (REST API, CLI, etc).
+// The directory is used instead of the file itself, because the file is replaced (renamed over) on every write.
+// Call the returned function to release the lock.
+func Lock(filePath string) (unlock func(), e error) {
+	dir, err := os.Open(filepath.Dir(filePath))
+	if err != nil {
+		e = err
+		return
+	}
+
+	err = syscall.Flock(int(dir.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		dir.Close()
+		e = err
+		return
+	}
+
+	unlock = func() {
+		_ = syscall.Flock(int(dir.Fd()), syscall.LOCK_UN)
+		dir.Close()
+	}
+	return
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+// Read-only access to the virtual disk images produced by other hypervisors (qcow2, VMDK, VHDX),
+// in order to convert them into the raw images (or zvols) used by bhyve.
+package DiskImage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	FORMAT_RAW   = "raw"
+	FORMAT_QCOW2 = "qcow2"
+	FORMAT_VMDK  = "vmdk"
+	FORMAT_VHDX  = "vhdx"
+)
+
+// Maximum length of the backing file chain (qcow2 backing files, VMDK parent disks), protects from the loops
+const maxChainDepth = 16
+
+// Upper limit of the top level tables (qcow2 L1 table, VMDK grain directory), the same one qemu uses
+const maxTableSize = 32 * 1024 * 1024
+
+var errChainTooLong = fmt.Errorf("backing file chain is longer than %d images (or has a loop)", maxChainDepth)
+
+// Virtual disk image, opened for reading. ReadAt returns the guest visible (raw) data: unallocated areas are
+// read from the backing image, or as zeros if there is none.
+type Image interface {
+	io.ReaderAt
+	io.Closer
+	Format() string
+	Size() int64 // virtual disk size in bytes
+}
+
+// Opens the disk image, the format is detected using the file header (anything unknown is treated as a raw image).
+func Open(path string) (Image, error) {
+	return open(path, 0)
+}
+
+func open(path string, depth int) (Image, error) {
+	if depth > maxChainDepth {
+		return nil, errChainTooLong
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	magic := make([]byte, len(vmdkDescriptorMagic))
+	n, err := f.ReadAt(magic, 0)
+	if err != nil && err != io.EOF {
+		f.Close()
+		return nil, err
+	}
+	magic = magic[:n]
+
+	var img Image
+	switch {
+	case bytes.HasPrefix(magic, qcow2Magic):
+		img, err = openQcow2(f, path, depth)
+	case bytes.HasPrefix(magic, vmdkSparseMagic):
+		img, err = openVmdkSparse(f, path, depth)
+	case bytes.HasPrefix(magic, vmdkDescriptorMagic):
+		f.Close()
+		img, err = openVmdkDescriptor(path, depth)
+	case bytes.HasPrefix(magic, vhdxMagic):
+		img, err = openVhdx(f)
+	default:
+		img, err = openRaw(f)
+	}
+	if err == errChainTooLong {
+		f.Close()
+		return nil, err
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+
+	return img, nil
+}
+
+type rawImage struct {
+	f    *os.File
+	size int64
+}
+
+func openRaw(f *os.File) (Image, error) {
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	return &rawImage{f: f, size: size}, nil
+}
+
+func (i *rawImage) Format() string { return FORMAT_RAW }
+func (i *rawImage) Size() int64    { return i.size }
+func (i *rawImage) Close() error   { return i.f.Close() }
+
+func (i *rawImage) ReadAt(p []byte, off int64) (int, error) {
+	return i.f.ReadAt(p, off)
+}
+
+// Splits the read into the chunks that don't cross the block boundaries, and calls read for each of them.
+// Reads past the virtual disk size are cut short with io.EOF, as required by io.ReaderAt.
+func readBlocks(p []byte, off int64, size int64, blockSize int64, read func(p []byte, block int64, offset int64) error) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= size {
+		return 0, io.EOF
+	}
+
+	var eof error
+	if off+int64(len(p)) > size {
+		p = p[:size-off]
+		eof = io.EOF
+	}
+
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		offset := pos % blockSize
+		chunk := p[n:min(len(p), n+int(blockSize-offset))]
+		err := read(chunk, pos/blockSize, offset)
+		if err != nil {
+			return n, err
+		}
+		n += len(chunk)
+	}
+
+	return n, eof
+}
+
+// Reads the area that isn't allocated in the child image: from the parent image if there is one, zeros otherwise.
+// The area beyond the parent's size (the child image was expanded) reads as zeros as well.
+func readParent(parent Image, p []byte, off int64) error {
+	clear(p)
+	if parent == nil || off >= parent.Size() {
+		return nil
+	}
+
+	n := min(int64(len(p)), parent.Size()-off)
+	_, err := parent.ReadAt(p[:n], off)
+	if err == io.EOF {
+		err = nil
+	}
+	return err
+}
+
+// Reads exactly len(p) bytes, the area beyond the end of file reads as zeros (truncated images, last compressed grains).
+func readFull(r io.ReaderAt, p []byte, off int64) error {
+	n, err := r.ReadAt(p, off)
+	if err == io.EOF {
+		clear(p[n:])
+		return nil
+	}
+	return err
+}
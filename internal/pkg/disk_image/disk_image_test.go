@@ -0,0 +1,193 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package DiskImage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Fixtures are generated by testdata/generate.py, every image comes with its expected guest visible data (<name>.raw.gz)
+var fixtures = []struct {
+	name   string
+	format string
+}{
+	{name: "base.raw", format: FORMAT_RAW},
+	{name: "v3.qcow2", format: FORMAT_QCOW2},    // zero and compressed clusters, raw backing file
+	{name: "v2.qcow2", format: FORMAT_QCOW2},    // compressed clusters, qcow2 v3 backing file (smaller than the image)
+	{name: "stream.vmdk", format: FORMAT_VMDK},  // streamOptimized
+	{name: "split.vmdk", format: FORMAT_VMDK},   // twoGbMaxExtentSparse descriptor with 2 sparse extents
+	{name: "dynamic.vhdx", format: FORMAT_VHDX}, // dynamic, with the unallocated and zero blocks
+}
+
+// Extracts all fixture images into a temporary folder, and returns its path
+func extractFixtures(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	files, err := filepath.Glob(filepath.Join("testdata", "*.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range files {
+		name := strings.TrimSuffix(filepath.Base(v), ".gz")
+		if strings.HasSuffix(name, ".raw") && name != "base.raw" {
+			continue
+		}
+		err = os.WriteFile(filepath.Join(dir, name), readGzip(t, v), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func readGzip(t *testing.T, path string) []byte {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestOpen(t *testing.T) {
+	dir := extractFixtures(t)
+
+	for _, tt := range fixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := readGzip(t, filepath.Join("testdata", tt.name+".raw.gz"))
+
+			img, err := Open(filepath.Join(dir, tt.name))
+			if err != nil {
+				t.Fatalf("Open: %s", err.Error())
+			}
+			defer img.Close()
+
+			if img.Format() != tt.format {
+				t.Errorf("format: got %s, want %s", img.Format(), tt.format)
+			}
+			if img.Size() != int64(len(expected)) {
+				t.Fatalf("size: got %d, want %d", img.Size(), len(expected))
+			}
+
+			data, err := io.ReadAll(io.NewSectionReader(img, 0, img.Size()))
+			if err != nil {
+				t.Fatalf("read: %s", err.Error())
+			}
+			if i := firstDifference(data, expected); i >= 0 {
+				t.Fatalf("data doesn't match the expected raw output, first difference at byte %d", i)
+			}
+		})
+	}
+}
+
+// Unaligned reads that cross the cluster/grain/block (and extent) boundaries
+func TestReadAtUnaligned(t *testing.T) {
+	dir := extractFixtures(t)
+
+	for _, tt := range fixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := readGzip(t, filepath.Join("testdata", tt.name+".raw.gz"))
+			img, err := Open(filepath.Join(dir, tt.name))
+			if err != nil {
+				t.Fatalf("Open: %s", err.Error())
+			}
+			defer img.Close()
+
+			buf := make([]byte, 5000)
+			for off := int64(0); off < img.Size(); off += 4999 * 7 {
+				n, err := img.ReadAt(buf, off)
+				want := expected[off:min(off+int64(len(buf)), int64(len(expected)))]
+				if n != len(want) {
+					t.Fatalf("ReadAt(%d): got %d bytes, want %d", off, n, len(want))
+				}
+				if n < len(buf) && err != io.EOF {
+					t.Fatalf("ReadAt(%d): short read must return io.EOF, got %v", off, err)
+				}
+				if n == len(buf) && err != nil && err != io.EOF {
+					t.Fatalf("ReadAt(%d): %s", off, err.Error())
+				}
+				if !bytes.Equal(buf[:n], want) {
+					t.Fatalf("ReadAt(%d): data doesn't match", off)
+				}
+			}
+
+			_, err = img.ReadAt(buf, img.Size())
+			if err != io.EOF {
+				t.Errorf("ReadAt past the end: got %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	dir := extractFixtures(t)
+
+	// Backing file points back to the image itself ("base.raw" and "v3.qcow2" have the same length)
+	data, err := os.ReadFile(filepath.Join(dir, "v3.qcow2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "v3.qcow2"), bytes.Replace(data, []byte("base.raw"), []byte("v3.qcow2"), 1), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Open(filepath.Join(dir, "v3.qcow2"))
+	if err != errChainTooLong {
+		t.Errorf("backing file loop: got %v, want %v", err, errChainTooLong)
+	}
+
+	// Stream-optimized image without the footer (e.g. an incomplete upload)
+	data, err = os.ReadFile(filepath.Join(dir, "stream.vmdk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "truncated.vmdk"), data[:len(data)-1536], 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Open(filepath.Join(dir, "truncated.vmdk"))
+	if err == nil || !strings.Contains(err.Error(), "invalid footer") {
+		t.Errorf("truncated stream-optimized image: got %v", err)
+	}
+
+	// Split image with a missing extent
+	err = os.Remove(filepath.Join(dir, "split-s002.vmdk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Open(filepath.Join(dir, "split.vmdk"))
+	if err == nil || !strings.Contains(err.Error(), "split-s002.vmdk") {
+		t.Errorf("missing split extent: got %v", err)
+	}
+}
+
+func firstDifference(a []byte, b []byte) int {
+	for i := 0; i < min(len(a), len(b)); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	if len(a) != len(b) {
+		return min(len(a), len(b))
+	}
+	return -1
+}
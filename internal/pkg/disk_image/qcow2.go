@@ -0,0 +1,259 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package DiskImage
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// https://gitlab.com/qemu-project/qemu/-/blob/master/docs/interop/qcow2.txt
+var qcow2Magic = []byte{'Q', 'F', 'I', 0xfb}
+
+const (
+	qcow2OffsetMask     = 0x00fffffffffffe00
+	qcow2FlagCompressed = 1 << 62
+	qcow2FlagZero       = 1 // version 3 only
+
+	qcow2IncompatDirty        = 1 << 0
+	qcow2IncompatCorrupt      = 1 << 1
+	qcow2IncompatDataFile     = 1 << 2
+	qcow2IncompatCompression  = 1 << 3
+	qcow2IncompatExtendedL2   = 1 << 4
+	qcow2IncompatKnownFlagSet = qcow2IncompatDirty | qcow2IncompatCorrupt | qcow2IncompatDataFile | qcow2IncompatCompression | qcow2IncompatExtendedL2
+
+	// Upper limit of the L2 tables kept in memory, the rest are re-read from the disk
+	qcow2L2CacheBytes = 32 * 1024 * 1024
+)
+
+type qcow2Image struct {
+	f           *os.File
+	version     uint32
+	size        int64
+	clusterBits uint32
+	clusterSize int64
+	l1          []uint64
+	backing     Image
+
+	mu           sync.Mutex
+	l2Cache      map[uint64][]uint64
+	l2CacheLimit int
+	// Last decompressed cluster, the compressed clusters are usually read sequentially in the smaller chunks
+	compressedOffset uint64
+	compressed       []byte
+}
+
+func openQcow2(f *os.File, path string, depth int) (Image, error) {
+	header := make([]byte, 112)
+	err := readFull(f, header, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &qcow2Image{
+		f:           f,
+		version:     binary.BigEndian.Uint32(header[4:]),
+		clusterBits: binary.BigEndian.Uint32(header[20:]),
+		size:        int64(binary.BigEndian.Uint64(header[24:])),
+	}
+	backingOffset := binary.BigEndian.Uint64(header[8:])
+	backingSize := binary.BigEndian.Uint32(header[16:])
+	cryptMethod := binary.BigEndian.Uint32(header[32:])
+	l1Size := binary.BigEndian.Uint32(header[36:])
+	l1Offset := binary.BigEndian.Uint64(header[40:])
+
+	if i.version != 2 && i.version != 3 {
+		return nil, fmt.Errorf("unsupported qcow2 version: %d", i.version)
+	}
+	if i.clusterBits < 9 || i.clusterBits > 21 {
+		return nil, fmt.Errorf("invalid qcow2 cluster size: 2^%d", i.clusterBits)
+	}
+	if i.size < 0 {
+		return nil, errors.New("invalid qcow2 virtual disk size")
+	}
+	if cryptMethod != 0 {
+		return nil, errors.New("encrypted qcow2 images are not supported")
+	}
+
+	if i.version >= 3 {
+		incompat := binary.BigEndian.Uint64(header[72:])
+		headerLength := binary.BigEndian.Uint32(header[100:])
+		switch {
+		case incompat&qcow2IncompatCorrupt != 0:
+			return nil, errors.New("qcow2 image is marked as corrupt, repair it using qemu-img check -r all")
+		case incompat&qcow2IncompatDataFile != 0:
+			return nil, errors.New("qcow2 images with an external data file are not supported")
+		case incompat&qcow2IncompatExtendedL2 != 0:
+			return nil, errors.New("qcow2 images with the extended L2 entries (subclusters) are not supported")
+		case incompat&qcow2IncompatCompression != 0 && headerLength > 104 && header[104] != 0:
+			return nil, errors.New("qcow2 images with the zstd compressed clusters are not supported, only zlib")
+		case incompat&^qcow2IncompatKnownFlagSet != 0:
+			return nil, fmt.Errorf("qcow2 image uses unknown incompatible features: %#x", incompat&^qcow2IncompatKnownFlagSet)
+		}
+		// The dirty flag only means that the refcounts may be out of date, which doesn't matter for reading
+	}
+
+	i.clusterSize = 1 << i.clusterBits
+	i.l2CacheLimit = max(4, qcow2L2CacheBytes/int(i.clusterSize))
+	i.l2Cache = make(map[uint64][]uint64)
+
+	// L1 table has to cover the whole virtual disk, every entry covers (cluster size / 8) clusters
+	l2Coverage := i.clusterSize * (i.clusterSize / 8)
+	if int64(l1Size) < (i.size+l2Coverage-1)/l2Coverage {
+		return nil, errors.New("qcow2 L1 table is too small for the virtual disk size")
+	}
+	if int64(l1Size)*8 > maxTableSize {
+		return nil, errors.New("qcow2 L1 table is too big")
+	}
+	l1 := make([]byte, int64(l1Size)*8)
+	err = readFull(f, l1, int64(l1Offset))
+	if err != nil {
+		return nil, err
+	}
+	i.l1 = make([]uint64, l1Size)
+	for n := range i.l1 {
+		i.l1[n] = binary.BigEndian.Uint64(l1[n*8:])
+	}
+
+	if backingOffset != 0 {
+		if backingSize < 1 || backingSize > 1023 {
+			return nil, errors.New("invalid qcow2 backing file name")
+		}
+		name := make([]byte, backingSize)
+		err = readFull(f, name, int64(backingOffset))
+		if err != nil {
+			return nil, err
+		}
+
+		backingFile := string(name)
+		if strings.Contains(backingFile, "://") || strings.HasPrefix(backingFile, "json:") {
+			return nil, errors.New("network and json: backing files are not supported: " + backingFile)
+		}
+		// Relative backing file names are relative to the image itself
+		if !filepath.IsAbs(backingFile) {
+			backingFile = filepath.Join(filepath.Dir(path), backingFile)
+		}
+		i.backing, err = open(backingFile, depth+1)
+		if err == errChainTooLong {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not open the backing file: %s", err.Error())
+		}
+	}
+
+	return i, nil
+}
+
+func (i *qcow2Image) Format() string { return FORMAT_QCOW2 }
+func (i *qcow2Image) Size() int64    { return i.size }
+
+func (i *qcow2Image) Close() error {
+	if i.backing != nil {
+		i.backing.Close()
+	}
+	return i.f.Close()
+}
+
+func (i *qcow2Image) ReadAt(p []byte, off int64) (int, error) {
+	return readBlocks(p, off, i.size, i.clusterSize, i.readCluster)
+}
+
+func (i *qcow2Image) readCluster(p []byte, cluster int64, offset int64) error {
+	l2Bits := i.clusterBits - 3
+	l1Index := cluster >> l2Bits
+	l2Index := cluster & (1<<l2Bits - 1)
+
+	if l1Index >= int64(len(i.l1)) || i.l1[l1Index]&qcow2OffsetMask == 0 {
+		return readParent(i.backing, p, cluster*i.clusterSize+offset)
+	}
+	l2, err := i.l2Table(i.l1[l1Index] & qcow2OffsetMask)
+	if err != nil {
+		return err
+	}
+	entry := l2[l2Index]
+
+	switch {
+	case entry&qcow2FlagCompressed != 0:
+		data, err := i.decompressCluster(entry)
+		if err != nil {
+			return err
+		}
+		copy(p, data[offset:])
+		return nil
+	case i.version >= 3 && entry&qcow2FlagZero != 0:
+		clear(p)
+		return nil
+	case entry&qcow2OffsetMask == 0:
+		return readParent(i.backing, p, cluster*i.clusterSize+offset)
+	}
+
+	return readFull(i.f, p, int64(entry&qcow2OffsetMask)+offset)
+}
+
+func (i *qcow2Image) l2Table(l2Offset uint64) ([]uint64, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	l2, ok := i.l2Cache[l2Offset]
+	if ok {
+		return l2, nil
+	}
+
+	data := make([]byte, i.clusterSize)
+	err := readFull(i.f, data, int64(l2Offset))
+	if err != nil {
+		return nil, err
+	}
+	l2 = make([]uint64, i.clusterSize/8)
+	for n := range l2 {
+		l2[n] = binary.BigEndian.Uint64(data[n*8:])
+	}
+
+	if len(i.l2Cache) >= i.l2CacheLimit {
+		clear(i.l2Cache)
+	}
+	i.l2Cache[l2Offset] = l2
+	return l2, nil
+}
+
+// Compressed cluster descriptor: the host offset takes (62 - (cluster bits - 8)) lower bits, the rest (up to bit 61)
+// is the number of the additional 512 byte sectors holding the compressed data (raw deflate stream).
+func (i *qcow2Image) decompressCluster(entry uint64) ([]byte, error) {
+	x := 62 - (i.clusterBits - 8)
+	hostOffset := entry & (1<<x - 1)
+	sectors := (entry>>x)&(1<<(62-x)-1) + 1
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.compressed != nil && i.compressedOffset == hostOffset {
+		return i.compressed, nil
+	}
+
+	compressed := make([]byte, sectors*512-hostOffset%512)
+	err := readFull(i.f, compressed, int64(hostOffset))
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, i.clusterSize)
+	_, err = io.ReadFull(flate.NewReader(bytes.NewReader(compressed)), data)
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress the qcow2 cluster at %d: %s", hostOffset, err.Error())
+	}
+
+	i.compressedOffset = hostOffset
+	i.compressed = data
+	return data, nil
+}
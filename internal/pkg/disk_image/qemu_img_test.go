@@ -0,0 +1,89 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package DiskImage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Images produced by qemu-img itself, to catch the spec details the hand built fixtures (testdata/generate.py) might get wrong.
+// Skipped if qemu-img is not installed.
+func TestOpenQemuImg(t *testing.T) {
+	qemuImg, err := exec.LookPath("qemu-img")
+	if err != nil {
+		t.Skip("qemu-img is not installed")
+	}
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.raw")
+	expected := qemuImgSource()
+	err = os.WriteFile(source, expected, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		args   []string
+	}{
+		{name: "compressed.qcow2", format: FORMAT_QCOW2, args: []string{"-O", "qcow2", "-c"}},
+		{name: "compressed-v2.qcow2", format: FORMAT_QCOW2, args: []string{"-O", "qcow2", "-o", "compat=0.10", "-c"}},
+		{name: "small-clusters.qcow2", format: FORMAT_QCOW2, args: []string{"-O", "qcow2", "-o", "cluster_size=4096"}},
+		{name: "dynamic.vhdx", format: FORMAT_VHDX, args: []string{"-O", "vhdx", "-o", "subformat=dynamic"}},
+		{name: "dynamic-small-blocks.vhdx", format: FORMAT_VHDX, args: []string{"-O", "vhdx", "-o", "subformat=dynamic,block_size=1M"}},
+		{name: "monolithic.vmdk", format: FORMAT_VMDK, args: []string{"-O", "vmdk", "-o", "subformat=monolithicSparse"}},
+		{name: "stream.vmdk", format: FORMAT_VMDK, args: []string{"-O", "vmdk", "-o", "subformat=streamOptimized"}},
+		{name: "split.vmdk", format: FORMAT_VMDK, args: []string{"-O", "vmdk", "-o", "subformat=twoGbMaxExtentSparse"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image := filepath.Join(dir, tt.name)
+			args := append([]string{"convert", "-f", "raw"}, tt.args...)
+			out, err := exec.Command(qemuImg, append(args, source, image)...).CombinedOutput()
+			if err != nil {
+				t.Fatalf("qemu-img convert: %s", string(out))
+			}
+
+			img, err := Open(image)
+			if err != nil {
+				t.Fatalf("Open: %s", err.Error())
+			}
+			defer img.Close()
+
+			if img.Format() != tt.format {
+				t.Errorf("format: got %s, want %s", img.Format(), tt.format)
+			}
+			if img.Size() != int64(len(expected)) {
+				t.Fatalf("size: got %d, want %d", img.Size(), len(expected))
+			}
+			data, err := io.ReadAll(io.NewSectionReader(img, 0, img.Size()))
+			if err != nil {
+				t.Fatalf("read: %s", err.Error())
+			}
+			if i := firstDifference(data, expected); i >= 0 {
+				t.Fatalf("data doesn't match the source image, first difference at byte %d", i)
+			}
+		})
+	}
+}
+
+// 6.5M source image: labeled data sectors, with the zero ranges that qemu-img leaves unallocated
+func qemuImgSource() []byte {
+	const mb = 1 << 20
+	r := make([]byte, 6*mb+mb/2)
+	for _, v := range [][2]int{{0, mb}, {2*mb + 4096, 3 * mb}, {4*mb - 512, 4*mb + 512}, {6 * mb, 6*mb + mb/2}} {
+		for off := v[0]; off < v[1]; off += 512 {
+			copy(r[off:off+512], fmt.Sprintf("qemu-img sector %d|", off/512))
+		}
+	}
+	return r
+}
@@ -0,0 +1,313 @@
+#!/usr/bin/env python3
+# Generates the disk image fixtures used by the DiskImage (and HosterVmUtils) tests:
+#
+#   cd internal/pkg/disk_image/testdata && python3 generate.py
+#
+# Every image is written gzip compressed (<name>.gz), along with the expected guest visible data (<name>.raw.gz).
+# The images are built by hand following the format specs, so the tests don't depend on qemu-img being installed.
+# The output is deterministic, re-running the script doesn't change the committed files.
+
+import gzip
+import os
+import struct
+import zlib
+
+OUT = os.path.dirname(os.path.abspath(__file__))
+
+
+def pattern(label, size):
+    # Every 512 byte sector starts with its own label, so misplaced sectors are easy to spot, the rest compresses well
+    out = bytearray()
+    sector = 0
+    while len(out) < size:
+        head = ("%s sector %d|" % (label, sector)).encode()
+        out += (head * (512 // len(head) + 1))[:512]
+        sector += 1
+    return bytes(out[:size])
+
+
+def save(name, image, expected):
+    for file, data in ((name + ".gz", image), (name + ".raw.gz", expected)):
+        with open(os.path.join(OUT, file), "wb") as f:
+            # mtime=0 keeps the output stable
+            with gzip.GzipFile(fileobj=f, mode="wb", compresslevel=9, mtime=0) as gz:
+                gz.write(data)
+
+
+def save_image(name, image):
+    with open(os.path.join(OUT, name + ".gz"), "wb") as f:
+        with gzip.GzipFile(fileobj=f, mode="wb", compresslevel=9, mtime=0) as gz:
+            gz.write(image)
+
+
+# ---------------- raw ----------------
+base = pattern("base", 30 * 4096 + 700)
+save("base.raw", base, base)
+
+
+# ---------------- qcow2 ----------------
+# https://gitlab.com/qemu-project/qemu/-/blob/master/docs/interop/qcow2.txt
+def qcow2(name, version, size, kinds, backing_name, backing_data, cbits=12):
+    cs = 1 << cbits
+    nclusters = (size + cs - 1) // cs
+    l2entries = cs // 8
+    l1size = (nclusters + l2entries - 1) // l2entries
+    # header, L1 table, refcount table (unused by the reader), L2 tables, data
+    data_start = (3 + l1size) * cs
+    out = bytearray(data_start)
+    expected = bytearray(size)
+    l2tables = [[0] * l2entries for _ in range(l1size)]
+
+    for c in range(nclusters):
+        kind = kinds[c % len(kinds)]
+        start = c * cs
+        n = min(cs, size - start)
+        if kind == "zero" and version < 3:
+            kind = "unalloc"
+
+        if kind == "data":
+            d = pattern("%s data %d" % (name, c), cs)
+            out += b"\0" * (-len(out) % cs)
+            off = len(out)
+            out += d
+            l2tables[c // l2entries][c % l2entries] = off | (1 << 63)
+            expected[start:start + n] = d[:n]
+        elif kind == "zero":
+            l2tables[c // l2entries][c % l2entries] = 1
+        elif kind == "compressed":
+            d = pattern("%s compressed %d" % (name, c), cs)
+            co = zlib.compressobj(9, zlib.DEFLATED, -12)
+            comp = co.compress(d) + co.flush()
+            # Compressed clusters are byte aligned, not sector aligned
+            if len(out) % 512 == 0:
+                out += b"\0" * 37
+            off = len(out)
+            out += comp
+            sectors = (off + len(comp) - 1) // 512 - off // 512
+            x = 62 - (cbits - 8)
+            l2tables[c // l2entries][c % l2entries] = (1 << 62) | (sectors << x) | off
+            expected[start:start + n] = d[:n]
+        else:
+            # Unallocated, read from the backing file (zeros beyond its end)
+            seg = backing_data[start:min(start + n, len(backing_data))]
+            expected[start:start + len(seg)] = seg
+
+    hdr = bytearray(cs)
+    # magic, version, backing_file_offset, backing_file_size, cluster_bits, size, crypt_method,
+    # l1_size, l1_table_offset, refcount_table_offset, refcount_table_clusters, nb_snapshots, snapshots_offset
+    struct.pack_into(">4sIQIIQIIQQIIQ", hdr, 0, b"QFI\xfb", version, 0, 0, cbits, size, 0,
+                     l1size, cs, 2 * cs, 1, 0, 0)
+    if version >= 3:
+        # incompatible, compatible and autoclear features, refcount_order, header_length
+        struct.pack_into(">QQQII", hdr, 72, 0, 0, 0, 4, 104)
+    bn = backing_name.encode()
+    struct.pack_into(">QI", hdr, 8, 512, len(bn))
+    hdr[512:512 + len(bn)] = bn
+    out[0:cs] = hdr
+
+    for i in range(l1size):
+        l2off = (3 + i) * cs
+        struct.pack_into(">Q", out, cs + i * 8, l2off | (1 << 63))
+        for j, e in enumerate(l2tables[i]):
+            struct.pack_into(">Q", out, l2off + j * 8, e)
+
+    save(name, bytes(out), bytes(expected))
+    return bytes(expected)
+
+
+# v3 on top of the raw base image, v2 on top of the v3 image (bigger than its backing file, the rest reads as zeros)
+v3 = qcow2("v3.qcow2", 3, 28 * 4096 + 123, ["data", "unalloc", "zero", "compressed", "compressed", "unalloc", "data"],
+           "base.raw", base)
+qcow2("v2.qcow2", 2, 36 * 4096 - 200, ["unalloc", "compressed", "data", "unalloc", "zero", "compressed"],
+      "v3.qcow2", v3)
+
+
+# ---------------- VMDK ----------------
+# Virtual Disk Format 5.0
+GRAIN = 8  # sectors
+GT_ENTRIES = 512
+
+
+def vmdk_descriptor(create_type, extents):
+    s = "# Disk DescriptorFile\nversion=1\nCID=12345678\nparentCID=ffffffff\ncreateType=\"%s\"\n" % create_type
+    s += "\n# Extent description\n" + "".join(extents)
+    s += "\n# The Disk Data Base\nddb.adapterType = \"lsilogic\"\nddb.virtualHWVersion = \"14\"\n"
+    return s.encode()
+
+
+def vmdk_sparse(name, capacity, kinds, stream=False, descriptor=b""):
+    gsize = GRAIN * 512
+    ngrains = (capacity + GRAIN - 1) // GRAIN
+    ngts = (ngrains + GT_ENTRIES - 1) // GT_ENTRIES
+    size = capacity * 512
+    expected = bytearray(size)
+
+    desc_sectors = (len(descriptor) + 511) // 512
+    descriptor = descriptor + b"\0" * (desc_sectors * 512 - len(descriptor))
+    flags = 1 | 2 | ((1 << 16) | (1 << 17) if stream else 0)
+
+    def header(gd_offset):
+        h = bytearray(512)
+        # magic, version, flags, capacity, grainSize, descriptorOffset, descriptorSize, numGTEsPerGT, rgdOffset,
+        # gdOffset, overHead, uncleanShutdown, newline detection characters, compressAlgorithm
+        struct.pack_into("<4sIIQQQQIQQQB4sH", h, 0, b"KDMV", 3 if stream else 1, flags, capacity, GRAIN,
+                         1 if desc_sectors else 0, desc_sectors, GT_ENTRIES, 0, gd_offset, 1 + desc_sectors, 0,
+                         b"\n \r\n", 1 if stream else 0)
+        return h
+
+    out = bytearray(512) + descriptor
+    # Grain table entries 0 and 1 mean unallocated and zero grain, so the first grain can't be stored at sector 1
+    if len(out) < 1024:
+        out += b"\0" * 512
+    gts = [[0] * GT_ENTRIES for _ in range(ngts)]
+    for g in range(ngrains):
+        kind = kinds[g % len(kinds)]
+        start = g * gsize
+        n = min(gsize, size - start)
+        if kind == "data":
+            d = pattern("%s grain %d" % (name, g), n)
+            sector = len(out) // 512
+            if stream:
+                # Grain marker: LBA, compressed size, zlib stream
+                comp = zlib.compress(d, 9)
+                rec = struct.pack("<QI", g * GRAIN, len(comp)) + comp
+                out += rec + b"\0" * (-len(rec) % 512)
+            else:
+                out += d + b"\0" * (gsize - n)
+            gts[g // GT_ENTRIES][g % GT_ENTRIES] = sector
+            expected[start:start + n] = d
+        elif kind == "zero":
+            gts[g // GT_ENTRIES][g % GT_ENTRIES] = 1
+
+    gt_sectors = []
+    for t in gts:
+        if stream:
+            out += struct.pack("<QII", GT_ENTRIES * 4 // 512, 0, 1) + b"\0" * 496  # grain table marker
+        gt_sectors.append(len(out) // 512)
+        out += b"".join(struct.pack("<I", e) for e in t)
+        out += b"\0" * (-len(out) % 512)
+    if stream:
+        out += struct.pack("<QII", 1, 0, 2) + b"\0" * 496  # grain directory marker
+    gd_sector = len(out) // 512
+    out += b"".join(struct.pack("<I", s) for s in gt_sectors)
+    out += b"\0" * (-len(out) % 512)
+
+    if stream:
+        # The real header is in the footer, the one at the start only says so
+        out[0:512] = header(0xffffffffffffffff)
+        out += struct.pack("<QII", 1, 0, 3) + b"\0" * 496  # footer marker
+        out += header(gd_sector)
+        out += b"\0" * 512  # end-of-stream marker
+    else:
+        out[0:512] = header(gd_sector)
+    return bytes(out), bytes(expected)
+
+
+stream_capacity = 90 * GRAIN + 5
+image, expected = vmdk_sparse("stream.vmdk", stream_capacity, ["data", "unalloc", "data", "zero", "data"], stream=True,
+                              descriptor=vmdk_descriptor("streamOptimized", ['RW %d SPARSE "stream.vmdk"\n' % stream_capacity]))
+save("stream.vmdk", image, expected)
+
+# twoGbMaxExtentSparse: the descriptor file, and the sparse extents without the embedded descriptor
+split_extents = [("split-s001.vmdk", 40 * GRAIN, ["data", "zero", "unalloc", "data"]),
+                 ("split-s002.vmdk", 25 * GRAIN + 3, ["unalloc", "data", "data"])]
+split_expected = b""
+for extent, capacity, kinds in split_extents:
+    image, expected = vmdk_sparse(extent, capacity, kinds)
+    save_image(extent, image)
+    split_expected += expected
+save("split.vmdk", vmdk_descriptor("twoGbMaxExtentSparse",
+                                   ['RW %d SPARSE "%s"\n' % (capacity, extent) for extent, capacity, _ in split_extents]),
+     split_expected)
+
+
+# ---------------- VHDX ----------------
+# [MS-VHDX] Virtual Hard Disk v2 (VHDX) File Format
+CRC32C_TABLE = []
+for i in range(256):
+    c = i
+    for _ in range(8):
+        c = (c >> 1) ^ 0x82F63B78 if c & 1 else c >> 1
+    CRC32C_TABLE.append(c)
+
+
+def crc32c(data):
+    c = 0xFFFFFFFF
+    for x in data:
+        c = CRC32C_TABLE[(c ^ x) & 0xFF] ^ (c >> 8)
+    return c ^ 0xFFFFFFFF
+
+
+def guid(s):
+    b = bytes.fromhex(s.replace("-", ""))
+    return b[3::-1] + b[5:3:-1] + b[7:5:-1] + b[8:]
+
+
+def with_checksum(data):
+    data = bytearray(data)
+    struct.pack_into("<I", data, 4, 0)
+    struct.pack_into("<I", data, 4, crc32c(data))
+    return data
+
+
+def vhdx(name, size, kinds, block=1 << 20, sector=512):
+    MB = 1 << 20
+    chunk = (1 << 23) * sector // block
+    nblocks = (size + block - 1) // block
+    nbat = nblocks + (nblocks - 1) // chunk
+
+    out = bytearray(4 * MB)
+    out[0:8] = b"vhdxfile"
+    # Two headers, the one with the higher sequence number is the current one
+    for i, seq in ((1, 5), (2, 4)):
+        h = bytearray(4096)
+        struct.pack_into("<4sIQ", h, 0, b"head", 0, seq)
+        struct.pack_into("<HHIQ", h, 64, 0, 1, MB, MB)
+        out[i * 64 * 1024:i * 64 * 1024 + 4096] = with_checksum(h)
+
+    rt = bytearray(64 * 1024)
+    struct.pack_into("<4sII", rt, 0, b"regi", 0, 2)
+    rt[16:32] = guid("2DC27766-F623-4200-9D64-115E9BFD4A08")  # BAT
+    struct.pack_into("<QII", rt, 32, 3 * MB, MB, 1)
+    rt[48:64] = guid("8B7CA206-4790-4B9A-B8FE-575F050F886E")  # metadata
+    struct.pack_into("<QII", rt, 64, 2 * MB, MB, 1)
+    rt = with_checksum(rt)
+    out[192 * 1024:256 * 1024] = rt
+    out[256 * 1024:320 * 1024] = rt
+
+    md = bytearray(MB)
+    md[0:8] = b"metadata"
+    struct.pack_into("<H", md, 10, 3)
+    items = [("CAA16737-FA36-4D43-B3B6-33F0AA44E76B", struct.pack("<II", block, 0)),  # file parameters (dynamic)
+             ("2FA54224-CD1B-4876-B211-5DBED83BF4B8", struct.pack("<Q", size)),  # virtual disk size
+             ("8141BF1D-A96F-4709-BA47-F233A8FAAB5F", struct.pack("<I", sector))]  # logical sector size
+    off = 64 * 1024
+    for i, (g, v) in enumerate(items):
+        e = 32 + i * 32
+        md[e:e + 16] = guid(g)
+        struct.pack_into("<III", md, e + 16, off, len(v), 4 | 2)
+        md[off:off + len(v)] = v
+        off += 4096
+    out[2 * MB:3 * MB] = md
+
+    expected = bytearray(size)
+    bat = [0] * nbat
+    for b in range(nblocks):
+        kind = kinds[b % len(kinds)]
+        start = b * block
+        n = min(block, size - start)
+        idx = b + b // chunk
+        if kind == "data":
+            d = pattern("%s block %d" % (name, b), block)
+            off = len(out)
+            out += d
+            bat[idx] = 6 | ((off // MB) << 20)  # PAYLOAD_BLOCK_FULLY_PRESENT
+            expected[start:start + n] = d[:n]
+        elif kind == "zero":
+            bat[idx] = 2  # PAYLOAD_BLOCK_ZERO
+    out[3 * MB:3 * MB + 8 * nbat] = b"".join(struct.pack("<Q", e) for e in bat)
+
+    save(name, bytes(out), bytes(expected))
+
+
+vhdx("dynamic.vhdx", 5 * (1 << 20) + 512 * 3, ["data", "unalloc", "zero", "data"])
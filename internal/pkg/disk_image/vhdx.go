@@ -0,0 +1,283 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package DiskImage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"strings"
+)
+
+// https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-vhdx
+var vhdxMagic = []byte("vhdxfile")
+
+const (
+	vhdxHeader1Offset      = 64 * 1024
+	vhdxHeader2Offset      = 128 * 1024
+	vhdxHeaderSize         = 4 * 1024
+	vhdxRegionTable1Offset = 192 * 1024
+	vhdxRegionTable2Offset = 256 * 1024
+	vhdxRegionTableSize    = 64 * 1024
+
+	vhdxBlockNotPresent       = 0
+	vhdxBlockUndefined        = 1
+	vhdxBlockZero             = 2
+	vhdxBlockUnmapped         = 3
+	vhdxBlockFullyPresent     = 6
+	vhdxBlockPartiallyPresent = 7
+
+	vhdxMetadataRequired = 1 << 2
+	vhdxHasParent        = 1 << 1
+)
+
+var (
+	vhdxRegionBat      = vhdxGuid("2DC27766-F623-4200-9D64-115E9BFD4A08")
+	vhdxRegionMetadata = vhdxGuid("8B7CA206-4790-4B9A-B8FE-575F050F886E")
+
+	vhdxFileParameters    = vhdxGuid("CAA16737-FA36-4D43-B3B6-33F0AA44E76B")
+	vhdxVirtualDiskSize   = vhdxGuid("2FA54224-CD1B-4876-B211-5DBED83BF4B8")
+	vhdxLogicalSectorSize = vhdxGuid("8141BF1D-A96F-4709-BA47-F233A8FAAB5F")
+	vhdxKnownMetadata     = [][16]byte{
+		vhdxFileParameters,
+		vhdxVirtualDiskSize,
+		vhdxLogicalSectorSize,
+		vhdxGuid("CDA348C7-445D-4471-9CC9-E9885251C556"), // physical sector size
+		vhdxGuid("BECA12AB-B2E6-4523-93EF-C309E000C746"), // page 83 data (disk identifier)
+		vhdxGuid("A8D35F2D-B30B-454D-ABF7-D3D84834AB0C"), // parent locator
+	}
+
+	vhdxCrc = crc32.MakeTable(crc32.Castagnoli)
+)
+
+type vhdxImage struct {
+	f          *os.File
+	size       int64
+	blockSize  int64
+	chunkRatio int64
+	bat        []uint64
+}
+
+func openVhdx(f *os.File) (Image, error) {
+	err := checkVhdxHeader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	regions, err := readVhdxRegionTable(f, vhdxRegionTable1Offset)
+	if err != nil {
+		regions, err = readVhdxRegionTable(f, vhdxRegionTable2Offset)
+		if err != nil {
+			return nil, err
+		}
+	}
+	batRegion, ok := regions[vhdxRegionBat]
+	if !ok {
+		return nil, errors.New("VHDX image has no BAT region")
+	}
+	metadataRegion, ok := regions[vhdxRegionMetadata]
+	if !ok {
+		return nil, errors.New("VHDX image has no metadata region")
+	}
+
+	i := &vhdxImage{f: f}
+	logicalSectorSize, err := i.readMetadata(metadataRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every chunk of the payload blocks is followed by a sector bitmap block entry in the BAT
+	i.chunkRatio = (1 << 23) * logicalSectorSize / i.blockSize
+	payloadBlocks := (i.size + i.blockSize - 1) / i.blockSize
+	batEntries := payloadBlocks + (payloadBlocks-1)/i.chunkRatio
+	if batEntries*8 > batRegion[1] {
+		return nil, errors.New("VHDX BAT region is too small for the virtual disk size")
+	}
+
+	bat := make([]byte, batEntries*8)
+	err = readFull(f, bat, batRegion[0])
+	if err != nil {
+		return nil, err
+	}
+	i.bat = make([]uint64, batEntries)
+	for n := range i.bat {
+		i.bat[n] = binary.LittleEndian.Uint64(bat[n*8:])
+	}
+
+	return i, nil
+}
+
+func (i *vhdxImage) Format() string { return FORMAT_VHDX }
+func (i *vhdxImage) Size() int64    { return i.size }
+func (i *vhdxImage) Close() error   { return i.f.Close() }
+
+func (i *vhdxImage) ReadAt(p []byte, off int64) (int, error) {
+	return readBlocks(p, off, i.size, i.blockSize, i.readBlock)
+}
+
+func (i *vhdxImage) readBlock(p []byte, block int64, offset int64) error {
+	entry := i.bat[block+block/i.chunkRatio]
+
+	switch entry & 7 {
+	case vhdxBlockFullyPresent:
+		// File offset is stored in MB, in the upper 44 bits
+		return readFull(i.f, p, int64(entry>>20)*1024*1024+offset)
+	case vhdxBlockNotPresent, vhdxBlockUndefined, vhdxBlockZero, vhdxBlockUnmapped:
+		clear(p)
+		return nil
+	case vhdxBlockPartiallyPresent:
+		return errors.New("VHDX block is partially present, differencing images are not supported")
+	default:
+		return fmt.Errorf("invalid VHDX BAT entry state: %d", entry&7)
+	}
+}
+
+// There are 2 copies of the header, the one with the higher sequence number is the current one.
+// The log is used to make the metadata updates crash-safe; a non-empty log means the image wasn't closed cleanly,
+// and the log would have to be replayed before the image can be read.
+func checkVhdxHeader(f *os.File) error {
+	var current []byte
+	var currentSeq uint64
+	for _, offset := range []int64{vhdxHeader1Offset, vhdxHeader2Offset} {
+		header := make([]byte, vhdxHeaderSize)
+		if readFull(f, header, offset) != nil || !bytes.HasPrefix(header, []byte("head")) || !vhdxChecksumValid(header, 4) {
+			continue
+		}
+		seq := binary.LittleEndian.Uint64(header[8:])
+		if current == nil || seq > currentSeq {
+			current = header
+			currentSeq = seq
+		}
+	}
+	if current == nil {
+		return errors.New("VHDX image has no valid header")
+	}
+
+	if version := binary.LittleEndian.Uint16(current[66:]); version != 1 {
+		return fmt.Errorf("unsupported VHDX version: %d", version)
+	}
+	if !bytes.Equal(current[48:64], make([]byte, 16)) {
+		return errors.New("VHDX image has a pending log (it was not closed cleanly), attach and detach it in Hyper-V first")
+	}
+
+	return nil
+}
+
+// Returns the file offset and length of the regions, by the region GUID.
+func readVhdxRegionTable(f *os.File, offset int64) (map[[16]byte][2]int64, error) {
+	table := make([]byte, vhdxRegionTableSize)
+	err := readFull(f, table, offset)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(table, []byte("regi")) || !vhdxChecksumValid(table, 4) {
+		return nil, errors.New("VHDX image has no valid region table")
+	}
+
+	count := binary.LittleEndian.Uint32(table[8:])
+	if count > 2047 {
+		return nil, errors.New("invalid VHDX region table entry count")
+	}
+
+	r := make(map[[16]byte][2]int64)
+	for n := 0; n < int(count); n++ {
+		entry := table[16+n*32:]
+		var guid [16]byte
+		copy(guid[:], entry)
+		required := binary.LittleEndian.Uint32(entry[28:])&1 != 0
+		if guid != vhdxRegionBat && guid != vhdxRegionMetadata && required {
+			return nil, errors.New("VHDX image uses an unknown required region")
+		}
+		r[guid] = [2]int64{int64(binary.LittleEndian.Uint64(entry[16:])), int64(binary.LittleEndian.Uint32(entry[24:]))}
+	}
+
+	return r, nil
+}
+
+// Reads the block size and the virtual disk size from the metadata region, returns the logical sector size.
+func (i *vhdxImage) readMetadata(region [2]int64) (int64, error) {
+	metadata := make([]byte, region[1])
+	err := readFull(i.f, metadata, region[0])
+	if err != nil {
+		return 0, err
+	}
+	if len(metadata) < 64*1024 || !bytes.HasPrefix(metadata, []byte("metadata")) {
+		return 0, errors.New("VHDX image has an invalid metadata region")
+	}
+
+	items := make(map[[16]byte][]byte)
+	count := int(binary.LittleEndian.Uint16(metadata[10:]))
+	if count > 2047 {
+		return 0, errors.New("invalid VHDX metadata table entry count")
+	}
+	for n := 0; n < count; n++ {
+		entry := metadata[32+n*32:]
+		var guid [16]byte
+		copy(guid[:], entry)
+		offset := int64(binary.LittleEndian.Uint32(entry[16:]))
+		length := int64(binary.LittleEndian.Uint32(entry[20:]))
+		flags := binary.LittleEndian.Uint32(entry[24:])
+
+		known := false
+		for _, v := range vhdxKnownMetadata {
+			known = known || v == guid
+		}
+		if !known && flags&vhdxMetadataRequired != 0 {
+			return 0, errors.New("VHDX image uses an unknown required metadata item")
+		}
+		if offset+length > int64(len(metadata)) {
+			return 0, errors.New("VHDX metadata item is outside of the metadata region")
+		}
+		items[guid] = metadata[offset : offset+length]
+	}
+
+	fileParameters := items[vhdxFileParameters]
+	virtualDiskSize := items[vhdxVirtualDiskSize]
+	logicalSectorSize := items[vhdxLogicalSectorSize]
+	if len(fileParameters) < 8 || len(virtualDiskSize) < 8 || len(logicalSectorSize) < 4 {
+		return 0, errors.New("VHDX image is missing the required metadata")
+	}
+
+	if binary.LittleEndian.Uint32(fileParameters[4:])&vhdxHasParent != 0 {
+		return 0, errors.New("differencing VHDX images are not supported, merge it into the parent disk first")
+	}
+	i.blockSize = int64(binary.LittleEndian.Uint32(fileParameters))
+	if i.blockSize < 1024*1024 || i.blockSize > 256*1024*1024 || i.blockSize&(i.blockSize-1) != 0 {
+		return 0, fmt.Errorf("invalid VHDX block size: %d", i.blockSize)
+	}
+	i.size = int64(binary.LittleEndian.Uint64(virtualDiskSize))
+	if i.size < 0 || i.size > 64<<40 {
+		return 0, errors.New("invalid VHDX virtual disk size")
+	}
+	sectorSize := int64(binary.LittleEndian.Uint32(logicalSectorSize))
+	if sectorSize != 512 && sectorSize != 4096 {
+		return 0, fmt.Errorf("invalid VHDX logical sector size: %d", sectorSize)
+	}
+
+	return sectorSize, nil
+}
+
+// CRC-32C of the whole structure, with the checksum field itself set to zero.
+func vhdxChecksumValid(data []byte, checksumOffset int) bool {
+	checksum := binary.LittleEndian.Uint32(data[checksumOffset:])
+	buf := bytes.Clone(data)
+	clear(buf[checksumOffset : checksumOffset+4])
+	return crc32.Checksum(buf, vhdxCrc) == checksum
+}
+
+// GUIDs are stored in the Microsoft mixed-endian layout: the first 3 groups are little-endian, the rest as is.
+func vhdxGuid(s string) (r [16]byte) {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || len(b) != 16 {
+		panic("invalid GUID: " + s)
+	}
+	r = [16]byte{b[3], b[2], b[1], b[0], b[5], b[4], b[7], b[6]}
+	copy(r[8:], b[8:])
+	return
+}
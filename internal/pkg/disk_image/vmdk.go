@@ -0,0 +1,472 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package DiskImage
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// https://www.vmware.com/app/vmdk/?src=vmdk (Virtual Disk Format 5.0)
+var (
+	vmdkSparseMagic     = []byte{'K', 'D', 'M', 'V'}
+	vmdkDescriptorMagic = []byte("# Disk DescriptorFile")
+)
+
+const (
+	vmdkFlagCompressed  = 1 << 16
+	vmdkCompressDeflate = 1
+	vmdkGdAtEnd         = 0xffffffffffffffff // stream-optimized images keep the real header in the footer
+
+	vmdkGrainUnallocated = 0
+	vmdkGrainZero        = 1
+
+	// Upper limit of the grain tables kept in memory, the rest are re-read from the disk
+	vmdkGtCacheEntries = 4096
+
+	// Descriptors are small text files, anything bigger is not a descriptor
+	vmdkMaxDescriptorSize = 1024 * 1024
+)
+
+var reVmdkExtent = regexp.MustCompile(`^(RW|RDONLY|NOACCESS)\s+(\d+)\s+(\w+)(?:\s+"([^"]*)"(?:\s+(\d+))?)?`)
+var reVmdkKeyValue = regexp.MustCompile(`^(\w+)\s*=\s*"?([^"]*)"?`)
+
+// Hosted sparse extent: monolithicSparse and streamOptimized images, or a part of the split (twoGbMaxExtentSparse) image.
+type vmdkSparse struct {
+	f          *os.File
+	size       int64
+	grainSize  int64
+	gtEntries  int64
+	gd         []uint32
+	compressed bool
+	// Unallocated grains are read from the parent image, at the extent's offset within the whole virtual disk
+	parent       Image
+	parentOffset int64
+
+	mu      sync.Mutex
+	gtCache map[uint32][]uint32
+	// Last decompressed grain, the grains are usually read sequentially in the smaller chunks
+	grainOffset uint32
+	grain       []byte
+}
+
+// Standalone monolithicSparse or streamOptimized image, with the embedded descriptor.
+func openVmdkSparse(f *os.File, path string, depth int) (Image, error) {
+	e, descriptor, err := openVmdkSparseExtent(f)
+	if err != nil {
+		return nil, err
+	}
+
+	e.parent, err = openVmdkParent(path, descriptor, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vmdkImage{extents: []vmdkExtent{{size: e.size, sparse: e}}, size: e.size, parent: e.parent}, nil
+}
+
+// Returns the sparse extent, and its embedded descriptor (if there is one).
+func openVmdkSparseExtent(f *os.File) (r *vmdkSparse, descriptor []byte, e error) {
+	header := make([]byte, 512)
+	err := readFull(f, header, 0)
+	if err != nil {
+		e = err
+		return
+	}
+
+	version := binary.LittleEndian.Uint32(header[4:])
+	if version < 1 || version > 3 {
+		e = fmt.Errorf("unsupported VMDK sparse extent version: %d", version)
+		return
+	}
+	descriptorOffset := int64(binary.LittleEndian.Uint64(header[28:]))
+	descriptorSize := int64(binary.LittleEndian.Uint64(header[36:]))
+
+	if binary.LittleEndian.Uint64(header[56:]) == vmdkGdAtEnd {
+		// Footer is the second to last sector, followed by the end-of-stream marker
+		size, err := f.Seek(0, io.SeekEnd)
+		if err != nil {
+			e = err
+			return
+		}
+		if size < 1536 {
+			e = errors.New("stream-optimized VMDK image is truncated (no footer)")
+			return
+		}
+		err = readFull(f, header, size-1024)
+		if err != nil {
+			e = err
+			return
+		}
+		if !bytes.HasPrefix(header, vmdkSparseMagic) || binary.LittleEndian.Uint64(header[56:]) == vmdkGdAtEnd {
+			e = errors.New("stream-optimized VMDK image has an invalid footer (incomplete upload?)")
+			return
+		}
+	}
+
+	flags := binary.LittleEndian.Uint32(header[8:])
+	capacity := int64(binary.LittleEndian.Uint64(header[12:]))
+	grainSize := int64(binary.LittleEndian.Uint64(header[20:]))
+	gtEntries := int64(binary.LittleEndian.Uint32(header[44:]))
+	gdOffset := int64(binary.LittleEndian.Uint64(header[56:]))
+	compressAlgorithm := binary.LittleEndian.Uint16(header[77:])
+
+	if grainSize < 1 || grainSize > 2048 || grainSize&(grainSize-1) != 0 {
+		e = fmt.Errorf("invalid VMDK grain size: %d sectors", grainSize)
+		return
+	}
+	if gtEntries < 1 || gtEntries > 4096 {
+		e = fmt.Errorf("invalid VMDK grain table size: %d", gtEntries)
+		return
+	}
+	if capacity < 0 || capacity > 1<<40 {
+		e = errors.New("invalid VMDK capacity")
+		return
+	}
+
+	r = &vmdkSparse{
+		f:          f,
+		size:       capacity * 512,
+		grainSize:  grainSize * 512,
+		gtEntries:  gtEntries,
+		compressed: flags&vmdkFlagCompressed != 0,
+		gtCache:    make(map[uint32][]uint32),
+	}
+	if r.compressed && compressAlgorithm != vmdkCompressDeflate {
+		e = fmt.Errorf("unsupported VMDK compression algorithm: %d", compressAlgorithm)
+		return
+	}
+
+	gtCoverage := r.grainSize * r.gtEntries
+	gdSize := (r.size + gtCoverage - 1) / gtCoverage * 4
+	if gdSize > maxTableSize {
+		e = errors.New("VMDK grain directory is too big")
+		return
+	}
+	gd := make([]byte, gdSize)
+	err = readFull(f, gd, gdOffset*512)
+	if err != nil {
+		e = err
+		return
+	}
+	r.gd = make([]uint32, len(gd)/4)
+	for n := range r.gd {
+		r.gd[n] = binary.LittleEndian.Uint32(gd[n*4:])
+	}
+
+	if descriptorOffset > 0 && descriptorSize > 0 && descriptorSize*512 <= vmdkMaxDescriptorSize {
+		descriptor = make([]byte, descriptorSize*512)
+		err = readFull(f, descriptor, descriptorOffset*512)
+		if err != nil {
+			e = err
+			return
+		}
+		descriptor, _, _ = bytes.Cut(descriptor, []byte{0})
+	}
+
+	return
+}
+
+func (s *vmdkSparse) ReadAt(p []byte, off int64) (int, error) {
+	return readBlocks(p, off, s.size, s.grainSize, s.readGrain)
+}
+
+func (s *vmdkSparse) readGrain(p []byte, grain int64, offset int64) error {
+	gdIndex := grain / s.gtEntries
+	if gdIndex >= int64(len(s.gd)) || s.gd[gdIndex] == 0 {
+		return readParent(s.parent, p, s.parentOffset+grain*s.grainSize+offset)
+	}
+	gt, err := s.grainTable(s.gd[gdIndex])
+	if err != nil {
+		return err
+	}
+
+	switch entry := gt[grain%s.gtEntries]; {
+	case entry == vmdkGrainUnallocated:
+		return readParent(s.parent, p, s.parentOffset+grain*s.grainSize+offset)
+	case entry == vmdkGrainZero:
+		clear(p)
+		return nil
+	case s.compressed:
+		data, err := s.decompressGrain(entry)
+		if err != nil {
+			return err
+		}
+		copy(p, data[offset:])
+		return nil
+	default:
+		return readFull(s.f, p, int64(entry)*512+offset)
+	}
+}
+
+func (s *vmdkSparse) grainTable(sector uint32) ([]uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gt, ok := s.gtCache[sector]
+	if ok {
+		return gt, nil
+	}
+
+	data := make([]byte, s.gtEntries*4)
+	err := readFull(s.f, data, int64(sector)*512)
+	if err != nil {
+		return nil, err
+	}
+	gt = make([]uint32, s.gtEntries)
+	for n := range gt {
+		gt[n] = binary.LittleEndian.Uint32(data[n*4:])
+	}
+
+	if len(s.gtCache) >= vmdkGtCacheEntries {
+		clear(s.gtCache)
+	}
+	s.gtCache[sector] = gt
+	return gt, nil
+}
+
+// Compressed grain: 8 byte LBA, 4 byte compressed data size, followed by the zlib (RFC 1950) stream.
+func (s *vmdkSparse) decompressGrain(sector uint32) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.grain != nil && s.grainOffset == sector {
+		return s.grain, nil
+	}
+
+	marker := make([]byte, 12)
+	err := readFull(s.f, marker, int64(sector)*512)
+	if err != nil {
+		return nil, err
+	}
+	size := binary.LittleEndian.Uint32(marker[8:])
+	if int64(size) > s.grainSize*2 {
+		return nil, fmt.Errorf("invalid VMDK compressed grain size at sector %d", sector)
+	}
+
+	compressed := make([]byte, size)
+	err = readFull(s.f, compressed, int64(sector)*512+12)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress the VMDK grain at sector %d: %s", sector, err.Error())
+	}
+	data := make([]byte, s.grainSize)
+	n, err := io.ReadFull(zr, data)
+	// The last grain of the disk may be shorter than the grain size
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("could not decompress the VMDK grain at sector %d: %s", sector, err.Error())
+	}
+	clear(data[n:])
+
+	s.grainOffset = sector
+	s.grain = data
+	return data, nil
+}
+
+type vmdkExtent struct {
+	size   int64
+	sparse *vmdkSparse // SPARSE extent
+	flat   *os.File    // FLAT (or VMFS) extent, nil for the ZERO extent
+	offset int64       // FLAT extent data offset within the file
+}
+
+// VMDK image assembled from the extents listed in the descriptor.
+type vmdkImage struct {
+	extents []vmdkExtent
+	size    int64
+	parent  Image
+}
+
+// Descriptor file with the separate extent files: monolithicFlat, twoGbMaxExtentFlat, twoGbMaxExtentSparse, vmfs.
+func openVmdkDescriptor(path string, depth int) (Image, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > vmdkMaxDescriptorSize {
+		return nil, errors.New("VMDK descriptor file is too big")
+	}
+	descriptor, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &vmdkImage{}
+	fail := func(err error) (Image, error) {
+		i.Close()
+		return nil, err
+	}
+
+	i.parent, err = openVmdkParent(path, descriptor, depth)
+	if err != nil {
+		return fail(err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(descriptor))
+	for scanner.Scan() {
+		m := reVmdkExtent.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+
+		sectors, _ := strconv.ParseInt(m[2], 10, 64)
+		extent := vmdkExtent{size: sectors * 512}
+		extentFile := m[4]
+		if len(extentFile) > 0 && !filepath.IsAbs(extentFile) {
+			extentFile = filepath.Join(filepath.Dir(path), extentFile)
+		}
+
+		if m[1] == "NOACCESS" {
+			return fail(errors.New("VMDK image has a NOACCESS extent"))
+		}
+
+		switch m[3] {
+		case "ZERO":
+		case "FLAT", "VMFS":
+			extent.flat, err = os.Open(extentFile)
+			if err != nil {
+				return fail(err)
+			}
+			offset, _ := strconv.ParseInt(m[5], 10, 64)
+			extent.offset = offset * 512
+		case "SPARSE":
+			f, err := os.Open(extentFile)
+			if err != nil {
+				return fail(err)
+			}
+			extent.sparse, _, err = openVmdkSparseExtent(f)
+			if err != nil {
+				f.Close()
+				return fail(fmt.Errorf("%s: %s", extentFile, err.Error()))
+			}
+			extent.sparse.parent = i.parent
+			extent.sparse.parentOffset = i.size
+		default:
+			return fail(fmt.Errorf("unsupported VMDK extent type: %s", m[3]))
+		}
+		i.extents = append(i.extents, extent)
+		i.size += extent.size
+	}
+	if len(i.extents) < 1 {
+		return fail(errors.New("VMDK descriptor doesn't list any extents"))
+	}
+
+	return i, nil
+}
+
+func (i *vmdkImage) Format() string { return FORMAT_VMDK }
+func (i *vmdkImage) Size() int64    { return i.size }
+
+func (i *vmdkImage) Close() error {
+	for _, v := range i.extents {
+		if v.sparse != nil {
+			v.sparse.f.Close()
+		}
+		if v.flat != nil {
+			v.flat.Close()
+		}
+	}
+	if i.parent != nil {
+		i.parent.Close()
+	}
+	return nil
+}
+
+func (i *vmdkImage) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= i.size {
+		return 0, io.EOF
+	}
+
+	var eof error
+	if off+int64(len(p)) > i.size {
+		p = p[:i.size-off]
+		eof = io.EOF
+	}
+
+	n := 0
+	var start int64
+	for _, v := range i.extents {
+		if n >= len(p) {
+			break
+		}
+		pos := off + int64(n)
+		if pos >= start+v.size {
+			start += v.size
+			continue
+		}
+
+		chunk := p[n : n+int(min(int64(len(p)-n), start+v.size-pos))]
+		var err error
+		switch {
+		case v.sparse != nil:
+			_, err = v.sparse.ReadAt(chunk, pos-start)
+			if err == io.EOF {
+				err = nil
+			}
+		case v.flat != nil:
+			err = readFull(v.flat, chunk, v.offset+pos-start)
+		default:
+			clear(chunk)
+		}
+		if err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		start += v.size
+	}
+
+	return n, eof
+}
+
+// Opens the parent disk of the delta (snapshot) image, returns nil if the descriptor doesn't reference one.
+func openVmdkParent(path string, descriptor []byte, depth int) (Image, error) {
+	values := parseVmdkDescriptor(descriptor)
+	parentHint := values["parentFileNameHint"]
+	if len(parentHint) < 1 || strings.ToLower(values["parentCID"]) == "ffffffff" {
+		return nil, nil
+	}
+
+	if !filepath.IsAbs(parentHint) {
+		parentHint = filepath.Join(filepath.Dir(path), parentHint)
+	}
+	parent, err := open(parentHint, depth+1)
+	if err == errChainTooLong {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open the parent disk: %s", err.Error())
+	}
+	return parent, nil
+}
+
+// Returns the "key = value" pairs from the descriptor, e.g. createType or parentFileNameHint.
+func parseVmdkDescriptor(descriptor []byte) map[string]string {
+	r := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(descriptor))
+	for scanner.Scan() {
+		m := reVmdkKeyValue.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m != nil {
+			r[m[1]] = m[2]
+		}
+	}
+	return r
+}
@@ -12,6 +12,7 @@ const (
 	LOGGER_SRV_REST_API    = "HOSTER_REST_API"
 	LOGGER_SRV_HA_REST_API = "HOSTER_HA_REST_API"
 	LOGGER_SRV_HA_WATCHDOG = "HOSTER_HA_WATCHDOG"
+	LOGGER_SRV_API_AUDIT   = "HOSTER_API_AUDIT"
 )
 
 const (
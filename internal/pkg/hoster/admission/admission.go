@@ -0,0 +1,239 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterAdmission
+
+import (
+	"HosterCore/internal/pkg/byteconversion"
+	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	HosterHost "HosterCore/internal/pkg/hoster/host"
+	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	zfsutils "HosterCore/internal/pkg/zfs_utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+	"fmt"
+	"strings"
+)
+
+const (
+	ACTION_DEPLOY = "deploy"
+	ACTION_CLONE  = "clone"
+	ACTION_IMPORT = "import"
+	ACTION_UPDATE = "update"
+
+	RESOURCE_VM   = "vm"
+	RESOURCE_JAIL = "jail"
+)
+
+// Returned (wrapped, with the list of violations) when the request would violate any of the admission policies.
+var ErrAdmissionDenied = ErrorMappings.NewError(ErrorMappings.CODE_ADMISSION_DENIED, "admission denied")
+
+// Describes the resource state after the requested action.
+// For updates, VCpus and Ram are the new totals of the resource, not the difference.
+type Request struct {
+	Action       string
+	ResourceType string
+	ResourceName string
+	Owner        string // VMs only, Jails don't have an owner
+	VCpus        int    // VMs only, Jail CPU limits are a percentage of a single core
+	Ram          string // e.g. 4G
+	Dataset      string // parent dataset of the new resource, used to check the pool free space, e.g. zroot/vm-encrypted
+}
+
+type usage struct {
+	vcpus     int
+	ram       uint64
+	ownerVms  int
+	ownerCpus int
+	ownerRam  uint64
+	// Current values of the resource that's being updated
+	selfCpus uint64
+	selfRam  uint64
+}
+
+// Checks the request against the host admission policies (host_config.json -> admission_policy).
+// Returns nil if no policy is configured, or if none of the policies would be violated.
+func Check(req Request) error {
+	hostConf, err := HosterHost.GetHostConfig()
+	if err != nil {
+		return err
+	}
+	policy := hostConf.AdmissionPolicy
+	if policy == nil {
+		return nil
+	}
+
+	ram := uint64(0)
+	if len(req.Ram) > 0 {
+		ram, err = byteconversion.HumanToBytes(req.Ram)
+		if err != nil {
+			return fmt.Errorf("could not parse the RAM value: %s", req.Ram)
+		}
+	}
+	if len(req.Owner) < 1 {
+		req.Owner = "system"
+	}
+
+	u, err := currentUsage(req)
+	if err != nil {
+		return err
+	}
+
+	// Settings changes that don't increase the usage are always allowed,
+	// otherwise an already overcommitted host could not be brought back within the limits
+	checkCpus := req.Action != ACTION_UPDATE || uint64(req.VCpus) > u.selfCpus
+	checkRam := req.Action != ACTION_UPDATE || ram > u.selfRam
+
+	violations := []string{}
+	if policy.MaxVcpuRatio > 0 && checkCpus && req.VCpus > 0 {
+		pCpus, err := FreeBSDsysctls.SysctlHwNcpu()
+		if err != nil {
+			return err
+		}
+		ratio := float64(u.vcpus+req.VCpus) / float64(pCpus)
+		if ratio > policy.MaxVcpuRatio {
+			violations = append(violations, fmt.Sprintf("vCPU:pCPU ratio would be %.2f:1 (%d vCPUs on %d pCPUs), the maximum allowed is %.2f:1",
+				ratio, u.vcpus+req.VCpus, pCpus, policy.MaxVcpuRatio))
+		}
+	}
+
+	if policy.MaxRamCommitPercent > 0 && checkRam {
+		hostRam, err := FreeBSDsysctls.SysctlHwRealmem()
+		if err != nil {
+			return err
+		}
+		committed := u.ram + ram
+		percent := float64(committed) / float64(hostRam) * 100
+		if percent > float64(policy.MaxRamCommitPercent) {
+			violations = append(violations, fmt.Sprintf("committed RAM would be %.0f%% (%s of %s), the maximum allowed is %d%%",
+				percent, byteconversion.BytesToHuman(committed), byteconversion.BytesToHuman(hostRam), policy.MaxRamCommitPercent))
+		}
+	}
+
+	if policy.MinPoolFreePercent > 0 && req.Action != ACTION_UPDATE && len(req.Dataset) > 0 {
+		poolName := strings.Split(req.Dataset, "/")[0]
+		pools, err := zfsutils.GetZpoolList()
+		if err != nil {
+			return err
+		}
+		for _, v := range pools {
+			if v.Name != poolName || v.SizeBytes < 1 {
+				continue
+			}
+			percent := float64(v.FreeBytes) / float64(v.SizeBytes) * 100
+			if percent < float64(policy.MinPoolFreePercent) {
+				violations = append(violations, fmt.Sprintf("pool %s has only %.0f%% (%s) of free space left, the minimum required is %d%%",
+					poolName, percent, v.FreeHuman, policy.MinPoolFreePercent))
+			}
+		}
+	}
+
+	if req.ResourceType == RESOURCE_VM {
+		for _, quota := range policy.OwnerQuotas {
+			if quota.Owner != req.Owner {
+				continue
+			}
+			violations = append(violations, checkOwnerQuota(quota, req, ram, u, checkCpus, checkRam)...)
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%w: %s", ErrAdmissionDenied, strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+func checkOwnerQuota(quota HosterHost.OwnerQuota, req Request, ram uint64, u usage, checkCpus bool, checkRam bool) (r []string) {
+	if quota.MaxVms > 0 && req.Action != ACTION_UPDATE && u.ownerVms+1 > quota.MaxVms {
+		r = append(r, fmt.Sprintf("owner %s would have %d VMs, the quota is %d", req.Owner, u.ownerVms+1, quota.MaxVms))
+	}
+
+	if quota.MaxVcpus > 0 && checkCpus && u.ownerCpus+req.VCpus > quota.MaxVcpus {
+		r = append(r, fmt.Sprintf("owner %s would have %d vCPUs, the quota is %d", req.Owner, u.ownerCpus+req.VCpus, quota.MaxVcpus))
+	}
+
+	if len(quota.MaxRam) > 0 && checkRam {
+		maxRam, err := byteconversion.HumanToBytes(quota.MaxRam)
+		if err != nil {
+			r = append(r, fmt.Sprintf("RAM quota for owner %s could not be parsed: %s", req.Owner, quota.MaxRam))
+			return
+		}
+		if u.ownerRam+ram > maxRam {
+			r = append(r, fmt.Sprintf("owner %s would have %s of RAM, the quota is %s",
+				req.Owner, byteconversion.BytesToHuman(u.ownerRam+ram), byteconversion.BytesToHuman(maxRam)))
+		}
+	}
+
+	return
+}
+
+// Sums up the resources committed to the VMs and Jails on this host.
+// Backups (resources which belong to another host) are not counted, and neither is the resource that's being updated.
+func currentUsage(req Request) (r usage, e error) {
+	hostname, _ := FreeBSDsysctls.SysctlKernHostname()
+
+	vms, err := HosterVmUtils.ListAllSimple()
+	if err != nil {
+		e = err
+		return
+	}
+	for _, v := range vms {
+		conf, err := HosterVmUtils.GetVmConfig(v.Mountpoint + "/" + v.VmName)
+		if err != nil {
+			continue
+		}
+		if conf.ParentHost != hostname {
+			continue
+		}
+
+		cpus := conf.CPUSockets * conf.CPUCores
+		if conf.CPUThreads > 0 {
+			cpus = cpus * conf.CPUThreads
+		}
+		ram, _ := byteconversion.HumanToBytes(conf.Memory)
+
+		if req.ResourceType == RESOURCE_VM && v.VmName == req.ResourceName {
+			r.selfCpus = uint64(cpus)
+			r.selfRam = ram
+			if req.Action == ACTION_UPDATE {
+				continue
+			}
+		}
+
+		r.vcpus += cpus
+		r.ram += ram
+		if conf.Owner == req.Owner {
+			r.ownerVms += 1
+			r.ownerCpus += cpus
+			r.ownerRam += ram
+		}
+	}
+
+	jails, err := HosterJailUtils.ListAllSimple()
+	if err != nil {
+		e = err
+		return
+	}
+	for _, v := range jails {
+		conf, err := HosterJailUtils.GetJailConfig(v.Mountpoint + "/" + v.JailName)
+		if err != nil {
+			continue
+		}
+		if len(conf.Parent) > 0 && conf.Parent != hostname {
+			continue
+		}
+
+		ram, _ := byteconversion.HumanToBytes(conf.RAMLimit)
+		if req.ResourceType == RESOURCE_JAIL && v.JailName == req.ResourceName {
+			r.selfRam = ram
+			if req.Action == ACTION_UPDATE {
+				continue
+			}
+		}
+
+		r.ram += ram
+	}
+
+	return
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build freebsd
+// +build freebsd
+
+package HosterBulk
+
+import (
+	SchedulerClient "HosterCore/internal/app/scheduler/client"
+	SchedulerUtils "HosterCore/internal/app/scheduler/utils"
+	HosterJail "HosterCore/internal/pkg/hoster/jail"
+	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	HosterVm "HosterCore/internal/pkg/hoster/vm"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	zfsutils "HosterCore/internal/pkg/zfs_utils"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Checks the action name and parameters, and sets the defaults.
+func ValidateParams(action string, p Params) (r Params, e error) {
+	r = p
+
+	if !slices.Contains(Actions, action) {
+		e = fmt.Errorf("unknown bulk action: %s", action)
+		return
+	}
+
+	switch action {
+	case ACTION_ADD_TAG, ACTION_REMOVE_TAG:
+		if len(r.Tag) < 1 || len(r.Tag) > 255 {
+			e = errors.New("tag must be between 1 and 255 characters long")
+			return
+		}
+	case ACTION_SNAPSHOT:
+		if r.SnapshotsToKeep < 1 {
+			r.SnapshotsToKeep = 5
+		}
+		if len(r.SnapshotType) < 1 {
+			r.SnapshotType = zfsutils.TYPE_CUSTOM
+		}
+	case ACTION_REPLICATE:
+		if len(r.SshEndpoint) < 1 {
+			e = errors.New("ssh endpoint cannot be empty")
+			return
+		}
+		if len(r.SshKey) < 1 {
+			r.SshKey = "/root/.ssh/id_rsa"
+		}
+		if r.SshPort < 1 {
+			r.SshPort = 22
+		}
+		if r.SpeedLimit < 1 {
+			r.SpeedLimit = 50
+		}
+	}
+
+	return
+}
+
+// Applies the action to every target, one by one, and returns the per-resource results.
+// onResult (optional) is called after every resource is processed.
+func Run(action string, targets []Target, p Params, onResult func(Result)) (r []Result) {
+	r = []Result{}
+
+	for i, v := range targets {
+		res := Result{Target: v}
+		jobId, err := runOne(action, v, p)
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Success = true
+			res.JobId = jobId
+		}
+
+		r = append(r, res)
+		if onResult != nil {
+			onResult(res)
+		}
+
+		// Give the scheduler some time to process the job, same as in the AddReplicationByTagJob()
+		if (action == ACTION_SNAPSHOT || action == ACTION_REPLICATE) && i < len(targets)-1 {
+			time.Sleep(700 * time.Millisecond)
+		}
+	}
+
+	return
+}
+
+func runOne(action string, t Target, p Params) (jobId string, e error) {
+	switch action {
+	case ACTION_START:
+		if t.ResourceType == RES_TYPE_VM {
+			e = HosterVm.Start(t.ResourceName, false, false)
+		} else {
+			e = HosterJail.Start(t.ResourceName)
+		}
+	case ACTION_STOP:
+		if t.ResourceType == RES_TYPE_VM {
+			e = HosterVm.Stop(t.ResourceName, false, false)
+		} else {
+			e = HosterJail.Stop(t.ResourceName)
+		}
+	case ACTION_SNAPSHOT:
+		jobId, e = SchedulerClient.AddSnapshotJob(t.ResourceName, p.SnapshotsToKeep, p.SnapshotType, false)
+	case ACTION_REPLICATE:
+		job := SchedulerUtils.ReplicationJob{}
+		job.ResName = t.ResourceName
+		job.SshKey = p.SshKey
+		job.SshEndpoint = p.SshEndpoint
+		job.SshPort = p.SshPort
+		job.SpeedLimit = p.SpeedLimit
+		e = SchedulerClient.AddReplicationJob(job)
+	case ACTION_ADD_TAG, ACTION_REMOVE_TAG:
+		e = changeTag(t, p.Tag, action == ACTION_ADD_TAG)
+	default:
+		e = fmt.Errorf("unknown bulk action: %s", action)
+	}
+
+	return
+}
+
+func changeTag(t Target, tag string, add bool) error {
+	updateTags := func(tags []string) []string {
+		tags = slices.DeleteFunc(tags, func(v string) bool { return v == tag })
+		if add {
+			tags = append(tags, tag)
+		}
+		return tags
+	}
+
+	if t.ResourceType == RES_TYPE_VM {
+		info, err := HosterVmUtils.InfoJsonApi(t.ResourceName)
+		if err != nil {
+			return err
+		}
+		location := info.Simple.Mountpoint + "/" + t.ResourceName
+		conf, err := HosterVmUtils.GetVmConfig(location)
+		if err != nil {
+			return err
+		}
+		conf.Tags = updateTags(conf.Tags)
+		return HosterVmUtils.ConfigFileWriter(conf, location+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	}
+
+	info, err := HosterJailUtils.InfoJsonApi(t.ResourceName)
+	if err != nil {
+		return err
+	}
+	location := info.Simple.Mountpoint + "/" + t.ResourceName
+	conf, err := HosterJailUtils.GetJailConfig(location)
+	if err != nil {
+		return err
+	}
+	conf.Tags = updateTags(conf.Tags)
+	return HosterJailUtils.ConfigFileWriter(conf, location+"/"+HosterJailUtils.JAIL_CONFIG_NAME)
+}
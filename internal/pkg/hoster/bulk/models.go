@@ -0,0 +1,58 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterBulk
+
+import "time"
+
+const (
+	ACTION_START      = "start"
+	ACTION_STOP       = "stop"
+	ACTION_SNAPSHOT   = "snapshot"
+	ACTION_REPLICATE  = "replicate"
+	ACTION_ADD_TAG    = "add-tag"
+	ACTION_REMOVE_TAG = "remove-tag"
+)
+
+var Actions = []string{ACTION_START, ACTION_STOP, ACTION_SNAPSHOT, ACTION_REPLICATE, ACTION_ADD_TAG, ACTION_REMOVE_TAG}
+
+// Action specific parameters
+type Params struct {
+	Tag             string `json:"tag,omitempty"`               // add-tag, remove-tag
+	SnapshotsToKeep int    `json:"snapshots_to_keep,omitempty"` // snapshot, 5 by default
+	SnapshotType    string `json:"snapshot_type,omitempty"`     // snapshot, custom by default
+	SshKey          string `json:"ssh_key,omitempty"`           // replicate, /root/.ssh/id_rsa by default
+	SshEndpoint     string `json:"ssh_endpoint,omitempty"`      // replicate
+	SshPort         int    `json:"ssh_port,omitempty"`          // replicate, 22 by default
+	SpeedLimit      int    `json:"speed_limit,omitempty"`       // replicate, 50 by default
+}
+
+type Result struct {
+	Target
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	JobId   string `json:"job_id,omitempty"` // scheduler job ID (snapshot action)
+}
+
+const (
+	STATUS_RUNNING = "running"
+	STATUS_DONE    = "done"    // all resources processed successfully
+	STATUS_PARTIAL = "partial" // some of the resources failed
+	STATUS_FAILED  = "failed"  // all resources failed
+)
+
+// An asynchronous bulk operation, started using the REST API.
+type Operation struct {
+	ID        string     `json:"id"`
+	Action    string     `json:"action"`
+	Selector  Selector   `json:"selector"`
+	Params    Params     `json:"params"`
+	Status    string     `json:"status"`
+	Total     int        `json:"total"`
+	Processed int        `json:"processed"`
+	Started   time.Time  `json:"started"`
+	Finished  *time.Time `json:"finished,omitempty"` // not set while the operation is running
+	Targets   []Target   `json:"targets"`
+	Results   []Result   `json:"results"`
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build freebsd
+// +build freebsd
+
+package HosterBulk
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// How many finished operations are kept in memory
+const operationsToKeep = 100
+
+var operationsMu sync.Mutex
+var operations = make(map[string]*Operation)
+
+// Resolves the selector, and starts the bulk action in the background.
+// Returns the operation, which can be polled using GetOperation().
+func StartOperation(action string, sel Selector, p Params) (r Operation, e error) {
+	p, e = ValidateParams(action, p)
+	if e != nil {
+		return
+	}
+
+	targets, err := Resolve(sel)
+	if err != nil {
+		e = err
+		return
+	}
+
+	op := &Operation{
+		ID:       ulid.Make().String(),
+		Action:   action,
+		Selector: sel,
+		Params:   p,
+		Status:   STATUS_RUNNING,
+		Total:    len(targets),
+		Started:  time.Now(),
+		Targets:  targets,
+		Results:  []Result{},
+	}
+
+	operationsMu.Lock()
+	operations[op.ID] = op
+	removeOldOperations()
+	r = copyOperation(op)
+	operationsMu.Unlock()
+
+	go func() {
+		results := Run(action, targets, p, func(res Result) {
+			operationsMu.Lock()
+			defer operationsMu.Unlock()
+			op.Results = append(op.Results, res)
+			op.Processed = len(op.Results)
+		})
+
+		operationsMu.Lock()
+		defer operationsMu.Unlock()
+		op.Status = resultStatus(results)
+		finished := time.Now()
+		op.Finished = &finished
+	}()
+
+	return
+}
+
+func resultStatus(results []Result) string {
+	failed := 0
+	for _, v := range results {
+		if !v.Success {
+			failed += 1
+		}
+	}
+
+	if failed == 0 {
+		return STATUS_DONE
+	}
+	if failed == len(results) {
+		return STATUS_FAILED
+	}
+	return STATUS_PARTIAL
+}
+
+// Returns a single operation by it's ID.
+func GetOperation(id string) (r Operation, found bool) {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+
+	op, found := operations[id]
+	if !found {
+		return
+	}
+
+	r = copyOperation(op)
+	return
+}
+
+// Returns all operations kept in memory, newest first.
+func ListOperations() (r []Operation) {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+
+	r = []Operation{}
+	for _, v := range operations {
+		r = append(r, copyOperation(v))
+	}
+	sort.SliceStable(r, func(i, j int) bool { return r[i].ID > r[j].ID })
+
+	return
+}
+
+// Must be called with the operationsMu held
+func copyOperation(op *Operation) Operation {
+	r := *op
+	r.Results = append([]Result{}, op.Results...)
+	return r
+}
+
+// Must be called with the operationsMu held
+func removeOldOperations() {
+	finished := []*Operation{}
+	for _, v := range operations {
+		if v.Status != STATUS_RUNNING {
+			finished = append(finished, v)
+		}
+	}
+	if len(finished) <= operationsToKeep {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool { return finished[i].Finished.Before(*finished[j].Finished) })
+	for _, v := range finished[:len(finished)-operationsToKeep] {
+		delete(operations, v.ID)
+	}
+}
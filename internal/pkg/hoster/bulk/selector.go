@@ -0,0 +1,101 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterBulk
+
+import (
+	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"errors"
+	"fmt"
+	"path"
+)
+
+const (
+	RES_TYPE_VM   = "vm"
+	RES_TYPE_JAIL = "jail"
+)
+
+// Selects the resources the bulk action is applied to.
+// All of the set fields must match. Backup resources (the ones that belong to another host) are never selected.
+type Selector struct {
+	Tags         []string `json:"tags,omitempty"`          // all of the tags must be present
+	Names        []string `json:"names,omitempty"`         // resource names or glob patterns, any of them must match
+	Owner        string   `json:"owner,omitempty"`         // VM owner
+	Production   *bool    `json:"production,omitempty"`    // production flag
+	ResourceType string   `json:"resource_type,omitempty"` // vm, jail, or empty for both
+}
+
+type Target struct {
+	ResourceType string `json:"resource_type"`
+	ResourceName string `json:"resource_name"`
+}
+
+// Returns the list of resources matching the selector.
+func Resolve(sel Selector) (r []Target, e error) {
+	r = []Target{}
+
+	if len(sel.Tags) < 1 && len(sel.Names) < 1 && len(sel.Owner) < 1 && sel.Production == nil {
+		e = errors.New("selector can't be empty, specify at least one of: tags, names, owner, production")
+		return
+	}
+	if len(sel.ResourceType) > 0 && sel.ResourceType != RES_TYPE_VM && sel.ResourceType != RES_TYPE_JAIL {
+		e = fmt.Errorf("resource type must be either %s or %s", RES_TYPE_VM, RES_TYPE_JAIL)
+		return
+	}
+	for _, v := range sel.Names {
+		_, err := path.Match(v, "")
+		if err != nil {
+			e = fmt.Errorf("invalid name pattern: %s", v)
+			return
+		}
+	}
+
+	backup := false
+	query := HosterListQuery.Query{Tags: sel.Tags, Owner: sel.Owner, Production: sel.Production, Backup: &backup}
+
+	if sel.ResourceType != RES_TYPE_JAIL {
+		vms, err := HosterVmUtils.ListJsonApi()
+		if err != nil {
+			e = err
+			return
+		}
+		for _, v := range vms {
+			if query.Matches(v.ListAttributes()) && sel.nameMatches(v.Name) {
+				r = append(r, Target{ResourceType: RES_TYPE_VM, ResourceName: v.Name})
+			}
+		}
+	}
+
+	if sel.ResourceType != RES_TYPE_VM {
+		jails, err := HosterJailUtils.ListJsonApi()
+		if err != nil {
+			e = err
+			return
+		}
+		for _, v := range jails {
+			if query.Matches(v.ListAttributes()) && sel.nameMatches(v.Name) {
+				r = append(r, Target{ResourceType: RES_TYPE_JAIL, ResourceName: v.Name})
+			}
+		}
+	}
+
+	return
+}
+
+func (sel Selector) nameMatches(name string) bool {
+	if len(sel.Names) < 1 {
+		return true
+	}
+
+	for _, v := range sel.Names {
+		matched, _ := path.Match(v, name)
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,216 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterCliContext
+
+import (
+	AtomicFile "HosterCore/internal/pkg/atomic_file"
+	ApiV2Client "HosterCore/pkg/api_v2_client"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// Overrides the default contexts file location
+const CONTEXTS_FILE_ENV = "HOSTER_CONTEXTS_FILE"
+
+// Used when the --context flag is not set
+const CONTEXT_ENV = "HOSTER_CONTEXT"
+
+// A remote Hoster node, managed through it's REST API v2
+type Context struct {
+	Name       string `json:"name"`
+	Endpoint   string `json:"endpoint"` // e.g. https://10.0.0.5:3000
+	User       string `json:"user,omitempty"`
+	Password   string `json:"password,omitempty"`
+	Token      string `json:"token,omitempty"` // takes precedence over the user and password
+	CACertFile string `json:"ca_cert_file,omitempty"`
+	Insecure   bool   `json:"insecure,omitempty"` // skip the TLS certificate verification
+}
+
+type Config struct {
+	Contexts []Context `json:"contexts"`
+}
+
+var reContextName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Returns the contexts file location, which is ~/.config/hoster/contexts.json by default.
+func FilePath() (string, error) {
+	if v := os.Getenv(CONTEXTS_FILE_ENV); len(v) > 0 {
+		return v, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "hoster", "contexts.json"), nil
+}
+
+// Reads the contexts file. A missing file is not an error, an empty config is returned instead.
+func Load() (r Config, e error) {
+	r.Contexts = []Context{}
+
+	filePath, err := FilePath()
+	if err != nil {
+		e = err
+		return
+	}
+
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		e = err
+		return
+	}
+
+	err = json.Unmarshal(data, &r)
+	if err != nil {
+		e = fmt.Errorf("could not parse %s: %s", filePath, err.Error())
+		return
+	}
+	if r.Contexts == nil {
+		r.Contexts = []Context{}
+	}
+
+	return
+}
+
+// Writes the contexts file. The file contains credentials, so it's only readable by the owner.
+func Save(c Config) error {
+	filePath, err := FilePath()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), 0700)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(c.Contexts, func(i, j int) bool { return c.Contexts[i].Name < c.Contexts[j].Name })
+	data, err := json.MarshalIndent(c, "", "   ")
+	if err != nil {
+		return err
+	}
+
+	return AtomicFile.WriteFile(filePath, data, 0600)
+}
+
+// Returns a single context by it's name.
+func Get(name string) (r Context, e error) {
+	c, err := Load()
+	if err != nil {
+		e = err
+		return
+	}
+
+	for _, v := range c.Contexts {
+		if v.Name == name {
+			r = v
+			return
+		}
+	}
+
+	e = fmt.Errorf("context not found: %s (use `hoster context list` to see the available contexts)", name)
+	return
+}
+
+// Adds a new context, or replaces the existing one with the same name.
+func Add(ctx Context) error {
+	err := ctx.Validate()
+	if err != nil {
+		return err
+	}
+
+	c, err := Load()
+	if err != nil {
+		return err
+	}
+
+	contexts := []Context{ctx}
+	for _, v := range c.Contexts {
+		if v.Name != ctx.Name {
+			contexts = append(contexts, v)
+		}
+	}
+	c.Contexts = contexts
+
+	return Save(c)
+}
+
+// Removes the context by it's name.
+func Remove(name string) error {
+	c, err := Load()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	contexts := []Context{}
+	for _, v := range c.Contexts {
+		if v.Name == name {
+			found = true
+			continue
+		}
+		contexts = append(contexts, v)
+	}
+	if !found {
+		return fmt.Errorf("context not found: %s", name)
+	}
+	c.Contexts = contexts
+
+	return Save(c)
+}
+
+func (ctx Context) Validate() error {
+	if !reContextName.MatchString(ctx.Name) {
+		return fmt.Errorf("invalid context name: '%s', only letters, numbers, dots, dashes and underscores are allowed", ctx.Name)
+	}
+
+	u, err := url.Parse(ctx.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) < 1 {
+		return fmt.Errorf("invalid endpoint: '%s', use the full URL, e.g. https://10.0.0.5:3000", ctx.Endpoint)
+	}
+
+	if len(ctx.Token) < 1 && (len(ctx.User) < 1 || len(ctx.Password) < 1) {
+		return errors.New("either a token, or a user and a password must be set")
+	}
+
+	if len(ctx.CACertFile) > 0 {
+		_, err := os.Stat(ctx.CACertFile)
+		if err != nil {
+			return fmt.Errorf("CA certificate file is not accessible: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Returns the REST API v2 client for the context. Extra options are applied after the context ones.
+func (ctx Context) Client(extra ...ApiV2Client.Option) (*ApiV2Client.Client, error) {
+	options := []ApiV2Client.Option{}
+	if len(ctx.Token) > 0 {
+		options = append(options, ApiV2Client.WithToken(ctx.Token))
+	} else {
+		options = append(options, ApiV2Client.WithBasicAuth(ctx.User, ctx.Password))
+	}
+	if len(ctx.CACertFile) > 0 {
+		options = append(options, ApiV2Client.WithCACertFile(ctx.CACertFile))
+	}
+	if ctx.Insecure {
+		options = append(options, ApiV2Client.WithInsecureSkipVerify())
+	}
+	options = append(options, extra...)
+
+	return ApiV2Client.New(ctx.Endpoint, options...)
+}
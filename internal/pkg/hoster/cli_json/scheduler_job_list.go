@@ -16,21 +16,7 @@ func GenerateSchedulerJobInfo(jobID string, pretty bool) error {
 		return err
 	}
 
-	var out []byte
-	if pretty {
-		out, err = json.MarshalIndent(resp, "", "   ")
-		if err != nil {
-			return err
-		}
-	} else {
-		out, err = json.Marshal(resp)
-		if err != nil {
-			return err
-		}
-	}
-
-	fmt.Println(string(out))
-	return nil
+	return PrintSchedulerJson(resp, pretty)
 }
 
 func GenerateSchedulerJson(pretty bool) error {
@@ -39,7 +25,13 @@ func GenerateSchedulerJson(pretty bool) error {
 		return err
 	}
 
+	return PrintSchedulerJson(resp, pretty)
+}
+
+// Prints a scheduler job (or a list of jobs), which could be received from the local scheduler or a remote node.
+func PrintSchedulerJson(resp interface{}, pretty bool) error {
 	var out []byte
+	var err error
 	if pretty {
 		out, err = json.MarshalIndent(resp, "", "   ")
 		if err != nil {
@@ -0,0 +1,265 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterTables
+
+import (
+	"HosterCore/internal/pkg/byteconversion"
+	HosterFleet "HosterCore/internal/pkg/hoster/fleet"
+	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aquasecurity/table"
+)
+
+func RenderFleetVmsTable(unix bool, vms []HosterFleet.Vm) {
+	t := newFleetTable(unix, "Hoster Fleet VMs",
+		"#",
+		"Node\nName",
+		"VM\nName",
+		"VM\nStatus",
+		"CPU\nSockets",
+		"CPU\nCores",
+		"VM\nMemory",
+		"Main IP\nAddress",
+		"OS\nType",
+		"VM\nUptime",
+		"OS Disk\n(Used/Total)",
+		"VM\nDescription",
+	)
+
+	for i, v := range vms {
+		row := HosterVmUtils.ListTableFromApi([]HosterVmUtils.VmApi{v.VmApi})[0]
+		osType := row.OsComment
+		uptime := row.VmUptime
+		if unix {
+			osType = row.OsType
+			uptime = row.VmUptimeNoSpaces
+		}
+
+		t.AddRow(
+			fmt.Sprintf("%d", i+1),
+			v.Node,
+			row.VmName,
+			row.VmStatus,
+			fmt.Sprintf("%d", row.CPUSockets),
+			fmt.Sprintf("%d", row.CPUCores),
+			row.VmMemory,
+			row.MainIpAddress,
+			osType,
+			uptime,
+			row.DiskUsedTotal,
+			row.VmDescription,
+		)
+	}
+
+	t.Render()
+}
+
+func RenderFleetJailsTable(unix bool, jails []HosterFleet.Jail) {
+	t := newFleetTable(unix, "Hoster Fleet Jails",
+		"#",
+		"Node\nName",
+		"Jail\nName",
+		"Jail\nStatus",
+		"CPU\nLimit",
+		"RAM\nLimit",
+		"Main IP\nAddress",
+		"FreeBSD\nRelease",
+		"Jail\nUptime",
+		"Storage\n(Used/Available)",
+		"Jail\nDescription",
+	)
+
+	for i, v := range jails {
+		row := HosterJailUtils.ExtendedTableFromApi([]HosterJailUtils.JailApi{v.JailApi})[0]
+		t.AddRow(
+			fmt.Sprintf("%d", i+1),
+			v.Node,
+			row.Name,
+			row.Status,
+			row.CPULimit,
+			row.RAMLimit,
+			row.MainIpAddress,
+			row.Release,
+			row.Uptime,
+			row.StorageUsed+"/"+row.StorageAvailable,
+			row.Description,
+		)
+	}
+
+	t.Render()
+}
+
+// Per-node capacity, followed by the fleet totals
+func RenderFleetHostsTable(unix bool, hosts []HosterFleet.Host) {
+	t := newFleetTable(unix, "Hoster Fleet Capacity",
+		"Node\nName",
+		"Hostname",
+		"VMs\n(Live/All)",
+		"Backup\nVMs",
+		"Host\nCPUs",
+		"vCPU:pCPU\nRatio",
+		"RAM\n(Used/Total)",
+		"Storage\n(Used/Total)",
+		"ZPOOL\nHealth",
+		"System\nUptime",
+	)
+
+	liveVms, allVms, backupVms, cpus := 0, 0, 0, 0
+	var ramUsed, ramTotal, storageUsed, storageTotal uint64
+	unhealthy := 0
+	for _, v := range hosts {
+		info := v.Info
+
+		hostStorageUsed, hostStorageTotal := uint64(0), uint64(0)
+		zpoolsHealthy := "Healthy"
+		for _, vv := range info.ZpoolList {
+			hostStorageUsed += vv.AllocatedBytes
+			hostStorageTotal += vv.SizeBytes
+			if !vv.Healthy {
+				zpoolsHealthy = "Unhealthy!"
+			}
+		}
+		if zpoolsHealthy != "Healthy" {
+			unhealthy += 1
+		}
+
+		liveVms += info.LiveVms
+		allVms += info.AllVms
+		backupVms += info.BackupVms
+		cpus += info.CpuInfo.OverallCpus
+		ramUsed += info.RamInfo.RamUsedBytes
+		ramTotal += info.RamInfo.RamOverallBytes
+		storageUsed += hostStorageUsed
+		storageTotal += hostStorageTotal
+
+		t.AddRow(
+			v.Node,
+			info.Hostname,
+			fmt.Sprintf("%d/%d", info.LiveVms, info.AllVms),
+			fmt.Sprintf("%d", info.BackupVms),
+			fmt.Sprintf("%d", info.CpuInfo.OverallCpus),
+			fmt.Sprintf("%.2f:1", info.VCPU2PCURatio),
+			info.RamInfo.RamUsedHuman+"/"+info.RamInfo.RamOverallHuman,
+			byteconversion.BytesToHuman(hostStorageUsed)+"/"+byteconversion.BytesToHuman(hostStorageTotal),
+			zpoolsHealthy,
+			info.SystemUptime,
+		)
+	}
+
+	fleetHealth := "Healthy"
+	if unhealthy > 0 {
+		fleetHealth = fmt.Sprintf("%d Unhealthy!", unhealthy)
+	}
+	t.AddRow(
+		"TOTAL",
+		fmt.Sprintf("%d nodes", len(hosts)),
+		fmt.Sprintf("%d/%d", liveVms, allVms),
+		fmt.Sprintf("%d", backupVms),
+		fmt.Sprintf("%d", cpus),
+		"-",
+		byteconversion.BytesToHuman(ramUsed)+"/"+byteconversion.BytesToHuman(ramTotal),
+		byteconversion.BytesToHuman(storageUsed)+"/"+byteconversion.BytesToHuman(storageTotal),
+		fleetHealth,
+		"-",
+	)
+
+	t.Render()
+}
+
+func RenderFleetFailedJobsTable(unix bool, jobs []HosterFleet.FailedJob) {
+	t := newFleetTable(unix, "Hoster Fleet Failed Jobs",
+		"#",
+		"Node\nName",
+		"Resource\nName",
+		"Resource\nType",
+		"Job\nULID",
+		"Job\nType",
+		"Time\nAdded",
+		"Job\nError",
+	)
+
+	for i, v := range jobs {
+		resName := v.Snapshot.ResName
+		if len(v.Replication.ResName) > 0 {
+			resName = v.Replication.ResName
+		}
+
+		t.AddRow(
+			fmt.Sprintf("%d", i+1),
+			v.Node,
+			resName,
+			v.ResType,
+			v.JobId,
+			v.JobType,
+			time.Unix(v.TimeAdded, 0).Format(time.RFC3339),
+			v.JobError,
+		)
+	}
+
+	t.Render()
+}
+
+func RenderFleetVmLocationsTable(unix bool, locations []HosterFleet.VmLocation) {
+	t := newFleetTable(unix, "Hoster Fleet VM Locations",
+		"#",
+		"Node\nName",
+		"VM\nName",
+		"Copy\nRole",
+		"VM\nRunning",
+		"Parent\nHost",
+		"ZFS\nDataset",
+	)
+
+	for i, v := range locations {
+		t.AddRow(
+			fmt.Sprintf("%d", i+1),
+			v.Node,
+			v.VmName,
+			v.Role,
+			fmt.Sprintf("%v", v.Running),
+			v.ParentHost,
+			v.Dataset,
+		)
+	}
+
+	t.Render()
+}
+
+func newFleetTable(unix bool, title string, headers ...string) *table.Table {
+	var t = table.New(os.Stdout)
+
+	if unix {
+		t.SetDividers(table.Dividers{
+			ALL: " ",
+			NES: " ",
+			NSW: " ",
+			NEW: " ",
+			ESW: " ",
+			NE:  " ",
+			NW:  " ",
+			SW:  " ",
+			ES:  " ",
+			EW:  " ",
+			NS:  " ",
+		})
+		t.SetRowLines(false)
+		t.SetBorderTop(false)
+		t.SetBorderBottom(false)
+	} else {
+		t.SetHeaders(title)
+		t.SetHeaderColSpans(0, len(headers))
+		t.AddHeaders(headers...)
+
+		t.SetLineStyle(table.StyleBrightCyan)
+		t.SetDividers(table.UnicodeRoundedDividers)
+		t.SetHeaderStyle(table.StyleBold)
+	}
+
+	return t
+}
@@ -18,6 +18,12 @@ func GenerateJailsTable(unixStyleTable bool) error {
 		return err
 	}
 
+	RenderJailsTable(unixStyleTable, jailList)
+	return nil
+}
+
+// Renders the Jail table rows, which could be generated locally or received from a remote node.
+func RenderJailsTable(unixStyleTable bool, jailList []HosterJailUtils.JailListExtendedTable) {
 	var t = table.New(os.Stdout)
 	t.SetAlignment(
 		table.AlignRight,  // ID
@@ -87,5 +93,4 @@ func GenerateJailsTable(unixStyleTable bool) error {
 	}
 
 	t.Render()
-	return nil
 }
@@ -6,6 +6,7 @@ package HosterTables
 
 import (
 	SchedulerClient "HosterCore/internal/app/scheduler/client"
+	SchedulerUtils "HosterCore/internal/app/scheduler/utils"
 	"HosterCore/internal/pkg/byteconversion"
 	"fmt"
 	"os"
@@ -20,6 +21,12 @@ func GenerateJobsTable(unix bool) error {
 		return err
 	}
 
+	RenderJobsTable(unix, jobs)
+	return nil
+}
+
+// Renders the scheduler jobs, which could be received from the local scheduler or a remote node.
+func RenderJobsTable(unix bool, jobs []SchedulerUtils.Job) {
 	var t = table.New(os.Stdout)
 	t.SetAlignment(
 		table.AlignRight,  // ID number
@@ -124,5 +131,4 @@ func GenerateJobsTable(unix bool) error {
 	}
 
 	t.Render()
-	return nil
 }
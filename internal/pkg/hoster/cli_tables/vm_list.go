@@ -5,6 +5,7 @@
 package HosterTables
 
 import (
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
 	"fmt"
 	"os"
@@ -12,12 +13,18 @@ import (
 	"github.com/aquasecurity/table"
 )
 
-func GenerateVMsTable(unix bool) error {
-	vms, err := HosterVmUtils.ListAllTable()
+func GenerateVMsTable(unix bool, q HosterListQuery.Query) error {
+	vms, err := HosterVmUtils.ListAllTable(q)
 	if err != nil {
 		return err
 	}
 
+	RenderVMsTable(unix, vms)
+	return nil
+}
+
+// Renders the VM table rows, which could be generated locally or received from a remote node.
+func RenderVMsTable(unix bool, vms []HosterVmUtils.ListTable) {
 	var t = table.New(os.Stdout)
 	t.SetAlignment(table.AlignRight, //ID
 		table.AlignLeft,   // VM Name
@@ -107,5 +114,4 @@ func GenerateVMsTable(unix bool) error {
 	}
 
 	t.Render()
-	return nil
 }
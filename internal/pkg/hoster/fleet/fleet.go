@@ -0,0 +1,109 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterFleet
+
+import (
+	HosterCliContext "HosterCore/internal/pkg/hoster/cli_context"
+	ApiV2Client "HosterCore/pkg/api_v2_client"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// How many nodes are queried at the same time
+const MAX_PARALLEL = 16
+
+// Slow or unreachable nodes must not block the whole fleet view
+const NODE_TIMEOUT = 15 * time.Second
+
+// A node that couldn't be queried, the rest of the fleet is still reported
+type NodeError struct {
+	Node  string `json:"node"`
+	Error string `json:"error"`
+}
+
+// Returns the fleet nodes: the named contexts, or all of the saved contexts if no names were given.
+func Nodes(names []string) (r []HosterCliContext.Context, e error) {
+	c, err := HosterCliContext.Load()
+	if err != nil {
+		e = err
+		return
+	}
+
+	if len(names) < 1 {
+		r = c.Contexts
+	} else {
+		for _, name := range names {
+			found := false
+			for _, v := range c.Contexts {
+				if v.Name == name {
+					r = append(r, v)
+					found = true
+					break
+				}
+			}
+			if !found {
+				e = fmt.Errorf("context not found: %s", name)
+				return
+			}
+		}
+	}
+
+	if len(r) < 1 {
+		e = errors.New("no nodes configured, add them using `hoster context add`")
+		return
+	}
+
+	return
+}
+
+type nodeResult[T any] struct {
+	node   string
+	result T
+	err    error
+}
+
+// Executes the call on every node in parallel. Results are returned in the same order as the nodes.
+func queryNodes[T any](nodes []HosterCliContext.Context, call func(ctx context.Context, client *ApiV2Client.Client) (T, error)) (r []nodeResult[T]) {
+	r = make([]nodeResult[T], len(nodes))
+	sem := make(chan struct{}, MAX_PARALLEL)
+	wg := sync.WaitGroup{}
+
+	for i, node := range nodes {
+		r[i].node = node.Name
+
+		wg.Add(1)
+		go func(i int, node HosterCliContext.Context) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			client, err := node.Client(ApiV2Client.WithTimeout(NODE_TIMEOUT), ApiV2Client.WithRetries(1, ApiV2Client.DEFAULT_RETRY_WAIT))
+			if err != nil {
+				r[i].err = err
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), NODE_TIMEOUT)
+			defer cancel()
+			r[i].result, r[i].err = call(ctx, client)
+		}(i, node)
+	}
+
+	wg.Wait()
+	return
+}
+
+func nodeErrors[T any](results []nodeResult[T]) (r []NodeError) {
+	r = []NodeError{}
+	for _, v := range results {
+		if v.err != nil {
+			r = append(r, NodeError{Node: v.node, Error: v.err.Error()})
+		}
+	}
+	return
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterFleet
+
+import (
+	HosterCliContext "HosterCore/internal/pkg/hoster/cli_context"
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
+	ApiV2Client "HosterCore/pkg/api_v2_client"
+	ApiV2Types "HosterCore/pkg/api_v2_types"
+	"context"
+	"net/url"
+	"sort"
+)
+
+const (
+	ROLE_PRIMARY = "primary"
+	ROLE_BACKUP  = "backup"
+)
+
+type Vm struct {
+	Node string `json:"node"`
+	ApiV2Types.VmApi
+}
+
+type Jail struct {
+	Node string `json:"node"`
+	ApiV2Types.JailApi
+}
+
+type Host struct {
+	Node string              `json:"node"`
+	Info ApiV2Types.HostInfo `json:"host_info"`
+}
+
+type FailedJob struct {
+	Node string `json:"node"`
+	ApiV2Types.SchedulerJob
+}
+
+// A single copy of the VM: the primary (running) one, or one of the ZFS replicated backups
+type VmLocation struct {
+	Node       string `json:"node"`
+	VmName     string `json:"vm_name"`
+	Role       string `json:"role"` // primary or backup
+	Running    bool   `json:"running"`
+	ParentHost string `json:"parent_host"`
+	Dataset    string `json:"dataset"`
+}
+
+// Returns the VMs from all of the nodes.
+// Filters are sent to each node, while the sorting and pagination are applied once, to the merged list.
+func ListVms(nodes []HosterCliContext.Context, query HosterListQuery.Query) (r []Vm, errs []NodeError, e error) {
+	// Sort fields are checked before any of the nodes get queried
+	_, _, e = HosterListQuery.Apply([]Vm{}, query)
+	if e != nil {
+		return
+	}
+
+	results := queryNodes(nodes, func(ctx context.Context, client *ApiV2Client.Client) ([]ApiV2Types.VmApi, error) {
+		vms, _, err := client.VmList(ctx, nodeFilters(query))
+		return vms, err
+	})
+
+	merged := []Vm{}
+	for _, v := range results {
+		for _, vv := range v.result {
+			merged = append(merged, Vm{Node: v.node, VmApi: vv})
+		}
+	}
+
+	r, _, e = HosterListQuery.Apply(merged, query)
+	return r, nodeErrors(results), e
+}
+
+// Returns the Jails from all of the nodes.
+// Filters are sent to each node, while the sorting and pagination are applied once, to the merged list.
+func ListJails(nodes []HosterCliContext.Context, query HosterListQuery.Query) (r []Jail, errs []NodeError, e error) {
+	// Sort fields are checked before any of the nodes get queried
+	_, _, e = HosterListQuery.Apply([]Jail{}, query)
+	if e != nil {
+		return
+	}
+
+	results := queryNodes(nodes, func(ctx context.Context, client *ApiV2Client.Client) ([]ApiV2Types.JailApi, error) {
+		jails, _, err := client.JailList(ctx, nodeFilters(query))
+		return jails, err
+	})
+
+	merged := []Jail{}
+	for _, v := range results {
+		for _, vv := range v.result {
+			merged = append(merged, Jail{Node: v.node, JailApi: vv})
+		}
+	}
+
+	r, _, e = HosterListQuery.Apply(merged, query)
+	return r, nodeErrors(results), e
+}
+
+// Only the filters are sent to the nodes, a per-node limit would cut the items off before the fleet-wide sort
+func nodeFilters(query HosterListQuery.Query) url.Values {
+	query.Sort = nil
+	query.Offset = 0
+	query.Limit = 0
+	query.Fields = nil
+	return query.Values()
+}
+
+// Returns the host information (capacity, usage, VM counts) for all of the nodes.
+func ListHosts(nodes []HosterCliContext.Context) (r []Host, errs []NodeError) {
+	results := queryNodes(nodes, func(ctx context.Context, client *ApiV2Client.Client) (ApiV2Types.HostInfo, error) {
+		return client.HostInfo(ctx)
+	})
+
+	r = []Host{}
+	for _, v := range results {
+		if v.err == nil {
+			r = append(r, Host{Node: v.node, Info: v.result})
+		}
+	}
+
+	return r, nodeErrors(results)
+}
+
+// Returns the failed scheduler jobs from all of the nodes, newest first.
+func ListFailedJobs(nodes []HosterCliContext.Context) (r []FailedJob, errs []NodeError) {
+	results := queryNodes(nodes, func(ctx context.Context, client *ApiV2Client.Client) ([]ApiV2Types.SchedulerJob, error) {
+		return client.SchedulerGetJobs(ctx)
+	})
+
+	r = []FailedJob{}
+	for _, v := range results {
+		for _, vv := range v.result {
+			if vv.JobFailed {
+				r = append(r, FailedJob{Node: v.node, SchedulerJob: vv})
+			}
+		}
+	}
+	sort.SliceStable(r, func(i, j int) bool { return r[i].TimeAdded > r[j].TimeAdded })
+
+	return r, nodeErrors(results)
+}
+
+// Finds all copies of the VM in the fleet, the primary one comes first.
+func LocateVm(nodes []HosterCliContext.Context, vmName string) (r []VmLocation, errs []NodeError) {
+	// Without the sort fields the query can't fail
+	vms, errs, _ := ListVms(nodes, HosterListQuery.Query{Name: vmName})
+
+	r = []VmLocation{}
+	for _, v := range vms {
+		if v.Name != vmName {
+			continue
+		}
+
+		role := ROLE_PRIMARY
+		if v.Backup {
+			role = ROLE_BACKUP
+		}
+		r = append(r, VmLocation{
+			Node:       v.Node,
+			VmName:     v.Name,
+			Role:       role,
+			Running:    v.Running,
+			ParentHost: v.ParentHost,
+			Dataset:    v.Simple.DsName,
+		})
+	}
+	sort.SliceStable(r, func(i, j int) bool { return r[i].Role == ROLE_PRIMARY && r[j].Role != ROLE_PRIMARY })
+
+	return r, errs
+}
@@ -31,6 +31,23 @@ type HostConfig struct {
 	DnsServers        []string          `json:"dns_servers,omitempty"`
 	DnsStaticRecords  []DnsStaticRecord `json:"dns_static_records,omitempty"`
 	HostSSHKeys       []HostConfigKey   `json:"host_ssh_keys"`
+	AdmissionPolicy   *AdmissionPolicy  `json:"admission_policy,omitempty"`
+}
+
+// Host-level limits, checked before a new resource is deployed (or cloned), and before the CPU/RAM settings are changed.
+// A zero value disables the respective policy.
+type AdmissionPolicy struct {
+	MaxVcpuRatio        float64      `json:"max_vcpu_ratio,omitempty"`         // Max vCPU:pCPU ratio, e.g. 4 for 4:1
+	MaxRamCommitPercent int          `json:"max_ram_commit_percent,omitempty"` // Max RAM committed to VMs and Jails, in % of the host RAM
+	MinPoolFreePercent  int          `json:"min_pool_free_percent,omitempty"`  // Min free space left on the target pool, in %
+	OwnerQuotas         []OwnerQuota `json:"owner_quotas,omitempty"`
+}
+
+type OwnerQuota struct {
+	Owner    string `json:"owner"`
+	MaxVms   int    `json:"max_vms,omitempty"`
+	MaxVcpus int    `json:"max_vcpus,omitempty"`
+	MaxRam   string `json:"max_ram,omitempty"` // e.g. 64G
 }
 
 const confFileName = "host_config.json"
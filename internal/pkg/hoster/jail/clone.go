@@ -5,9 +5,11 @@
 package HosterJail
 
 import (
+	HosterAdmission "HosterCore/internal/pkg/hoster/admission"
 	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
 	zfsutils "HosterCore/internal/pkg/zfs_utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -33,7 +35,22 @@ func Clone(jailName string, newJailName string, snapshotName string) error {
 		}
 	}
 	if !jailFound {
-		return fmt.Errorf("jail doesn't exist")
+		return fmt.Errorf("%w: %s", HosterJailUtils.ErrJailDoesntExist, jailName)
+	}
+
+	jailConf, err := HosterJailUtils.GetJailConfig(jailInfo.Mountpoint + "/" + jailName)
+	if err != nil {
+		return err
+	}
+	err = HosterAdmission.Check(HosterAdmission.Request{
+		Action:       HosterAdmission.ACTION_CLONE,
+		ResourceType: HosterAdmission.RESOURCE_JAIL,
+		ResourceName: newJailName,
+		Ram:          jailConf.RAMLimit,
+		Dataset:      jailInfo.DsName,
+	})
+	if err != nil {
+		return err
 	}
 
 	snaps, err := zfsutils.SnapshotListAll()
@@ -57,7 +74,7 @@ func Clone(jailName string, newJailName string, snapshotName string) error {
 		}
 	}
 	if !snapFound {
-		return fmt.Errorf("snapshot doesn't exist")
+		return ErrorMappings.NewError(ErrorMappings.CODE_SNAPSHOT_NOT_FOUND, "snapshot doesn't exist: "+snapshotName)
 	}
 
 	out, err := exec.Command("zfs", "clone", snapshotName, jailInfo.DsName+"/"+newJailName).CombinedOutput()
@@ -3,6 +3,7 @@ package HosterJail
 import (
 	FreeBSDOsInfo "HosterCore/internal/pkg/freebsd/info"
 	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	HosterAdmission "HosterCore/internal/pkg/hoster/admission"
 	HosterHost "HosterCore/internal/pkg/hoster/host"
 	HosterHostUtils "HosterCore/internal/pkg/hoster/host/utils"
 	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
@@ -74,6 +75,17 @@ func Deploy(input DeployInput) error {
 		return err
 	}
 
+	err = HosterAdmission.Check(HosterAdmission.Request{
+		Action:       HosterAdmission.ACTION_DEPLOY,
+		ResourceType: HosterAdmission.RESOURCE_JAIL,
+		ResourceName: input.JailName,
+		Ram:          jailConfig.RAMLimit,
+		Dataset:      input.DsParent,
+	})
+	if err != nil {
+		return err
+	}
+
 	err = HosterJailUtils.ZfsTemplateClone(input.JailName, input.DsParent, input.Release)
 	if err != nil {
 		return err
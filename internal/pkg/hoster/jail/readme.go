@@ -53,6 +53,6 @@ func GetReadme(jailName string) (r string, e error) {
 		}
 	}
 
-	e = fmt.Errorf("jail was not found")
+	e = fmt.Errorf("%w: %s", HosterJailUtils.ErrJailDoesntExist, jailName)
 	return
 }
@@ -43,9 +43,9 @@ func Start(jailName string) error {
 		return err
 	}
 	if running {
-		errorValue := "Jail is already running: " + jailName
-		log.ErrorToFile(errorValue)
-		return errors.New(errorValue)
+		err = fmt.Errorf("%w: %s", HosterJailUtils.ErrJailIsRunning, jailName)
+		log.ErrorToFile(err.Error())
+		return err
 	}
 
 	// Check if Jail exists and get it's dataset configuration
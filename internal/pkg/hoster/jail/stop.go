@@ -25,9 +25,9 @@ func Stop(jailName string) error {
 		return err
 	}
 	if !running {
-		errorValue := "Jail is already offline: " + jailName
-		log.ErrorToFile(errorValue)
-		return errors.New(errorValue)
+		err = fmt.Errorf("%w: %s", HosterJailUtils.ErrJailIsStopped, jailName)
+		log.ErrorToFile(err.Error())
+		return err
 	}
 
 	// Check if Jail exists and get it's dataset configuration
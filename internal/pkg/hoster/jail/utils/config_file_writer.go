@@ -5,8 +5,9 @@
 package HosterJailUtils
 
 import (
+	AtomicFile "HosterCore/internal/pkg/atomic_file"
+	"context"
 	"encoding/json"
-	"os"
 )
 
 // Function that writes a new config to the disk.
@@ -14,23 +15,17 @@ import (
 //
 // e.g. /tank/vm-encrypted/test-jail-1/jail_config.json
 func ConfigFileWriter(conf JailConfig, confLocation string) error {
-	jsonOutput, err := json.MarshalIndent(conf, "", "   ")
-	if err != nil {
-		return err
-	}
-
-	// Open the file in write-only mode, truncating (overwriting) it if it already exists
-	file, err := os.OpenFile(confLocation, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+	return ConfigFileWriterContext(context.Background(), conf, confLocation)
+}
 
-	// Write data to the file
-	_, err = file.Write(jsonOutput)
+// Same as ConfigFileWriter(), but if the context carries the config revision expected by the API client (If-Match),
+// the config is only written if it hasn't been changed since (AtomicFile.ErrRevisionMismatch is returned otherwise).
+func ConfigFileWriterContext(ctx context.Context, conf JailConfig, confLocation string) error {
+	jsonOutput, err := json.MarshalIndent(conf, "", "   ")
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// Lock the config folder, and replace the file atomically, to avoid partially written configs
+	return AtomicFile.WriteFileContext(ctx, confLocation, jsonOutput, 0644)
 }
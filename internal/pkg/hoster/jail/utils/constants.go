@@ -4,8 +4,21 @@
 
 package HosterJailUtils
 
+import ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+
 const JAIL_CONFIG_NAME = "jail_config.json"
 const JAIL_ROOT_FOLDER = "root_folder"
 const JAIL_TEMP_RUNTIME = "jail_temp_runtime.conf"
 const JAIL_AUDIT_LOG_LOCATION = "/var/log/hoster_audit_jail.log"
 const JAIL_CACHE_FILE = "/var/run/hoster_jail_cache.json"
+
+const ERRTXT_JAIL_IS_RUNNING = "Jail is already running"
+const ERRTXT_JAIL_IS_STOPPED = "Jail is already offline"
+const ERRTXT_JAIL_DOESNT_EXIST = "Jail doesn't exist"
+
+// Sentinel errors with the API error code attached, check them using errors.Is
+var (
+	ErrJailIsRunning   = ErrorMappings.NewError(ErrorMappings.CODE_JAIL_RUNNING, ERRTXT_JAIL_IS_RUNNING)
+	ErrJailIsStopped   = ErrorMappings.NewError(ErrorMappings.CODE_JAIL_NOT_RUNNING, ERRTXT_JAIL_IS_STOPPED)
+	ErrJailDoesntExist = ErrorMappings.NewError(ErrorMappings.CODE_JAIL_NOT_FOUND, ERRTXT_JAIL_DOESNT_EXIST)
+)
@@ -1,8 +1,11 @@
 package HosterJailUtils
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
-func UpdateDescription(jailName string, description string) error {
+func UpdateDescription(ctx context.Context, jailName string, description string) error {
 	jail, err := InfoJsonApi(jailName)
 	if err != nil {
 		return err
@@ -17,7 +20,7 @@ func UpdateDescription(jailName string, description string) error {
 	}
 
 	jail.JailConfig.Description = description
-	err = ConfigFileWriter(jail.JailConfig, jailConfLoc)
+	err = ConfigFileWriterContext(ctx, jail.JailConfig, jailConfLoc)
 	if err != nil {
 		return err
 	}
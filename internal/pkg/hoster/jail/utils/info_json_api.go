@@ -92,6 +92,6 @@ func InfoJsonApi(jailName string) (r JailApi, e error) {
 		return
 	}
 
-	e = fmt.Errorf("jail was not found")
+	e = fmt.Errorf("%w: %s", ErrJailDoesntExist, jailName)
 	return
 }
@@ -7,6 +7,7 @@ package HosterJailUtils
 import (
 	"HosterCore/internal/pkg/byteconversion"
 	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
 	HosterZfs "HosterCore/internal/pkg/hoster/zfs"
 )
 
@@ -79,3 +80,15 @@ func ListJsonApi() (r []JailApi, e error) {
 
 	return
 }
+
+// Used to filter the Jail lists (REST API and CLI).
+func (v JailApi) ListAttributes() HosterListQuery.Attributes {
+	return HosterListQuery.Attributes{
+		Name:       v.Name,
+		Dataset:    v.Simple.DsName,
+		Tags:       v.Tags,
+		Running:    v.Running,
+		Production: v.Production,
+		Backup:     v.Backup,
+	}
+}
@@ -5,9 +5,6 @@
 package HosterJailUtils
 
 import (
-	"HosterCore/internal/pkg/byteconversion"
-	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
-	HosterZfs "HosterCore/internal/pkg/hoster/zfs"
 	"fmt"
 )
 
@@ -33,46 +30,26 @@ const JAIL_EMOJI_ENCRYPTED = "🔒"
 const JAIL_EMOJI_PRODUCTION = "🔁"
 
 func ListAllExtendedTable() (r []JailListExtendedTable, e error) {
-	jails, err := ListAllSimple()
+	jails, err := ListJsonApi()
 	if err != nil {
 		e = err
 		return
 	}
 
-	onlineJails, err := GetRunningJails()
-	if err != nil {
-		e = err
-		return
-	}
-
-	zfsSpace, err := HosterZfs.ListUsedAndAvailableSpace()
-	if err != nil {
-		e = err
-		return
-	}
+	r = ExtendedTableFromApi(jails)
+	return
+}
 
-	hostname, _ := FreeBSDsysctls.SysctlKernHostname()
+// Converts the Jail API list (local, or received from a remote node) into the table rows.
+func ExtendedTableFromApi(jails []JailApi) (r []JailListExtendedTable) {
 	for _, v := range jails {
 		jailStruct := JailListExtendedTable{}
-		jailDsFolder := v.MountPoint.Mountpoint + "/" + v.JailName
+		jailStruct.Name = v.Name
+		jailStruct.Running = v.Running
+		jailStruct.Backup = v.Backup
 
-		jailConfig, err := GetJailConfig(jailDsFolder)
-		if err != nil {
-			// fmt.Println(err)
-			continue
-		}
-
-		jailStruct.Name = v.JailName
-
-		if jailConfig.Parent == hostname {
-			for _, vv := range onlineJails {
-				if v.JailName == vv.Name {
-					jailStruct.Running = true
-				}
-			}
-		} else {
+		if jailStruct.Backup {
 			jailStruct.Status += JAIL_EMOJI_BACKUP
-			jailStruct.Backup = true
 		}
 
 		if jailStruct.Running {
@@ -83,38 +60,28 @@ func ListAllExtendedTable() (r []JailListExtendedTable, e error) {
 			}
 		}
 
-		if v.MountPoint.Encrypted {
+		if v.Encrypted {
 			jailStruct.Status += JAIL_EMOJI_ENCRYPTED
 		}
 
-		if jailConfig.Production {
+		if v.Production {
 			jailStruct.Status += JAIL_EMOJI_PRODUCTION
 		}
 
-		jailStruct.CPULimit = fmt.Sprintf("%d%%", jailConfig.CPULimitPercent)
-		jailStruct.RAMLimit = jailConfig.RAMLimit
-		jailStruct.MainIpAddress = jailConfig.IPAddress
-
-		release, err := ReleaseVersion(jailDsFolder)
-		if err != nil {
-			// fmt.Println(err)
-			continue
-		}
-		jailStruct.Release = release
-		jailStruct.Uptime = GetUptimeHuman(v.JailName)
+		jailStruct.CPULimit = fmt.Sprintf("%d%%", v.CPULimitPercent)
+		jailStruct.RAMLimit = v.RAMLimit
+		jailStruct.MainIpAddress = v.IPAddress
+		jailStruct.Release = v.Release
+		jailStruct.Uptime = v.Uptime
 
 		if jailStruct.Backup {
-			jailStruct.Description = "💾 Backup from " + jailConfig.Parent
+			jailStruct.Description = "💾 Backup from " + v.Parent
 		} else {
-			jailStruct.Description = jailConfig.Description
+			jailStruct.Description = v.Description
 		}
 
-		for _, vv := range zfsSpace {
-			if v.MountPoint.DsName+"/"+v.JailName == vv.Name {
-				jailStruct.StorageUsed = byteconversion.BytesToHuman(vv.Used)
-				jailStruct.StorageAvailable = byteconversion.BytesToHuman(vv.Available)
-			}
-		}
+		jailStruct.StorageUsed = v.SpaceUsedHuman
+		jailStruct.StorageAvailable = v.SpaceFreeHuman
 
 		r = append(r, jailStruct)
 	}
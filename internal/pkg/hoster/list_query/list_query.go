@@ -0,0 +1,452 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterListQuery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Resource attributes the lists can be filtered by.
+type Attributes struct {
+	Name       string
+	Owner      string
+	Dataset    string
+	Tags       []string
+	Running    bool
+	Production bool
+	Backup     bool
+}
+
+// Implemented by the VM and Jail list items.
+type Listable interface {
+	ListAttributes() Attributes
+}
+
+type Query struct {
+	Tags       []string // all of the tags must be present
+	Running    *bool
+	Production *bool
+	Backup     *bool
+	Owner      string
+	Dataset    string
+	Name       string   // glob pattern, e.g. "web-*"
+	Sort       []string // JSON field names, prefix with "-" for the descending order, e.g. "-running,name"
+	Offset     int
+	Limit      int      // 0 means no limit
+	Fields     []string // JSON field names to return (sparse fieldset), all fields are returned if empty
+}
+
+// Filter keys accepted by ParseValues()
+var FilterKeys = []string{"tag", "running", "production", "backup", "owner", "dataset", "name"}
+
+// Parses the query from the URL query parameters, e.g.:
+//
+// ?tag=web&running=true&name=web-*&sort=-uptime_unix,name&offset=0&limit=20&fields=name,running
+//
+// Multiple values can be passed either as repeated parameters, or as a comma separated list.
+// Unknown parameters are ignored.
+func ParseValues(values url.Values) (r Query, e error) {
+	for key, list := range values {
+		all := []string{}
+		for _, v := range list {
+			for _, vv := range strings.Split(v, ",") {
+				vv = strings.TrimSpace(vv)
+				if len(vv) > 0 {
+					all = append(all, vv)
+				}
+			}
+		}
+		if len(all) < 1 {
+			continue
+		}
+		last := all[len(all)-1]
+
+		var err error
+		switch key {
+		case "tag":
+			r.Tags = append(r.Tags, all...)
+		case "running":
+			r.Running, err = parseBool(key, last)
+		case "production":
+			r.Production, err = parseBool(key, last)
+		case "backup":
+			r.Backup, err = parseBool(key, last)
+		case "owner":
+			r.Owner = last
+		case "dataset":
+			r.Dataset = last
+		case "name":
+			r.Name = last
+			_, err = path.Match(r.Name, "")
+			if err != nil {
+				err = fmt.Errorf("invalid name pattern: %s", r.Name)
+			}
+		case "sort":
+			r.Sort = append(r.Sort, all...)
+		case "fields":
+			r.Fields = append(r.Fields, all...)
+		case "offset":
+			r.Offset, err = parseInt(key, last)
+		case "limit":
+			r.Limit, err = parseInt(key, last)
+		default:
+			// Unknown parameters are ignored (e.g. the "_=..." cache busters), only the invalid values of the known ones are errors
+		}
+		if err != nil {
+			e = err
+			return
+		}
+	}
+
+	return
+}
+
+// Parses the CLI style filters (e.g. "tag=web", "running=true,production=false") and the sort expression,
+// so the CLI uses exactly the same filtering code as the REST API.
+func ParseFilters(filters []string, sortBy string) (r Query, e error) {
+	values := url.Values{}
+	for _, v := range filters {
+		for _, vv := range strings.Split(v, ",") {
+			vv = strings.TrimSpace(vv)
+			if len(vv) < 1 {
+				continue
+			}
+			key, value, found := strings.Cut(vv, "=")
+			if !found || !slices.Contains(FilterKeys, key) {
+				e = fmt.Errorf("invalid filter '%s', use key=value, where key is one of: %s", vv, strings.Join(FilterKeys, ", "))
+				return
+			}
+			values.Add(key, value)
+		}
+	}
+	if len(sortBy) > 0 {
+		values.Set("sort", sortBy)
+	}
+
+	return ParseValues(values)
+}
+
+// Encodes the query back into the URL query parameters (the opposite of ParseValues),
+// which is used by the CLI to send the same query to a remote node.
+func (q Query) Values() url.Values {
+	r := url.Values{}
+	for _, v := range q.Tags {
+		r.Add("tag", v)
+	}
+	if q.Running != nil {
+		r.Set("running", strconv.FormatBool(*q.Running))
+	}
+	if q.Production != nil {
+		r.Set("production", strconv.FormatBool(*q.Production))
+	}
+	if q.Backup != nil {
+		r.Set("backup", strconv.FormatBool(*q.Backup))
+	}
+	if len(q.Owner) > 0 {
+		r.Set("owner", q.Owner)
+	}
+	if len(q.Dataset) > 0 {
+		r.Set("dataset", q.Dataset)
+	}
+	if len(q.Name) > 0 {
+		r.Set("name", q.Name)
+	}
+	if len(q.Sort) > 0 {
+		r.Set("sort", strings.Join(q.Sort, ","))
+	}
+	if q.Offset > 0 {
+		r.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.Limit > 0 {
+		r.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if len(q.Fields) > 0 {
+		r.Set("fields", strings.Join(q.Fields, ","))
+	}
+
+	return r
+}
+
+func parseBool(key string, value string) (*bool, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be either true or false, got %s", key, value)
+	}
+	return &b, nil
+}
+
+func parseInt(key string, value string) (int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("%s must be a positive number, got %s", key, value)
+	}
+	return i, nil
+}
+
+// Checks if the item matches all of the query filters.
+func (q Query) Matches(a Attributes) bool {
+	for _, v := range q.Tags {
+		if !slices.Contains(a.Tags, v) {
+			return false
+		}
+	}
+	if q.Running != nil && *q.Running != a.Running {
+		return false
+	}
+	if q.Production != nil && *q.Production != a.Production {
+		return false
+	}
+	if q.Backup != nil && *q.Backup != a.Backup {
+		return false
+	}
+	if len(q.Owner) > 0 && q.Owner != a.Owner {
+		return false
+	}
+	if len(q.Dataset) > 0 && q.Dataset != a.Dataset {
+		return false
+	}
+	if len(q.Name) > 0 {
+		matched, _ := path.Match(q.Name, a.Name)
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Filters, sorts and paginates the list.
+// Returns the resulting page, and the total number of the items that matched the filters.
+func Apply[T Listable](items []T, q Query) (r []T, total int, e error) {
+	for _, v := range q.Sort {
+		field := strings.TrimPrefix(v, "-")
+		if !IsField[T](field) {
+			e = fmt.Errorf("unknown sort field: %s", field)
+			return
+		}
+	}
+
+	r = []T{}
+	for _, v := range items {
+		if q.Matches(v.ListAttributes()) {
+			r = append(r, v)
+		}
+	}
+	total = len(r)
+
+	if len(q.Sort) > 0 && len(r) > 1 {
+		r, e = sortItems(r, q.Sort)
+		if e != nil {
+			return
+		}
+	}
+
+	if q.Offset >= len(r) {
+		r = []T{}
+		return
+	}
+	r = r[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(r) {
+		r = r[:q.Limit]
+	}
+
+	return
+}
+
+// Items are sorted by their JSON field values, so the sort keys are the same as the ones returned by the API.
+// Fields missing from the item (omitempty) are sorted as null.
+func sortItems[T any](items []T, sortBy []string) (r []T, e error) {
+	type sortable struct {
+		item   T
+		fields map[string]any
+	}
+
+	list := []sortable{}
+	for _, v := range items {
+		fields, err := jsonFields(v)
+		if err != nil {
+			e = err
+			return
+		}
+		list = append(list, sortable{item: v, fields: fields})
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		for _, v := range sortBy {
+			field := strings.TrimPrefix(v, "-")
+			c := compareValues(list[i].fields[field], list[j].fields[field])
+			if c == 0 {
+				continue
+			}
+			if strings.HasPrefix(v, "-") {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+
+	r = []T{}
+	for _, v := range list {
+		r = append(r, v.item)
+	}
+	return
+}
+
+func jsonFields(item any) (r map[string]any, e error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		e = err
+		return
+	}
+
+	e = json.Unmarshal(data, &r)
+	return
+}
+
+// Ordering between the types: null < bool < number < string < everything else
+func compareValues(a any, b any) int {
+	rank := func(v any) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case bool:
+			return 1
+		case float64:
+			return 2
+		case string:
+			return 3
+		}
+		return 4
+	}
+	if rank(a) != rank(b) {
+		return rank(a) - rank(b)
+	}
+
+	switch av := a.(type) {
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		}
+		if !av {
+			return -1
+		}
+		return 1
+	case float64:
+		bv := b.(float64)
+		if av < bv {
+			return -1
+		}
+		if av > bv {
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(av, b.(string))
+	}
+
+	return 0
+}
+
+// Returns the items with only the requested JSON fields (sparse fieldset).
+// Fields missing from the item (omitempty) are returned as null.
+func SelectFields[T any](items []T, fields []string) (r []map[string]json.RawMessage, e error) {
+	for _, f := range fields {
+		if !IsField[T](f) {
+			e = fmt.Errorf("unknown field: %s", f)
+			return
+		}
+	}
+
+	r = []map[string]json.RawMessage{}
+	for _, v := range items {
+		data, err := json.Marshal(v)
+		if err != nil {
+			e = err
+			return
+		}
+		all := make(map[string]json.RawMessage)
+		err = json.Unmarshal(data, &all)
+		if err != nil {
+			e = err
+			return
+		}
+
+		selected := make(map[string]json.RawMessage)
+		for _, f := range fields {
+			value, ok := all[f]
+			if !ok {
+				value = json.RawMessage("null")
+			}
+			selected[f] = value
+		}
+		r = append(r, selected)
+	}
+
+	return
+}
+
+// JSON field names of the struct types, keyed by reflect.Type
+var fieldNames sync.Map
+
+// Checks if the JSON field name exists in the T struct type (including the embedded structs).
+// Field names are validated against the type, not the values, so the fields omitted from some of the items (omitempty) are still valid.
+// Non-struct types accept any field name.
+func IsField[T any](name string) bool {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+
+	names, ok := fieldNames.Load(t)
+	if !ok {
+		names, _ = fieldNames.LoadOrStore(t, jsonFieldNames(t, map[string]bool{}))
+	}
+	return names.(map[string]bool)[name]
+}
+
+// Follows the encoding/json rules: the "json" tag name (or the Go field name), "-" is skipped, and the untagged embedded structs are flattened.
+func jsonFieldNames(t reflect.Type, r map[string]bool) map[string]bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && len(name) < 1 && ft.Kind() == reflect.Struct {
+			jsonFieldNames(ft, r)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if len(name) < 1 {
+			name = f.Name
+		}
+		r[name] = true
+	}
+
+	return r
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterListQuery
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type testConfig struct {
+	Description string `json:"description,omitempty"`
+	Memory      string `json:"memory,omitempty"`
+	Parent      string `json:"parent,omitempty"`
+	secret      string
+}
+
+// Same shape as the VM list items: an embedded config with the omitempty fields
+type testItem struct {
+	testConfig
+	Name     string   `json:"name"`
+	Running  bool     `json:"running"`
+	Uptime   int64    `json:"uptime_unix,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Internal string   `json:"-"`
+	Extra    *string
+}
+
+func (v testItem) ListAttributes() Attributes {
+	return Attributes{Name: v.Name, Tags: v.Tags, Running: v.Running}
+}
+
+// None of the omitempty fields are set on all of the items
+func testItems() []testItem {
+	return []testItem{
+		{Name: "vm-c", Running: true, Uptime: 300, testConfig: testConfig{Memory: "2G"}},
+		{Name: "vm-a"},
+		{Name: "vm-d", Running: true, Uptime: 100, Tags: []string{"web"}, testConfig: testConfig{Description: "web server", Parent: "template-1"}},
+		{Name: "vm-b", Tags: []string{"web"}, testConfig: testConfig{Memory: "1G"}},
+	}
+}
+
+func names(items []testItem) string {
+	r := []string{}
+	for _, v := range items {
+		r = append(r, v.Name)
+	}
+	return strings.Join(r, ",")
+}
+
+func TestApplySortHeterogeneous(t *testing.T) {
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{sort: "name", want: "vm-a,vm-b,vm-c,vm-d"},
+		{sort: "-uptime_unix,name", want: "vm-c,vm-d,vm-a,vm-b"},
+		{sort: "memory,name", want: "vm-a,vm-d,vm-b,vm-c"}, // missing values are sorted as null (first)
+		{sort: "-description,name", want: "vm-d,vm-a,vm-b,vm-c"},
+		{sort: "parent,-running,name", want: "vm-c,vm-a,vm-b,vm-d"},
+		{sort: "Extra,name", want: "vm-a,vm-b,vm-c,vm-d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			q, err := ParseValues(url.Values{"sort": {tt.sort}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Order of the items must not matter for the validation, try every item at the first position
+			items := testItems()
+			for i := range items {
+				rotated := append(append([]testItem{}, items[i:]...), items[:i]...)
+				page, total, err := Apply(rotated, q)
+				if err != nil {
+					t.Fatalf("Apply: %s", err.Error())
+				}
+				if total != len(items) {
+					t.Errorf("total: got %d, want %d", total, len(items))
+				}
+				if names(page) != tt.want {
+					t.Errorf("got %s, want %s", names(page), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyUnknownSortField(t *testing.T) {
+	for _, sortBy := range []string{"memroy", "-secret", "Internal", "testConfig"} {
+		// Validated even if there is nothing to sort
+		for _, items := range [][]testItem{testItems(), {}, testItems()[:1]} {
+			_, _, err := Apply(items, Query{Sort: []string{sortBy}})
+			if err == nil || !strings.HasPrefix(err.Error(), "unknown sort field: ") {
+				t.Errorf("sort %s (%d items): got %v, want an unknown sort field error", sortBy, len(items), err)
+			}
+		}
+	}
+}
+
+func TestSelectFieldsHeterogeneous(t *testing.T) {
+	selected, err := SelectFields(testItems(), []string{"name", "memory", "parent", "tags"})
+	if err != nil {
+		t.Fatalf("SelectFields: %s", err.Error())
+	}
+
+	got, err := json.Marshal(selected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"memory":"2G","name":"vm-c","parent":null,"tags":null},` +
+		`{"memory":null,"name":"vm-a","parent":null,"tags":null},` +
+		`{"memory":null,"name":"vm-d","parent":"template-1","tags":["web"]},` +
+		`{"memory":"1G","name":"vm-b","parent":null,"tags":["web"]}]`
+	if string(got) != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+
+	for _, items := range [][]testItem{testItems(), {}} {
+		_, err = SelectFields(items, []string{"name", "uptime"})
+		if err == nil || err.Error() != "unknown field: uptime" {
+			t.Errorf("%d items: got %v, want an unknown field error", len(items), err)
+		}
+	}
+}
+
+func TestIsField(t *testing.T) {
+	for name, want := range map[string]bool{
+		"name": true, "running": true, "uptime_unix": true, "description": true, "memory": true, "Extra": true,
+		"Uptime": false, "Internal": false, "secret": false, "testConfig": false, "": false,
+	} {
+		if IsField[testItem](name) != want {
+			t.Errorf("IsField(%q): got %v, want %v", name, !want, want)
+		}
+		if IsField[*testItem](name) != want {
+			t.Errorf("IsField[*testItem](%q): got %v, want %v", name, !want, want)
+		}
+	}
+	if !IsField[map[string]any]("anything") {
+		t.Errorf("non-struct types must accept any field name")
+	}
+}
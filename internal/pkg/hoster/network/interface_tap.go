@@ -66,12 +66,20 @@ func VmNetworkCleanup(vmName string) (r []Iface, e error) {
 		}
 	}
 
+	// Per-VLAN bridges that were only used by this VM are not needed anymore (best effort, the taps are already gone)
+	vlanIfaces, err := CleanupVlanBridges()
+	if err == nil {
+		r = append(r, vlanIfaces...)
+	}
+
 	return
 }
 
 // This function creates a new TAP interface, sets the correct description for it,
 // and returns it's (TAP interface) name back to the caller.
-func CreateTapInterface(vmName string, networkName string) (r string, e error) {
+//
+// If vlanTag is set (not 0), the interface is added to the per-VLAN bridge instead of the network bridge.
+func CreateTapInterface(vmName string, networkName string, vlanTag int) (r string, e error) {
 	// Check if the network exists
 	networks, err := GetNetworkConfig()
 	if err != nil {
@@ -90,6 +98,15 @@ func CreateTapInterface(vmName string, networkName string) (r string, e error) {
 	}
 	// EOF Check if the network exists
 
+	bridge := "vm-" + networkName
+	if vlanTag > 0 {
+		bridge, err = EnsureVlanBridge(networkName, vlanTag)
+		if err != nil {
+			e = err
+			return
+		}
+	}
+
 	// Create new epair interface
 	out, err := exec.Command("ifconfig", "tap", "create").CombinedOutput()
 	if err != nil {
@@ -111,7 +128,7 @@ func CreateTapInterface(vmName string, networkName string) (r string, e error) {
 	// EOF Set a description for the new interface
 
 	// Add the interface to the VM network bridge
-	out, err = exec.Command("ifconfig", "vm-"+networkName, "addm", r, "up").CombinedOutput()
+	out, err = exec.Command("ifconfig", bridge, "addm", r, "up").CombinedOutput()
 	if err != nil {
 		e = fmt.Errorf("%s; %s", strings.TrimSpace(string(out)), err.Error())
 		return
@@ -0,0 +1,220 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterNetwork
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	VLAN_TAG_MIN = 1
+	VLAN_TAG_MAX = 4094
+)
+
+// Tagged VM interfaces don't join the network bridge itself (vm-external), but a separate per-VLAN bridge
+// (vm-external.100), which has the 802.1Q VLAN interface on top of the network uplink (em0.100) as a member.
+// This way the tagging is done by the host, and the VM only ever sees the untagged frames.
+
+// Returns the per-VLAN bridge name, e.g. vm-external.100
+func VlanBridgeName(networkName string, vlanTag int) string {
+	return fmt.Sprintf("vm-%s.%d", networkName, vlanTag)
+}
+
+// Checks if the network can carry the VLAN traffic, and returns the network uplink interface.
+func CheckVlanSupport(networkName string, vlanTag int) (r string, e error) {
+	if vlanTag < VLAN_TAG_MIN || vlanTag > VLAN_TAG_MAX {
+		e = fmt.Errorf("VLAN tag must be between %d and %d, got %d", VLAN_TAG_MIN, VLAN_TAG_MAX, vlanTag)
+		return
+	}
+
+	networks, err := GetNetworkConfig()
+	if err != nil {
+		e = err
+		return
+	}
+	for _, v := range networks {
+		if v.NetworkName != networkName {
+			continue
+		}
+		if len(v.BridgeInterface) < 1 || v.BridgeInterface == "None" {
+			e = fmt.Errorf("network %s has no uplink interface (bridge_interface), VLAN tags can't be used on it", networkName)
+			return
+		}
+		r = v.BridgeInterface
+		break
+	}
+	if len(r) < 1 {
+		e = fmt.Errorf("network with the name %s does not exist", networkName)
+		return
+	}
+
+	// IFNAMSIZ is 16, including the trailing null byte
+	if len(VlanBridgeName(networkName, vlanTag)) > 15 {
+		e = fmt.Errorf("VLAN bridge name %s is too long (15 characters max), use a shorter network name", VlanBridgeName(networkName, vlanTag))
+		return
+	}
+	if len(fmt.Sprintf("%s.%d", r, vlanTag)) > 15 {
+		e = fmt.Errorf("VLAN interface name %s.%d is too long (15 characters max)", r, vlanTag)
+		return
+	}
+
+	return
+}
+
+// Creates the per-VLAN bridge and its VLAN interface member (unless they already exist), and returns the bridge name.
+func EnsureVlanBridge(networkName string, vlanTag int) (r string, e error) {
+	uplink, err := CheckVlanSupport(networkName, vlanTag)
+	if err != nil {
+		e = err
+		return
+	}
+
+	r = VlanBridgeName(networkName, vlanTag)
+	if exec.Command("ifconfig", r).Run() == nil {
+		return
+	}
+
+	// A VLAN interface that already exists (e.g. created by the host admin) is reused, but it doesn't get
+	// the Hoster description, so CleanupVlanBridges never destroys it
+	vlanIface := fmt.Sprintf("%s.%d", uplink, vlanTag)
+	if exec.Command("ifconfig", vlanIface).Run() != nil {
+		out, err := exec.Command("ifconfig", vlanIface, "create").CombinedOutput()
+		if err != nil {
+			e = fmt.Errorf("could not create the VLAN interface %s: %s; %s", vlanIface, strings.TrimSpace(string(out)), err.Error())
+			return
+		}
+		out, err = exec.Command("ifconfig", vlanIface, "description", vlanIfaceDescription(networkName, vlanTag)).CombinedOutput()
+		if err != nil {
+			e = fmt.Errorf("%s; %s", strings.TrimSpace(string(out)), err.Error())
+			return
+		}
+	}
+	out, err := exec.Command("ifconfig", vlanIface, "up").CombinedOutput()
+	if err != nil {
+		e = fmt.Errorf("%s; %s", strings.TrimSpace(string(out)), err.Error())
+		return
+	}
+
+	out, err = exec.Command("ifconfig", "bridge", "create", "name", r).CombinedOutput()
+	if err != nil {
+		e = fmt.Errorf("could not create the VLAN bridge %s: %s; %s", r, strings.TrimSpace(string(out)), err.Error())
+		return
+	}
+	out, err = exec.Command("ifconfig", r, "addm", vlanIface, "up").CombinedOutput()
+	if err != nil {
+		e = fmt.Errorf("%s; %s", strings.TrimSpace(string(out)), err.Error())
+		return
+	}
+
+	return
+}
+
+func vlanIfaceDescription(networkName string, vlanTag int) string {
+	return fmt.Sprintf("\"network::%s vlan::%d\"", networkName, vlanTag)
+}
+
+// Destroys the per-VLAN bridges that have no VM (or Jail) interfaces left as members, along with the VLAN
+// interfaces created for them by EnsureVlanBridge. Bridges that are still in use are left alone, so it's safe to
+// call this after any NIC change, or after the VM network clean-up. Returns the list of the destroyed interfaces.
+func CleanupVlanBridges() (r []Iface, e error) {
+	networks, err := GetNetworkConfig()
+	if err != nil {
+		e = err
+		return
+	}
+	networkBridges := map[string]bool{}
+	for _, v := range networks {
+		networkBridges["vm-"+v.NetworkName] = true
+	}
+
+	out, err := exec.Command("ifconfig", "-g", "bridge").CombinedOutput()
+	if err != nil {
+		e = fmt.Errorf("%s; %s", strings.TrimSpace(string(out)), err.Error())
+		return
+	}
+
+	for _, bridge := range strings.Fields(string(out)) {
+		// Regular network bridges are never touched, even if the network name looks like a VLAN bridge (e.g. lan.10)
+		if networkBridges[bridge] {
+			continue
+		}
+		for _, v := range networks {
+			tagString, found := strings.CutPrefix(bridge, "vm-"+v.NetworkName+".")
+			if !found {
+				continue
+			}
+			vlanTag, err := strconv.Atoi(tagString)
+			if err != nil || vlanTag < VLAN_TAG_MIN || vlanTag > VLAN_TAG_MAX || VlanBridgeName(v.NetworkName, vlanTag) != bridge {
+				continue
+			}
+
+			vlanIface := fmt.Sprintf("%s.%d", v.BridgeInterface, vlanTag)
+			members, err := bridgeMembers(bridge)
+			if err != nil {
+				e = err
+				return
+			}
+			if slices.ContainsFunc(members, func(m string) bool { return m != vlanIface }) {
+				break
+			}
+
+			r = append(r, destroyIface(bridge))
+			if strings.Trim(ifaceDescription(vlanIface), "\"") == strings.Trim(vlanIfaceDescription(v.NetworkName, vlanTag), "\"") {
+				r = append(r, destroyIface(vlanIface))
+			}
+			break
+		}
+	}
+
+	return
+}
+
+var reBridgeMember = regexp.MustCompile(`^\s*member:\s+(\S+)`)
+
+// Returns the member interfaces of the bridge
+func bridgeMembers(bridge string) (r []string, e error) {
+	out, err := exec.Command("ifconfig", bridge).CombinedOutput()
+	if err != nil {
+		e = fmt.Errorf("%s; %s", strings.TrimSpace(string(out)), err.Error())
+		return
+	}
+	for _, v := range strings.Split(string(out), "\n") {
+		match := reBridgeMember.FindStringSubmatch(v)
+		if len(match) > 1 {
+			r = append(r, match[1])
+		}
+	}
+	return
+}
+
+var reIfaceDescription = regexp.MustCompile(`^\s*description:\s+(.*)$`)
+
+// Returns the interface description, or an empty string if it's not set (or the interface doesn't exist)
+func ifaceDescription(iface string) string {
+	out, err := exec.Command("ifconfig", iface).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	for _, v := range strings.Split(string(out), "\n") {
+		match := reIfaceDescription.FindStringSubmatch(v)
+		if len(match) > 1 {
+			return strings.TrimSpace(match[1])
+		}
+	}
+	return ""
+}
+
+func destroyIface(iface string) Iface {
+	err := exec.Command("ifconfig", iface, "destroy").Run()
+	if err != nil {
+		return Iface{IfaceName: iface, Failure: true}
+	}
+	return Iface{IfaceName: iface, Success: true}
+}
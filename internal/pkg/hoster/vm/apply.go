@@ -0,0 +1,544 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build freebsd
+// +build freebsd
+
+package HosterVm
+
+import (
+	"HosterCore/internal/pkg/byteconversion"
+	HosterHost "HosterCore/internal/pkg/hoster/host"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const gigabyte = 1024 * 1024 * 1024
+
+// A planned change, and the function that applies it
+type applyStep struct {
+	changes []VmApplyChange
+	run     func() error
+}
+
+// Compares the spec with the current VM state, and applies the minimal set of changes needed:
+// deploy, config changes (CPU, RAM, tags, etc), new disks, disk expansions and new network interfaces.
+//
+// If dryRun is set, only the plan is returned. Re-applying an unchanged spec is a no-op.
+func Apply(spec VmSpec, dryRun bool) (r VmApplyResult, e error) {
+	// If the logger was already set, ignore this
+	if !log.ConfigSet {
+		log.SetFileLocation(HosterVmUtils.VM_AUDIT_LOG_LOCATION)
+	}
+
+	r.VmName = spec.Name
+	r.DryRun = dryRun
+	r.Changes = []VmApplyChange{}
+
+	err := spec.Validate()
+	if err != nil {
+		e = err
+		return
+	}
+
+	vm, found, err := applyFindVm(spec.Name)
+	if err != nil {
+		e = err
+		return
+	}
+
+	if !found {
+		input, err := applyDeployInput(spec)
+		if err != nil {
+			e = err
+			return
+		}
+		r.Changes = append(r.Changes, VmApplyChange{Action: APPLY_DEPLOY, Target: spec.Name, New: input.OsType + " on " + input.TargetDataset})
+
+		// The rest of the plan is based on the config that the deployment would produce
+		if dryRun {
+			steps, err := planApply(spec, applyDeployedConfig(spec, input), "", false)
+			if err != nil {
+				e = err
+				return
+			}
+			for _, v := range steps {
+				r.Changes = append(r.Changes, v.changes...)
+			}
+			return
+		}
+
+		err = Deploy(input)
+		if err != nil {
+			e = err
+			return
+		}
+		log.Info("vm apply: new vm has been deployed: " + spec.Name)
+
+		vm, found, err = applyFindVm(spec.Name)
+		if err != nil {
+			e = err
+			return
+		}
+		if !found {
+			e = errors.New("vm could not be found after the deployment: " + spec.Name)
+			return
+		}
+	}
+
+	if vm.Backup {
+		e = fmt.Errorf("vm is a backup, apply the spec on it's parent host instead: %s", vm.ParentHost)
+		return
+	}
+
+	config, err := HosterVmUtils.GetVmConfig(vm.Simple.Mountpoint + "/" + vm.Name)
+	if err != nil {
+		e = err
+		return
+	}
+
+	steps, err := planApply(spec, config, vm.Simple.Mountpoint+"/"+vm.Name, vm.Running)
+	if err != nil {
+		e = err
+		return
+	}
+	// Disks can only be expanded while the VM is offline, the dry run reports it as a part of the plan
+	for _, v := range steps {
+		for i, vv := range v.changes {
+			if vm.Running && vv.Action == APPLY_EXPAND_DISK {
+				if dryRun {
+					v.changes[i].StopRequired = true
+					continue
+				}
+				e = fmt.Errorf("%s has to be expanded, which can only be done while the VM is offline, stop the VM first", vv.Target)
+				return
+			}
+		}
+	}
+
+	for _, v := range steps {
+		if !dryRun {
+			err := v.run()
+			if err != nil {
+				e = err
+				return
+			}
+			for _, vv := range v.changes {
+				log.Info("vm apply: " + vm.Name + ": " + vv.Action + " " + vv.Target + " -> " + vv.New)
+			}
+		}
+		r.Changes = append(r.Changes, v.changes...)
+	}
+
+	return
+}
+
+func applyFindVm(vmName string) (r HosterVmUtils.VmApi, found bool, e error) {
+	vms, err := HosterVmUtils.ListAllSimple()
+	if err != nil {
+		e = err
+		return
+	}
+
+	for _, v := range vms {
+		if v.VmName == vmName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return
+	}
+
+	r, e = HosterVmUtils.InfoJsonApi(vmName)
+	return
+}
+
+func applyDeployInput(spec VmSpec) (r VmDeployInput, e error) {
+	if len(spec.OsType) < 1 {
+		e = errors.New("os_type must be set in the spec, in order to deploy a new VM")
+		return
+	}
+
+	r.VmName = spec.Name
+	r.OsType = spec.OsType
+	r.VCpus = spec.CpuCores
+	r.RAM = spec.Ram
+	r.Owner = spec.Owner
+	r.TargetDataset = spec.Dataset
+	if len(spec.Networks) > 0 {
+		r.NetworkName = spec.Networks[0].Network
+		r.IpAddress = spec.Networks[0].IpAddress
+	}
+
+	if len(r.TargetDataset) < 1 {
+		hostConf, err := HosterHost.GetHostConfig()
+		if err != nil {
+			e = err
+			return
+		}
+		if len(hostConf.ActiveZfsDatasets) < 1 {
+			e = errors.New("there are no active datasets in the host config")
+			return
+		}
+		r.TargetDataset = hostConf.ActiveZfsDatasets[0]
+	}
+
+	return
+}
+
+// Mirrors the config written by Deploy(), used to plan the dry run for the VMs that don't exist yet
+func applyDeployedConfig(spec VmSpec, input VmDeployInput) (r HosterVmUtils.VmConfig) {
+	r.CPUSockets = 1
+	r.CPUCores = input.VCpus
+	r.Memory = input.RAM
+	r.Loader = "uefi"
+	r.Owner = "system"
+	if len(input.Owner) > 0 {
+		r.Owner = input.Owner
+	}
+	r.Production = !strings.Contains(input.VmName, "test")
+	r.Description = "-"
+	r.IncludeHostSSHKeys = true
+	r.VmSshKeys, _ = getSystemSshKeys()
+
+	r.Disks = []HosterVmUtils.VmDisk{
+		{DiskType: "nvme", DiskLocation: "internal", DiskImage: "disk0.img", Comment: "OS Disk"},
+		{DiskType: "ahci-cd", DiskLocation: "internal", DiskImage: "seed.iso", Comment: "CloudInit ISO"},
+	}
+	if len(spec.Networks) > 0 {
+		r.Networks = []HosterVmUtils.VmNetwork{{
+			NetworkAdaptorType: "virtio-net",
+			NetworkBridge:      input.NetworkName,
+			IPAddress:          input.IpAddress,
+		}}
+	}
+
+	return
+}
+
+// Plans the changes for an existing VM. If vmFolder is empty (the VM is not deployed yet), the disk sizes are unknown.
+func planApply(spec VmSpec, config HosterVmUtils.VmConfig, vmFolder string, running bool) (r []applyStep, e error) {
+	newConfig := applySpecToConfig(spec, config)
+	configChanges, err := HosterVmUtils.DiffVmConfig(config, newConfig, running)
+	if err != nil {
+		e = err
+		return
+	}
+	restartRequired := make(map[string]bool)
+	for _, v := range configChanges {
+		restartRequired[v.Setting] = v.RestartRequired
+	}
+
+	// All config changes are written at once, as a single step
+	changes, err := describeConfigChanges(config, newConfig, restartRequired)
+	if err != nil {
+		e = err
+		return
+	}
+	if len(changes) > 0 {
+		r = append(r, applyStep{changes: changes, run: func() error {
+			return applyConfig(spec, vmFolder)
+		}})
+	}
+
+	dataDisks := applyDataDisks(config)
+	for i, v := range spec.Disks {
+		target := fmt.Sprintf("disk%d", i)
+		diskType := v.Type
+		if len(diskType) < 1 {
+			diskType = "nvme"
+		}
+		size := v.Size
+		if len(size) < 1 {
+			size = "10G"
+		}
+		sizeBytes, _ := byteconversion.HumanToBytes(size)
+		// Disks are created and expanded in whole GiB, so the spec size is rounded up once and used for every comparison,
+		// otherwise a size like "10.5G" would be planned as an expansion on every apply
+		sizeGiB := (sizeBytes + gigabyte - 1) / gigabyte
+
+		if i >= len(dataDisks) {
+			input := HosterVmUtils.VmDisk{
+				DiskType:      diskType,
+				DiskLocation:  "internal",
+				DiskInputSize: sizeGiB,
+				Comment:       v.Comment,
+			}
+			r = append(r, applyStep{
+				changes: []VmApplyChange{{Action: APPLY_ADD_DISK, Target: target, New: fmt.Sprintf("%s %dG", diskType, input.DiskInputSize)}},
+				run:     func() error { return HosterVmUtils.AddNewVmDisk(context.Background(), spec.Name, input) },
+			})
+			continue
+		}
+		if len(v.Size) < 1 {
+			continue
+		}
+
+		disk := dataDisks[i]
+		if len(vmFolder) < 1 {
+			r = append(r, applyStep{changes: []VmApplyChange{{Action: APPLY_EXPAND_DISK, Target: target, Old: "template size", New: v.Size}}})
+			continue
+		}
+
+		var currentBytes uint64
+		if disk.DiskLocation == HosterVmUtils.DISK_LOCATION_ZVOL {
+			vmDataset, err := HosterVmUtils.GetVmDataset(spec.Name)
+			if err != nil {
+				e = fmt.Errorf("%s: %s", target, err.Error())
+				return
+			}
+			currentBytes, err = HosterVmUtils.ZvolSize(vmDataset + "/" + disk.DiskImage)
+			if err != nil {
+				e = fmt.Errorf("%s: %s", target, err.Error())
+				return
+			}
+		} else {
+			diskPath := disk.DiskImage
+			if disk.DiskLocation == "internal" && !strings.HasPrefix(disk.DiskImage, "/") {
+				diskPath = vmFolder + "/" + disk.DiskImage
+			}
+			stat, err := os.Stat(diskPath)
+			if err != nil {
+				e = fmt.Errorf("%s: %s", target, err.Error())
+				return
+			}
+			currentBytes = uint64(stat.Size())
+		}
+
+		currentGiB := (currentBytes + gigabyte - 1) / gigabyte
+		if currentGiB > sizeGiB {
+			e = fmt.Errorf("%s is %s, which is larger than %dG in the spec, disks can't be shrunk",
+				target, byteconversion.BytesToHuman(currentBytes), sizeGiB)
+			return
+		}
+		if currentGiB < sizeGiB {
+			expansion := int(sizeGiB - currentGiB)
+			r = append(r, applyStep{
+				changes: []VmApplyChange{{Action: APPLY_EXPAND_DISK, Target: target, Old: byteconversion.BytesToHuman(currentBytes), New: fmt.Sprintf("%dG", sizeGiB)}},
+				run:     func() error { return HosterVmUtils.DiskExpandOffline(disk.DiskImage, expansion, spec.Name) },
+			})
+		}
+	}
+
+	for i, v := range spec.Networks {
+		target := fmt.Sprintf("network%d", i)
+		driver := v.Driver
+		if len(driver) < 1 {
+			driver = "virtio-net"
+		}
+
+		if i < len(config.Networks) {
+			current := config.Networks[i]
+			if current.NetworkBridge != v.Network {
+				e = fmt.Errorf("%s is connected to %s instead of %s, apply can't move the existing network interfaces", target, current.NetworkBridge, v.Network)
+				return
+			}
+			if len(v.IpAddress) > 0 && len(current.IPAddress) > 0 && current.IPAddress != v.IpAddress {
+				e = fmt.Errorf("%s uses %s instead of %s, apply can't change the IP addresses of the existing network interfaces", target, current.IPAddress, v.IpAddress)
+				return
+			}
+			continue
+		}
+
+		input := HosterVmUtils.VmNetwork{
+			NetworkAdaptorType: driver,
+			NetworkBridge:      v.Network,
+			IPAddress:          v.IpAddress,
+			Comment:            v.Comment,
+		}
+		newValue := driver + " on " + v.Network
+		if len(v.IpAddress) > 0 {
+			newValue += " (" + v.IpAddress + ")"
+		}
+		r = append(r, applyStep{
+			changes: []VmApplyChange{{Action: APPLY_ADD_NETWORK, Target: target, New: newValue, RestartRequired: running}},
+			run:     func() error { return AddNewVmNetwork(context.Background(), spec.Name, input) },
+		})
+	}
+
+	return
+}
+
+// Returns the config with the spec applied. Settings that are not set in the spec are left untouched.
+func applySpecToConfig(spec VmSpec, config HosterVmUtils.VmConfig) HosterVmUtils.VmConfig {
+	config.Disks = slices.Clone(config.Disks)
+	config.Networks = slices.Clone(config.Networks)
+
+	config.CPUSockets = max(spec.CpuSockets, 1)
+	config.CPUCores = spec.CpuCores
+	if max(spec.CpuThreads, 1) != max(config.CPUThreads, 1) {
+		config.CPUThreads = max(spec.CpuThreads, 1)
+	}
+
+	oldRam, _ := byteconversion.HumanToBytes(config.Memory)
+	newRam, _ := byteconversion.HumanToBytes(spec.Ram)
+	if oldRam != newRam {
+		config.Memory = spec.Ram
+	}
+
+	if len(spec.Owner) > 0 {
+		config.Owner = spec.Owner
+	}
+	if spec.Production != nil {
+		config.Production = *spec.Production
+	}
+	if len(spec.Description) > 0 {
+		config.Description = spec.Description
+	}
+	if spec.Tags != nil {
+		oldTags := slices.Clone(config.Tags)
+		newTags := slices.Clone(spec.Tags)
+		sort.Strings(oldTags)
+		sort.Strings(newTags)
+		if !slices.Equal(oldTags, newTags) {
+			config.Tags = spec.Tags
+		}
+	}
+
+	dataDiskIndexes := []int{}
+	for i, v := range config.Disks {
+		if v.DiskType != "ahci-cd" {
+			dataDiskIndexes = append(dataDiskIndexes, i)
+		}
+	}
+	for i, v := range spec.Disks {
+		if i >= len(dataDiskIndexes) {
+			break
+		}
+		if len(v.Type) > 0 {
+			config.Disks[dataDiskIndexes[i]].DiskType = v.Type
+		}
+		if len(v.Comment) > 0 {
+			config.Disks[dataDiskIndexes[i]].Comment = v.Comment
+		}
+	}
+
+	for i, v := range spec.Networks {
+		if i >= len(config.Networks) {
+			break
+		}
+		if len(v.Driver) > 0 {
+			config.Networks[i].NetworkAdaptorType = v.Driver
+		}
+		if len(v.Comment) > 0 {
+			config.Networks[i].Comment = v.Comment
+		}
+	}
+
+	if spec.CloudInit != nil {
+		if spec.CloudInit.IncludeHostSshKeys != nil {
+			config.IncludeHostSSHKeys = *spec.CloudInit.IncludeHostSshKeys
+		}
+		if spec.CloudInit.SshKeys != nil {
+			config.VmSshKeys = spec.CloudInit.SshKeys
+		}
+	}
+
+	return config
+}
+
+// Disks that can be managed by the spec (CD-ROM drives are skipped)
+func applyDataDisks(config HosterVmUtils.VmConfig) (r []HosterVmUtils.VmDisk) {
+	for _, v := range config.Disks {
+		if v.DiskType != "ahci-cd" {
+			r = append(r, v)
+		}
+	}
+	return
+}
+
+func describeConfigChanges(oldConfig HosterVmUtils.VmConfig, newConfig HosterVmUtils.VmConfig, restartRequired map[string]bool) (r []VmApplyChange, e error) {
+	oldMap, err := applyConfigMap(oldConfig)
+	if err != nil {
+		e = err
+		return
+	}
+	newMap, err := applyConfigMap(newConfig)
+	if err != nil {
+		e = err
+		return
+	}
+
+	keys := []string{}
+	for k := range newMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if string(oldMap[k]) == string(newMap[k]) {
+			continue
+		}
+
+		switch k {
+		case "disks":
+			oldDisks, newDisks := applyDataDisks(oldConfig), applyDataDisks(newConfig)
+			for i := range newDisks {
+				if oldDisks[i].DiskType != newDisks[i].DiskType || oldDisks[i].Comment != newDisks[i].Comment {
+					r = append(r, VmApplyChange{Action: APPLY_SET, Target: fmt.Sprintf("disk%d", i),
+						Old:             oldDisks[i].DiskType + " (" + oldDisks[i].Comment + ")",
+						New:             newDisks[i].DiskType + " (" + newDisks[i].Comment + ")",
+						RestartRequired: restartRequired[k]})
+				}
+			}
+		case "networks":
+			for i := range newConfig.Networks {
+				oldNet, newNet := oldConfig.Networks[i], newConfig.Networks[i]
+				if oldNet.NetworkAdaptorType != newNet.NetworkAdaptorType || oldNet.Comment != newNet.Comment {
+					r = append(r, VmApplyChange{Action: APPLY_SET, Target: fmt.Sprintf("network%d", i),
+						Old:             oldNet.NetworkAdaptorType + " (" + oldNet.Comment + ")",
+						New:             newNet.NetworkAdaptorType + " (" + newNet.Comment + ")",
+						RestartRequired: restartRequired[k]})
+				}
+			}
+		case "vm_ssh_keys":
+			r = append(r, VmApplyChange{Action: APPLY_SET, Target: k,
+				Old:             fmt.Sprintf("%d key(s)", len(oldConfig.VmSshKeys)),
+				New:             fmt.Sprintf("%d key(s)", len(newConfig.VmSshKeys)),
+				RestartRequired: restartRequired[k]})
+		default:
+			r = append(r, VmApplyChange{Action: APPLY_SET, Target: k,
+				Old:             strings.Trim(string(oldMap[k]), `"`),
+				New:             strings.Trim(string(newMap[k]), `"`),
+				RestartRequired: restartRequired[k]})
+		}
+	}
+
+	return
+}
+
+func applyConfigMap(config HosterVmUtils.VmConfig) (r map[string]json.RawMessage, e error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		e = err
+		return
+	}
+
+	e = json.Unmarshal(data, &r)
+	return
+}
+
+// Re-reads the VM config, applies the spec and writes it back, after the same validation as the settings patch
+func applyConfig(spec VmSpec, vmFolder string) error {
+	config, err := HosterVmUtils.GetVmConfig(vmFolder)
+	if err != nil {
+		return err
+	}
+	newConfig := applySpecToConfig(spec, config)
+
+	err = ValidateVmUpdate(spec.Name, vmFolder, config, newConfig)
+	if err != nil {
+		return err
+	}
+
+	return HosterVmUtils.ConfigFileWriter(newConfig, vmFolder+"/"+HosterVmUtils.VM_CONFIG_NAME)
+}
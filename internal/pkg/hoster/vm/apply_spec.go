@@ -0,0 +1,188 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVm
+
+import (
+	"HosterCore/internal/pkg/byteconversion"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Kept outside of the FreeBSD specific apply.go, because the structs are shared with the REST API client.
+
+// Declarative VM spec, used by `hoster vm apply` and the /vm/apply endpoint.
+//
+// Optional fields that are not set are left untouched on the existing VMs. Disks and networks are matched
+// by their position in the VM config (CD-ROM drives are skipped), the ones that are not in the spec are left untouched.
+type VmSpec struct {
+	Name        string           `json:"name"`
+	OsType      string           `json:"os_type,omitempty"` // template used to deploy a new VM, ignored for the existing VMs
+	Dataset     string           `json:"dataset,omitempty"` // parent dataset for a new VM, the first active dataset is used by default
+	Owner       string           `json:"owner,omitempty"`
+	CpuSockets  int              `json:"cpu_sockets,omitempty"` // 1 by default
+	CpuCores    int              `json:"cpu_cores"`
+	CpuThreads  int              `json:"cpu_threads,omitempty"` // 1 by default
+	Ram         string           `json:"ram"`                   // e.g. 4G
+	Production  *bool            `json:"production,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Tags        []string         `json:"tags,omitempty"`
+	Disks       []VmSpecDisk     `json:"disks,omitempty"` // the first disk is the OS disk
+	Networks    []VmSpecNetwork  `json:"networks,omitempty"`
+	CloudInit   *VmSpecCloudInit `json:"cloud_init,omitempty"`
+}
+
+type VmSpecDisk struct {
+	Type    string `json:"type,omitempty"` // nvme (default), virtio-blk or ahci-hd
+	Size    string `json:"size,omitempty"` // e.g. 20G, disks are only ever expanded, never shrunk
+	Comment string `json:"comment,omitempty"`
+}
+
+type VmSpecNetwork struct {
+	Network   string `json:"network"`
+	Driver    string `json:"driver,omitempty"`     // virtio-net (default) or e1000
+	IpAddress string `json:"ip_address,omitempty"` // a free IP is picked automatically if not set
+	Comment   string `json:"comment,omitempty"`
+}
+
+type VmSpecCloudInit struct {
+	IncludeHostSshKeys *bool                    `json:"include_host_ssh_keys,omitempty"`
+	SshKeys            []HosterVmUtils.VmSshKey `json:"ssh_keys,omitempty"`
+}
+
+const (
+	APPLY_DEPLOY      = "deploy"
+	APPLY_SET         = "set"
+	APPLY_ADD_DISK    = "add_disk"
+	APPLY_EXPAND_DISK = "expand_disk"
+	APPLY_ADD_NETWORK = "add_network"
+)
+
+// A single planned (or applied) change.
+type VmApplyChange struct {
+	Action          string `json:"action"` // deploy, set, add_disk, expand_disk or add_network
+	Target          string `json:"target"` // VM setting or device, e.g. memory, disk1, network0
+	Old             string `json:"old,omitempty"`
+	New             string `json:"new,omitempty"`
+	RestartRequired bool   `json:"restart_required"` // the VM is running, and the change will only be applied after the VM restart
+	StopRequired    bool   `json:"stop_required"`    // the VM is running, and the change can't be applied until the VM is stopped (dry run only)
+}
+
+type VmApplyResult struct {
+	VmName  string          `json:"vm_name"`
+	DryRun  bool            `json:"dry_run"`
+	Changes []VmApplyChange `json:"changes"` // empty if the VM already matches the spec
+}
+
+// Parses the VM spec. YAML and JSON are both accepted, unknown fields are rejected to avoid silently ignoring the typos.
+func ParseVmSpec(data []byte) (r VmSpec, e error) {
+	var value interface{}
+	err := yaml.Unmarshal(data, &value)
+	if err != nil {
+		e = fmt.Errorf("could not parse the VM spec: %s", err.Error())
+		return
+	}
+
+	value, err = yamlToJson(value)
+	if err != nil {
+		e = err
+		return
+	}
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		e = err
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&r)
+	if err != nil {
+		e = fmt.Errorf("could not parse the VM spec: %s", err.Error())
+		return
+	}
+
+	e = r.Validate()
+	return
+}
+
+// YAML maps are decoded with interface{} keys, which can't be marshalled to JSON
+func yamlToJson(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		r := make(map[string]interface{})
+		for key, val := range v {
+			keyString, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("VM spec keys must be strings, got: %v", key)
+			}
+			converted, err := yamlToJson(val)
+			if err != nil {
+				return nil, err
+			}
+			r[keyString] = converted
+		}
+		return r, nil
+	case []interface{}:
+		for i, val := range v {
+			converted, err := yamlToJson(val)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	}
+
+	return value, nil
+}
+
+func (spec VmSpec) Validate() error {
+	err := HosterVmUtils.ValidateResName(spec.Name)
+	if err != nil {
+		return err
+	}
+	if spec.Name == "test-vm" {
+		return errors.New("test-vm name is reserved for the automatically generated VM names")
+	}
+
+	if spec.CpuSockets < 0 || spec.CpuCores < 1 || spec.CpuThreads < 0 {
+		return errors.New("CPU sockets, cores and threads must be greater than 0")
+	}
+
+	_, err = byteconversion.HumanToBytes(spec.Ram)
+	if err != nil || len(spec.Ram) < 1 {
+		return fmt.Errorf("invalid RAM value: '%s'", spec.Ram)
+	}
+
+	validDrivers := []string{"nvme", "virtio-blk", "ahci-hd"}
+	for i, v := range spec.Disks {
+		if len(v.Type) > 0 && !slices.Contains(validDrivers, v.Type) {
+			return fmt.Errorf("disk%d: invalid disk type '%s', must be one of: nvme, virtio-blk, ahci-hd", i, v.Type)
+		}
+		if len(v.Size) > 0 {
+			_, err := byteconversion.HumanToBytes(v.Size)
+			if err != nil {
+				return fmt.Errorf("disk%d: invalid disk size '%s'", i, v.Size)
+			}
+		}
+	}
+
+	for i, v := range spec.Networks {
+		if len(v.Network) < 1 {
+			return fmt.Errorf("network%d: network name must be set", i)
+		}
+		if len(v.Driver) > 0 && v.Driver != "virtio-net" && v.Driver != "e1000" {
+			return fmt.Errorf("network%d: invalid network driver '%s', must be one of: virtio-net, e1000", i, v.Driver)
+		}
+	}
+
+	return nil
+}
@@ -36,7 +36,7 @@ func ChangeParent(vmName string, newParent string, ignoreLiveCheck bool) error {
 		}
 	}
 	if !found {
-		return errors.New("vm does not exist on this system")
+		return fmt.Errorf("%w: %s", HosterVmUtils.ErrVmDoesntExist, vmName)
 	}
 
 	if !ignoreLiveCheck {
@@ -5,8 +5,10 @@
 package HosterVm
 
 import (
+	HosterAdmission "HosterCore/internal/pkg/hoster/admission"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
 	zfsutils "HosterCore/internal/pkg/zfs_utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -32,7 +34,28 @@ func Clone(vmName string, newVmName string, snapshotName string) error {
 		}
 	}
 	if !vmFound {
-		return fmt.Errorf("vm doesn't exist")
+		return fmt.Errorf("%w: %s", HosterVmUtils.ErrVmDoesntExist, vmName)
+	}
+
+	vmConf, err := HosterVmUtils.GetVmConfig(vmInfo.Mountpoint + "/" + vmName)
+	if err != nil {
+		return err
+	}
+	vCpus := vmConf.CPUSockets * vmConf.CPUCores
+	if vmConf.CPUThreads > 0 {
+		vCpus = vCpus * vmConf.CPUThreads
+	}
+	err = HosterAdmission.Check(HosterAdmission.Request{
+		Action:       HosterAdmission.ACTION_CLONE,
+		ResourceType: HosterAdmission.RESOURCE_VM,
+		ResourceName: newVmName,
+		Owner:        vmConf.Owner,
+		VCpus:        vCpus,
+		Ram:          vmConf.Memory,
+		Dataset:      vmInfo.DsName,
+	})
+	if err != nil {
+		return err
 	}
 
 	snaps, err := zfsutils.SnapshotListAll()
@@ -56,7 +79,7 @@ func Clone(vmName string, newVmName string, snapshotName string) error {
 		}
 	}
 	if !snapFound {
-		return fmt.Errorf("snapshot doesn't exist")
+		return ErrorMappings.NewError(ErrorMappings.CODE_SNAPSHOT_NOT_FOUND, "snapshot doesn't exist: "+snapshotName)
 	}
 
 	out, err := exec.Command("zfs", "clone", snapshotName, vmInfo.DsName+"/"+newVmName).CombinedOutput()
@@ -65,6 +88,11 @@ func Clone(vmName string, newVmName string, snapshotName string) error {
 		log.Error("vm could not be cloned: " + vmName + "; error: " + errValue)
 		return fmt.Errorf(errValue)
 	}
+	err = zfsutils.CloneZvolChildren(snapshotName, vmInfo.DsName+"/"+newVmName)
+	if err != nil {
+		log.Error("vm zvol disks could not be cloned: " + vmName + "; error: " + err.Error())
+		return err
+	}
 
 	log.Warn("vm has been cloned: " + vmName + "; cloned vm name: " + newVmName)
 	return nil
@@ -9,11 +9,13 @@ package HosterVm
 
 import (
 	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	HosterAdmission "HosterCore/internal/pkg/hoster/admission"
 	HosterHost "HosterCore/internal/pkg/hoster/host"
 	HosterHostUtils "HosterCore/internal/pkg/hoster/host/utils"
 	HosterNetwork "HosterCore/internal/pkg/hoster/network"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
 	zfsutils "HosterCore/internal/pkg/zfs_utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
 	"bufio"
 	"errors"
 	"os"
@@ -25,18 +27,6 @@ import (
 	"github.com/google/uuid"
 )
 
-type VmDeployInput struct {
-	StartWhenRdy    bool   `json:"start_when_ready"`
-	VCpus           int    `json:"vcpus"`
-	VmName          string `json:"vm_name"`
-	RAM             string `json:"ram"`
-	NetworkName     string `json:"network_name"`
-	IpAddress       string `json:"ip_address"`
-	CustomDnsServer string `json:"custom_dns_server"`
-	OsType          string `json:"os_type"`
-	TargetDataset   string `json:"target_dataset"`
-}
-
 // Deploy a new VM. Returns an error if something went wrong.
 func Deploy(input VmDeployInput) error {
 	var err error
@@ -51,6 +41,21 @@ func Deploy(input VmDeployInput) error {
 		return err
 	}
 
+	if len(input.Owner) < 1 {
+		input.Owner = "system"
+	}
+	// The default dataset has to be known before the admission check, otherwise the pool free space policy is skipped
+	if len(input.TargetDataset) < 1 {
+		input.TargetDataset, err = defaultDeployDataset()
+		if err != nil {
+			return err
+		}
+	}
+	err = CheckDeployAdmission(input)
+	if err != nil {
+		return err
+	}
+
 	// Initialize values
 	c := ConfigOutput{}
 	// Set CPU cores and RAM
@@ -112,7 +117,7 @@ func Deploy(input VmDeployInput) error {
 			}
 		}
 		if len(c.NetworkName) < 1 {
-			return errors.New("network name supplied doesn't exist")
+			return ErrorMappings.NewError(ErrorMappings.CODE_NETWORK_NOT_FOUND, "network name supplied doesn't exist")
 		}
 	}
 
@@ -211,7 +216,7 @@ func Deploy(input VmDeployInput) error {
 	vmConfig.Production = c.Production
 	vmConfig.OsType = c.OsType
 	vmConfig.OsComment = c.OsComment
-	vmConfig.Owner = "system"
+	vmConfig.Owner = input.Owner
 	vmConfig.ParentHost = c.ParentHost
 	vmConfig.DnsSearchDomain = c.DnsSearchDomain
 
@@ -380,6 +385,37 @@ func getSystemSshKeys() (r []HosterVmUtils.VmSshKey, e error) {
 	return
 }
 
+// Checks the new VM against the host admission policies. Used by the ISO deployments too, which don't go through Deploy().
+func CheckDeployAdmission(input VmDeployInput) error {
+	owner := input.Owner
+	if len(owner) < 1 {
+		owner = "system"
+	}
+
+	return HosterAdmission.Check(HosterAdmission.Request{
+		Action:       HosterAdmission.ACTION_DEPLOY,
+		ResourceType: HosterAdmission.RESOURCE_VM,
+		ResourceName: input.VmName,
+		Owner:        owner,
+		VCpus:        input.VCpus,
+		Ram:          input.RAM,
+		Dataset:      input.TargetDataset,
+	})
+}
+
+// Returns the first active dataset, which is used if the deployment doesn't specify one.
+func defaultDeployDataset() (string, error) {
+	hostConf, err := HosterHost.GetHostConfig()
+	if err != nil {
+		return "", err
+	}
+	if len(hostConf.ActiveZfsDatasets) < 1 {
+		return "", errors.New("there are no active datasets on this host")
+	}
+
+	return hostConf.ActiveZfsDatasets[0], nil
+}
+
 func zfsDatasetClone(dsParent string, osType string, newVmName string) (bool, error) {
 	vmTemplateExist := "/" + dsParent + "/template-" + osType + "/disk0.img"
 	_, err := os.Stat(vmTemplateExist)
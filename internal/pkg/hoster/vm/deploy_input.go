@@ -0,0 +1,19 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVm
+
+// Kept outside of the FreeBSD specific deploy.go, because the struct is shared with the REST API client.
+type VmDeployInput struct {
+	StartWhenRdy    bool   `json:"start_when_ready"`
+	VCpus           int    `json:"vcpus"`
+	VmName          string `json:"vm_name"`
+	RAM             string `json:"ram"`
+	NetworkName     string `json:"network_name"`
+	IpAddress       string `json:"ip_address"`
+	CustomDnsServer string `json:"custom_dns_server"`
+	OsType          string `json:"os_type"`
+	TargetDataset   string `json:"target_dataset"`
+	Owner           string `json:"owner,omitempty"` // Defaults to "system", used by the admission owner quotas
+}
@@ -25,7 +25,7 @@ func Destroy(vmName string) error {
 		return err
 	}
 	if slices.Contains(running, vmName) {
-		return fmt.Errorf("vm is running")
+		return fmt.Errorf("%w: %s", HosterVmUtils.ErrVmIsRunning, vmName)
 	}
 
 	vms, err := HosterVmUtils.ListAllSimple()
@@ -42,7 +42,7 @@ func Destroy(vmName string) error {
 		}
 	}
 	if !vmFound {
-		return fmt.Errorf("vm doesn't exist")
+		return fmt.Errorf("%w: %s", HosterVmUtils.ErrVmDoesntExist, vmName)
 	}
 
 	vmDataset := vmInfo.DsName + "/" + vmName
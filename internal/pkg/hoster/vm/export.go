@@ -0,0 +1,327 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVm
+
+import (
+	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	HosterNetwork "HosterCore/internal/pkg/hoster/network"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// VM export archive (tar.zst) layout:
+//
+//	hoster-export.json          - VmExportHeader, always the first entry
+//	vm_config.json              - VM config, as is
+//	disk0.img, seed.iso, ...    - VM folder files: internal disk images, cloud-init seed and files, README
+//	zvols/<name>                - raw zvol data
+//	manifest.json               - VmExportManifest (sha256 of every entry above), always the last entry
+const (
+	VM_EXPORT_FORMAT_VERSION = 1
+
+	vmExportHeaderFile   = "hoster-export.json"
+	vmExportManifestFile = "manifest.json"
+	vmExportZvolDir      = "zvols/"
+)
+
+type VmExportHeader struct {
+	FormatVersion int                           `json:"format_version"`
+	VmName        string                        `json:"vm_name"`
+	SourceHost    string                        `json:"source_host"`
+	Created       string                        `json:"created"`  // RFC3339
+	Networks      []HosterNetwork.NetworkConfig `json:"networks"` // source host networks used by the VM, to remap the cloud-init network config on import
+	Disks         []VmExportDisk                `json:"disks"`
+}
+
+type VmExportDisk struct {
+	Path         string                     `json:"path"` // archive entry, e.g. disk0.img or zvols/disk1
+	DiskImage    string                     `json:"disk_image"`
+	DiskLocation string                     `json:"disk_location"`
+	Size         int64                      `json:"size"` // logical size in bytes
+	Zvol         *HosterVmUtils.ZvolOptions `json:"zvol,omitempty"`
+}
+
+type VmExportManifest struct {
+	Algorithm string                 `json:"algorithm"`
+	Files     []VmExportManifestFile `json:"files"`
+}
+
+type VmExportManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// Prepared VM export, created by NewVmExport and streamed by Write.
+type VmExport struct {
+	Header  VmExportHeader
+	sources []vmExportSource
+}
+
+type vmExportSource struct {
+	source string // file or zvol device
+	path   string // archive entry
+	size   int64
+}
+
+// Collects everything that goes into the VM export archive: VM config, internal disk images and zvols,
+// cloud-init seed and files, README. External disks (e.g. installation ISOs) are not exported.
+//
+// The VM has to be offline, otherwise the disk images would be inconsistent.
+func NewVmExport(vmName string) (r VmExport, e error) {
+	vm, err := HosterVmUtils.InfoJsonApi(vmName)
+	if err != nil {
+		e = err
+		return
+	}
+	if vm.Running {
+		e = errors.New("vm must be offline to perform this operation")
+		return
+	}
+
+	vmDataset := vm.Simple.DsName + "/" + vmName
+	vmFolder := vm.Simple.Mountpoint + "/" + vmName
+	hostname, _ := FreeBSDsysctls.SysctlKernHostname()
+
+	r.Header = VmExportHeader{
+		FormatVersion: VM_EXPORT_FORMAT_VERSION,
+		VmName:        vmName,
+		SourceHost:    hostname,
+		Created:       time.Now().UTC().Format(time.RFC3339),
+		Networks:      []HosterNetwork.NetworkConfig{},
+		Disks:         []VmExportDisk{},
+	}
+
+	networks, err := HosterNetwork.GetNetworkConfig()
+	if err != nil {
+		e = err
+		return
+	}
+	for _, v := range networks {
+		for _, vv := range vm.VmConfig.Networks {
+			if v.NetworkName == vv.NetworkBridge {
+				r.Header.Networks = append(r.Header.Networks, v)
+				break
+			}
+		}
+	}
+
+	added := []string{}
+	addFile := func(path string) error {
+		if slices.Contains(added, path) {
+			return nil
+		}
+		info, err := os.Stat(vmFolder + "/" + path)
+		if err != nil {
+			return err
+		}
+		r.sources = append(r.sources, vmExportSource{source: vmFolder + "/" + path, path: path, size: info.Size()})
+		added = append(added, path)
+		return nil
+	}
+
+	err = addFile(HosterVmUtils.VM_CONFIG_NAME)
+	if err != nil {
+		e = err
+		return
+	}
+
+	for _, v := range vm.VmConfig.Disks {
+		disk := VmExportDisk{DiskImage: v.DiskImage, DiskLocation: v.DiskLocation, Zvol: v.Zvol}
+		switch v.DiskLocation {
+		case "internal":
+			if strings.HasPrefix(filepath.Clean(v.DiskImage), "..") {
+				e = errors.New("internal disk image is outside of the VM folder: " + v.DiskImage)
+				return
+			}
+			disk.Path = filepath.Clean(v.DiskImage)
+			err = addFile(disk.Path)
+			if err != nil {
+				e = err
+				return
+			}
+			disk.Size = r.sources[len(r.sources)-1].size
+		case HosterVmUtils.DISK_LOCATION_ZVOL:
+			size, err := HosterVmUtils.ZvolSize(vmDataset + "/" + v.DiskImage)
+			if err != nil {
+				e = err
+				return
+			}
+			disk.Path = vmExportZvolDir + v.DiskImage
+			disk.Size = int64(size)
+			r.sources = append(r.sources, vmExportSource{
+				source: HosterVmUtils.VmDiskPath(vmDataset, vmFolder, v),
+				path:   disk.Path,
+				size:   disk.Size,
+			})
+		default:
+			continue
+		}
+		r.Header.Disks = append(r.Header.Disks, disk)
+	}
+
+	// Cloud-init seed and files, and the README (if they exist)
+	files, err := os.ReadDir(vmFolder)
+	if err != nil {
+		e = err
+		return
+	}
+	for _, v := range files {
+		if v.Name() == "seed.iso" || strings.ToLower(v.Name()) == "readme.md" {
+			err = addFile(v.Name())
+			if err != nil {
+				e = err
+				return
+			}
+		}
+	}
+	ciFiles, _ := os.ReadDir(vmFolder + "/cloud-init-files")
+	for _, v := range ciFiles {
+		if v.Type().IsRegular() {
+			err = addFile("cloud-init-files/" + v.Name())
+			if err != nil {
+				e = err
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// Total (uncompressed) size of the exported data.
+func (v VmExport) Size() (r int64) {
+	for _, vv := range v.sources {
+		r += vv.size
+	}
+	return
+}
+
+// Streams the VM export archive (tar.zst) into w. Holes in the disk images are not read from the disk,
+// and compress down to almost nothing, so the archive stays close to the actual data size.
+func (v VmExport) Write(w io.Writer) error {
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(zw)
+
+	manifest := VmExportManifest{Algorithm: "sha256", Files: []VmExportManifestFile{}}
+
+	header, err := json.MarshalIndent(v.Header, "", "   ")
+	if err != nil {
+		return err
+	}
+	file, err := writeVmExportEntry(tw, vmExportHeaderFile, int64(len(header)), func(w io.Writer) error {
+		_, err := w.Write(header)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	manifest.Files = append(manifest.Files, file)
+
+	for _, vv := range v.sources {
+		source := vv
+		file, err := writeVmExportEntry(tw, source.path, source.size, func(w io.Writer) error {
+			f, err := os.Open(source.source)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return HosterVmUtils.SparseRead(w, f, source.size)
+		})
+		if err != nil {
+			return fmt.Errorf("could not export %s: %s", source.path, err.Error())
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	manifestJson, err := json.MarshalIndent(manifest, "", "   ")
+	if err != nil {
+		return err
+	}
+	_, err = writeVmExportEntry(tw, vmExportManifestFile, int64(len(manifestJson)), func(w io.Writer) error {
+		_, err := w.Write(manifestJson)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Exports the VM into a tar.zst file.
+func ExportVm(vmName string, archive string) error {
+	// If the logger was already set, ignore this
+	if !log.ConfigSet {
+		log.SetFileLocation(HosterVmUtils.VM_AUDIT_LOG_LOCATION)
+	}
+
+	export, err := NewVmExport(vmName)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(archive, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	err = export.Write(f)
+	if err != nil {
+		f.Close()
+		_ = os.Remove(archive)
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	log.Info("vm exported: " + vmName + " -> " + archive)
+	return nil
+}
+
+func writeVmExportEntry(tw *tar.Writer, path string, size int64, write func(w io.Writer) error) (r VmExportManifestFile, e error) {
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     path,
+		Size:     size,
+		Mode:     0640,
+		ModTime:  time.Now(),
+	})
+	if err != nil {
+		e = err
+		return
+	}
+
+	hash := sha256.New()
+	err = write(io.MultiWriter(tw, hash))
+	if err != nil {
+		e = err
+		return
+	}
+
+	r = VmExportManifestFile{Path: path, Size: size, Sha256: hex.EncodeToString(hash.Sum(nil))}
+	return
+}
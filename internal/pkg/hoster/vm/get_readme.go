@@ -53,6 +53,6 @@ func GetReadme(vmName string) (r string, e error) {
 		}
 	}
 
-	e = fmt.Errorf("vm not found")
+	e = fmt.Errorf("%w: %s", HosterVmUtils.ErrVmDoesntExist, vmName)
 	return
 }
@@ -0,0 +1,618 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVm
+
+import (
+	FileExists "HosterCore/internal/pkg/file_exists"
+	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	HosterAdmission "HosterCore/internal/pkg/hoster/admission"
+	HosterHost "HosterCore/internal/pkg/hoster/host"
+	HosterHostUtils "HosterCore/internal/pkg/hoster/host/utils"
+	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	HosterNetwork "HosterCore/internal/pkg/hoster/network"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	HosterZfs "HosterCore/internal/pkg/hoster/zfs"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
+)
+
+type VmImportInput struct {
+	VmName        string `json:"vm_name"`        // the name stored in the archive is used by default
+	Dataset       string `json:"dataset"`        // parent dataset, e.g. zroot/vm-encrypted (the first active dataset is used by default)
+	Network       string `json:"network"`        // move all network interfaces to this network, by default they keep their network (if it exists on this host)
+	RegenerateMac bool   `json:"regenerate_mac"` // always generate new MAC addresses, e.g. if the source VM is still running on the same L2 network
+	// Generate a new cloud-init instance-id if the primary interface address changes, so the guest applies the new network config on the next boot.
+	// cloud-init then re-runs all of its per-instance modules: SSH host keys are regenerated, and the user-data (passwords, SSH keys) is applied again.
+	ResetInstanceId bool `json:"reset_instance_id"`
+}
+
+type VmImportResult struct {
+	VmName   string                    `json:"vm_name"`
+	Dataset  string                    `json:"dataset"`
+	VncPort  int                       `json:"vnc_port"`
+	Networks []HosterVmUtils.VmNetwork `json:"networks"`
+	Warnings []string                  `json:"warnings"`
+}
+
+// Recreates the VM from the export archive (tar.zst, check NewVmExport) on this host.
+//
+// Every archive entry is verified against the checksum manifest, and the VM is remapped to this host:
+// parent host and VNC port are always reset, network interfaces are moved to the target network, and keep their
+// MAC and IP addresses, unless they conflict with this host (or don't fit the network range). The VM UUID is regenerated
+// if another VM on this host uses it already.
+// If the primary interface address changes, the cloud-init network config gets updated, but the instance-id stays the same,
+// so the guest keeps its old network settings until they are changed manually (a warning is returned),
+// or until the cloud-init runs again as a new instance (ResetInstanceId, which also regenerates the SSH host keys).
+//
+// Nothing is left behind if the import fails, the new VM dataset is destroyed.
+func ImportVm(archive io.Reader, input VmImportInput) (r VmImportResult, e error) {
+	// If the logger was already set, ignore this
+	if !log.ConfigSet {
+		log.SetFileLocation(HosterVmUtils.VM_AUDIT_LOG_LOCATION)
+	}
+	r.Warnings = []string{}
+
+	zr, err := zstd.NewReader(archive)
+	if err != nil {
+		e = err
+		return
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	// Header and the VM config come first, everything is checked before the dataset is created
+	received := map[string]VmExportManifestFile{}
+	headerJson, err := readVmImportEntry(tr, vmExportHeaderFile, received)
+	if err != nil {
+		e = archiveError("not a Hoster VM export archive: %s", err.Error())
+		return
+	}
+	header := VmExportHeader{}
+	err = json.Unmarshal(headerJson, &header)
+	if err != nil {
+		e = archiveError("could not parse the archive header: %s", err.Error())
+		return
+	}
+	if header.FormatVersion < 1 || header.FormatVersion > VM_EXPORT_FORMAT_VERSION {
+		e = archiveError("unsupported export format version %d, upgrade Hoster on this host", header.FormatVersion)
+		return
+	}
+	configJson, err := readVmImportEntry(tr, HosterVmUtils.VM_CONFIG_NAME, received)
+	if err != nil {
+		e = archiveError("could not read the archive: %s", err.Error())
+		return
+	}
+	conf, configVersion, err := HosterVmUtils.MigrateVmConfig(configJson)
+	if err != nil {
+		e = archiveError("could not parse the archive VM config: %s", err.Error())
+		return
+	}
+	// Newer configs can't be written back, which has to be known before anything is written
+	err = HosterVmUtils.CheckVmConfigVersion(configVersion)
+	if err != nil {
+		e = archiveError("%s", err.Error())
+		return
+	}
+
+	r.VmName = input.VmName
+	if len(r.VmName) < 1 {
+		r.VmName = header.VmName
+	}
+	err = HosterVmUtils.ValidateResName(r.VmName)
+	if err != nil {
+		e = err
+		return
+	}
+
+	r.Dataset = input.Dataset
+	hostConf, err := HosterHost.GetHostConfig()
+	if err != nil {
+		e = err
+		return
+	}
+	if len(r.Dataset) < 1 && len(hostConf.ActiveZfsDatasets) > 0 {
+		r.Dataset = hostConf.ActiveZfsDatasets[0]
+	}
+	if !slices.Contains(hostConf.ActiveZfsDatasets, r.Dataset) {
+		e = ErrorMappings.NewError(ErrorMappings.CODE_DATASET_NOT_ACTIVE, "dataset is not active on this host: "+r.Dataset)
+		return
+	}
+	vmDataset := r.Dataset + "/" + r.VmName
+	err = checkVmNameAvailable(r.VmName, vmDataset)
+	if err != nil {
+		e = err
+		return
+	}
+
+	vCpus := conf.CPUSockets * conf.CPUCores
+	if conf.CPUThreads > 0 {
+		vCpus = vCpus * conf.CPUThreads
+	}
+	err = HosterAdmission.Check(HosterAdmission.Request{
+		Action:       HosterAdmission.ACTION_IMPORT,
+		ResourceType: HosterAdmission.RESOURCE_VM,
+		ResourceName: r.VmName,
+		Owner:        conf.Owner,
+		VCpus:        vCpus,
+		Ram:          conf.Memory,
+		Dataset:      r.Dataset,
+	})
+	if err != nil {
+		e = err
+		return
+	}
+
+	out, err := exec.Command("zfs", "create", vmDataset).CombinedOutput()
+	if err != nil {
+		e = fmt.Errorf("could not create the VM dataset: %s; %s", strings.TrimSpace(string(out)), err.Error())
+		return
+	}
+	defer func() {
+		if e == nil {
+			return
+		}
+		out, err := exec.Command("zfs", "destroy", "-r", vmDataset).CombinedOutput()
+		if err != nil {
+			log.Error("vm import failed, and the dataset could not be removed: " + vmDataset + "; " + strings.TrimSpace(string(out)))
+		}
+	}()
+
+	vmFolder := "/" + vmDataset
+	mountPoints, err := HosterZfs.ListMountPoints()
+	if err != nil {
+		e = err
+		return
+	}
+	for _, v := range mountPoints {
+		if v.DsName == r.Dataset {
+			vmFolder = v.Mountpoint + "/" + r.VmName
+		}
+	}
+
+	// The VM config is written last, after it was remapped, so the VM doesn't show up
+	// (with its source MAC and IP addresses) before the import is done
+	manifest := VmExportManifest{}
+	for {
+		th, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			e = archiveError("could not read the archive: %s", err.Error())
+			return
+		}
+		if th.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if th.Name == vmExportManifestFile {
+			data, err := io.ReadAll(io.LimitReader(tr, 1024*1024))
+			if err != nil {
+				e = err
+				return
+			}
+			err = json.Unmarshal(data, &manifest)
+			if err != nil {
+				e = archiveError("could not parse the archive manifest: %s", err.Error())
+				return
+			}
+			continue
+		}
+
+		file, err := importVmArchiveEntry(tr, th, header, vmDataset, vmFolder)
+		if err != nil {
+			e = fmt.Errorf("could not import %s: %w", th.Name, err)
+			return
+		}
+		received[file.Path] = file
+	}
+
+	err = verifyVmImportManifest(manifest, received)
+	if err != nil {
+		e = err
+		return
+	}
+
+	oldConf := conf
+	oldConf.Networks = slices.Clone(conf.Networks)
+	conf, warnings, err := remapImportedVmConfig(conf, input)
+	if err != nil {
+		e = err
+		return
+	}
+	r.Warnings = append(r.Warnings, warnings...)
+	r.VncPort = conf.VncPort
+	r.Networks = conf.Networks
+
+	err = HosterVmUtils.ConfigFileWriter(conf, vmFolder+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	if err != nil {
+		e = err
+		return
+	}
+	err = HosterVmUtils.ValidateVmConfigOnHost(r.VmName, vmFolder, conf)
+	if err != nil {
+		e = err
+		return
+	}
+
+	warnings, err = remapImportedVmCloudInit(vmFolder, r.VmName, header, oldConf, conf, input.ResetInstanceId)
+	if err != nil {
+		e = fmt.Errorf("could not update the cloud-init config: %s", err.Error())
+		return
+	}
+	r.Warnings = append(r.Warnings, warnings...)
+
+	_, err = HosterVmUtils.WriteCache()
+	if err != nil {
+		log.Debug("could not refresh the VM cache: " + err.Error())
+	}
+	err = HosterHostUtils.ReloadDns()
+	if err != nil {
+		log.Debug("could not reload the DNS server: " + err.Error())
+	}
+
+	log.Info("vm imported: " + r.VmName + " (exported as " + header.VmName + " from " + header.SourceHost + ")")
+	return
+}
+
+// Reads the small (JSON) archive entry, which has to be the next one in the archive.
+func readVmImportEntry(tr *tar.Reader, path string, received map[string]VmExportManifestFile) (r []byte, e error) {
+	th, err := tr.Next()
+	if err != nil {
+		e = err
+		return
+	}
+	if th.Name != path {
+		e = fmt.Errorf("expected %s, got %s", path, th.Name)
+		return
+	}
+
+	r, err = io.ReadAll(io.LimitReader(tr, 1024*1024))
+	if err != nil {
+		e = err
+		return
+	}
+	hash := sha256.Sum256(r)
+	received[path] = VmExportManifestFile{Path: path, Size: int64(len(r)), Sha256: hex.EncodeToString(hash[:])}
+	return
+}
+
+// Writes the archive entry into the VM folder, or into a new zvol (zvols/<name> entries).
+func importVmArchiveEntry(tr *tar.Reader, th *tar.Header, header VmExportHeader, vmDataset string, vmFolder string) (r VmExportManifestFile, e error) {
+	path := filepath.Clean(th.Name)
+	if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, "../") ||
+		path == HosterVmUtils.VM_CONFIG_NAME || path == vmExportHeaderFile {
+		e = archiveError("invalid archive entry")
+		return
+	}
+
+	var dst *os.File
+	var err error
+	if strings.HasPrefix(path, vmExportZvolDir) {
+		diskIndex := slices.IndexFunc(header.Disks, func(d VmExportDisk) bool { return d.Path == path })
+		if diskIndex < 0 || header.Disks[diskIndex].DiskLocation != HosterVmUtils.DISK_LOCATION_ZVOL {
+			e = archiveError("zvol is not listed in the archive header")
+			return
+		}
+		disk := header.Disks[diskIndex]
+		if !HosterVmUtils.IsValidZvolName(disk.DiskImage) {
+			e = archiveError("invalid zvol name in the archive header: %s", disk.DiskImage)
+			return
+		}
+		opts := HosterVmUtils.ZvolOptions{}
+		if disk.Zvol != nil {
+			opts = *disk.Zvol
+		}
+		err = HosterVmUtils.CreateZvol(vmDataset+"/"+disk.DiskImage, uint64(th.Size), opts)
+		if err != nil {
+			e = err
+			return
+		}
+		dst, err = os.OpenFile("/dev/zvol/"+vmDataset+"/"+disk.DiskImage, os.O_WRONLY, 0)
+	} else {
+		err = os.MkdirAll(filepath.Dir(vmFolder+"/"+path), 0750)
+		if err != nil {
+			e = err
+			return
+		}
+		dst, err = os.OpenFile(vmFolder+"/"+path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	}
+	if err != nil {
+		e = err
+		return
+	}
+	defer dst.Close()
+
+	hash := sha256.New()
+	n, err := HosterVmUtils.SparseCopy(dst, io.TeeReader(tr, hash))
+	if err != nil {
+		e = err
+		return
+	}
+
+	r = VmExportManifestFile{Path: path, Size: n, Sha256: hex.EncodeToString(hash.Sum(nil))}
+	return
+}
+
+// Every archive entry must be listed in the manifest with the same checksum, and every manifest entry must be present.
+func verifyVmImportManifest(manifest VmExportManifest, received map[string]VmExportManifestFile) error {
+	if manifest.Algorithm != "sha256" || len(manifest.Files) < 1 {
+		return archiveError("archive manifest is missing or invalid, the archive may be truncated")
+	}
+
+	listed := []string{}
+	for _, v := range manifest.Files {
+		file, found := received[v.Path]
+		if !found {
+			return archiveError("archive is missing a file listed in the manifest: %s", v.Path)
+		}
+		if file.Size != v.Size || file.Sha256 != v.Sha256 {
+			return archiveError("checksum mismatch: %s", v.Path)
+		}
+		listed = append(listed, v.Path)
+	}
+	for k := range received {
+		if !slices.Contains(listed, k) {
+			return archiveError("archive file is not listed in the manifest: %s", k)
+		}
+	}
+
+	return nil
+}
+
+// Remaps the imported VM config to this host: parent, VNC port, networks and MAC addresses.
+func remapImportedVmConfig(conf HosterVmUtils.VmConfig, input VmImportInput) (r HosterVmUtils.VmConfig, warnings []string, e error) {
+	r = conf
+	warnings = []string{}
+
+	r.ParentHost, _ = FreeBSDsysctls.SysctlKernHostname()
+	vncPort, err := HosterVmUtils.GenerateVncPort()
+	if err != nil {
+		e = err
+		return
+	}
+	if vncPort != r.VncPort {
+		warnings = append(warnings, fmt.Sprintf("VNC port was changed from %d to %d", r.VncPort, vncPort))
+	}
+	r.VncPort = vncPort
+
+	// Disk sizes are not a part of the config, and the external images may not exist on this host
+	r.Disks = []HosterVmUtils.VmDisk{}
+	for _, v := range conf.Disks {
+		v.DiskSize = HosterVmUtils.DiskSize{}
+		if v.DiskLocation == "external" && !FileExists.CheckUsingOsStat(v.DiskImage) {
+			warnings = append(warnings, "external disk was removed from the config, it doesn't exist on this host: "+v.DiskImage)
+			continue
+		}
+		r.Disks = append(r.Disks, v)
+	}
+
+	hostNetworks, err := HosterNetwork.GetNetworkConfig()
+	if err != nil {
+		e = err
+		return
+	}
+	vms, err := HosterVmUtils.ListJsonApi()
+	if err != nil {
+		e = err
+		return
+	}
+	usedMacs := []string{}
+	for _, v := range vms {
+		for _, vv := range v.Networks {
+			usedMacs = append(usedMacs, strings.ToLower(vv.NetworkMac))
+		}
+	}
+
+	// The UUID is exposed to the guest (SMBIOS), two VMs with the same one look like the same machine
+	if len(r.UUID) > 0 && slices.ContainsFunc(vms, func(v HosterVmUtils.VmApi) bool { return strings.EqualFold(v.UUID, r.UUID) }) {
+		oldUuid := r.UUID
+		r.UUID = uuid.New().String()
+		warnings = append(warnings, fmt.Sprintf("UUID was changed from %s to %s, another VM on this host uses it already", oldUuid, r.UUID))
+	}
+
+	r.Networks = []HosterVmUtils.VmNetwork{}
+	for i, v := range conf.Networks {
+		nic := v
+		if len(input.Network) > 0 {
+			nic.NetworkBridge = input.Network
+		}
+		net := HosterNetwork.NetworkConfig{}
+		for _, vv := range hostNetworks {
+			if vv.NetworkName == nic.NetworkBridge {
+				net = vv
+				break
+			}
+		}
+		if len(net.NetworkName) < 1 {
+			e = ErrorMappings.NewError(ErrorMappings.CODE_IMPORT_NETWORK_MISSING, "network "+nic.NetworkBridge+" doesn't exist on this host, pick the target network explicitly")
+			return
+		}
+
+		if nic.VlanTag != 0 {
+			_, err = HosterNetwork.CheckVlanSupport(nic.NetworkBridge, nic.VlanTag)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("NIC %d VLAN tag %d was removed: %s", i, nic.VlanTag, err.Error()))
+				nic.VlanTag = 0
+			}
+		}
+
+		if input.RegenerateMac || slices.Contains(usedMacs, strings.ToLower(nic.NetworkMac)) {
+			nic.NetworkMac, err = HosterVmUtils.GenerateMacAddress()
+			if err != nil {
+				e = err
+				return
+			}
+			warnings = append(warnings, fmt.Sprintf("NIC %d MAC address was changed from %s to %s", i, v.NetworkMac, nic.NetworkMac))
+		}
+		usedMacs = append(usedMacs, strings.ToLower(nic.NetworkMac))
+
+		ipUsed, err := isVmImportIpUsed(nic.IPAddress, nic.NetworkBridge, r.Networks)
+		if err != nil {
+			e = err
+			return
+		}
+		if ipUsed || !HosterHostUtils.IsIpWithinRange(nic.IPAddress, net.Subnet, net.RangeStart, net.RangeEnd) {
+			nic.IPAddress, err = HosterHostUtils.GenerateNewRandomIp(nic.NetworkBridge)
+			if err != nil {
+				e = err
+				return
+			}
+			// The new VM is not listed yet, so its own new addresses have to be checked separately
+			for slices.ContainsFunc(r.Networks, func(n HosterVmUtils.VmNetwork) bool { return n.IPAddress == nic.IPAddress }) {
+				nic.IPAddress, err = HosterHostUtils.GenerateNewRandomIp(nic.NetworkBridge)
+				if err != nil {
+					e = err
+					return
+				}
+			}
+			warnings = append(warnings, fmt.Sprintf("NIC %d IP address was changed from %s to %s", i, v.IPAddress, nic.IPAddress))
+		}
+
+		r.Networks = append(r.Networks, nic)
+	}
+
+	return
+}
+
+func isVmImportIpUsed(ipAddress string, networkName string, imported []HosterVmUtils.VmNetwork) (bool, error) {
+	for _, v := range imported {
+		if v.IPAddress == ipAddress {
+			return true, nil
+		}
+	}
+
+	vms, err := HosterVmUtils.ListJsonApi()
+	if err != nil {
+		return false, err
+	}
+	for _, v := range vms {
+		for _, vv := range v.Networks {
+			if vv.NetworkBridge == networkName && vv.IPAddress == ipAddress {
+				return true, nil
+			}
+		}
+	}
+
+	jails, err := HosterJailUtils.ListJsonApi()
+	if err != nil {
+		return false, err
+	}
+	for _, v := range jails {
+		if v.IPAddress == ipAddress {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Updates the cloud-init network config (primary interface only, the way it's generated on deploy),
+// hostname and the seed ISO.
+//
+// The instance-id is only changed if resetInstanceId is set: cloud-init applies the network config once per instance,
+// and a new instance-id also re-runs the rest of the per-instance modules (new SSH host keys, user-data passwords applied again).
+func remapImportedVmCloudInit(vmFolder string, vmName string, header VmExportHeader, oldConf HosterVmUtils.VmConfig, newConf HosterVmUtils.VmConfig, resetInstanceId bool) (warnings []string, e error) {
+	warnings = []string{}
+	networkConfigFile := vmFolder + "/cloud-init-files/network-config"
+	metaDataFile := vmFolder + "/cloud-init-files/meta-data"
+	if !FileExists.CheckUsingOsStat(networkConfigFile) || !FileExists.CheckUsingOsStat(metaDataFile) {
+		return
+	}
+	if len(oldConf.Networks) < 1 || len(newConf.Networks) < 1 {
+		e = renameVmCloudInitHostname(vmFolder, vmName)
+		return
+	}
+
+	oldNic := oldConf.Networks[0]
+	newNic := newConf.Networks[0]
+	oldNet := HosterNetwork.NetworkConfig{}
+	for _, v := range header.Networks {
+		if v.NetworkName == oldNic.NetworkBridge {
+			oldNet = v
+		}
+	}
+	newNet := HosterNetwork.NetworkConfig{}
+	hostNetworks, err := HosterNetwork.GetNetworkConfig()
+	if err != nil {
+		e = err
+		return
+	}
+	for _, v := range hostNetworks {
+		if v.NetworkName == newNic.NetworkBridge {
+			newNet = v
+		}
+	}
+
+	_, oldPrefix, _ := strings.Cut(oldNet.Subnet, "/")
+	_, newPrefix, _ := strings.Cut(newNet.Subnet, "/")
+	changed := !strings.EqualFold(oldNic.NetworkMac, newNic.NetworkMac) || oldNic.IPAddress != newNic.IPAddress ||
+		oldPrefix != newPrefix || oldNet.Gateway != newNet.Gateway
+	if !changed {
+		e = renameVmCloudInitHostname(vmFolder, vmName)
+		return
+	}
+
+	networkConfig, err := os.ReadFile(networkConfigFile)
+	if err != nil {
+		e = err
+		return
+	}
+	replacer := strings.NewReplacer(
+		`macaddress: "`+oldNic.NetworkMac+`"`, `macaddress: "`+newNic.NetworkMac+`"`,
+		`macaddress: "`+strings.ToLower(oldNic.NetworkMac)+`"`, `macaddress: "`+newNic.NetworkMac+`"`,
+		"- "+oldNic.IPAddress+"/"+oldPrefix, "- "+newNic.IPAddress+"/"+newPrefix,
+		"gateway4: "+oldNet.Gateway, "gateway4: "+newNet.Gateway,
+		"addresses: ["+oldNet.Gateway+", ]", "addresses: ["+newNet.Gateway+", ]",
+		"search: [ "+oldConf.ParentHost+".internal.lan, ]", "search: [ "+newConf.ParentHost+".internal.lan, ]",
+	)
+	err = os.WriteFile(networkConfigFile, []byte(replacer.Replace(string(networkConfig))), 0640)
+	if err != nil {
+		e = err
+		return
+	}
+
+	if !resetInstanceId {
+		warnings = append(warnings, fmt.Sprintf("primary NIC address was changed to %s, update the network settings inside of the VM (cloud-init only applies them to a new instance)", newNic.IPAddress))
+		e = renameVmCloudInitHostname(vmFolder, vmName)
+		return
+	}
+
+	metaData, err := os.ReadFile(metaDataFile)
+	if err != nil {
+		e = err
+		return
+	}
+	reMatchInstanceId := regexp.MustCompile(`(?m)^instance-id:.*$`)
+	metaData = reMatchInstanceId.ReplaceAll(metaData, []byte("instance-id: iid-"+HosterHostUtils.GenerateRandomPassword(5, false, true)))
+	err = os.WriteFile(metaDataFile, metaData, 0640)
+	if err != nil {
+		e = err
+		return
+	}
+	warnings = append(warnings, "cloud-init instance-id was reset, the guest will regenerate its SSH host keys and apply the user-data again on the next boot")
+
+	e = renameVmCloudInitHostname(vmFolder, vmName)
+	return
+}
+
+// Archive errors are reported as ARCHIVE_INVALID by the REST API.
+func archiveError(format string, a ...any) error {
+	return &ErrorMappings.CodedError{Code: ErrorMappings.CODE_ARCHIVE_INVALID, Err: fmt.Errorf(format, a...)}
+}
@@ -2,12 +2,18 @@ package HosterVm
 
 import (
 	HosterHostUtils "HosterCore/internal/pkg/hoster/host/utils"
+	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
 	HosterNetwork "HosterCore/internal/pkg/hoster/network"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 )
 
-func AddNewVmNetwork(vmName string, network HosterVmUtils.VmNetwork) error {
+func AddNewVmNetwork(ctx context.Context, vmName string, network HosterVmUtils.VmNetwork) error {
 	if len(network.NetworkMac) < 1 {
 		var err error
 		network.NetworkMac, err = HosterVmUtils.GenerateMacAddress()
@@ -65,7 +71,13 @@ func AddNewVmNetwork(vmName string, network HosterVmUtils.VmNetwork) error {
 		}
 	}
 	if !networkBridgeFound {
-		return errors.New("network bridge not found")
+		return ErrorMappings.NewError(ErrorMappings.CODE_NETWORK_NOT_FOUND, "network bridge not found")
+	}
+	if network.VlanTag != 0 {
+		_, err = HosterNetwork.CheckVlanSupport(network.NetworkBridge, network.VlanTag)
+		if err != nil {
+			return err
+		}
 	}
 
 OUTER:
@@ -86,6 +98,12 @@ OUTER:
 		return errors.New("IP address is not within the network range")
 	}
 
+	if !ipConflict {
+		ipConflict, err = jailIpAddressInUse(network.IPAddress, network.NetworkBridge)
+		if err != nil {
+			return err
+		}
+	}
 	if ipConflict {
 		return errors.New("IP address is already in use")
 	}
@@ -93,11 +111,226 @@ OUTER:
 		return errors.New("MAC address is already in use")
 	}
 
-	vm.VmConfig.Networks = append(vm.VmConfig.Networks, network)
-	err = HosterVmUtils.ConfigFileWriter(vm.VmConfig, vm.Simple.Mountpoint+"/"+vm.Name+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	oldConfig := vm.VmConfig
+	vm.VmConfig.Networks = append(slices.Clone(vm.VmConfig.Networks), network)
+	err = HosterVmUtils.ValidateVmConfigChange(vm.Name, vm.Simple.Mountpoint+"/"+vm.Name, oldConfig, vm.VmConfig)
+	if err != nil {
+		return err
+	}
+	err = HosterVmUtils.ConfigFileWriterContext(ctx, vm.VmConfig, vm.Simple.Mountpoint+"/"+vm.Name+"/"+HosterVmUtils.VM_CONFIG_NAME)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// NIC settings update, only the fields that are set are changed.
+type VmNetworkUpdate struct {
+	NetworkBridge      *string `json:"network_bridge,omitempty"`       // move the NIC to another network, the IP address is re-allocated if it doesn't fit the new network range
+	NetworkAdaptorType *string `json:"network_adaptor_type,omitempty"` // virtio-net or e1000
+	NetworkMac         *string `json:"network_mac,omitempty"`          // set a specific MAC address
+	RegenerateMac      bool    `json:"regenerate_mac,omitempty"`       // generate a new random MAC address
+	IPAddress          *string `json:"ip_address,omitempty"`           // must be within the network range
+	VlanTag            *int    `json:"vlan_tag,omitempty"`             // 802.1Q VLAN tag (1-4094), 0 removes the tag
+	Comment            *string `json:"comment,omitempty"`
+}
+
+// Removes the NIC from the VM config. The IP address is released along with it,
+// as the network IP reservations are derived from the VM and Jail configs.
+// The VM must be stopped: the running VM would keep using the released address (and its tap interface).
+func RemoveVmNetwork(ctx context.Context, vmName string, nicIndex int) (r HosterVmUtils.VmNetwork, e error) {
+	vm, err := HosterVmUtils.InfoJsonApi(vmName)
+	if err != nil {
+		e = err
+		return
+	}
+	if vm.Backup {
+		e = errors.New("this is a backup VM")
+		return
+	}
+	if vm.Running {
+		e = fmt.Errorf("%w: %s", HosterVmUtils.ErrVmIsRunning, vm.Name)
+		return
+	}
+	if nicIndex < 0 || nicIndex >= len(vm.VmConfig.Networks) {
+		e = fmt.Errorf("NIC index %d doesn't exist, this VM has %d network interface(s)", nicIndex, len(vm.VmConfig.Networks))
+		return
+	}
+
+	r = vm.VmConfig.Networks[nicIndex]
+	vm.VmConfig.Networks = slices.Delete(slices.Clone(vm.VmConfig.Networks), nicIndex, nicIndex+1)
+	e = HosterVmUtils.ConfigFileWriterContext(ctx, vm.VmConfig, vm.Simple.Mountpoint+"/"+vm.Name+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	if e != nil {
+		return
+	}
+
+	// The VLAN bridge is removed, unless another VM or Jail still uses it
+	if r.VlanTag != 0 {
+		_, _ = HosterNetwork.CleanupVlanBridges()
+	}
+	return
+}
+
+// Changes the NIC settings. Takes effect after the next VM start.
+func UpdateVmNetwork(ctx context.Context, vmName string, nicIndex int, update VmNetworkUpdate) (r HosterVmUtils.VmNetwork, e error) {
+	vm, err := HosterVmUtils.InfoJsonApi(vmName)
+	if err != nil {
+		e = err
+		return
+	}
+	if vm.Backup {
+		e = errors.New("this is a backup VM")
+		return
+	}
+	if nicIndex < 0 || nicIndex >= len(vm.VmConfig.Networks) {
+		e = fmt.Errorf("NIC index %d doesn't exist, this VM has %d network interface(s)", nicIndex, len(vm.VmConfig.Networks))
+		return
+	}
+	if update.RegenerateMac && update.NetworkMac != nil {
+		e = errors.New("MAC address can't be set and regenerated at the same time")
+		return
+	}
+
+	old := vm.VmConfig.Networks[nicIndex]
+	r = old
+	if update.NetworkBridge != nil {
+		r.NetworkBridge = *update.NetworkBridge
+	}
+	if update.NetworkAdaptorType != nil {
+		r.NetworkAdaptorType = *update.NetworkAdaptorType
+	}
+	if update.NetworkMac != nil {
+		r.NetworkMac = strings.ToLower(*update.NetworkMac)
+	}
+	if update.RegenerateMac {
+		r.NetworkMac, err = HosterVmUtils.GenerateMacAddress()
+		if err != nil {
+			e = err
+			return
+		}
+	}
+	if update.IPAddress != nil {
+		r.IPAddress = *update.IPAddress
+	}
+	if update.VlanTag != nil {
+		r.VlanTag = *update.VlanTag
+	}
+	if update.Comment != nil {
+		r.Comment = *update.Comment
+	}
+
+	if r.NetworkAdaptorType != "virtio-net" && r.NetworkAdaptorType != "e1000" {
+		e = errors.New("invalid network driver type")
+		return
+	}
+	if !HosterVmUtils.IsMacAddressValid(r.NetworkMac) {
+		e = errors.New("invalid MAC address")
+		return
+	}
+
+	netConfig, err := HosterNetwork.GetNetworkConfig()
+	if err != nil {
+		e = err
+		return
+	}
+	net := HosterNetwork.NetworkConfig{}
+	for _, v := range netConfig {
+		if v.NetworkName == r.NetworkBridge {
+			net = v
+			break
+		}
+	}
+	if len(net.NetworkName) < 1 {
+		e = ErrorMappings.NewError(ErrorMappings.CODE_NETWORK_NOT_FOUND, "network bridge not found")
+		return
+	}
+	if r.VlanTag != 0 {
+		_, err = HosterNetwork.CheckVlanSupport(r.NetworkBridge, r.VlanTag)
+		if err != nil {
+			e = err
+			return
+		}
+	}
+
+	// Keep the IP reservation in step with the network: the old address is released, and a new one is allocated from the new network range
+	if update.IPAddress == nil && r.NetworkBridge != old.NetworkBridge &&
+		!HosterHostUtils.IsIpWithinRange(r.IPAddress, net.Subnet, net.RangeStart, net.RangeEnd) {
+		r.IPAddress, err = HosterHostUtils.GenerateNewRandomIp(r.NetworkBridge)
+		if err != nil {
+			e = err
+			return
+		}
+	}
+	if !HosterHostUtils.IsIpWithinRange(r.IPAddress, net.Subnet, net.RangeStart, net.RangeEnd) {
+		e = errors.New("IP address is not within the network range")
+		return
+	}
+
+	vms, err := HosterVmUtils.ListJsonApi()
+	if err != nil {
+		e = err
+		return
+	}
+	for _, v := range vms {
+		for i, vv := range v.Networks {
+			if v.Name == vm.Name && i == nicIndex {
+				continue
+			}
+			if r.IPAddress != old.IPAddress && vv.IPAddress == r.IPAddress && vv.NetworkBridge == r.NetworkBridge {
+				e = errors.New("IP address is already in use")
+				return
+			}
+			if r.NetworkMac != strings.ToLower(old.NetworkMac) && strings.EqualFold(vv.NetworkMac, r.NetworkMac) {
+				e = errors.New("MAC address is already in use")
+				return
+			}
+		}
+	}
+
+	if r.IPAddress != old.IPAddress || r.NetworkBridge != old.NetworkBridge {
+		used, err := jailIpAddressInUse(r.IPAddress, r.NetworkBridge)
+		if err != nil {
+			e = err
+			return
+		}
+		if used {
+			e = errors.New("IP address is already in use")
+			return
+		}
+	}
+
+	oldConfig := vm.VmConfig
+	vm.VmConfig.Networks = slices.Clone(vm.VmConfig.Networks)
+	vm.VmConfig.Networks[nicIndex] = r
+	err = HosterVmUtils.ValidateVmConfigChange(vm.Name, vm.Simple.Mountpoint+"/"+vm.Name, oldConfig, vm.VmConfig)
+	if err != nil {
+		e = err
+		return
+	}
+
+	e = HosterVmUtils.ConfigFileWriterContext(ctx, vm.VmConfig, vm.Simple.Mountpoint+"/"+vm.Name+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	if e != nil {
+		return
+	}
+
+	// The NIC was moved off its old VLAN (retagged, untagged or moved to another network)
+	if old.VlanTag != 0 && (old.VlanTag != r.VlanTag || old.NetworkBridge != r.NetworkBridge) {
+		_, _ = HosterNetwork.CleanupVlanBridges()
+	}
+	return
+}
+
+// Checks if one of the Jails uses the IP address on the same network (Jails have a single address each)
+func jailIpAddressInUse(ipAddress string, networkName string) (bool, error) {
+	jails, err := HosterJailUtils.ListJsonApi()
+	if err != nil {
+		return false, err
+	}
+	for _, v := range jails {
+		if v.IPAddress == ipAddress && v.Network == networkName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
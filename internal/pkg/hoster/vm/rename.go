@@ -0,0 +1,334 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVm
+
+import (
+	SchedulerClient "HosterCore/internal/app/scheduler/client"
+	FileExists "HosterCore/internal/pkg/file_exists"
+	HosterHostUtils "HosterCore/internal/pkg/hoster/host/utils"
+	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	cronDir        = "/etc/cron.d"
+	replicationDir = "/var/run/replication"
+)
+
+// Renames the VM, without touching the guest identity: MAC and IP addresses, passwords, SSH keys, UUID
+// and the cloud-init instance-id are all kept as is.
+//
+// The ZFS dataset (including the zvol disks) is renamed, the paths in vm_config.json and the cloud-init hostname
+// are updated, and the VM name is replaced in the Hoster cron jobs. The bhyve config and the tmux console session
+// of the old name are removed, the nmdm devices and the bhyve config get the new name on the next start.
+// DNS records are also derived from the VM name, the DNS server is reloaded to pick up the change.
+//
+// The rename is refused while a replication cron job covers the VM: the backup node would keep the dataset under
+// the old name, and receive a full copy under the new one. Remove the job (or rename the dataset on the backup node
+// and re-create the job) first.
+//
+// Cloud-init only sets the hostname on the first boot of an instance, so the hostname inside of the guest OS
+// has to be changed by the VM owner.
+func RenameVm(oldName string, newName string) error {
+	// If the logger was already set, ignore this
+	if !log.ConfigSet {
+		log.SetFileLocation(HosterVmUtils.VM_AUDIT_LOG_LOCATION)
+	}
+
+	if oldName == newName {
+		return errors.New("new VM name is the same as the old one")
+	}
+	err := HosterVmUtils.ValidateResName(newName)
+	if err != nil {
+		return err
+	}
+
+	vm, err := HosterVmUtils.InfoJsonApi(oldName)
+	if err != nil {
+		return err
+	}
+	if vm.Running {
+		return errors.New("vm must be offline to perform this operation")
+	}
+	if vm.Backup {
+		return errors.New("this is a backup VM")
+	}
+
+	oldDataset := vm.Simple.DsName + "/" + oldName
+	newDataset := vm.Simple.DsName + "/" + newName
+	oldFolder := vm.Simple.Mountpoint + "/" + oldName
+	newFolder := vm.Simple.Mountpoint + "/" + newName
+
+	err = checkVmNameAvailable(newName, newDataset)
+	if err != nil {
+		return err
+	}
+	err = checkVmNotReplicating(oldName, oldDataset)
+	if err != nil {
+		return err
+	}
+	err = checkVmNoReplicationJobs(oldName, vm.Tags, vm.Production)
+	if err != nil {
+		return err
+	}
+
+	out, err := exec.Command("zfs", "rename", oldDataset, newDataset).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to rename ZFS dataset: %s; %s", strings.TrimSpace(string(out)), err.Error())
+	}
+	log.Info("vm dataset renamed: " + oldDataset + " -> " + newDataset)
+
+	// The dataset is already renamed at this point, so the rest of the steps only log their errors,
+	// and the first one is returned once everything else was attempted
+	var errs []error
+
+	conf := vm.VmConfig
+	conf.Disks = []HosterVmUtils.VmDisk{}
+	for _, v := range vm.VmConfig.Disks {
+		v.DiskSize = HosterVmUtils.DiskSize{}
+		if v.DiskLocation == "external" && strings.HasPrefix(v.DiskImage, oldFolder+"/") {
+			v.DiskImage = newFolder + strings.TrimPrefix(v.DiskImage, oldFolder)
+		}
+		conf.Disks = append(conf.Disks, v)
+	}
+	conf.Shares = nil
+	for _, v := range vm.VmConfig.Shares {
+		if v.ShareLocation == oldFolder || strings.HasPrefix(v.ShareLocation, oldFolder+"/") {
+			v.ShareLocation = newFolder + strings.TrimPrefix(v.ShareLocation, oldFolder)
+		}
+		conf.Shares = append(conf.Shares, v)
+	}
+	err = HosterVmUtils.ConfigFileWriter(conf, newFolder+"/"+HosterVmUtils.VM_CONFIG_NAME)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("could not update the VM config: %s", err.Error()))
+	}
+
+	err = renameVmCloudInitHostname(newFolder, newName)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("could not update the cloud-init hostname: %s", err.Error()))
+	}
+
+	// Stale runtime leftovers of the old name
+	_ = os.Remove(HosterVmUtils.BhyveConfigLocation(oldName))
+	if FileExists.CheckUsingOsStat("/dev/vmm/" + oldName) {
+		_ = exec.Command("bhyvectl", "--destroy", "--vm="+oldName).Run()
+	}
+	_ = exec.Command("tmux", "kill-session", "-t", oldName).Run()
+
+	cronFiles, err := renameVmCronJobs(oldName, newName)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("could not update the cron jobs: %s", err.Error()))
+	}
+	for _, v := range cronFiles {
+		log.Info("cron jobs updated for the renamed vm: " + v)
+	}
+
+	_, err = HosterVmUtils.WriteCache()
+	if err != nil {
+		log.Debug("could not refresh the VM cache: " + err.Error())
+	}
+	err = HosterHostUtils.ReloadDns()
+	if err != nil {
+		log.Debug("could not reload the DNS server: " + err.Error())
+	}
+
+	for _, v := range errs {
+		log.Error("vm rename " + oldName + " -> " + newName + ": " + v.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("vm dataset was renamed, but: %s", errs[0].Error())
+	}
+
+	log.Info("vm renamed: " + oldName + " -> " + newName)
+	return nil
+}
+
+// The new name has to be unique across all VMs and Jails (DNS records use both), and the dataset must not exist.
+func checkVmNameAvailable(newName string, newDataset string) error {
+	vms, err := HosterVmUtils.ListAllSimple()
+	if err != nil {
+		return err
+	}
+	for _, v := range vms {
+		if v.VmName == newName {
+			return ErrorMappings.NewError(ErrorMappings.CODE_NAME_CONFLICT, "vm with the name "+newName+" already exists")
+		}
+	}
+
+	jails, err := HosterJailUtils.ListAllSimple()
+	if err != nil {
+		return err
+	}
+	for _, v := range jails {
+		if v.JailName == newName {
+			return ErrorMappings.NewError(ErrorMappings.CODE_NAME_CONFLICT, "jail with the name "+newName+" already exists")
+		}
+	}
+
+	if exec.Command("zfs", "list", "-H", "-o", "name", newDataset).Run() == nil {
+		return ErrorMappings.NewError(ErrorMappings.CODE_NAME_CONFLICT, "dataset already exists: "+newDataset)
+	}
+
+	return nil
+}
+
+// Refuses the rename if the scheduler has unfinished jobs for this VM, or a manual replication is sending its dataset.
+func checkVmNotReplicating(vmName string, vmDataset string) error {
+	// Scheduler may not be running, in which case there are no scheduled jobs to conflict with
+	jobs, _ := SchedulerClient.GetJobList()
+	for _, v := range jobs {
+		if v.JobDone || v.JobFailed {
+			continue
+		}
+		if v.Replication.ResName == vmName || v.Snapshot.ResName == vmName {
+			return fmt.Errorf("vm has an unfinished %s job in the scheduler (%s), try again once it's done", v.JobType, v.JobId)
+		}
+	}
+
+	scripts, _ := filepath.Glob(replicationDir + "/*.sh")
+	reMatchDataset := regexp.MustCompile(`\s` + regexp.QuoteMeta(vmDataset) + `[@/\s]`)
+	for _, v := range scripts {
+		script, err := os.ReadFile(v)
+		if err != nil {
+			continue
+		}
+		if reMatchDataset.Match(script) {
+			return errors.New("vm is being replicated at the moment (lock file exists): " + v)
+		}
+	}
+
+	return nil
+}
+
+// Refuses the rename if one of the Hoster cron jobs replicates this VM: by name, by one of its tags (replicate-by-tag),
+// or all of the production VMs (replicate-all).
+func checkVmNoReplicationJobs(vmName string, tags []string, production bool) error {
+	files, err := filepath.Glob(cronDir + "/hoster_*")
+	if err != nil {
+		return err
+	}
+
+	reMatchName := regexp.MustCompile(`(^|[\s=,'"])` + regexp.QuoteMeta(vmName) + `($|[\s,'"])`)
+	reMatchByTag := regexp.MustCompile(`replicate-by-tag\s+['"]?([^\s'"]+)`)
+	for _, v := range files {
+		file, err := os.ReadFile(v)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range strings.Split(string(file), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "#") || !strings.Contains(line, "hoster") || !strings.Contains(line, "replicate") {
+				continue
+			}
+
+			covered := reMatchName.MatchString(line) || (production && strings.Contains(line, "replicate-all"))
+			if match := reMatchByTag.FindStringSubmatch(line); match != nil && slices.Contains(tags, match[1]) {
+				covered = true
+			}
+			if covered {
+				return ErrorMappings.NewError(ErrorMappings.CODE_DATASET_BUSY,
+					"vm is replicated by a cron job in "+v+", remove the job (or rename the dataset on the backup node and re-create the job) before renaming the VM")
+			}
+		}
+	}
+
+	return nil
+}
+
+// Updates the hostname in the cloud-init meta-data, and re-generates the seed ISO.
+// The instance-id is kept, otherwise cloud-init would treat the VM as a new instance and reset the credentials.
+func renameVmCloudInitHostname(vmFolder string, newName string) error {
+	metaDataFile := vmFolder + "/cloud-init-files/meta-data"
+	if !FileExists.CheckUsingOsStat(metaDataFile) {
+		return nil
+	}
+
+	metaData, err := os.ReadFile(metaDataFile)
+	if err != nil {
+		return err
+	}
+	reMatchHostname := regexp.MustCompile(`(?m)^(local-hostname|hostname):.*$`)
+	metaData = reMatchHostname.ReplaceAllFunc(metaData, func(b []byte) []byte {
+		key, _, _ := strings.Cut(string(b), ":")
+		return []byte(key + ": " + newName)
+	})
+	err = os.WriteFile(metaDataFile, metaData, 0640)
+	if err != nil {
+		return err
+	}
+
+	if !FileExists.CheckUsingOsStat(vmFolder + "/seed.iso") {
+		return nil
+	}
+	ciFolder := vmFolder + "/cloud-init-files/"
+	out, err := exec.Command("genisoimage", "-output", vmFolder+"/seed.iso", "-volid", "cidata", "-joliet", "-rock",
+		ciFolder+"user-data", ciFolder+"meta-data", ciFolder+"network-config").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("there was a problem generating an ISO: %s; %s", strings.TrimSpace(string(out)), err.Error())
+	}
+
+	return nil
+}
+
+// Replaces the old VM name with the new one in the Hoster cron jobs (/etc/cron.d/hoster_*).
+// Only the exact name arguments are replaced, e.g. "hoster vm snapshot old-vm" or "--vm=old-vm".
+// Returns the list of files that were changed.
+func renameVmCronJobs(oldName string, newName string) (r []string, e error) {
+	files, err := filepath.Glob(cronDir + "/hoster_*")
+	if err != nil {
+		e = err
+		return
+	}
+
+	reMatchName := regexp.MustCompile(`(^|[\s=,'"])` + regexp.QuoteMeta(oldName) + `($|[\s,'"])`)
+	for _, v := range files {
+		file, err := os.ReadFile(v)
+		if err != nil {
+			e = err
+			return
+		}
+
+		changed := false
+		lines := strings.Split(string(file), "\n")
+		for i, line := range lines {
+			if !strings.Contains(line, "hoster") || !reMatchName.MatchString(line) {
+				continue
+			}
+			// Matches can't overlap, so the adjacent names (e.g. "old-vm,old-vm") need a second pass
+			for reMatchName.MatchString(line) {
+				line = reMatchName.ReplaceAllString(line, "${1}"+newName+"${2}")
+			}
+			lines[i] = line
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+
+		info, err := os.Stat(v)
+		if err != nil {
+			e = err
+			return
+		}
+		err = os.WriteFile(v, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+		if err != nil {
+			e = err
+			return
+		}
+		r = append(r, v)
+	}
+
+	return
+}
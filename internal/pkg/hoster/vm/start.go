@@ -8,13 +8,14 @@
 package HosterVm
 
 import (
-	ErrorMappings "HosterCore/internal/app/rest_api_v2/pkg/error_mappings"
 	HosterLocations "HosterCore/internal/pkg/hoster/locations"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"slices"
+	"strings"
 	"syscall"
 
 	"github.com/sirupsen/logrus"
@@ -34,7 +35,7 @@ func Start(vmName string, waitVnc bool, debugRun bool) error {
 		return err
 	}
 	if slices.Contains(live, vmName) {
-		return fmt.Errorf("vm is already running")
+		return fmt.Errorf("%w: %s", HosterVmUtils.ErrVmIsRunning, vmName)
 	}
 	// EOF VM is live check
 
@@ -52,7 +53,7 @@ func Start(vmName string, waitVnc bool, debugRun bool) error {
 		}
 	}
 	if !found {
-		return fmt.Errorf(ErrorMappings.VmDoesntExist.String())
+		return fmt.Errorf("%w: %s", HosterVmUtils.ErrVmDoesntExist, vmName)
 	}
 	// EOF Check if VM exists
 
@@ -62,16 +63,29 @@ func Start(vmName string, waitVnc bool, debugRun bool) error {
 	}
 	// EOF Check if VM is a backup
 
-	log.Info("starting the vm: " + vmName)
+	// Validate the config, broken configs would otherwise only surface as cryptic bhyve failures in the supervisor log
 	vmLocation := vmInfo.Simple.Mountpoint + "/" + vmName
-	bhyveCmd, err := HosterVmUtils.GenerateBhyveStartCmd(vmName, vmLocation, false, waitVnc)
+	err = HosterVmUtils.ValidateVmConfigOnHost(vmName, vmLocation, vmInfo.VmConfig)
+	if err != nil {
+		log.Error("vm could not be started: " + vmName + "; " + err.Error())
+		return err
+	}
+	// EOF Validate the config
+
+	log.Info("starting the vm: " + vmName)
+	bhyveArgs, err := HosterVmUtils.WriteBhyveConfig(vmName, vmLocation, false, waitVnc)
+	if err != nil {
+		return err
+	}
+	// bhyve is executed by the supervisor directly (no shell involved), the argv is passed down as JSON
+	bhyveArgsJson, err := json.Marshal(bhyveArgs)
 	if err != nil {
 		return err
 	}
-	os.Setenv("VM_START", bhyveCmd)
+	os.Setenv("VM_START_ARGS", string(bhyveArgsJson))
 	os.Setenv("VM_NAME", vmName)
 	os.Setenv("LOG_FILE", vmLocation+"/"+HosterVmUtils.VM_LOG_NAME)
-	log.Debug("bhyve cmd: " + bhyveCmd)
+	log.Debug("bhyve cmd: " + strings.Join(bhyveArgs, " "))
 
 	// binaryLoc := ""
 	// for _, v := range HosterLocations.GetBinaryFolders() {
@@ -10,9 +10,11 @@ package HosterVm
 import (
 	HosterLocations "HosterCore/internal/pkg/hoster/locations"
 	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"encoding/json"
 	"os"
 	"os/exec"
 	"slices"
+	"strings"
 	"syscall"
 	"time"
 
@@ -39,6 +41,11 @@ func StartAll(prodOnly bool, waitTime int) error {
 	if err != nil {
 		return err
 	}
+	// Loaded once, the other VM configs are needed to validate each one of them
+	hostState, err := HosterVmUtils.LoadVmHostState()
+	if err != nil {
+		return err
+	}
 
 	startId := 0
 	for _, v := range vms {
@@ -64,15 +71,27 @@ func StartAll(prodOnly bool, waitTime int) error {
 		log.Info("starting the VM: " + v.Name)
 
 		vmLocation := v.Simple.Mountpoint + "/" + v.Name
-		bhyveCmd, err := HosterVmUtils.GenerateBhyveStartCmd(v.Name, vmLocation, false, false)
+		err = hostState.ValidateVmConfig(v.Name, vmLocation, v.VmConfig)
 		if err != nil {
-			log.Error("error generating bhyve start cmd: " + err.Error())
+			log.Error("vm could not be started: " + v.Name + "; " + err.Error())
+			continue
+		}
+
+		bhyveArgs, err := HosterVmUtils.WriteBhyveConfig(v.Name, vmLocation, false, false)
+		if err != nil {
+			log.Error("error generating bhyve config: " + err.Error())
+			continue
+		}
+		bhyveArgsJson, err := json.Marshal(bhyveArgs)
+		if err != nil {
+			log.Error("error generating bhyve config: " + err.Error())
+			continue
 		}
 
-		os.Setenv("VM_START", bhyveCmd)
+		os.Setenv("VM_START_ARGS", string(bhyveArgsJson))
 		os.Setenv("VM_NAME", v.Name)
 		os.Setenv("LOG_FILE", vmLocation+"/"+HosterVmUtils.VM_LOG_NAME)
-		log.Debug("bhyve cmd: " + bhyveCmd)
+		log.Debug("bhyve cmd: " + strings.Join(bhyveArgs, " "))
 
 		cmd := exec.Command(binaryLoc, "for", v.Name)
 		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
@@ -37,14 +37,14 @@ func Stop(vmName string, forceKill bool, forceCleanup bool) error {
 		}
 	}
 	if !vmFound {
-		return fmt.Errorf("%s: %s", HosterVmUtils.ERRTXT_VM_DOESNT_EXIST, vmName)
+		return fmt.Errorf("%w: %s", HosterVmUtils.ErrVmDoesntExist, vmName)
 	}
 	// EOF Check if the VM exists block
 
 	// Check if the VM is running block
 	vmsRunning, _ := HosterVmUtils.GetRunningVms()
 	if !slices.Contains(vmsRunning, vmName) {
-		return fmt.Errorf("%s: %s", HosterVmUtils.ERRTXT_VM_IS_STOPPED, vmName)
+		return fmt.Errorf("%w: %s", HosterVmUtils.ErrVmIsStopped, vmName)
 	}
 	// EOF Check if the VM is running block
 
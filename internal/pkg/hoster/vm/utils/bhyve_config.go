@@ -0,0 +1,403 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	AtomicFile "HosterCore/internal/pkg/atomic_file"
+	HosterNetwork "HosterCore/internal/pkg/hoster/network"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A single bhyve_config(5) setting, e.g. pci.0.2.0.device=virtio-net
+type BhyveConfigOption struct {
+	Key   string
+	Value string
+}
+
+// Ordered list of the bhyve settings, passed to bhyve using `-k config_file`.
+type BhyveConfig []BhyveConfigOption
+
+func (c *BhyveConfig) set(key string, value string) {
+	*c = append(*c, BhyveConfigOption{Key: key, Value: value})
+}
+
+func (c *BhyveConfig) setPci(slot int, function int, key string, value string) {
+	c.set(fmt.Sprintf("pci.0.%d.%d.%s", slot, function, key), value)
+}
+
+// Renders the config in the key=value format understood by `bhyve -k`.
+// Returns an error if any of the values can't be represented in this format.
+func (c BhyveConfig) Render() (r []byte, e error) {
+	var buf bytes.Buffer
+	for _, v := range c {
+		if !bhyveConfigKeyRegex.MatchString(v.Key) {
+			e = fmt.Errorf("invalid bhyve config key: '%s'", v.Key)
+			return
+		}
+		if strings.ContainsAny(v.Value, "\n\r\x00") {
+			e = fmt.Errorf("bhyve config value for %s can't contain the line breaks", v.Key)
+			return
+		}
+		buf.WriteString(v.Key + "=" + v.Value + "\n")
+	}
+
+	r = buf.Bytes()
+	return
+}
+
+var bhyveConfigKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_\-]+(\.[A-Za-z0-9_\-]+)*$`)
+
+const (
+	bhyveBootromBios = "/usr/local/share/uefi-firmware/BHYVE_UEFI_CSM.fd"
+	bhyveBootromUefi = "/usr/local/share/uefi-firmware/BHYVE_UEFI.fd"
+)
+
+// Guest PCI address (bus 0 is always used)
+type bhyvePciAddress struct {
+	slot     int
+	function int
+}
+
+// Result of the PCI slot allocation for a single VM config
+type bhyvePciSlots struct {
+	networks []int
+	disks    []int
+	shares   []int
+	fbuf     int
+	passthru []bhyvePciAddress // same order as VmConfig.Passthru, slot 0 marks an invalid device
+	xhci     int               // 0 if XHCI is disabled
+	last     int               // last allocated slot
+}
+
+// Hands out the guest PCI slots in order. Slot 0 is reserved for the hostbridge, and slot 31 for the LPC bridge.
+type bhyvePciAllocator struct {
+	next int
+}
+
+func (a *bhyvePciAllocator) allocate() int {
+	slot := a.next
+	a.next += 1
+	return slot
+}
+
+// Allocates the guest PCI slots for all VM devices. Device order (and so the slot numbers the guest OS sees) is stable:
+// network adapters, disks, 9p shares, VNC framebuffer, passthru devices and finally the XHCI controller.
+func allocateBhyvePciSlots(conf VmConfig) (r bhyvePciSlots) {
+	a := bhyvePciAllocator{next: 2}
+
+	for range conf.Networks {
+		r.networks = append(r.networks, a.allocate())
+	}
+	// Same layout as the old bhyve command generator: slot 2 is skipped if there is no network adapter,
+	// so the disks of the existing VMs don't move to a different guest PCI address
+	if len(conf.Networks) < 1 {
+		a.allocate()
+	}
+	for range conf.Disks {
+		r.disks = append(r.disks, a.allocate())
+	}
+	for range conf.Shares {
+		r.shares = append(r.shares, a.allocate())
+	}
+	r.fbuf = a.allocate()
+
+	// Functions of the same host device (bus/slot) are kept together in a single guest slot,
+	// "-" prefix forces a separate guest slot with the function 0
+	groups := map[string]int{}
+	for _, v := range conf.Passthru {
+		bus, slot, function, ok := parsePassthruDevice(v)
+		if !ok {
+			r.passthru = append(r.passthru, bhyvePciAddress{})
+			continue
+		}
+		if strings.HasPrefix(v, "-") {
+			r.passthru = append(r.passthru, bhyvePciAddress{slot: a.allocate(), function: 0})
+			continue
+		}
+
+		group := fmt.Sprintf("%d/%d", bus, slot)
+		guestSlot, found := groups[group]
+		if !found {
+			guestSlot = a.allocate()
+			groups[group] = guestSlot
+		}
+		r.passthru = append(r.passthru, bhyvePciAddress{slot: guestSlot, function: function})
+	}
+
+	if !conf.DisableXHCI {
+		r.xhci = a.allocate()
+	}
+
+	r.last = a.next - 1
+	return
+}
+
+// Parses the PCI device in the bus/slot/function format, e.g. 4/0/0 or -43/0/1
+func parsePassthruDevice(device string) (bus int, slot int, function int, ok bool) {
+	if !passthruDeviceRegex.MatchString(device) {
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(device, "-"), "/")
+	bus, _ = strconv.Atoi(parts[0])
+	slot, _ = strconv.Atoi(parts[1])
+	function, _ = strconv.Atoi(parts[2])
+	ok = true
+	return
+}
+
+// Builds the bhyve config for the VM. Pure function, all of the host state (e.g. tap interface names) is passed in:
+// taps must hold a tap interface name for every VM network, vmDataset is only used for the zvol disks.
+func BuildBhyveConfig(vmName string, vmLocation string, vmDataset string, conf VmConfig, taps []string, waitVnc bool) (r BhyveConfig, e error) {
+	vmLocation = strings.TrimSuffix(vmLocation, "/")
+	if len(taps) != len(conf.Networks) {
+		e = fmt.Errorf("expected %d tap interfaces, got %d", len(conf.Networks), len(taps))
+		return
+	}
+
+	slots := allocateBhyvePciSlots(conf)
+	if slots.last > maxBhyvePciSlot {
+		e = fmt.Errorf("VM needs %d PCI slots, but only %d are available", slots.last-1, maxBhyvePciSlot-1)
+		return
+	}
+
+	r = BhyveConfig{}
+	r.set("name", vmName)
+
+	// CPU and RAM
+	threads := max(conf.CPUThreads, 1)
+	r.set("cpus", strconv.Itoa(conf.CPUSockets*conf.CPUCores*threads))
+	r.set("sockets", strconv.Itoa(conf.CPUSockets))
+	r.set("cores", strconv.Itoa(conf.CPUCores))
+	if conf.CPUThreads > 0 {
+		r.set("threads", strconv.Itoa(conf.CPUThreads))
+	}
+	r.set("memory.size", conf.Memory)
+	// Wired guest memory is required for the PCI passthru to work
+	if len(conf.Passthru) > 0 {
+		r.set("memory.wired", "true")
+	}
+	// EOF CPU and RAM
+
+	// Generate ACPI tables (required for FreeBSD/amd64 guests), yield the vCPU thread on HLT instead of spinning at 100%,
+	// and ignore the accesses to unimplemented MSRs
+	r.set("acpi_tables", "true")
+	r.set("x86.vmexit_on_hlt", "true")
+	r.set("x86.strictmsr", "false")
+
+	// In some cases, the host clock should be ignored.
+	// For example, if the host sits in a different timezone than the VM.
+	// This also applied sometimes if the VM is Windows-based.
+	if conf.IgnoreHostClock {
+		r.set("rtc.use_localtime", "true")
+	} else {
+		r.set("rtc.use_localtime", "false")
+	}
+
+	if len(conf.UUID) > 0 {
+		r.set("uuid", conf.UUID)
+	}
+
+	r.setPci(0, 0, "device", "hostbridge")
+
+	for i, v := range conf.Networks {
+		slot := slots.networks[i]
+		r.setPci(slot, 0, "device", v.NetworkAdaptorType)
+		r.setPci(slot, 0, "backend", taps[i])
+		r.setPci(slot, 0, "mac", v.NetworkMac)
+	}
+
+	for i, v := range conf.Disks {
+		slot := slots.disks[i]
+		diskImageLocation := VmDiskPath(vmDataset, vmLocation, v)
+
+		switch v.DiskType {
+		case "ahci-hd", "ahci-cd":
+			r.setPci(slot, 0, "device", "ahci")
+			r.setPci(slot, 0, "port.0.type", strings.TrimPrefix(v.DiskType, "ahci-"))
+			r.setPci(slot, 0, "port.0.path", diskImageLocation)
+		default:
+			r.setPci(slot, 0, "device", v.DiskType)
+			r.setPci(slot, 0, "path", diskImageLocation)
+		}
+	}
+
+	for i, v := range conf.Shares {
+		slot := slots.shares[i]
+		r.setPci(slot, 0, "device", "virtio-9p")
+		r.setPci(slot, 0, "sharename", v.ShareName)
+		r.setPci(slot, 0, "path", v.ShareLocation)
+		if v.ReadOnly {
+			r.setPci(slot, 0, "ro", "true")
+		}
+	}
+
+	// VNC options
+	vncBind := "0.0.0.0"
+	if conf.VncLocalhostOnly {
+		vncBind = "127.0.0.1"
+	}
+	width, height := setScreenResolution(conf.VncResolution)
+	r.setPci(slots.fbuf, 0, "device", "fbuf")
+	r.setPci(slots.fbuf, 0, "tcp", fmt.Sprintf("%s:%d", vncBind, conf.VncPort))
+	r.setPci(slots.fbuf, 0, "w", strconv.Itoa(width))
+	r.setPci(slots.fbuf, 0, "h", strconv.Itoa(height))
+	r.setPci(slots.fbuf, 0, "password", conf.VncPassword)
+	if len(conf.VGA) > 0 {
+		r.setPci(slots.fbuf, 0, "vga", conf.VGA)
+	}
+	// Wait for the VNC connection before booting the VM, if waitVnc was enabled at runtime
+	if waitVnc {
+		r.setPci(slots.fbuf, 0, "wait", "true")
+	}
+	// EOF VNC options
+
+	for i, v := range conf.Passthru {
+		address := slots.passthru[i]
+		bus, slot, function, ok := parsePassthruDevice(v)
+		if !ok {
+			e = fmt.Errorf("invalid passthru device '%s', must use the bus/slot/function format, e.g. 4/0/0", v)
+			return
+		}
+		r.setPci(address.slot, address.function, "device", "passthru")
+		r.setPci(address.slot, address.function, "bus", strconv.Itoa(bus))
+		r.setPci(address.slot, address.function, "slot", strconv.Itoa(slot))
+		r.setPci(address.slot, address.function, "func", strconv.Itoa(function))
+	}
+
+	if slots.xhci > 0 {
+		r.setPci(slots.xhci, 0, "device", "xhci")
+		r.setPci(slots.xhci, 0, "slot.1.device", "tablet")
+	}
+
+	// LPC bridge, serial console and the bootloader
+	r.setPci(31, 0, "device", "lpc")
+	r.set("lpc.com1.path", "/dev/nmdm-"+vmName+"-1A")
+	switch conf.Loader {
+	case "bios":
+		r.set("lpc.bootrom", bhyveBootromBios)
+	case "uefi":
+		r.set("lpc.bootrom", bhyveBootromUefi)
+	}
+
+	// Check man BHYVE_CONFIG(5) to get the full list of options
+	// If an option doesn't exist in bhyve, it will be ignored
+	// This particular option, for example, can be used to execute custom commands at VM boot time
+	// system.serial_number="https://script-location-here.com/script.sh"
+	for _, v := range conf.CustomOptions {
+		key, value, found := strings.Cut(v, "=")
+		if !found {
+			e = fmt.Errorf("invalid custom option '%s', must use the key=value format", v)
+			return
+		}
+		// The options used to be passed through the shell, which removed the quotes around the values
+		value = strings.TrimSpace(value)
+		if len(value) > 1 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		r.set(strings.TrimSpace(key), value)
+	}
+
+	return
+}
+
+// Generates the bhyve config for the VM (creating the tap interfaces on the way), writes it to VM_BHYVE_CONFIG_DIR,
+// and returns the bhyve argv (including the binary name) which must be executed directly, never through a shell.
+func WriteBhyveConfig(vmName string, vmLocation string, restoreVmState bool, waitVnc bool) (r []string, e error) {
+	vmLocation = strings.TrimSuffix(vmLocation, "/")
+	conf, err := GetVmConfig(vmLocation)
+	if err != nil {
+		e = err
+		return
+	}
+
+	vmDataset := ""
+	for _, v := range conf.Disks {
+		if v.DiskLocation == DISK_LOCATION_ZVOL {
+			vmDataset, err = GetVmDataset(vmName)
+			if err != nil {
+				e = err
+				return
+			}
+			break
+		}
+	}
+
+	taps := []string{}
+	for _, v := range conf.Networks {
+		tap, err := HosterNetwork.CreateTapInterface(vmName, v.NetworkBridge, v.VlanTag)
+		if err != nil {
+			e = err
+			return
+		}
+		taps = append(taps, tap)
+	}
+
+	bhyveConf, err := BuildBhyveConfig(vmName, vmLocation, vmDataset, conf, taps, waitVnc)
+	if err != nil {
+		e = err
+		return
+	}
+	data, err := bhyveConf.Render()
+	if err != nil {
+		e = err
+		return
+	}
+
+	// The config holds the VNC password, so it's only readable by root
+	err = os.MkdirAll(VM_BHYVE_CONFIG_DIR, 0700)
+	if err != nil {
+		e = err
+		return
+	}
+	confLocation := BhyveConfigLocation(vmName)
+	err = AtomicFile.WriteFile(confLocation, data, 0600)
+	if err != nil {
+		e = err
+		return
+	}
+
+	r = []string{"bhyve", "-k", confLocation}
+	if restoreVmState {
+		r = append(r, "-r", vmLocation+"/vm_state")
+	} else {
+		r = append(r, vmName)
+	}
+	return
+}
+
+// Returns the generated bhyve config location for the VM, e.g. /var/run/hoster_bhyve/test-vm-1.conf
+func BhyveConfigLocation(vmName string) string {
+	return VM_BHYVE_CONFIG_DIR + "/" + vmName + ".conf"
+}
+
+// Generate a VNC resolution (width and height) from a pre-set integer.
+func setScreenResolution(input int) (width int, height int) {
+	switch input {
+	case 1:
+		return 640, 480
+	case 3:
+		return 1024, 768
+	case 4:
+		return 1280, 720
+	case 5:
+		return 1280, 1024
+	case 6:
+		return 1600, 900
+	case 7:
+		return 1600, 1200
+	case 8:
+		return 1920, 1080
+	case 9:
+		return 1920, 1200
+	}
+
+	// 2, and the default case
+	return 800, 600
+}
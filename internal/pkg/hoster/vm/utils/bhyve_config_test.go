@@ -0,0 +1,312 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// go test ./internal/pkg/hoster/vm/utils -run TestBuildBhyveConfig -update
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/")
+
+func testVmConfig() VmConfig {
+	return VmConfig{
+		CPUSockets:  1,
+		CPUCores:    2,
+		Memory:      "2G",
+		Loader:      "uefi",
+		VncPort:     5901,
+		VncPassword: "s3cret",
+		Networks: []VmNetwork{
+			{NetworkAdaptorType: "virtio-net", NetworkBridge: "internal", NetworkMac: "58:9c:fc:00:00:01"},
+		},
+		Disks: []VmDisk{
+			{DiskType: "nvme", DiskLocation: "internal", DiskImage: "disk0.img"},
+		},
+	}
+}
+
+func TestBuildBhyveConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *VmConfig)
+		taps   []string
+	}{
+		{
+			name:   "uefi",
+			modify: func(c *VmConfig) {},
+			taps:   []string{"tap0"},
+		},
+		{
+			name: "bios_localhost_vnc",
+			modify: func(c *VmConfig) {
+				c.Loader = "bios"
+				c.VncLocalhostOnly = true
+				c.VncResolution = 8
+				c.VGA = "io"
+				c.IgnoreHostClock = true
+				c.UUID = "0f6d3d2c-5a3e-4a32-9a57-3f6c1c1d6e11"
+			},
+			taps: []string{"tap0"},
+		},
+		{
+			name: "multi_disk",
+			modify: func(c *VmConfig) {
+				c.Disks = []VmDisk{
+					{DiskType: "virtio-blk", DiskLocation: "internal", DiskImage: "disk0.img"},
+					{DiskType: "nvme", DiskLocation: DISK_LOCATION_ZVOL, DiskImage: "disk1"},
+					{DiskType: "ahci-hd", DiskLocation: "external", DiskImage: "/tank/images/data.img"},
+					{DiskType: "ahci-cd", DiskLocation: "external", DiskImage: "/tank/iso/FreeBSD-14.1-RELEASE-amd64-disc1.iso"},
+				}
+			},
+			taps: []string{"tap0"},
+		},
+		{
+			name: "multi_nic_vlan",
+			modify: func(c *VmConfig) {
+				c.Networks = []VmNetwork{
+					{NetworkAdaptorType: "virtio-net", NetworkBridge: "internal", NetworkMac: "58:9c:fc:00:00:01"},
+					{NetworkAdaptorType: "virtio-net", NetworkBridge: "external", NetworkMac: "58:9c:fc:00:00:02", VlanTag: 100},
+					{NetworkAdaptorType: "e1000", NetworkBridge: "external", NetworkMac: "58:9c:fc:00:00:03", VlanTag: 200},
+				}
+			},
+			taps: []string{"tap0", "tap1", "tap2"},
+		},
+		{
+			name: "no_network",
+			modify: func(c *VmConfig) {
+				c.Networks = nil
+				c.DisableXHCI = true
+			},
+		},
+		{
+			name: "no_network_multi_disk",
+			modify: func(c *VmConfig) {
+				c.Networks = nil
+				c.Disks = testDisks(2)
+				c.Shares = []Virtio9P{{ShareName: "data", ShareLocation: "/tank/shares/data"}}
+			},
+		},
+		{
+			name: "passthru",
+			modify: func(c *VmConfig) {
+				c.Passthru = []string{"4/0/0", "4/0/1", "-43/0/0", "7/0/0"}
+				c.Shares = []Virtio9P{{ShareName: "data", ShareLocation: "/tank/shares/data", ReadOnly: true}}
+			},
+			taps: []string{"tap0"},
+		},
+		{
+			name: "custom_options",
+			modify: func(c *VmConfig) {
+				c.CPUSockets = 2
+				c.CPUThreads = 2
+				c.VncPassword = "pa$$ `word` ;rm -rf /"
+				c.CustomOptions = []string{`system.serial_number="https://example.com/script.sh"`, "x86.verbosemsr=true"}
+			},
+			taps: []string{"tap0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := testVmConfig()
+			tt.modify(&conf)
+
+			bhyveConf, err := BuildBhyveConfig("test-vm-1", "/tank/vm-encrypted/test-vm-1/", "tank/vm-encrypted/test-vm-1", conf, tt.taps, false)
+			if err != nil {
+				t.Fatalf("BuildBhyveConfig: %s", err.Error())
+			}
+			got, err := bhyveConf.Render()
+			if err != nil {
+				t.Fatalf("Render: %s", err.Error())
+			}
+
+			golden := filepath.Join("testdata", "bhyve_config", tt.name+".conf")
+			if *updateGolden {
+				err = os.WriteFile(golden, got, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%s (run with -update to create it)", err.Error())
+			}
+			if string(got) != string(want) {
+				t.Errorf("config doesn't match %s\n--- got:\n%s\n--- want:\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestBuildBhyveConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *VmConfig)
+		taps   []string
+		err    string
+	}{
+		{
+			name:   "missing tap",
+			modify: func(c *VmConfig) {},
+			err:    "expected 1 tap interfaces, got 0",
+		},
+		{
+			name:   "invalid passthru",
+			modify: func(c *VmConfig) { c.Passthru = []string{"4:0:0"} },
+			taps:   []string{"tap0"},
+			err:    "invalid passthru device '4:0:0'",
+		},
+		{
+			name:   "invalid custom option",
+			modify: func(c *VmConfig) { c.CustomOptions = []string{"x86.verbosemsr"} },
+			taps:   []string{"tap0"},
+			err:    "invalid custom option 'x86.verbosemsr'",
+		},
+		{
+			// 1 network + 27 disks + fbuf + xhci needs slots 2-31, but slot 31 belongs to the LPC bridge
+			name:   "pci slot exhaustion",
+			modify: func(c *VmConfig) { c.Disks = testDisks(27) },
+			taps:   []string{"tap0"},
+			err:    "VM needs 30 PCI slots, but only 29 are available",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := testVmConfig()
+			tt.modify(&conf)
+
+			_, err := BuildBhyveConfig("test-vm-1", "/tank/vm-encrypted/test-vm-1", "", conf, tt.taps, false)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tt.err)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %q", tt.err, err.Error())
+			}
+		})
+	}
+}
+
+func TestAllocateBhyvePciSlots(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(c *VmConfig)
+		networks []int
+		disks    []int
+		fbuf     int
+		passthru []bhyvePciAddress
+		xhci     int
+		last     int
+	}{
+		{
+			name:     "default",
+			modify:   func(c *VmConfig) {},
+			networks: []int{2},
+			disks:    []int{3},
+			fbuf:     4,
+			xhci:     5,
+			last:     5,
+		},
+		{
+			// Same as the old bhyve command generator: the first disk gets slot 3 even if there is no network adapter
+			name:   "no network",
+			modify: func(c *VmConfig) { c.Networks = nil },
+			disks:  []int{3},
+			fbuf:   4,
+			xhci:   5,
+			last:   5,
+		},
+		{
+			name: "no network, multiple disks and a share",
+			modify: func(c *VmConfig) {
+				c.Networks = nil
+				c.Disks = testDisks(2)
+				c.Shares = []Virtio9P{{ShareName: "data", ShareLocation: "/tank/shares/data"}}
+			},
+			disks: []int{3, 4},
+			fbuf:  6,
+			xhci:  7,
+			last:  7,
+		},
+		{
+			name: "passthru functions share a slot",
+			modify: func(c *VmConfig) {
+				c.Passthru = []string{"4/0/0", "4/0/1", "-4/0/2", "7/0/0", "bad"}
+				c.DisableXHCI = true
+			},
+			networks: []int{2},
+			disks:    []int{3},
+			fbuf:     4,
+			passthru: []bhyvePciAddress{{slot: 5, function: 0}, {slot: 5, function: 1}, {slot: 6, function: 0}, {slot: 7, function: 0}, {}},
+			last:     7,
+		},
+		{
+			// The last usable slot (30) is taken, which still fits
+			name:     "all slots used",
+			modify:   func(c *VmConfig) { c.Disks = testDisks(26) },
+			networks: []int{2},
+			disks:    testSlots(3, 26),
+			fbuf:     29,
+			xhci:     30,
+			last:     30,
+		},
+		{
+			name: "exhausted",
+			modify: func(c *VmConfig) {
+				c.Disks = testDisks(26)
+				c.Shares = []Virtio9P{{ShareName: "data", ShareLocation: "/tank/shares/data"}}
+			},
+			networks: []int{2},
+			disks:    testSlots(3, 26),
+			fbuf:     30,
+			xhci:     31,
+			last:     31,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := testVmConfig()
+			tt.modify(&conf)
+
+			slots := allocateBhyvePciSlots(conf)
+			if fmt.Sprint(slots.networks) != fmt.Sprint(tt.networks) {
+				t.Errorf("networks: got %v, want %v", slots.networks, tt.networks)
+			}
+			if fmt.Sprint(slots.disks) != fmt.Sprint(tt.disks) {
+				t.Errorf("disks: got %v, want %v", slots.disks, tt.disks)
+			}
+			if fmt.Sprint(slots.passthru) != fmt.Sprint(tt.passthru) {
+				t.Errorf("passthru: got %v, want %v", slots.passthru, tt.passthru)
+			}
+			if slots.fbuf != tt.fbuf || slots.xhci != tt.xhci || slots.last != tt.last {
+				t.Errorf("fbuf/xhci/last: got %d/%d/%d, want %d/%d/%d", slots.fbuf, slots.xhci, slots.last, tt.fbuf, tt.xhci, tt.last)
+			}
+			if (slots.last > maxBhyvePciSlot) != (tt.last > maxBhyvePciSlot) {
+				t.Errorf("unexpected slot exhaustion result for the last slot %d", slots.last)
+			}
+		})
+	}
+}
+
+func testDisks(count int) (r []VmDisk) {
+	for i := 0; i < count; i++ {
+		r = append(r, VmDisk{DiskType: "virtio-blk", DiskLocation: "internal", DiskImage: fmt.Sprintf("disk%d.img", i)})
+	}
+	return
+}
+
+func testSlots(first int, count int) (r []int) {
+	for i := 0; i < count; i++ {
+		r = append(r, first+i)
+	}
+	return
+}
@@ -5,6 +5,8 @@
 package HosterVmUtils
 
 import (
+	AtomicFile "HosterCore/internal/pkg/atomic_file"
+	"context"
 	"encoding/json"
 	"os"
 )
@@ -13,24 +15,34 @@ import (
 // It takes in a new config struct to be written, and an absolute config file location:
 //
 // e.g. /tank/vm-encrypted/test-vm-1/vm_config.json
+//
+// Refuses to overwrite the configs created by a newer Hoster version, the unknown settings would be silently lost.
 func ConfigFileWriter(conf VmConfig, confLocation string) error {
-	jsonOutput, err := json.MarshalIndent(conf, "", "   ")
-	if err != nil {
-		return err
-	}
+	return ConfigFileWriterContext(context.Background(), conf, confLocation)
+}
 
-	// Open the file in write-only mode, truncating (overwriting) it if it already exists
-	file, err := os.OpenFile(confLocation, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
+// Same as ConfigFileWriter(), but if the context carries the config revision expected by the API client (If-Match),
+// the config is only written if it hasn't been changed since (AtomicFile.ErrRevisionMismatch is returned otherwise).
+func ConfigFileWriterContext(ctx context.Context, conf VmConfig, confLocation string) error {
+	err := CheckVmConfigVersion(conf.ConfigVersion)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	data, err := os.ReadFile(confLocation)
+	if err == nil {
+		onDiskVersion, _ := VmConfigVersion(data)
+		err = CheckVmConfigVersion(onDiskVersion)
+		if err != nil {
+			return err
+		}
+	}
+	conf.ConfigVersion = VM_CONFIG_VERSION
 
-	// Write data to the file
-	_, err = file.Write(jsonOutput)
+	jsonOutput, err := json.MarshalIndent(conf, "", "   ")
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// Lock the config folder, and replace the file atomically, to avoid partially written configs
+	return AtomicFile.WriteFileContext(ctx, confLocation, jsonOutput, 0644)
 }
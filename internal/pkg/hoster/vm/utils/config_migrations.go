@@ -0,0 +1,183 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// The latest vm_config.json schema version this binary understands.
+// Configs without the config_version field are treated as version 0.
+const VM_CONFIG_VERSION = 2
+
+// A single schema upgrade, from (version - 1) to version.
+// Migrations work on the raw JSON object, because the old formats don't necessarily fit the current VmConfig struct.
+type vmConfigMigration struct {
+	version     int
+	description string
+	migrate     func(conf map[string]any) error
+}
+
+// Ordered chain of the config migrations. New schema changes (e.g. the network_adaptor_type -> network_driver rename)
+// must be added here as the next version, and VM_CONFIG_VERSION bumped accordingly.
+var vmConfigMigrations = []vmConfigMigration{
+	{version: 1, description: "convert the numeric values stored as strings, and the legacy live_status field", migrate: migrateVmConfigV1},
+	{version: 2, description: "set the failover strategy explicitly (change_parent by default)", migrate: migrateVmConfigV2},
+}
+
+// Returns the config_version of the raw config (0 if it's not set).
+func VmConfigVersion(data []byte) (r int, e error) {
+	header := struct {
+		ConfigVersion int `json:"config_version"`
+	}{}
+
+	e = json.Unmarshal(data, &header)
+	r = header.ConfigVersion
+	return
+}
+
+// Returns an error if the config version is newer than this binary understands,
+// writing such config would silently drop the settings we don't know about.
+func CheckVmConfigVersion(version int) error {
+	if version > VM_CONFIG_VERSION {
+		return fmt.Errorf("vm config version %d is newer than the supported version %d, upgrade Hoster to modify this config", version, VM_CONFIG_VERSION)
+	}
+	return nil
+}
+
+// Returns the descriptions of the migrations that would be applied to the config of the given version.
+func PendingVmConfigMigrations(version int) (r []string) {
+	r = []string{}
+	for _, v := range vmConfigMigrations {
+		if v.version > version {
+			r = append(r, fmt.Sprintf("v%d: %s", v.version, v.description))
+		}
+	}
+	return
+}
+
+// Parses the raw config, and upgrades it to the latest schema version (in memory).
+// Newer configs are parsed as is, but can't be written back (check ConfigFileWriter).
+func MigrateVmConfig(data []byte) (r VmConfig, fromVersion int, e error) {
+	fromVersion, err := VmConfigVersion(data)
+	if err != nil {
+		// Very old configs may not even match the config_version type, let the migrations deal with it
+		fromVersion = 0
+	}
+	if fromVersion >= VM_CONFIG_VERSION {
+		e = json.Unmarshal(data, &r)
+		return
+	}
+
+	// Keep the numbers as is, large integers (e.g. disk sizes in bytes) would lose precision as float64
+	conf := make(map[string]any)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&conf)
+	if err != nil {
+		e = err
+		return
+	}
+
+	for _, v := range vmConfigMigrations {
+		if v.version <= fromVersion {
+			continue
+		}
+		err := v.migrate(conf)
+		if err != nil {
+			e = fmt.Errorf("vm config migration to v%d has failed: %s", v.version, err.Error())
+			return
+		}
+		conf["config_version"] = v.version
+	}
+
+	migrated, err := json.Marshal(conf)
+	if err != nil {
+		e = err
+		return
+	}
+
+	e = json.Unmarshal(migrated, &r)
+	return
+}
+
+// Upgrades the config file on disk to the latest schema version. The original file is kept as vm_config.json.v<N>.bak
+// (an existing backup is never overwritten, so the oldest original is preserved).
+//
+// Returns the version the config was migrated from. Configs that are up to date are left untouched.
+func MigrateVmConfigFile(confLocation string) (fromVersion int, e error) {
+	data, err := os.ReadFile(confLocation)
+	if err != nil {
+		e = err
+		return
+	}
+
+	conf, fromVersion, err := MigrateVmConfig(data)
+	if err != nil {
+		e = err
+		return
+	}
+	if fromVersion >= VM_CONFIG_VERSION {
+		e = CheckVmConfigVersion(fromVersion)
+		return
+	}
+
+	backupLocation := fmt.Sprintf("%s.v%d.bak", confLocation, fromVersion)
+	backup, err := os.OpenFile(backupLocation, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err == nil {
+		_, err = backup.Write(data)
+		backup.Close()
+		if err != nil {
+			e = fmt.Errorf("could not write the config backup: %s", err.Error())
+			return
+		}
+	} else if !os.IsExist(err) {
+		e = fmt.Errorf("could not create the config backup: %s", err.Error())
+		return
+	}
+
+	e = ConfigFileWriter(conf, confLocation)
+	return
+}
+
+// v1: cpu_sockets, cpu_cores, cpu_threads and vnc_port used to be stored as strings,
+// and the production flag used to be a "live_status" string
+func migrateVmConfigV1(conf map[string]any) error {
+	for _, key := range []string{"cpu_sockets", "cpu_cores", "cpu_threads", "vnc_port"} {
+		value, ok := conf[key].(string)
+		if !ok {
+			continue
+		}
+		parsedInt, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s is not a number: %s", key, value)
+		}
+		conf[key] = parsedInt
+	}
+
+	liveStatus, ok := conf["live_status"].(string)
+	if ok {
+		if liveStatus == "production" || liveStatus == "prod" {
+			conf["production"] = true
+		}
+		delete(conf, "live_status")
+	}
+
+	return nil
+}
+
+// v2: the failover strategy used to be optional, with the change_parent as an implicit default
+func migrateVmConfigV2(conf map[string]any) error {
+	strategy, _ := conf["failover_strategy"].(string)
+	if strategy != "cireset" && strategy != "change_parent" {
+		conf["failover_strategy"] = "change_parent"
+	}
+
+	return nil
+}
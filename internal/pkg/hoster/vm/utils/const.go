@@ -4,6 +4,8 @@
 
 package HosterVmUtils
 
+import ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+
 const VM_CONFIG_NAME = "vm_config.json"
 const VM_LOG_NAME = "vm_supervisor.log"
 const VM_AUDIT_LOG_LOCATION = "/var/log/hoster_audit_vm.log"
@@ -13,4 +15,15 @@ const ERRTXT_VM_IS_STOPPED = "VM is already offline"
 const ERRTXT_VM_DOESNT_EXIST = "VM doesn't exist"
 const ERRTXT_VM_ALREADY_EXIST = "VM already exists"
 
+// Sentinel errors with the API error code attached, check them using errors.Is
+var (
+	ErrVmIsRunning     = ErrorMappings.NewError(ErrorMappings.CODE_VM_RUNNING, ERRTXT_VM_IS_RUNNING)
+	ErrVmIsStopped     = ErrorMappings.NewError(ErrorMappings.CODE_VM_NOT_RUNNING, ERRTXT_VM_IS_STOPPED)
+	ErrVmDoesntExist   = ErrorMappings.NewError(ErrorMappings.CODE_VM_NOT_FOUND, ERRTXT_VM_DOESNT_EXIST)
+	ErrVmAlreadyExists = ErrorMappings.NewError(ErrorMappings.CODE_NAME_CONFLICT, ERRTXT_VM_ALREADY_EXIST)
+)
+
 const VM_CACHE_FILE = "/var/run/hoster_vm_cache.json"
+
+// Generated bhyve_config(5) files, one per running VM
+const VM_BHYVE_CONFIG_DIR = "/var/run/hoster_bhyve"
@@ -1,8 +1,11 @@
 package HosterVmUtils
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
-func UpdateDescription(vmName string, description string) error {
+func UpdateDescription(ctx context.Context, vmName string, description string) error {
 	vm, err := InfoJsonApi(vmName)
 	if err != nil {
 		return err
@@ -17,7 +20,7 @@ func UpdateDescription(vmName string, description string) error {
 	}
 
 	vm.VmConfig.Description = description
-	err = ConfigFileWriter(vm.VmConfig, vmConfLoc)
+	err = ConfigFileWriterContext(ctx, vm.VmConfig, vmConfLoc)
 	if err != nil {
 		return err
 	}
@@ -2,19 +2,20 @@ package HosterVmUtils
 
 import (
 	FileExists "HosterCore/internal/pkg/file_exists"
+	"context"
 	"fmt"
 	"os/exec"
 	"slices"
 	"strings"
 )
 
-func AddNewVmDisk(vmName string, input VmDisk) error {
+func AddNewVmDisk(ctx context.Context, vmName string, input VmDisk) error {
 	validDrivers := []string{"ahci-hd", "virtio-blk", "nvme"}
 	if !slices.Contains(validDrivers, input.DiskType) {
 		return fmt.Errorf("invalid disk type")
 	}
 
-	validLocations := []string{"external", "internal"}
+	validLocations := []string{"external", "internal", DISK_LOCATION_ZVOL}
 	if !slices.Contains(validLocations, input.DiskLocation) {
 		return fmt.Errorf("invalid disk location")
 	}
@@ -45,6 +46,9 @@ func AddNewVmDisk(vmName string, input VmDisk) error {
 		// Example location this call should generate: /tank/vm-encrypted/test-vm-1/disk0.img
 		input.DiskImage = vmInfo.Simple.Mountpoint + "/" + vmName + "/disk" + fmt.Sprintf("%d", len(vmInfo.VmConfig.Disks)) + ".img"
 	}
+	if input.DiskLocation == DISK_LOCATION_ZVOL {
+		return addNewZvolDisk(ctx, vmName, vmInfo, input)
+	}
 	if FileExists.CheckUsingOsStat(input.DiskImage) {
 		return fmt.Errorf("disk file already exists")
 	}
@@ -55,6 +59,12 @@ func AddNewVmDisk(vmName string, input VmDisk) error {
 		}
 	}
 
+	candidate := vmInfo.VmConfig
+	candidate.Disks = append(slices.Clone(candidate.Disks), input)
+	if lastBhyvePciSlot(candidate) > maxBhyvePciSlot {
+		return fmt.Errorf("no free PCI slots left for a new disk")
+	}
+
 	out, err := exec.Command("truncate", "-s", fmt.Sprintf("%dG", input.DiskInputSize), input.DiskImage).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("%s; %s", strings.TrimSpace(string(out)), strings.TrimSpace(err.Error()))
@@ -77,10 +87,51 @@ func AddNewVmDisk(vmName string, input VmDisk) error {
 	vmInfo.VmConfig.Disks = append(vmInfo.VmConfig.Disks, input)
 
 	configLocation := vmInfo.Simple.Mountpoint + "/" + vmName + "/" + VM_CONFIG_NAME
-	err = ConfigFileWriter(vmInfo.VmConfig, configLocation)
+	err = ConfigFileWriterContext(ctx, vmInfo.VmConfig, configLocation)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// Example volume this call should generate: tank/vm-encrypted/test-vm-1/disk1
+func addNewZvolDisk(ctx context.Context, vmName string, vmInfo VmApi, input VmDisk) error {
+	vmDataset := vmInfo.Simple.DsName + "/" + vmName
+	input.DiskImage = "disk" + fmt.Sprintf("%d", len(vmInfo.VmConfig.Disks))
+	if FileExists.CheckUsingOsStat(VmDiskPath(vmDataset, "", input)) {
+		return fmt.Errorf("zvol already exists: %s/%s", vmDataset, input.DiskImage)
+	}
+
+	for _, v := range vmInfo.VmConfig.Disks {
+		if v.DiskLocation == DISK_LOCATION_ZVOL && v.DiskImage == input.DiskImage {
+			return fmt.Errorf("zvol is already mounted")
+		}
+	}
+
+	candidate := vmInfo.VmConfig
+	candidate.Disks = append(slices.Clone(candidate.Disks), input)
+	if lastBhyvePciSlot(candidate) > maxBhyvePciSlot {
+		return fmt.Errorf("no free PCI slots left for a new disk")
+	}
+
+	opts := ZvolOptions{}
+	if input.Zvol != nil {
+		opts = *input.Zvol
+	}
+	err := CreateZvol(vmDataset+"/"+input.DiskImage, input.DiskInputSize*1024*1024*1024, opts)
+	if err != nil {
+		return err
+	}
+	input.DiskInputSize = 0
+
+	disks := []VmDisk{}
+	for _, v := range vmInfo.VmConfig.Disks {
+		v.DiskSize = DiskSize{}
+		disks = append(disks, v)
+	}
+	vmInfo.VmConfig.Disks = append(disks, input)
+
+	configLocation := vmInfo.Simple.Mountpoint + "/" + vmName + "/" + VM_CONFIG_NAME
+	return ConfigFileWriterContext(ctx, vmInfo.VmConfig, configLocation)
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	FileExists "HosterCore/internal/pkg/file_exists"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Converts the VM disk from an image file to a zvol (target "zvol"), or from a zvol back to an image file inside of
+// the VM folder (target "file"). The data is copied block by block, skipping the empty (zeroed) blocks.
+//
+// The source is removed after the config was updated, unless keepSource is set.
+// Returns the new disk_image value.
+func ConvertVmDisk(vmName string, diskImage string, target string, opts ZvolOptions, keepSource bool) (r string, e error) {
+	vm, err := InfoJsonApi(vmName)
+	if err != nil {
+		e = err
+		return
+	}
+	if vm.Running {
+		e = errors.New("vm has to be offline, the disk can't be converted while it's in use")
+		return
+	}
+	if vm.Backup {
+		e = errors.New("this is a backup VM")
+		return
+	}
+	if target != DISK_LOCATION_ZVOL && target != "file" {
+		e = fmt.Errorf("invalid conversion target '%s', use either zvol or file", target)
+		return
+	}
+
+	diskIndex := -1
+	for i, v := range vm.VmConfig.Disks {
+		if v.DiskImage == diskImage {
+			diskIndex = i
+			break
+		}
+	}
+	if diskIndex < 0 {
+		e = errors.New("disk is not attached to this VM: " + diskImage)
+		return
+	}
+
+	vmDataset := vm.Simple.DsName + "/" + vmName
+	vmLocation := vm.Simple.Mountpoint + "/" + vmName
+	disk := vm.VmConfig.Disks[diskIndex]
+	source := VmDiskPath(vmDataset, vmLocation, disk)
+	name := strings.TrimSuffix(filepath.Base(disk.DiskImage), ".img")
+
+	newDisk := disk
+	newDisk.DiskSize = DiskSize{}
+	if target == DISK_LOCATION_ZVOL {
+		if disk.DiskLocation == DISK_LOCATION_ZVOL {
+			e = errors.New("disk is already a zvol")
+			return
+		}
+		if disk.DiskType == "ahci-cd" {
+			e = errors.New("CD-ROM drives can't be backed by a zvol")
+			return
+		}
+		if !IsValidZvolName(name) {
+			e = errors.New("can't derive a valid zvol name from the disk image: " + disk.DiskImage)
+			return
+		}
+
+		newDisk.DiskLocation = DISK_LOCATION_ZVOL
+		newDisk.DiskImage = name
+		newDisk.Zvol = nil
+		if opts != (ZvolOptions{}) {
+			newDisk.Zvol = &opts
+		}
+		err = copyDiskToZvol(source, vmDataset+"/"+name, opts)
+	} else {
+		if disk.DiskLocation != DISK_LOCATION_ZVOL {
+			e = errors.New("disk is already an image file")
+			return
+		}
+
+		newDisk.DiskLocation = "internal"
+		newDisk.DiskImage = name + ".img"
+		newDisk.Zvol = nil
+		err = copyZvolToDisk(vmDataset+"/"+disk.DiskImage, vmLocation+"/"+newDisk.DiskImage)
+	}
+	if err != nil {
+		e = err
+		return
+	}
+
+	disks := []VmDisk{}
+	for _, v := range vm.VmConfig.Disks {
+		v.DiskSize = DiskSize{}
+		disks = append(disks, v)
+	}
+	disks[diskIndex] = newDisk
+	vm.VmConfig.Disks = disks
+
+	err = ConfigFileWriter(vm.VmConfig, vmLocation+"/"+VM_CONFIG_NAME)
+	if err != nil {
+		e = err
+		return
+	}
+	r = newDisk.DiskImage
+
+	if keepSource {
+		return
+	}
+	switch disk.DiskLocation {
+	case DISK_LOCATION_ZVOL:
+		// Recursive, in order to remove the zvol snapshots as well
+		out, err := exec.Command("zfs", "destroy", "-r", vmDataset+"/"+disk.DiskImage).CombinedOutput()
+		if err != nil {
+			e = fmt.Errorf("disk was converted, but the source zvol could not be removed: %s; %s", strings.TrimSpace(string(out)), err.Error())
+			return
+		}
+	case "internal":
+		err = os.Remove(source)
+		if err != nil {
+			e = fmt.Errorf("disk was converted, but the source image could not be removed: %s", err.Error())
+			return
+		}
+	}
+	// External images are never removed, they live outside of the VM folder and could be used elsewhere
+
+	return
+}
+
+func copyDiskToZvol(source string, dataset string, opts ZvolOptions) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if FileExists.CheckUsingOsStat("/dev/zvol/" + dataset) {
+		return errors.New("zvol already exists: " + dataset)
+	}
+
+	err = CreateZvol(dataset, uint64(info.Size()), opts)
+	if err != nil {
+		return err
+	}
+
+	err = ddCopy(source, "/dev/zvol/"+dataset)
+	if err != nil {
+		_ = exec.Command("zfs", "destroy", dataset).Run()
+		return err
+	}
+
+	return nil
+}
+
+func copyZvolToDisk(dataset string, destination string) error {
+	if FileExists.CheckUsingOsStat(destination) {
+		return errors.New("disk image already exists: " + destination)
+	}
+
+	size, err := ZvolSize(dataset)
+	if err != nil {
+		return err
+	}
+
+	// Pre-allocate the sparse image, the zeroed blocks will not be written by dd
+	out, err := exec.Command("truncate", "-s", strconv.FormatUint(size, 10), destination).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not create the disk image: %s; %s", strings.TrimSpace(string(out)), err.Error())
+	}
+
+	err = ddCopy("/dev/zvol/"+dataset, destination)
+	if err != nil {
+		_ = os.Remove(destination)
+		return err
+	}
+
+	return nil
+}
+
+func ddCopy(source string, destination string) error {
+	out, err := exec.Command("dd", "if="+source, "of="+destination, "bs=1M", "conv=sparse,notrunc").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not copy the disk data: %s; %s", strings.TrimSpace(string(out)), err.Error())
+	}
+
+	return nil
+}
@@ -27,6 +27,12 @@ func DiskExpandOffline(diskImage string, expansionSize int, vmName string) error
 		return errors.New("expansion size is less than 1G")
 	}
 
+	for _, v := range vm.VmConfig.Disks {
+		if v.DiskLocation == DISK_LOCATION_ZVOL && v.DiskImage == diskImage {
+			return ExpandZvol(vm.Simple.DsName+"/"+vm.Name+"/"+diskImage, expansionSize)
+		}
+	}
+
 	diskImageLocation := ""
 	if strings.Contains(diskImage, "/") {
 		diskImageLocation = diskImage
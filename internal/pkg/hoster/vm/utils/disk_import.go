@@ -0,0 +1,184 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	DiskImage "HosterCore/internal/pkg/disk_image"
+	FileExists "HosterCore/internal/pkg/file_exists"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Writes the virtual disk data of the image into dst (a new file, or a freshly created zvol), skipping the zeroed blocks.
+// The data is padded to the full 512 byte sector, bhyve (and the zvol devices) can't handle the partial sectors.
+// The number of processed bytes is written into progress (e.g. a progress bar), if it's not nil.
+func ConvertDiskImage(img DiskImage.Image, dst *os.File, progress io.Writer) error {
+	var src io.Reader = io.NewSectionReader(img, 0, img.Size())
+	if progress != nil {
+		src = io.TeeReader(src, progress)
+	}
+	if pad := -img.Size() & 511; pad > 0 {
+		src = io.MultiReader(src, bytes.NewReader(make([]byte, pad)))
+	}
+
+	_, err := SparseCopy(dst, src)
+	return err
+}
+
+// Converts the image into a sparse raw image file. The data is written into a temporary file first (unique per import,
+// in the same folder, so a leftover from an interrupted import never gets in the way), and then renamed over the destination,
+// so the existing image (e.g. an older template) is only replaced on success.
+func ImportDiskImage(img DiskImage.Image, destination string, progress io.Writer) error {
+	f, err := os.CreateTemp(filepath.Dir(destination), "."+filepath.Base(destination)+".import-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	err = f.Chmod(0640)
+	if err != nil {
+		f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	err = ConvertDiskImage(img, f, progress)
+	if err != nil {
+		f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("could not convert the %s image: %s", img.Format(), err.Error())
+	}
+	err = f.Close()
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, destination)
+}
+
+// Adds a new disk to the (offline) VM, with the data converted from the image. The disk is created as an image file
+// inside of the VM folder (location "internal"), or as a zvol under the VM dataset (location "zvol").
+//
+// The disk size matches the virtual disk size of the image, unless a bigger input.DiskInputSize (in GB) is requested.
+// Returns the new disk config.
+func AddVmDiskFromImage(vmName string, img DiskImage.Image, input VmDisk, progress io.Writer) (r VmDisk, e error) {
+	validDrivers := []string{"ahci-hd", "virtio-blk", "nvme"}
+	if !slices.Contains(validDrivers, input.DiskType) {
+		e = fmt.Errorf("invalid disk type")
+		return
+	}
+	if input.DiskLocation != "internal" && input.DiskLocation != DISK_LOCATION_ZVOL {
+		e = fmt.Errorf("invalid disk location, the image can only be imported as an internal disk or a zvol")
+		return
+	}
+
+	vm, err := InfoJsonApi(vmName)
+	if err != nil {
+		e = err
+		return
+	}
+	if vm.Running {
+		e = errors.New("vm has to be offline, due to the fact that bhyve can't hot-reload settings")
+		return
+	}
+	if vm.Backup {
+		e = errors.New("this is a backup VM")
+		return
+	}
+
+	vmDataset := vm.Simple.DsName + "/" + vmName
+	vmLocation := vm.Simple.Mountpoint + "/" + vmName
+	size := max(img.Size(), int64(input.DiskInputSize)*1024*1024*1024)
+	size = (size + 511) / 512 * 512
+
+	if len(input.Comment) < 1 {
+		input.Comment = "Imported " + img.Format() + " disk image"
+	}
+	input.DiskInputSize = 0
+	input.DiskSize = DiskSize{}
+	if input.DiskLocation == "internal" {
+		input.DiskImage = fmt.Sprintf("disk%d.img", len(vm.VmConfig.Disks))
+		input.Zvol = nil
+	} else {
+		input.DiskImage = fmt.Sprintf("disk%d", len(vm.VmConfig.Disks))
+	}
+	diskPath := VmDiskPath(vmDataset, vmLocation, input)
+	if FileExists.CheckUsingOsStat(diskPath) {
+		e = errors.New("disk already exists: " + diskPath)
+		return
+	}
+
+	candidate := vm.VmConfig
+	candidate.Disks = append(slices.Clone(candidate.Disks), input)
+	if lastBhyvePciSlot(candidate) > maxBhyvePciSlot {
+		e = fmt.Errorf("no free PCI slots left for a new disk")
+		return
+	}
+
+	var f *os.File
+	cleanUp := func() {}
+	if input.DiskLocation == DISK_LOCATION_ZVOL {
+		opts := ZvolOptions{}
+		if input.Zvol != nil {
+			opts = *input.Zvol
+		}
+		err = CreateZvol(vmDataset+"/"+input.DiskImage, uint64(size), opts)
+		if err != nil {
+			e = err
+			return
+		}
+		cleanUp = func() { _ = exec.Command("zfs", "destroy", vmDataset+"/"+input.DiskImage).Run() }
+		f, err = os.OpenFile(diskPath, os.O_WRONLY, 0)
+	} else {
+		cleanUp = func() { _ = os.Remove(diskPath) }
+		f, err = os.OpenFile(diskPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	}
+	if err != nil {
+		cleanUp()
+		e = err
+		return
+	}
+
+	err = ConvertDiskImage(img, f, progress)
+	if err == nil && input.DiskLocation == "internal" {
+		err = f.Truncate(size)
+	}
+	if err != nil {
+		f.Close()
+		cleanUp()
+		e = fmt.Errorf("could not convert the %s image: %s", img.Format(), strings.TrimSpace(err.Error()))
+		return
+	}
+	err = f.Close()
+	if err != nil {
+		cleanUp()
+		e = err
+		return
+	}
+
+	disks := []VmDisk{}
+	for _, v := range vm.VmConfig.Disks {
+		v.DiskSize = DiskSize{}
+		disks = append(disks, v)
+	}
+	vm.VmConfig.Disks = append(disks, input)
+
+	err = ConfigFileWriter(vm.VmConfig, vmLocation+"/"+VM_CONFIG_NAME)
+	if err != nil {
+		cleanUp()
+		e = err
+		return
+	}
+
+	r = input
+	return
+}
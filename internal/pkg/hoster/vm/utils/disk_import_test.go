@@ -0,0 +1,108 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	DiskImage "HosterCore/internal/pkg/disk_image"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Disk image fixtures are shared with the DiskImage package, check testdata/generate.py over there
+var diskImageFixtures = filepath.Join("..", "..", "..", "disk_image", "testdata")
+
+func TestImportDiskImage(t *testing.T) {
+	dir := t.TempDir()
+	files, err := filepath.Glob(filepath.Join(diskImageFixtures, "*.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range files {
+		name := strings.TrimSuffix(filepath.Base(v), ".gz")
+		err = os.WriteFile(filepath.Join(dir, name), readGzipFixture(t, v), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"v2.qcow2", "v3.qcow2", "stream.vmdk", "split.vmdk", "dynamic.vhdx"} {
+		t.Run(name, func(t *testing.T) {
+			img, err := DiskImage.Open(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatalf("Open: %s", err.Error())
+			}
+			defer img.Close()
+
+			destination := filepath.Join(t.TempDir(), "disk0.img")
+			// An older image gets replaced, and a temporary file left behind by an interrupted import doesn't get in the way
+			err = os.WriteFile(destination, []byte("old template image"), 0640)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(destination+".import", []byte("stale"), 0640)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var progress bytes.Buffer
+			err = ImportDiskImage(img, destination, &progress)
+			if err != nil {
+				t.Fatalf("ImportDiskImage: %s", err.Error())
+			}
+
+			// Raw output is padded to the full 512 byte sector
+			expected, err := os.ReadFile(filepath.Join(dir, name+".raw"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected = append(expected, make([]byte, -len(expected)&511)...)
+			got, err := os.ReadFile(destination)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, expected) {
+				t.Fatalf("converted image doesn't match the expected raw output (got %d bytes, want %d)", len(got), len(expected))
+			}
+			if int64(progress.Len()) != img.Size() {
+				t.Errorf("progress: got %d bytes, want %d", progress.Len(), img.Size())
+			}
+
+			info, err := os.Stat(destination)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0640 {
+				t.Errorf("permissions: got %v, want 0640", info.Mode().Perm())
+			}
+			leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(destination), ".disk0.img.import-*"))
+			if len(leftovers) > 0 {
+				t.Errorf("temporary files were left behind: %v", leftovers)
+			}
+		})
+	}
+}
+
+func readGzipFixture(t *testing.T, path string) []byte {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,223 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	FileExists "HosterCore/internal/pkg/file_exists"
+	zfsutils "HosterCore/internal/pkg/zfs_utils"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// Removes the disk from the VM config, the disk image (or zvol) itself is kept.
+// The boot disk (first disk in the list) can't be detached, reorder the disks first.
+func DetachVmDisk(ctx context.Context, vmName string, diskImage string) (r VmDisk, e error) {
+	vm, err := InfoJsonApi(vmName)
+	if err != nil {
+		e = err
+		return
+	}
+	if vm.Running {
+		e = errors.New("vm has to be offline, due to the fact that bhyve can't hot-reload settings")
+		return
+	}
+	if vm.Backup {
+		e = errors.New("this is a backup VM")
+		return
+	}
+
+	index := findVmDisk(vm.VmConfig.Disks, diskImage)
+	if index < 0 {
+		e = errors.New("disk is not attached to this VM: " + diskImage)
+		return
+	}
+	if index == 0 {
+		e = errors.New("can't detach the boot disk, move another disk to the top of the list first (disk reorder)")
+		return
+	}
+
+	r = vm.VmConfig.Disks[index]
+	r.DiskSize = DiskSize{}
+
+	disks := []VmDisk{}
+	for i, v := range vm.VmConfig.Disks {
+		if i == index {
+			continue
+		}
+		v.DiskSize = DiskSize{}
+		disks = append(disks, v)
+	}
+	vm.VmConfig.Disks = disks
+
+	e = ConfigFileWriterContext(ctx, vm.VmConfig, vm.Simple.Mountpoint+"/"+vmName+"/"+VM_CONFIG_NAME)
+	return
+}
+
+// Detaches the disk and deletes its image file (or zvol).
+//
+// Fails if any of the VM snapshots still references the disk data (clones are always based on a snapshot,
+// so they are covered as well): the space would not be freed, and a rollback would bring back a disk that is not in the config.
+// External images are never deleted, as they are not managed by Hoster.
+func RemoveVmDisk(ctx context.Context, vmName string, diskImage string) error {
+	vm, err := InfoJsonApi(vmName)
+	if err != nil {
+		return err
+	}
+	if vm.Running {
+		return errors.New("vm has to be offline, due to the fact that bhyve can't hot-reload settings")
+	}
+	if vm.Backup {
+		return errors.New("this is a backup VM")
+	}
+
+	index := findVmDisk(vm.VmConfig.Disks, diskImage)
+	if index < 0 {
+		return errors.New("disk is not attached to this VM: " + diskImage)
+	}
+	disk := vm.VmConfig.Disks[index]
+	if disk.DiskLocation != "internal" && disk.DiskLocation != DISK_LOCATION_ZVOL {
+		return errors.New("external disk images are not managed by Hoster, use disk detach instead")
+	}
+
+	vmDataset := vm.Simple.DsName + "/" + vmName
+	vmLocation := vm.Simple.Mountpoint + "/" + vmName
+	references, err := vmDiskReferences(vmDataset, vmLocation, disk)
+	if err != nil {
+		return err
+	}
+	if len(references) > 0 {
+		return fmt.Errorf("disk is still referenced by: %s; remove these snapshots first, or use disk detach to keep the image", strings.Join(references, ", "))
+	}
+
+	_, err = DetachVmDisk(ctx, vmName, diskImage)
+	if err != nil {
+		return err
+	}
+
+	if disk.DiskLocation == DISK_LOCATION_ZVOL {
+		out, err := exec.Command("zfs", "destroy", vmDataset+"/"+disk.DiskImage).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("disk was detached, but the zvol could not be destroyed: %s; %s", strings.TrimSpace(string(out)), err.Error())
+		}
+		return nil
+	}
+
+	err = os.Remove(VmDiskPath(vmDataset, vmLocation, disk))
+	if err != nil {
+		return fmt.Errorf("disk was detached, but the image could not be removed: %s", err.Error())
+	}
+	return nil
+}
+
+// Moves the listed disks to the top of the list, in the given order, the rest of the disks keep their relative order.
+// The first disk is the boot disk. Takes effect after the next VM start.
+func ReorderVmDisks(ctx context.Context, vmName string, order []string) error {
+	vm, err := InfoJsonApi(vmName)
+	if err != nil {
+		return err
+	}
+	if vm.Backup {
+		return errors.New("this is a backup VM")
+	}
+	if len(order) < 1 {
+		return errors.New("disk order can't be empty")
+	}
+
+	oldConfig := vm.VmConfig
+	oldConfig.Disks = slices.Clone(oldConfig.Disks)
+
+	disks := []VmDisk{}
+	for _, v := range order {
+		index := findVmDisk(vm.VmConfig.Disks, v)
+		if index < 0 {
+			return errors.New("disk is not attached to this VM: " + v)
+		}
+		for _, vv := range disks {
+			if vv.DiskImage == vm.VmConfig.Disks[index].DiskImage {
+				return errors.New("disk is listed more than once: " + v)
+			}
+		}
+		disks = append(disks, vm.VmConfig.Disks[index])
+	}
+	for _, v := range vm.VmConfig.Disks {
+		if findVmDisk(disks, v.DiskImage) < 0 {
+			disks = append(disks, v)
+		}
+	}
+	for i := range disks {
+		disks[i].DiskSize = DiskSize{}
+	}
+	vm.VmConfig.Disks = disks
+
+	vmLocation := vm.Simple.Mountpoint + "/" + vmName
+	err = ValidateVmConfigChange(vmName, vmLocation, oldConfig, vm.VmConfig)
+	if err != nil {
+		return err
+	}
+
+	return ConfigFileWriterContext(ctx, vm.VmConfig, vmLocation+"/"+VM_CONFIG_NAME)
+}
+
+// Returns the disk index, or -1 if the disk is not in the list.
+// External disks can be referenced by their absolute path, or the file name.
+func findVmDisk(disks []VmDisk, diskImage string) int {
+	for i, v := range disks {
+		if v.DiskImage == diskImage {
+			return i
+		}
+	}
+
+	if !strings.Contains(diskImage, "/") {
+		for i, v := range disks {
+			if v.DiskLocation == "external" && strings.HasSuffix(v.DiskImage, "/"+diskImage) {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// Returns the snapshots (and their clones) that hold the disk data.
+func vmDiskReferences(vmDataset string, vmLocation string, disk VmDisk) (r []string, e error) {
+	r = []string{}
+
+	dataset := vmDataset
+	if disk.DiskLocation == DISK_LOCATION_ZVOL {
+		dataset = vmDataset + "/" + disk.DiskImage
+	}
+	snaps, err := zfsutils.DatasetSnapshots(dataset)
+	if err != nil {
+		e = err
+		return
+	}
+
+	for _, v := range snaps {
+		// Internal images share the dataset with the rest of the VM files, check if this snapshot has a copy of the image
+		if disk.DiskLocation == "internal" {
+			_, snapName, _ := strings.Cut(v, "@")
+			if !FileExists.CheckUsingOsStat(vmLocation + "/.zfs/snapshot/" + snapName + "/" + disk.DiskImage) {
+				continue
+			}
+		}
+
+		reference := v
+		out, err := exec.Command("zfs", "get", "-H", "-o", "value", "clones", v).CombinedOutput()
+		if err == nil {
+			clones := strings.TrimSpace(string(out))
+			if len(clones) > 0 && clones != "-" {
+				reference = reference + " (cloned to: " + clones + ")"
+			}
+		}
+		r = append(r, reference)
+	}
+
+	return
+}
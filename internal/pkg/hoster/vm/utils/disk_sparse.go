@@ -0,0 +1,114 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// lseek(2) whence values, the same on FreeBSD and Linux
+const (
+	seekData = 3
+	seekHole = 4
+)
+
+const sparseBlockSize = 1024 * 1024
+
+// Writes size bytes of the src file into the dst. The holes are not read from the disk (SEEK_DATA/SEEK_HOLE),
+// zeros are written in their place instead. Devices (zvols) and file systems without the hole support are read as is.
+func SparseRead(dst io.Writer, src *os.File, size int64) error {
+	zeros := make([]byte, sparseBlockSize)
+
+	var offset int64
+	for offset < size {
+		data, err := src.Seek(offset, seekData)
+		if errors.Is(err, syscall.ENXIO) {
+			// No more data after the offset, the rest of the file is a hole
+			data = size
+		} else if err != nil {
+			_, err = src.Seek(offset, io.SeekStart)
+			if err != nil {
+				return err
+			}
+			_, err = io.CopyN(dst, src, size-offset)
+			return err
+		}
+		data = min(data, size)
+
+		for offset < data {
+			n, err := dst.Write(zeros[:min(int64(len(zeros)), data-offset)])
+			if err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+		if offset >= size {
+			break
+		}
+
+		hole, err := src.Seek(offset, seekHole)
+		if err != nil {
+			hole = size
+		}
+		hole = min(hole, size)
+
+		_, err = src.Seek(offset, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		_, err = io.CopyN(dst, src, hole-offset)
+		if err != nil {
+			return err
+		}
+		offset = hole
+	}
+
+	return nil
+}
+
+// Copies the src into the dst file (or device), skipping the zeroed blocks instead of writing them.
+// The dst must be empty (a new file or a freshly created zvol), so the skipped blocks read back as zeros.
+// Regular files are truncated to the final size, in case the data ends with a hole.
+//
+// Returns the number of bytes copied.
+func SparseCopy(dst *os.File, src io.Reader) (r int64, e error) {
+	buf := make([]byte, sparseBlockSize)
+	zeros := make([]byte, sparseBlockSize)
+
+	for {
+		n, err := io.ReadFull(src, buf)
+		if n > 0 && !bytes.Equal(buf[:n], zeros[:n]) {
+			_, werr := dst.WriteAt(buf[:n], r)
+			if werr != nil {
+				e = werr
+				return
+			}
+		}
+		r += int64(n)
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			e = err
+			return
+		}
+	}
+
+	info, err := dst.Stat()
+	if err != nil {
+		e = err
+		return
+	}
+	if info.Mode().IsRegular() && info.Size() < r {
+		e = dst.Truncate(r)
+	}
+
+	return
+}
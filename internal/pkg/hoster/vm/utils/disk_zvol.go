@@ -0,0 +1,185 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	"HosterCore/internal/pkg/byteconversion"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zvol disks are ZFS volumes created under the VM dataset, e.g. tank/vm-encrypted/test-vm-1/disk1,
+// and attached to the VM as /dev/zvol/tank/vm-encrypted/test-vm-1/disk1.
+// The disk_image config field holds the volume name relative to the VM dataset (disk1).
+const DISK_LOCATION_ZVOL = "zvol"
+
+// zvol creation settings
+type ZvolOptions struct {
+	VolBlockSize string `json:"volblocksize,omitempty"` // e.g. 16K, ZFS default is used if not set
+	Compression  string `json:"compression,omitempty"`  // e.g. lz4, zstd or off, inherited from the parent dataset if not set
+	Sparse       bool   `json:"sparse,omitempty"`       // thin provisioned volume, space is not reserved upfront
+}
+
+var zvolNameRegex = regexp.MustCompile(`^[A-Za-z0-9_\-.]+$`)
+
+// Checks if the name can be used as a zvol name relative to the VM dataset (a single dataset component).
+func IsValidZvolName(name string) bool {
+	return zvolNameRegex.MatchString(name) && name != "." && name != ".."
+}
+
+func (o ZvolOptions) Validate() error {
+	if len(o.VolBlockSize) > 0 {
+		size, err := byteconversion.HumanToBytes(o.VolBlockSize)
+		if err != nil || size < 512 || size > 128*1024 || size&(size-1) != 0 {
+			return fmt.Errorf("invalid volblocksize '%s', must be a power of 2 between 512 and 128K", o.VolBlockSize)
+		}
+	}
+	if len(o.Compression) > 0 {
+		valid := []string{"on", "off", "lz4", "lzjb", "gzip", "zle", "zstd", "zstd-fast"}
+		algorithm := strings.Split(o.Compression, "-")[0]
+		if o.Compression == "zstd-fast" || strings.HasPrefix(o.Compression, "zstd-fast-") {
+			algorithm = "zstd-fast"
+		}
+		if !slices.Contains(valid, algorithm) {
+			return fmt.Errorf("invalid compression '%s', must be one of: %s", o.Compression, strings.Join(valid, ", "))
+		}
+	}
+	return nil
+}
+
+// Returns the absolute disk path bhyve should use.
+//
+// vmDataset is only used for the zvol disks, e.g. tank/vm-encrypted/test-vm-1
+func VmDiskPath(vmDataset string, vmLocation string, disk VmDisk) string {
+	switch disk.DiskLocation {
+	case "internal":
+		return strings.TrimSuffix(vmLocation, "/") + "/" + disk.DiskImage
+	case DISK_LOCATION_ZVOL:
+		return "/dev/zvol/" + vmDataset + "/" + disk.DiskImage
+	}
+	return disk.DiskImage
+}
+
+// Returns the VM dataset name, e.g. tank/vm-encrypted/test-vm-1
+func GetVmDataset(vmName string) (r string, e error) {
+	vms, err := ListAllSimple()
+	if err != nil {
+		e = err
+		return
+	}
+	for _, v := range vms {
+		if v.VmName == vmName {
+			r = v.DsName + "/" + v.VmName
+			return
+		}
+	}
+
+	e = fmt.Errorf("%w: %s", ErrVmDoesntExist, vmName)
+	return
+}
+
+// Creates a new zvol, and waits for its device node to appear.
+func CreateZvol(dataset string, sizeBytes uint64, opts ZvolOptions) error {
+	err := opts.Validate()
+	if err != nil {
+		return err
+	}
+
+	// volsize must be a multiple of the volblocksize, rounding up to 1M covers all of the valid block sizes
+	sizeBytes = (sizeBytes + 1024*1024 - 1) / (1024 * 1024) * (1024 * 1024)
+	args := []string{"create"}
+	if opts.Sparse {
+		args = append(args, "-s")
+	}
+	args = append(args, "-V", strconv.FormatUint(sizeBytes, 10))
+	if len(opts.VolBlockSize) > 0 {
+		args = append(args, "-o", "volblocksize="+opts.VolBlockSize)
+	}
+	if len(opts.Compression) > 0 {
+		args = append(args, "-o", "compression="+opts.Compression)
+	}
+	// Don't expose the guest partitions to the host GEOM layer
+	args = append(args, "-o", "volmode=dev", dataset)
+
+	out, err := exec.Command("zfs", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not create the zvol: %s; %s", strings.TrimSpace(string(out)), err.Error())
+	}
+
+	devicePath := "/dev/zvol/" + dataset
+	for i := 0; i < 50; i++ {
+		_, err := os.Stat(devicePath)
+		if err == nil {
+			return nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return errors.New("zvol was created, but its device node did not appear: " + devicePath)
+}
+
+// Returns the zvol size in bytes.
+func ZvolSize(dataset string) (r uint64, e error) {
+	out, err := exec.Command("zfs", "get", "-Hp", "-o", "value", "volsize", dataset).CombinedOutput()
+	if err != nil {
+		e = fmt.Errorf("could not get the zvol size: %s; %s", strings.TrimSpace(string(out)), err.Error())
+		return
+	}
+
+	r, e = strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+	return
+}
+
+// Grows the zvol by expansionSize gigabytes.
+func ExpandZvol(dataset string, expansionSize int) error {
+	size, err := ZvolSize(dataset)
+	if err != nil {
+		return err
+	}
+
+	newSize := size + uint64(expansionSize)*1024*1024*1024
+	out, err := exec.Command("zfs", "set", "volsize="+strconv.FormatUint(newSize, 10), dataset).CombinedOutput()
+	if err != nil {
+		return errors.New("can't expand the zvol: " + strings.TrimSpace(string(out)) + "; " + strings.TrimSpace(err.Error()))
+	}
+
+	return nil
+}
+
+// zvol counterpart of the DiskInfo: total is the volume size, used is the amount of data referenced by the volume.
+func ZvolDiskInfo(dataset string) (r DiskSize, e error) {
+	out, err := exec.Command("zfs", "get", "-Hp", "-o", "value", "volsize,referenced", dataset).CombinedOutput()
+	if err != nil {
+		e = fmt.Errorf("%s; %s", strings.TrimSpace(string(out)), err.Error())
+		return
+	}
+
+	values := strings.Fields(string(out))
+	if len(values) < 2 {
+		e = errors.New("unexpected zfs get output: " + strings.TrimSpace(string(out)))
+		return
+	}
+
+	r.TotalBytes, err = strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		e = err
+		return
+	}
+	r.UsedBytes, err = strconv.ParseUint(values[1], 10, 64)
+	if err != nil {
+		e = err
+		return
+	}
+	r.TotalHuman = byteconversion.BytesToHuman(r.TotalBytes)
+	r.UsedHuman = byteconversion.BytesToHuman(r.UsedBytes)
+
+	return
+}
@@ -6,21 +6,18 @@ package HosterVmUtils
 
 import (
 	FileExists "HosterCore/internal/pkg/file_exists"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"os"
-	"reflect"
-	"strconv"
 	"strings"
 )
 
 type VmDisk struct {
-	DiskType      string `json:"disk_type"`     // this is a disk driver type, e.g. virtio-blk, nvme, ahci-hd, ahci-cd.
-	DiskLocation  string `json:"disk_location"` // this is a disk location, e.g. internal or external.
-	DiskImage     string `json:"disk_image"`    // this is a disk image name (internal: disk0.img) or an absolute path to the disk image (external: /tank/windows.iso).
-	DiskInputSize uint64 `json:"disk_input_size,omitempty"`
-	Comment       string `json:"comment"`
+	DiskType      string       `json:"disk_type"`     // this is a disk driver type, e.g. virtio-blk, nvme, ahci-hd, ahci-cd.
+	DiskLocation  string       `json:"disk_location"` // this is a disk location, e.g. internal, external or zvol.
+	DiskImage     string       `json:"disk_image"`    // this is a disk image name (internal: disk0.img, zvol: disk1) or an absolute path to the disk image (external: /tank/windows.iso).
+	DiskInputSize uint64       `json:"disk_input_size,omitempty"`
+	Zvol          *ZvolOptions `json:"zvol,omitempty"` // zvol creation settings, only used if the disk_location is zvol
+	Comment       string       `json:"comment"`
 	DiskSize
 }
 
@@ -29,6 +26,7 @@ type VmNetwork struct {
 	NetworkBridge      string `json:"network_bridge"`       // this is a network name
 	NetworkMac         string `json:"network_mac"`          // rename to mac_address in the v2 release
 	IPAddress          string `json:"ip_address"`
+	VlanTag            int    `json:"vlan_tag,omitempty"` // 802.1Q VLAN tag (1-4094), the traffic is untagged if not set
 	Comment            string `json:"comment"`
 }
 
@@ -45,6 +43,7 @@ type Virtio9P struct {
 }
 
 type VmConfig struct {
+	ConfigVersion      int         `json:"config_version"` // schema version, check VM_CONFIG_VERSION and config_migrations.go
 	Production         bool        `json:"production"`
 	IgnoreHostClock    bool        `json:"ignore_host_clock,omitempty"`
 	IncludeHostSSHKeys bool        `json:"include_host_ssh_keys"`
@@ -55,6 +54,7 @@ type VmConfig struct {
 	VncResolution      int         `json:"vnc_resolution,omitempty"`
 	VncPort            int         `json:"vnc_port"`
 	VncPassword        string      `json:"vnc_password"`
+	VncLocalhostOnly   bool        `json:"vnc_localhost_only,omitempty"` // bind VNC to 127.0.0.1, so it's only reachable through the REST API VNC proxy
 	Memory             string      `json:"memory"`
 	Loader             string      `json:"loader"`
 	OsType             string      `json:"os_type"`
@@ -93,16 +93,13 @@ func GetVmConfig(vmLocation string) (r VmConfig, e error) {
 		return
 	}
 
-	err = json.Unmarshal(data, &r)
-	if err != nil {
-		r, e = FixVmConfig(vmConfLocation)
+	// Older config formats are upgraded in memory, `hoster vm migrate-configs` writes them back to disk
+	r, _, e = MigrateVmConfig(data)
+	if e != nil {
 		return
 	}
 
 	// Set the default failover strategy
-	if len(r.FailoverStrategy) < 1 {
-		r.FailoverStrategy = "change_parent"
-	}
 	if r.FailoverStrategy != "cireset" && r.FailoverStrategy != "change_parent" {
 		r.FailoverStrategy = "change_parent"
 	}
@@ -110,91 +107,21 @@ func GetVmConfig(vmLocation string) (r VmConfig, e error) {
 	return
 }
 
-// Function that helps us keep the backwards compatibility with a bit older JSON config formats (e.g. when a value changes type, cpu_cores was string -> now int).
+// Function that helps us keep the backwards compatibility with the older JSON config formats (e.g. when a value changes type, cpu_cores was string -> now int).
 //
-// Usually executed inside of GetVmConfig to return a fixed struct.
+// Kept for compatibility, the actual work is done by the ordered migrations in MigrateVmConfig.
 func FixVmConfig(vmConfLocation string) (r VmConfig, e error) {
 	if !FileExists.CheckUsingOsStat(vmConfLocation) {
 		e = errors.New("vm config file could not be found here: " + vmConfLocation)
 		return
 	}
 
-	oldConfig := make(map[string]any)
 	data, err := os.ReadFile(vmConfLocation)
 	if err != nil {
 		e = err
 		return
 	}
 
-	err = json.Unmarshal(data, &oldConfig)
-	if err != nil {
-		e = err
-		return
-	}
-
-	cpuSockets, exist := oldConfig["cpu_sockets"]
-	if exist && reflect.TypeOf(cpuSockets) == reflect.TypeOf("string") {
-		parsedInt, err := strconv.Atoi(fmt.Sprintf("%s", cpuSockets))
-		if err != nil {
-			e = err
-			return
-		}
-		oldConfig["cpu_sockets"] = parsedInt
-	}
-
-	cpuCores, exist := oldConfig["cpu_cores"]
-	if exist && reflect.TypeOf(cpuCores) == reflect.TypeOf("string") {
-		parsedInt, err := strconv.Atoi(fmt.Sprintf("%s", cpuCores))
-		if err != nil {
-			e = err
-			return
-		}
-		oldConfig["cpu_cores"] = parsedInt
-	}
-
-	cpuThreads, exist := oldConfig["cpu_threads"]
-	if exist && reflect.TypeOf(cpuThreads) == reflect.TypeOf("string") {
-		parsedInt, err := strconv.Atoi(fmt.Sprintf("%s", cpuThreads))
-		if err != nil {
-			e = err
-			return
-		}
-		oldConfig["cpu_threads"] = parsedInt
-	}
-
-	vncPort, exist := oldConfig["vnc_port"]
-	if exist && reflect.TypeOf(vncPort) == reflect.TypeOf("string") {
-		parsedInt, err := strconv.Atoi(fmt.Sprintf("%s", vncPort))
-		if err != nil {
-			e = err
-			return
-		}
-		oldConfig["vnc_port"] = parsedInt
-	}
-
-	prod, exist := oldConfig["live_status"]
-	if exist && reflect.TypeOf(prod) == reflect.TypeOf("string") {
-		parsed := fmt.Sprintf("%s", prod)
-		// if err != nil {
-		// 	e = err
-		// 	return
-		// }
-		if parsed == "production" || parsed == "prod" {
-			oldConfig["production"] = true
-		}
-	}
-
-	m, err := json.Marshal(oldConfig)
-	if err != nil {
-		e = err
-		return
-	}
-
-	err = json.Unmarshal(m, &r)
-	if err != nil {
-		e = err
-		return
-	}
-
+	r, _, e = MigrateVmConfig(data)
 	return
 }
@@ -67,7 +67,13 @@ func InfoJsonApi(vmName string) (r VmApi, e error) {
 		}
 
 		for ii, vv := range conf.Disks {
-			if vv.DiskLocation == "internal" && !strings.HasPrefix(vv.DiskImage, "/") {
+			if vv.DiskLocation == DISK_LOCATION_ZVOL {
+				diskInfo, err := ZvolDiskInfo(v.DsName + "/" + v.VmName + "/" + vv.DiskImage)
+				if err != nil {
+					continue
+				}
+				r.Disks[ii].DiskSize = diskInfo
+			} else if vv.DiskLocation == "internal" && !strings.HasPrefix(vv.DiskImage, "/") {
 				diskInfo, err := DiskInfo(v.Mountpoint + "/" + v.VmName + "/" + vv.DiskImage)
 				if err != nil {
 					continue
@@ -7,6 +7,7 @@ package HosterVmUtils
 import (
 	FreeBSDps "HosterCore/internal/pkg/freebsd/ps"
 	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
 	timeconversion "HosterCore/internal/pkg/time_conversion"
 	"regexp"
 	"slices"
@@ -99,7 +100,13 @@ func ListJsonApi() (r []VmApi, e error) {
 		// 	temp.Disks[ii].DiskSize = diskInfo
 		// }
 		for ii, vv := range conf.Disks {
-			if vv.DiskLocation == "internal" && !strings.HasPrefix(vv.DiskImage, "/") {
+			if vv.DiskLocation == DISK_LOCATION_ZVOL {
+				diskInfo, err := ZvolDiskInfo(v.DsName + "/" + v.VmName + "/" + vv.DiskImage)
+				if err != nil {
+					continue
+				}
+				temp.Disks[ii].DiskSize = diskInfo
+			} else if vv.DiskLocation == "internal" && !strings.HasPrefix(vv.DiskImage, "/") {
 				diskInfo, err := DiskInfo(v.Mountpoint + "/" + v.VmName + "/" + vv.DiskImage)
 				if err != nil {
 					continue
@@ -119,3 +126,16 @@ func ListJsonApi() (r []VmApi, e error) {
 
 	return
 }
+
+// Used to filter the VM lists (REST API and CLI).
+func (v VmApi) ListAttributes() HosterListQuery.Attributes {
+	return HosterListQuery.Attributes{
+		Name:       v.Name,
+		Owner:      v.Owner,
+		Dataset:    v.Simple.DsName,
+		Tags:       v.Tags,
+		Running:    v.Running,
+		Production: v.Production,
+		Backup:     v.Backup,
+	}
+}
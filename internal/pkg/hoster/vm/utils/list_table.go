@@ -4,7 +4,10 @@
 
 package HosterVmUtils
 
-import "strings"
+import (
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
+	"strings"
+)
 
 type ListTable struct {
 	VmName           string
@@ -21,13 +24,26 @@ type ListTable struct {
 	VmDescription    string
 }
 
-func ListAllTable() (r []ListTable, e error) {
+// Returns the VM list table rows, filtered and sorted using the list query (empty query returns all VMs).
+func ListAllTable(q HosterListQuery.Query) (r []ListTable, e error) {
 	vms, err := ListJsonApi()
 	if err != nil {
 		e = err
 		return
 	}
 
+	vms, _, err = HosterListQuery.Apply(vms, q)
+	if err != nil {
+		e = err
+		return
+	}
+
+	r = ListTableFromApi(vms)
+	return
+}
+
+// Converts the VM API list (local, or received from a remote node) into the table rows.
+func ListTableFromApi(vms []VmApi) (r []ListTable) {
 	for _, v := range vms {
 		l := ListTable{}
 		l.VmName = v.Name
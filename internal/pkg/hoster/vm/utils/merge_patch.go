@@ -0,0 +1,131 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+)
+
+// A single top level VM setting, that has been changed by the config patch.
+type VmConfigChange struct {
+	Setting         string `json:"setting"`
+	RestartRequired bool   `json:"restart_required"` // the VM is running, and the change will only be applied after the VM restart
+}
+
+// Settings that are only used by Hoster itself, and are applied without restarting the VM
+var liveVmSettings = []string{"production", "owner", "parent_host", "failover_strategy", "os_comment", "description", "tags"}
+
+// Applies the JSON Merge Patch (RFC 7396) to the VM config, and returns the patched config.
+//
+// Unknown config fields in the patch are rejected, to avoid silently ignoring the typos.
+func PatchVmConfig(conf VmConfig, patch []byte) (r VmConfig, e error) {
+	var patchValue any
+	err := json.Unmarshal(patch, &patchValue)
+	if err != nil {
+		e = err
+		return
+	}
+	if _, ok := patchValue.(map[string]any); !ok {
+		e = errors.New("merge patch must be a JSON object")
+		return
+	}
+
+	original, err := json.Marshal(conf)
+	if err != nil {
+		e = err
+		return
+	}
+	var target any
+	err = json.Unmarshal(original, &target)
+	if err != nil {
+		e = err
+		return
+	}
+
+	patched, err := json.Marshal(mergePatch(target, patchValue))
+	if err != nil {
+		e = err
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	e = decoder.Decode(&r)
+	return
+}
+
+// RFC 7396 merge: objects are merged recursively, null removes the member, and any other value replaces the target.
+func mergePatch(target any, patch any) any {
+	patchMap, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetMap, ok := target.(map[string]any)
+	if !ok {
+		targetMap = make(map[string]any)
+	}
+
+	for k, v := range patchMap {
+		if v == nil {
+			delete(targetMap, k)
+			continue
+		}
+		targetMap[k] = mergePatch(targetMap[k], v)
+	}
+
+	return targetMap
+}
+
+// Compares the VM configs setting by setting (using the JSON field names).
+// If vmRunning is set, every change that can't be applied live is marked as requiring a VM restart.
+func DiffVmConfig(oldConf VmConfig, newConf VmConfig, vmRunning bool) (r []VmConfigChange, e error) {
+	r = []VmConfigChange{}
+
+	oldMap, err := vmConfigMap(oldConf)
+	if err != nil {
+		e = err
+		return
+	}
+	newMap, err := vmConfigMap(newConf)
+	if err != nil {
+		e = err
+		return
+	}
+
+	keys := []string{}
+	for k := range oldMap {
+		keys = append(keys, k)
+	}
+	for k := range newMap {
+		if _, ok := oldMap[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if !bytes.Equal(oldMap[k], newMap[k]) {
+			r = append(r, VmConfigChange{Setting: k, RestartRequired: vmRunning && !slices.Contains(liveVmSettings, k)})
+		}
+	}
+
+	return
+}
+
+func vmConfigMap(conf VmConfig) (r map[string]json.RawMessage, e error) {
+	data, err := json.Marshal(conf)
+	if err != nil {
+		e = err
+		return
+	}
+
+	e = json.Unmarshal(data, &r)
+	return
+}
@@ -2,7 +2,9 @@ package HosterVmUtils
 
 import (
 	FileExists "HosterCore/internal/pkg/file_exists"
+	"context"
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -10,7 +12,7 @@ import (
 //
 // The function returns an error if something goes wrong,
 // otherwise it returns nil and writes the new config file with the absolute ISO file path in it.
-func MountInstallationIso(vmName string, isoPath string, isoComment string) error {
+func MountInstallationIso(ctx context.Context, vmName string, isoPath string, isoComment string) error {
 	if len(isoComment) < 1 {
 		isoComment = "Installation ISO file"
 	}
@@ -43,10 +45,15 @@ func MountInstallationIso(vmName string, isoPath string, isoComment string) erro
 			return fmt.Errorf("ISO file is already mounted")
 		}
 	}
-	vmInfo.VmConfig.Disks = append(vmInfo.VmConfig.Disks, disk)
+	oldConfig := vmInfo.VmConfig
+	vmInfo.VmConfig.Disks = append(slices.Clone(vmInfo.VmConfig.Disks), disk)
+	err = ValidateVmConfigChange(vmName, vmInfo.Simple.Mountpoint+"/"+vmName, oldConfig, vmInfo.VmConfig)
+	if err != nil {
+		return err
+	}
 
 	configLocation := vmInfo.Simple.Mountpoint + "/" + vmName + "/" + VM_CONFIG_NAME
-	err = ConfigFileWriter(vmInfo.VmConfig, configLocation)
+	err = ConfigFileWriterContext(ctx, vmInfo.VmConfig, configLocation)
 	if err != nil {
 		return err
 	}
@@ -54,7 +61,7 @@ func MountInstallationIso(vmName string, isoPath string, isoComment string) erro
 	return nil
 }
 
-func UnmountInstallationIso(vmName string, isoPath string) error {
+func UnmountInstallationIso(ctx context.Context, vmName string, isoPath string) error {
 	if len(isoPath) < 1 {
 		return fmt.Errorf("ISO file path is empty")
 	}
@@ -86,7 +93,7 @@ func UnmountInstallationIso(vmName string, isoPath string) error {
 
 	vmInfo.VmConfig.Disks = disks
 	configLocation := vmInfo.Simple.Mountpoint + "/" + vmName + "/" + VM_CONFIG_NAME
-	err = ConfigFileWriter(vmInfo.VmConfig, configLocation)
+	err = ConfigFileWriterContext(ctx, vmInfo.VmConfig, configLocation)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,398 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	"HosterCore/internal/pkg/byteconversion"
+	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	HosterNetwork "HosterCore/internal/pkg/hoster/network"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Only enforced when the VM is created or its memory is changed, the existing VMs with less memory can still be started
+const minVmMemoryBytes = 512 * 1024 * 1024
+
+// bhyve has 32 PCI slots: 0 is used by the hostbridge, 31 by the LPC bridge
+const maxBhyvePciSlot = 30
+
+// PCI device in the bus/slot/function format (as shown by `pciconf -l`), "-" prefix forces the function 0
+var passthruDeviceRegex = regexp.MustCompile(`^-?\d{1,3}/\d{1,2}/\d{1,2}$`)
+
+// A single VM config problem, e.g.:
+//
+// Field: disks[1].disk_type, Problem: unknown disk type: sata, Fix: use one of ahci-hd, ahci-cd, virtio-blk or nvme
+type VmConfigIssue struct {
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+	Fix     string `json:"fix"`
+}
+
+type VmConfigIssues []VmConfigIssue
+
+func (i VmConfigIssues) Error() string {
+	problems := []string{}
+	for _, v := range i {
+		problems = append(problems, fmt.Sprintf("%s: %s (fix: %s)", v.Field, v.Problem, v.Fix))
+	}
+	return "invalid VM config: " + strings.Join(problems, "; ")
+}
+
+func (i *VmConfigIssues) add(field string, problem string, fix string) {
+	*i = append(*i, VmConfigIssue{Field: field, Problem: problem, Fix: fix})
+}
+
+// Validates the new VM config as a whole: CPU topology, memory format and minimum, disk and network consistency.
+//
+// Host capabilities (overall CPU and RAM amount) are not checked here, it's up to the caller.
+// Returns VmConfigIssues as an error, if any problems were found.
+func ValidateVmConfig(conf VmConfig) error {
+	issues := append(CheckVmConfig(conf), CheckVmMemoryMinimum(conf)...)
+	if len(issues) > 0 {
+		return issues
+	}
+	return nil
+}
+
+// Validates the VM config against the host state, in addition to the ValidateVmConfig checks:
+// disk images and shares must exist, VNC ports and MAC addresses must not be used by the other VMs on this host.
+//
+// Used right before the VM start, and by `hoster vm validate`.
+func ValidateVmConfigOnHost(vmName string, vmLocation string, conf VmConfig) error {
+	state, err := LoadVmHostState()
+	if err != nil {
+		return err
+	}
+	return state.ValidateVmConfig(vmName, vmLocation, conf)
+}
+
+// Validates the VM settings update. Only the problems introduced by the update are reported,
+// so the settings of a VM with an already broken config can still be changed (and fixed).
+func ValidateVmConfigChange(vmName string, vmLocation string, oldConf VmConfig, newConf VmConfig) error {
+	state, err := LoadVmHostState()
+	if err != nil {
+		return err
+	}
+	oldIssues := append(state.CheckVmConfig(vmName, vmLocation, oldConf), CheckVmMemoryMinimum(oldConf)...)
+	newIssues := append(state.CheckVmConfig(vmName, vmLocation, newConf), CheckVmMemoryMinimum(newConf)...)
+
+	issues := VmConfigIssues{}
+	for _, v := range newIssues {
+		if !slices.Contains(oldIssues, v) {
+			issues = append(issues, v)
+		}
+	}
+	issues = append(issues, CheckVmDiskTypeChanges(oldConf, newConf)...)
+	if len(issues) > 0 {
+		return issues
+	}
+	return nil
+}
+
+// Returns the disk driver changes (virtio-blk, nvme, ahci-hd) the guest OS will not survive.
+// Disks are matched by their location and image, so the reordered disks are compared correctly.
+func CheckVmDiskTypeChanges(oldConf VmConfig, newConf VmConfig) (r VmConfigIssues) {
+	bootDisk := ""
+	for _, v := range oldConf.Disks {
+		if v.DiskType != "ahci-cd" {
+			bootDisk = v.DiskLocation + ":" + v.DiskImage
+			break
+		}
+	}
+
+	for i, v := range newConf.Disks {
+		oldType := ""
+		for _, vv := range oldConf.Disks {
+			if vv.DiskLocation == v.DiskLocation && vv.DiskImage == v.DiskImage {
+				oldType = vv.DiskType
+				break
+			}
+		}
+		// New disks are not a type change
+		if len(oldType) < 1 || oldType == v.DiskType {
+			continue
+		}
+
+		field := fmt.Sprintf("disks[%d].disk_type", i)
+		if oldType == "ahci-cd" || v.DiskType == "ahci-cd" {
+			r.add(field, fmt.Sprintf("can't change the disk type from %s to %s", oldType, v.DiskType), "CD-ROM drives and hard disks are not interchangeable, detach the disk and add a new one instead")
+			continue
+		}
+		if !IsOsWindows(newConf.OsType) {
+			continue
+		}
+		// Windows doesn't ship the virtio drivers, and only loads the storage drivers it has booted with before
+		if v.DiskType == "virtio-blk" {
+			r.add(field, "Windows doesn't include the virtio-blk driver", "use nvme or ahci-hd, or install the virtio drivers and add a new virtio-blk data disk first")
+		} else if v.DiskLocation+":"+v.DiskImage == bootDisk {
+			r.add(field, fmt.Sprintf("Windows will fail to boot (INACCESSIBLE_BOOT_DEVICE) if the boot disk type is changed from %s to %s", oldType, v.DiskType),
+				"add a temporary "+v.DiskType+" data disk, boot Windows once to load the driver, then change the boot disk type")
+		}
+	}
+
+	return
+}
+
+// Returns all config problems that can be found without looking at the host state.
+func CheckVmConfig(conf VmConfig) (r VmConfigIssues) {
+	r = VmConfigIssues{}
+
+	if conf.CPUSockets < 1 {
+		r.add("cpu_sockets", fmt.Sprintf("must be greater than 0, got %d", conf.CPUSockets), "set cpu_sockets to 1")
+	}
+	if conf.CPUCores < 1 {
+		r.add("cpu_cores", fmt.Sprintf("must be greater than 0, got %d", conf.CPUCores), "set cpu_cores to 1 or more")
+	}
+	if conf.CPUThreads < 0 {
+		r.add("cpu_threads", fmt.Sprintf("can't be negative, got %d", conf.CPUThreads), "set cpu_threads to 1, or remove it")
+	}
+
+	_, err := byteconversion.HumanToBytes(conf.Memory)
+	if err != nil {
+		r.add("memory", fmt.Sprintf("invalid value: '%s'", conf.Memory), "use a number with a unit suffix, e.g. 2G or 512M")
+	}
+
+	if conf.Loader != "bios" && conf.Loader != "uefi" {
+		r.add("loader", fmt.Sprintf("unknown loader: '%s'", conf.Loader), "use either bios or uefi")
+	}
+	if conf.VncPort < 1 || conf.VncPort > 65535 {
+		r.add("vnc_port", fmt.Sprintf("must be between 1 and 65535, got %d", conf.VncPort), "pick a free port, Hoster uses 5900-6100 by default")
+	}
+	if conf.VncResolution < 0 || conf.VncResolution > 9 {
+		r.add("vnc_resolution", fmt.Sprintf("unknown resolution: %d", conf.VncResolution), "use a value between 1 (640x480) and 9 (1920x1200), or remove it")
+	}
+	if len(conf.VGA) > 0 && !slices.Contains([]string{"io", "on", "off"}, conf.VGA) {
+		r.add("vga", fmt.Sprintf("unknown VGA mode: '%s'", conf.VGA), "use one of io, on or off, or remove it")
+	}
+	if len(conf.FailoverStrategy) > 0 && conf.FailoverStrategy != "cireset" && conf.FailoverStrategy != "change_parent" {
+		r.add("failover_strategy", fmt.Sprintf("unknown strategy: '%s'", conf.FailoverStrategy), "use either cireset or change_parent")
+	}
+
+	if len(conf.Disks) < 1 {
+		r.add("disks", "at least one disk must be configured", "add the OS disk, e.g. {\"disk_type\": \"nvme\", \"disk_location\": \"internal\", \"disk_image\": \"disk0.img\"}")
+	}
+	diskImages := []string{}
+	for i, v := range conf.Disks {
+		field := fmt.Sprintf("disks[%d]", i)
+		if !slices.Contains([]string{"ahci-hd", "ahci-cd", "virtio-blk", "nvme"}, v.DiskType) {
+			r.add(field+".disk_type", fmt.Sprintf("unknown disk type: '%s'", v.DiskType), "use one of ahci-hd, ahci-cd, virtio-blk or nvme")
+		}
+		if !slices.Contains([]string{"internal", "external", DISK_LOCATION_ZVOL}, v.DiskLocation) {
+			r.add(field+".disk_location", fmt.Sprintf("unknown disk location: '%s'", v.DiskLocation),
+				"use internal (image inside of the VM folder), external (absolute path) or zvol (ZFS volume under the VM dataset)")
+		}
+		if len(v.DiskImage) < 1 {
+			r.add(field+".disk_image", "can't be empty", "set the image file name, e.g. disk1.img")
+			continue
+		}
+		if v.DiskLocation == "internal" && strings.Contains(v.DiskImage, "/") {
+			r.add(field+".disk_image", "internal disk image must be a file name, not a path: "+v.DiskImage, "use the file name only, or set disk_location to external")
+		}
+		if v.DiskLocation == "external" && !strings.HasPrefix(v.DiskImage, "/") {
+			r.add(field+".disk_image", "external disk image must be an absolute path: "+v.DiskImage, "use the absolute path, e.g. /tank/isos/"+v.DiskImage)
+		}
+		if v.DiskLocation == DISK_LOCATION_ZVOL {
+			if !IsValidZvolName(v.DiskImage) {
+				r.add(field+".disk_image", "zvol disk image must be a volume name relative to the VM dataset: "+v.DiskImage, "use the volume name only, e.g. disk1")
+			}
+			if v.DiskType == "ahci-cd" {
+				r.add(field+".disk_type", "CD-ROM drives can't be backed by a zvol", "use ahci-hd, virtio-blk or nvme, or move the ISO file to an external location")
+			}
+		}
+		if v.Zvol != nil {
+			err := v.Zvol.Validate()
+			if err != nil {
+				r.add(field+".zvol", err.Error(), "fix or remove the zvol settings")
+			}
+		}
+
+		image := v.DiskLocation + ":" + v.DiskImage
+		if slices.Contains(diskImages, image) {
+			r.add(field+".disk_image", "disk image is used more than once: "+v.DiskImage, "remove the duplicate disk entry")
+		}
+		diskImages = append(diskImages, image)
+	}
+
+	macs := []string{}
+	for i, v := range conf.Networks {
+		field := fmt.Sprintf("networks[%d]", i)
+		if v.NetworkAdaptorType != "virtio-net" && v.NetworkAdaptorType != "e1000" {
+			r.add(field+".network_adaptor_type", fmt.Sprintf("unknown network driver: '%s'", v.NetworkAdaptorType), "use either virtio-net or e1000")
+		}
+		if len(v.NetworkBridge) < 1 {
+			r.add(field+".network_bridge", "can't be empty", "set the network name (check `hoster network list`)")
+		}
+		if v.VlanTag != 0 && (v.VlanTag < HosterNetwork.VLAN_TAG_MIN || v.VlanTag > HosterNetwork.VLAN_TAG_MAX) {
+			r.add(field+".vlan_tag", fmt.Sprintf("must be between %d and %d, got %d", HosterNetwork.VLAN_TAG_MIN, HosterNetwork.VLAN_TAG_MAX, v.VlanTag), "set a valid VLAN tag, or remove it to use the untagged traffic")
+		}
+		if !IsMacAddressValid(v.NetworkMac) {
+			r.add(field+".network_mac", fmt.Sprintf("invalid MAC address: '%s'", v.NetworkMac), "use the xx:xx:xx:xx:xx:xx format, e.g. 58:9c:fc:01:02:03")
+			continue
+		}
+
+		mac := strings.ToLower(v.NetworkMac)
+		if slices.Contains(macs, mac) {
+			r.add(field+".network_mac", "MAC address is used more than once: "+v.NetworkMac, "generate a new unique MAC address for this interface")
+		}
+		macs = append(macs, mac)
+	}
+
+	for i, v := range conf.Passthru {
+		if !passthruDeviceRegex.MatchString(v) {
+			r.add(fmt.Sprintf("passthru[%d]", i), fmt.Sprintf("invalid PCI device: '%s'", v), "use the bus/slot/function format, e.g. 4/0/0 (check `hoster passthru list`)")
+		}
+	}
+
+	shareNames := []string{}
+	for i, v := range conf.Shares {
+		field := fmt.Sprintf("9p_shares[%d]", i)
+		if len(v.ShareName) < 1 {
+			r.add(field+".share_name", "can't be empty", "set a unique share name")
+		} else if slices.Contains(shareNames, v.ShareName) {
+			r.add(field+".share_name", "share name is used more than once: "+v.ShareName, "rename or remove the duplicate share")
+		}
+		shareNames = append(shareNames, v.ShareName)
+		if !strings.HasPrefix(v.ShareLocation, "/") {
+			r.add(field+".share_location", fmt.Sprintf("must be an absolute path: '%s'", v.ShareLocation), "use the absolute path of the shared folder")
+		}
+	}
+
+	lastSlot := lastBhyvePciSlot(conf)
+	if lastSlot > maxBhyvePciSlot {
+		r.add("disks, networks, 9p_shares, passthru", fmt.Sprintf("VM needs %d PCI slots, but only %d are available", lastSlot-1, maxBhyvePciSlot-1),
+			"reduce the number of disks, networks, 9p shares or passthru devices (or set disable_xhci)")
+	}
+
+	return
+}
+
+// Returns the memory minimum problem. Not a part of CheckVmConfig, because it only applies to the new VMs
+// and the memory changes: the existing VMs with less memory are still valid.
+func CheckVmMemoryMinimum(conf VmConfig) (r VmConfigIssues) {
+	r = VmConfigIssues{}
+	memoryBytes, err := byteconversion.HumanToBytes(conf.Memory)
+	if err == nil && memoryBytes < minVmMemoryBytes {
+		r.add("memory", "must be at least 512M, got "+conf.Memory, "set memory to 512M or more")
+	}
+	return
+}
+
+// Host state the VM configs are checked against: the VM list and the configs of all VMs on this host.
+// Load it once to check many VMs (e.g. on start all), instead of re-reading every config for each one of them.
+type VmHostState struct {
+	vms      []VmListSimple
+	configs  map[string]VmConfig
+	hostname string
+}
+
+func LoadVmHostState() (r VmHostState, e error) {
+	r.vms, e = ListAllSimple()
+	if e != nil {
+		return
+	}
+
+	r.configs = make(map[string]VmConfig, len(r.vms))
+	for _, v := range r.vms {
+		conf, err := GetVmConfig(v.Mountpoint + "/" + v.VmName)
+		if err != nil {
+			continue
+		}
+		r.configs[v.VmName] = conf
+	}
+	r.hostname, _ = FreeBSDsysctls.SysctlKernHostname()
+
+	return
+}
+
+// Same as ValidateVmConfigOnHost, using the already loaded host state.
+func (h VmHostState) ValidateVmConfig(vmName string, vmLocation string, conf VmConfig) error {
+	issues := h.CheckVmConfig(vmName, vmLocation, conf)
+	if len(issues) > 0 {
+		return issues
+	}
+	return nil
+}
+
+// Returns all config problems, including the ones caused by the host state (missing files, conflicts with the other VMs).
+func (h VmHostState) CheckVmConfig(vmName string, vmLocation string, conf VmConfig) (r VmConfigIssues) {
+	vmLocation = strings.TrimSuffix(vmLocation, "/")
+	r = CheckVmConfig(conf)
+
+	vmDataset := ""
+	for _, v := range h.vms {
+		if v.VmName == vmName {
+			vmDataset = v.DsName + "/" + v.VmName
+		}
+	}
+
+	for i, v := range conf.Disks {
+		if len(v.DiskImage) < 1 {
+			continue
+		}
+		image := VmDiskPath(vmDataset, vmLocation, v)
+		_, err := os.Stat(image)
+		if err != nil {
+			fix := "restore the disk image, or remove this disk from the config"
+			if v.DiskType == "ahci-cd" {
+				fix = "upload the ISO file, or unmount it (remove this CD-ROM drive from the config)"
+			}
+			r.add(fmt.Sprintf("disks[%d].disk_image", i), "disk image doesn't exist: "+image, fix)
+		}
+	}
+
+	for i, v := range conf.Networks {
+		if v.VlanTag < HosterNetwork.VLAN_TAG_MIN || v.VlanTag > HosterNetwork.VLAN_TAG_MAX {
+			continue
+		}
+		_, err := HosterNetwork.CheckVlanSupport(v.NetworkBridge, v.VlanTag)
+		if err != nil {
+			r.add(fmt.Sprintf("networks[%d].vlan_tag", i), err.Error(), "remove the VLAN tag, or move the interface to a network with an uplink interface")
+		}
+	}
+
+	for i, v := range conf.Shares {
+		if !strings.HasPrefix(v.ShareLocation, "/") {
+			continue
+		}
+		info, err := os.Stat(v.ShareLocation)
+		if err != nil || !info.IsDir() {
+			r.add(fmt.Sprintf("9p_shares[%d].share_location", i), "shared folder doesn't exist: "+v.ShareLocation, "create the folder, or remove this share")
+		}
+	}
+
+	for _, v := range h.vms {
+		if v.VmName == vmName {
+			continue
+		}
+		otherConf, ok := h.configs[v.VmName]
+		if !ok {
+			continue
+		}
+
+		// Backups can't be started on this host, so their VNC ports are not in use.
+		// MAC addresses still must be unique, the backup will share the network with this VM after a failover.
+		if otherConf.ParentHost == h.hostname && otherConf.VncPort == conf.VncPort {
+			r.add("vnc_port", fmt.Sprintf("VNC port %d is already used by the VM: %s", conf.VncPort, v.VmName), "pick a free VNC port")
+		}
+		for i, net := range conf.Networks {
+			for _, otherNet := range otherConf.Networks {
+				if len(net.NetworkMac) > 0 && strings.EqualFold(net.NetworkMac, otherNet.NetworkMac) {
+					r.add(fmt.Sprintf("networks[%d].network_mac", i), fmt.Sprintf("MAC address %s is already used by the VM: %s", net.NetworkMac, v.VmName),
+						"generate a new unique MAC address for this interface")
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// Returns the last guest PCI slot the VM would use
+func lastBhyvePciSlot(conf VmConfig) int {
+	return allocateBhyvePciSlots(conf).last
+}
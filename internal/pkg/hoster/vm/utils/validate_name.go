@@ -6,6 +6,7 @@ package HosterVmUtils
 
 import (
 	"errors"
+	"regexp"
 	"strconv"
 )
 
@@ -56,3 +57,22 @@ func ValidateResName(resourceName string) error {
 
 	return nil
 }
+
+var osTypeRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_\-]*$`)
+
+// Validates the OS type used in the template dataset name (template-[osType]), e.g. debian12 or freebsd14ufs.
+// Unlike the resource names, short OS types (e.g. "arch") are allowed.
+func ValidateOsType(osType string) error {
+	maxLength := 32
+
+	if len(osType) < 1 {
+		return errors.New("OS type cannot be empty")
+	} else if len(osType) > maxLength {
+		return errors.New("OS type cannot contain more than " + strconv.Itoa(maxLength) + " characters")
+	}
+	if !osTypeRegex.MatchString(osType) {
+		return errors.New("OS type must start with a letter, and can only contain letters, numbers, underscores and hyphens: " + osType)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package HosterVmUtils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateOsType(t *testing.T) {
+	for osType, valid := range map[string]bool{
+		"debian12":              true,
+		"freebsd14ufs":          true,
+		"arch":                  true,
+		"win":                   true,
+		"rocky_9-minimal":       true,
+		"":                      false,
+		"12debian":              false,
+		"-debian":               false,
+		"debian/12":             false,
+		"debian.12":             false,
+		"..":                    false,
+		"debian 12":             false,
+		strings.Repeat("a", 32): true,
+		strings.Repeat("a", 33): false,
+	} {
+		err := ValidateOsType(osType)
+		if (err == nil) != valid {
+			t.Errorf("ValidateOsType(%q): got %v, want valid=%v", osType, err, valid)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+//go:build freebsd
+// +build freebsd
+
+package HosterVm
+
+import (
+	"HosterCore/internal/pkg/byteconversion"
+	FreeBSDsysctls "HosterCore/internal/pkg/freebsd/sysctls"
+	HosterAdmission "HosterCore/internal/pkg/hoster/admission"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	"errors"
+	"fmt"
+)
+
+var ErrHostCapacityExceeded = errors.New("settings exceed the host's capabilities")
+
+// Validates the VM settings update before it gets written: the config problems introduced by the update,
+// host capacity (CPU and RAM) and the admission policies. Used by the settings patch (REST API) and `vm apply`.
+//
+// Returns HosterVmUtils.VmConfigIssues, ErrHostCapacityExceeded or HosterAdmission.ErrAdmissionDenied (wrapped) for the rejected updates.
+func ValidateVmUpdate(vmName string, vmFolder string, oldConf HosterVmUtils.VmConfig, newConf HosterVmUtils.VmConfig) error {
+	err := HosterVmUtils.ValidateVmConfigChange(vmName, vmFolder, oldConf, newConf)
+	if err != nil {
+		return err
+	}
+
+	vCpus := newConf.CPUSockets * newConf.CPUCores * max(newConf.CPUThreads, 1)
+	hostCpus, err := FreeBSDsysctls.SysctlHwNcpu()
+	if err != nil {
+		return err
+	}
+	if vCpus > hostCpus {
+		return fmt.Errorf("CPU %w", ErrHostCapacityExceeded)
+	}
+	hostRam, err := FreeBSDsysctls.SysctlHwRealmem()
+	if err != nil {
+		return err
+	}
+	memoryBytes, _ := byteconversion.HumanToBytes(newConf.Memory)
+	if memoryBytes >= hostRam {
+		return fmt.Errorf("RAM %w", ErrHostCapacityExceeded)
+	}
+
+	return HosterAdmission.Check(HosterAdmission.Request{
+		Action:       HosterAdmission.ACTION_UPDATE,
+		ResourceType: HosterAdmission.RESOURCE_VM,
+		ResourceName: vmName,
+		Owner:        newConf.Owner,
+		VCpus:        vCpus,
+		Ram:          newConf.Memory,
+	})
+}
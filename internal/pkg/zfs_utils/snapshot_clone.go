@@ -33,5 +33,5 @@ func SnapshotClone(snapshotName string, newRes string) error {
 		return fmt.Errorf("%s; %s", strings.TrimSpace(string(out)), err.Error())
 	}
 
-	return nil
+	return CloneZvolChildren(snapshotName, newRes)
 }
@@ -18,7 +18,8 @@ func RemoveSnapshot(snapshotName string) error {
 		return errors.New("not a snapshot, provide a correct snapshot name")
 	}
 
-	out, err := exec.Command("zfs", "destroy", snapshotName).CombinedOutput()
+	// Recursive, to remove the same snapshot from the zvol children
+	out, err := exec.Command("zfs", "destroy", "-r", snapshotName).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("%s; %s", strings.TrimSpace(string(out)), err.Error())
 	}
@@ -23,5 +23,5 @@ func RollbackSnapshot(snapshotName string) error {
 		return fmt.Errorf("%s; %s", strings.TrimSpace(string(out)), err.Error())
 	}
 
-	return nil
+	return RollbackZvolChildren(snapshotName)
 }
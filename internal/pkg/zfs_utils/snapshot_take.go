@@ -29,7 +29,8 @@ func TakeScheduledSnapshot(dataset string, snapshotType string, keep int) (snaps
 	timeNow := time.Now().Format("20060102_150405.000000")
 	snapshotName = dataset + "@" + snapshotType + "_" + timeNow
 
-	out, err := exec.Command("zfs", "snapshot", snapshotName).CombinedOutput()
+	// Recursive, to include the zvol disks that live under the VM dataset
+	out, err := exec.Command("zfs", "snapshot", "-r", snapshotName).CombinedOutput()
 	if err != nil {
 		e = fmt.Errorf(strings.TrimSpace(string(out))+"; %s", err.Error())
 		return
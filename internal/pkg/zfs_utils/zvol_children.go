@@ -0,0 +1,186 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package zfsutils
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// VM disks can be ZFS volumes (zvols) created under the VM dataset, e.g. tank/vm-encrypted/test-vm-1/disk1.
+// The VM snapshots are taken recursively, so every zvol has the same snapshots as its parent dataset,
+// and the functions below keep the clone, rollback and replication operations consistent for the whole VM.
+
+// Returns the zvols that live under the dataset, relative to it (e.g. disk1).
+func ZvolChildren(dataset string) (r []string, e error) {
+	r = []string{}
+	out, err := exec.Command("zfs", "list", "-H", "-o", "name", "-t", "volume", "-r", dataset).CombinedOutput()
+	if err != nil {
+		e = fmt.Errorf("could not list the zvols: %s; %s", strings.TrimSpace(string(out)), err.Error())
+		return
+	}
+
+	for _, v := range strings.Split(string(out), "\n") {
+		v = strings.TrimSpace(v)
+		if !strings.HasPrefix(v, dataset+"/") {
+			continue
+		}
+		r = append(r, strings.TrimPrefix(v, dataset+"/"))
+	}
+
+	return
+}
+
+// Returns the snapshots of a single dataset (not including the snapshots of its children), oldest first.
+func DatasetSnapshots(dataset string) (r []string, e error) {
+	r = []string{}
+	out, err := exec.Command("zfs", "list", "-H", "-o", "name", "-t", "snapshot", "-s", "createtxg", "-d", "1", dataset).CombinedOutput()
+	if err != nil {
+		e = fmt.Errorf("could not list the snapshots: %s; %s", strings.TrimSpace(string(out)), err.Error())
+		return
+	}
+
+	for _, v := range strings.Split(string(out), "\n") {
+		v = strings.TrimSpace(v)
+		if strings.HasPrefix(v, dataset+"@") {
+			r = append(r, v)
+		}
+	}
+
+	return
+}
+
+// Clones the zvols under the snapshot's dataset into the new dataset (which must already exist, e.g. it's a clone itself).
+// zvols that don't have this snapshot (created after the snapshot was taken) are skipped.
+func CloneZvolChildren(snapshotName string, newRes string) error {
+	dataset, snapName, found := strings.Cut(snapshotName, "@")
+	if !found {
+		return fmt.Errorf("not a snapshot, provide a correct snapshot name")
+	}
+
+	zvols, err := ZvolChildren(dataset)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range zvols {
+		childSnaps, err := DatasetSnapshots(dataset + "/" + v)
+		if err != nil {
+			return err
+		}
+		childSnap := dataset + "/" + v + "@" + snapName
+		if !slices.Contains(childSnaps, childSnap) {
+			continue
+		}
+
+		out, err := exec.Command("zfs", "clone", childSnap, newRes+"/"+v).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("could not clone the zvol %s: %s; %s", v, strings.TrimSpace(string(out)), err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Rolls back the zvols under the snapshot's dataset to the snapshot with the same name (if they have one).
+func RollbackZvolChildren(snapshotName string) error {
+	dataset, snapName, found := strings.Cut(snapshotName, "@")
+	if !found {
+		return fmt.Errorf("not a snapshot, provide a correct snapshot name")
+	}
+
+	zvols, err := ZvolChildren(dataset)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range zvols {
+		childSnaps, err := DatasetSnapshots(dataset + "/" + v)
+		if err != nil {
+			return err
+		}
+		childSnap := dataset + "/" + v + "@" + snapName
+		if !slices.Contains(childSnaps, childSnap) {
+			continue
+		}
+
+		out, err := exec.Command("zfs", "rollback", "-r", childSnap).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("could not roll back the zvol %s: %s; %s", v, strings.TrimSpace(string(out)), err.Error())
+		}
+	}
+
+	return nil
+}
+
+// A single `zfs send | zfs receive` step, From is empty for the initial (full) send.
+type ZfsSendStep struct {
+	Dataset string
+	From    string
+	To      string
+}
+
+// Plans the replication of the zvols under the dataset, after the dataset itself was replicated.
+//
+// remoteList must hold all dataset and snapshot names on the remote side (`zfs list -H -o name -t all`).
+// The remote zvol snapshots that no longer exist locally are returned in the toRemove list.
+func ZvolReplicationSteps(dataset string, remoteList []string) (steps []ZfsSendStep, toRemove []string, e error) {
+	steps = []ZfsSendStep{}
+	toRemove = []string{}
+
+	zvols, err := ZvolChildren(dataset)
+	if err != nil {
+		e = err
+		return
+	}
+
+	for _, v := range zvols {
+		child := dataset + "/" + v
+		localSnaps, err := DatasetSnapshots(child)
+		if err != nil {
+			e = err
+			return
+		}
+		if len(localSnaps) < 1 {
+			continue
+		}
+
+		remoteSnaps := []string{}
+		for _, vv := range remoteList {
+			if strings.HasPrefix(vv, child+"@") {
+				remoteSnaps = append(remoteSnaps, vv)
+			}
+		}
+		for _, vv := range remoteSnaps {
+			if !slices.Contains(localSnaps, vv) {
+				toRemove = append(toRemove, vv)
+			}
+		}
+
+		// Start from the latest common snapshot, or send the oldest snapshot in full
+		start := -1
+		for i, vv := range localSnaps {
+			if slices.Contains(remoteSnaps, vv) {
+				start = i
+			}
+		}
+		if start < 0 {
+			if slices.Contains(remoteList, child) {
+				e = fmt.Errorf("could not find any common snapshots for the zvol %s on the remote endpoint", child)
+				return
+			}
+			steps = append(steps, ZfsSendStep{Dataset: child, To: localSnaps[0]})
+			start = 0
+		}
+
+		for i := start + 1; i < len(localSnaps); i++ {
+			steps = append(steps, ZfsSendStep{Dataset: child, From: localSnaps[i-1], To: localSnaps[i]})
+		}
+	}
+
+	return
+}
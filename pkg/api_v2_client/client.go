@@ -0,0 +1,403 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+// Go client for the Hoster REST API v2.
+//
+// The route methods (one per API route, named after the route handler) are generated from the REST API router and
+// the handler annotations, see routes_gen.go. Request and response payloads are shared with the handlers,
+// and are exported via the HosterCore/pkg/api_v2_types package.
+//
+//	client, err := ApiV2Client.New("https://hoster-01:3000", ApiV2Client.WithBasicAuth("admin", "password"))
+//	vms, total, err := client.VmList(ctx, url.Values{"tag": {"web"}})
+package ApiV2Client
+
+//go:generate go run ../../internal/app/api_client_gen
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const DEFAULT_TIMEOUT = 30 * time.Second
+const DEFAULT_RETRIES = 2
+const DEFAULT_RETRY_WAIT = 500 * time.Millisecond
+
+// Response bodies larger than this are rejected (the VM and Jail lists on the busiest nodes are well below it)
+const maxResponseSize = 64 << 20
+
+type Client struct {
+	endpoint   *url.URL
+	user       string
+	password   string
+	token      string
+	tlsConfig  *tls.Config
+	httpClient *http.Client
+	timeout    time.Duration
+	retries    int
+	retryWait  time.Duration
+}
+
+type Option func(c *Client) error
+
+// Creates a new API client.
+// The endpoint is the node's REST API base address, e.g. "https://10.0.0.10:3000" (without the /api/v2 prefix).
+func New(endpoint string, options ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(endpoint), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API endpoint: %s", err.Error())
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("API endpoint must start with http:// or https://, got: %s", endpoint)
+	}
+	if len(u.Host) < 1 {
+		return nil, fmt.Errorf("API endpoint has no host: %s", endpoint)
+	}
+
+	c := &Client{
+		endpoint:  u,
+		timeout:   DEFAULT_TIMEOUT,
+		retries:   DEFAULT_RETRIES,
+		retryWait: DEFAULT_RETRY_WAIT,
+		tlsConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	for _, option := range options {
+		err := option(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: c.timeout, Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     c.tlsConfig,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		}}
+	}
+
+	return c, nil
+}
+
+// Authenticate using the HTTP basic auth.
+func WithBasicAuth(user string, password string) Option {
+	return func(c *Client) error {
+		c.user = user
+		c.password = password
+		return nil
+	}
+}
+
+// Authenticate using the API token ("token" in the http_auth section of restapi_config.json).
+// Takes priority over the basic auth.
+func WithToken(token string) Option {
+	return func(c *Client) error {
+		c.token = token
+		return nil
+	}
+}
+
+// Trust the CA certificate(s) from the PEM file, in addition to the system CAs.
+func WithCACertFile(path string) Option {
+	return func(c *Client) error {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no valid certificates found in: %s", path)
+		}
+
+		c.tlsConfig.RootCAs = pool
+		return nil
+	}
+}
+
+// Skip the TLS certificate verification (self-signed node certificates).
+func WithInsecureSkipVerify() Option {
+	return func(c *Client) error {
+		c.tlsConfig.InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// Use a custom TLS config (client certificates, custom CAs, etc).
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(c *Client) error {
+		if tlsConfig == nil {
+			return errors.New("TLS config can't be nil")
+		}
+		c.tlsConfig = tlsConfig.Clone()
+		return nil
+	}
+}
+
+// Timeout of a single HTTP request (each retry gets its own timeout). Use the context to limit the total time.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// Number of retries for the failed requests, and the initial wait time between them (doubled on every retry).
+// Only the GET requests are retried after a server side error; other methods are only retried if the connection
+// could not be established (the request never reached the server).
+func WithRetries(retries int, wait time.Duration) Option {
+	return func(c *Client) error {
+		if retries < 0 {
+			return errors.New("number of retries can't be negative")
+		}
+		c.retries = retries
+		c.retryWait = wait
+		return nil
+	}
+}
+
+// Use a custom HTTP client. Note that the TLS and timeout options are ignored in this case.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) error {
+		if httpClient == nil {
+			return errors.New("HTTP client can't be nil")
+		}
+		c.httpClient = httpClient
+		return nil
+	}
+}
+
+// Returns the API endpoint the client is connected to.
+func (c *Client) Endpoint() string {
+	return c.endpoint.String()
+}
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   interface{} // nil, string (sent as is, text/plain), io.Reader (streamed, application/octet-stream), or anything else (sent as JSON)
+}
+
+// Executes the API request, and decodes the JSON response into the `out` (if not nil).
+// A *string `out` receives the "message" field of the standard success response.
+func (c *Client) do(ctx context.Context, req request, out interface{}) (header http.Header, e error) {
+	var body []byte
+	contentType := ""
+
+	switch v := req.body.(type) {
+	case nil:
+	case io.Reader:
+		// Streamed uploads can't be replayed, so they are never retried, and only the context limits their duration
+		res, _, err := c.send(ctx, c.streamClient(), req, v, "application/octet-stream")
+		if err != nil {
+			e = err
+			return
+		}
+		header = res.Header
+		e = decodeResponse(res, out)
+		return
+	case string:
+		body = []byte(v)
+		contentType = "text/plain"
+	default:
+		body, e = json.Marshal(v)
+		if e != nil {
+			e = fmt.Errorf("could not encode the request payload: %s", e.Error())
+			return
+		}
+		contentType = "application/json"
+	}
+
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		var res *http.Response
+		var retryable bool
+
+		res, retryable, e = c.send(ctx, c.httpClient, req, bytes.NewReader(body), contentType)
+		if e == nil {
+			header = res.Header
+			e = decodeResponse(res, out)
+			return
+		}
+		if !retryable || attempt >= c.retries {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			e = ctx.Err()
+			return
+		case <-time.After(wait):
+		}
+		wait = wait * 2
+	}
+}
+
+// Executes the API request, and returns the response body as is (file downloads, e.g. VM exports).
+// The body must be closed by the caller. The client timeout doesn't apply here, use the context to limit the download time.
+func (c *Client) stream(ctx context.Context, req request) (io.ReadCloser, error) {
+	res, _, err := c.send(ctx, c.streamClient(), req, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Copy of the HTTP client without the overall timeout, for the requests that stream large payloads.
+func (c *Client) streamClient() *http.Client {
+	client := *c.httpClient
+	client.Timeout = 0
+	return &client
+}
+
+// Sends a single request. Returns the response only if the status code is 2xx (the body must be closed by the caller).
+func (c *Client) send(ctx context.Context, httpClient *http.Client, req request, body io.Reader, contentType string) (res *http.Response, retryable bool, e error) {
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, c.url(req.path, req.query), body)
+	if err != nil {
+		e = err
+		return
+	}
+	for k, v := range req.header {
+		httpReq.Header[k] = v
+	}
+	if len(contentType) > 0 {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	c.authenticate(httpReq.Header)
+
+	res, err = httpClient.Do(httpReq)
+	if err != nil {
+		e = err
+		// Connection errors are always safe to retry, the request never reached the server
+		var opErr *net.OpError
+		retryable = ctx.Err() == nil && (req.method == http.MethodGet || (errors.As(err, &opErr) && opErr.Op == "dial"))
+		return
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return
+	}
+
+	defer res.Body.Close()
+	e = parseError(res)
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		retryable = req.method == http.MethodGet || res.StatusCode == http.StatusServiceUnavailable
+		// Authentication lockouts last much longer than the retry backoff
+		if apiErr, ok := e.(*Error); ok && apiErr.Code == CODE_AUTH_LOCKED_OUT {
+			retryable = false
+		}
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		retryable = req.method == http.MethodGet
+	}
+	res = nil
+
+	return
+}
+
+// The path must already be escaped (the generated route methods escape the path parameters).
+func (c *Client) url(path string, query url.Values) string {
+	r := c.endpoint.String() + path
+	if len(query) > 0 {
+		r = r + "?" + query.Encode()
+	}
+	return r
+}
+
+func (c *Client) authenticate(header http.Header) {
+	if len(c.token) > 0 {
+		header.Set("Authorization", "Bearer "+c.token)
+		return
+	}
+	if len(c.user) > 0 {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.user+":"+c.password)))
+	}
+}
+
+func decodeResponse(res *http.Response, out interface{}) error {
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("could not read the response: %s", err.Error())
+	}
+	if out == nil {
+		return nil
+	}
+
+	// Standard success response, e.g. {"message": "success"}
+	message, ok := out.(*string)
+	if ok {
+		msg := struct {
+			Message json.RawMessage `json:"message"`
+		}{}
+		err = json.Unmarshal(data, &msg)
+		if err != nil {
+			return fmt.Errorf("could not decode the response: %s", err.Error())
+		}
+		err = json.Unmarshal(msg.Message, message)
+		if err != nil {
+			*message = string(msg.Message)
+		}
+		return nil
+	}
+
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("could not decode the response: %s", err.Error())
+	}
+	return nil
+}
+
+// Opens a WebSocket connection (VM serial and VNC consoles).
+func (c *Client) dial(ctx context.Context, path string, query url.Values) (*websocket.Conn, error) {
+	wsUrl := "ws" + strings.TrimPrefix(c.url(path, query), "http")
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		TLSClientConfig:  c.tlsConfig,
+		HandshakeTimeout: c.timeout,
+	}
+	header := http.Header{}
+	c.authenticate(header)
+
+	conn, res, err := dialer.DialContext(ctx, wsUrl, header)
+	if err != nil {
+		if res != nil && res.StatusCode != http.StatusSwitchingProtocols {
+			defer res.Body.Close()
+			return nil, parseError(res)
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func parseInt(value string) int {
+	i, _ := strconv.Atoi(value)
+	return i
+}
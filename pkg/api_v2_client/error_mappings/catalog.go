@@ -0,0 +1,223 @@
+package ErrorMappings
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Stable, machine-readable error codes. Codes are never renamed or reused, so the clients can rely on them
+// instead of matching the error messages.
+const (
+	CODE_INTERNAL_ERROR           = "INTERNAL_ERROR"
+	CODE_BAD_REQUEST              = "BAD_REQUEST"
+	CODE_INVALID_INPUT            = "INVALID_INPUT"
+	CODE_UNAUTHORIZED             = "UNAUTHORIZED"
+	CODE_ROUTE_NOT_FOUND          = "ROUTE_NOT_FOUND"
+	CODE_RESOURCE_NOT_FOUND       = "RESOURCE_NOT_FOUND"
+	CODE_VM_NOT_FOUND             = "VM_NOT_FOUND"
+	CODE_VM_RUNNING               = "VM_RUNNING"
+	CODE_VM_NOT_RUNNING           = "VM_NOT_RUNNING"
+	CODE_JAIL_NOT_FOUND           = "JAIL_NOT_FOUND"
+	CODE_JAIL_RUNNING             = "JAIL_RUNNING"
+	CODE_JAIL_NOT_RUNNING         = "JAIL_NOT_RUNNING"
+	CODE_RESOURCE_IS_BACKUP       = "RESOURCE_IS_BACKUP"
+	CODE_SNAPSHOT_NOT_FOUND       = "SNAPSHOT_NOT_FOUND"
+	CODE_SNAPSHOT_TYPE_INVALID    = "SNAPSHOT_TYPE_INVALID"
+	CODE_SNAPSHOT_HAS_CLONES      = "SNAPSHOT_HAS_CLONES"
+	CODE_DATASET_LOCKED           = "DATASET_LOCKED"
+	CODE_DATASET_BUSY             = "DATASET_BUSY"
+	CODE_NETWORK_NOT_FOUND        = "NETWORK_NOT_FOUND"
+	CODE_HOST_NOT_FOUND           = "HOST_NOT_FOUND"
+	CODE_HOST_DISABLED            = "HOST_DISABLED"
+	CODE_CONFIG_CONFLICT          = "CONFIG_CONFLICT"
+	CODE_PRECONDITION_REQUIRED    = "PRECONDITION_REQUIRED"
+	CODE_CONSOLE_WRITER_TAKEN     = "CONSOLE_WRITER_TAKEN"
+	CODE_VNC_TOKEN_INVALID        = "VNC_TOKEN_INVALID"
+	CODE_TOO_MANY_VIEWERS         = "TOO_MANY_VIEWERS"
+	CODE_BULK_OPERATION_NOT_FOUND = "BULK_OPERATION_NOT_FOUND"
+	CODE_ADMISSION_DENIED         = "ADMISSION_DENIED"
+	CODE_NAME_CONFLICT            = "NAME_CONFLICT"
+	CODE_DATASET_NOT_ACTIVE       = "DATASET_NOT_ACTIVE"
+	CODE_ARCHIVE_INVALID          = "ARCHIVE_INVALID"
+	CODE_IMPORT_NETWORK_MISSING   = "IMPORT_NETWORK_MISSING"
+	CODE_AUTH_LOCKED_OUT          = "AUTH_LOCKED_OUT"
+)
+
+// A single error catalog entry.
+type CatalogEntry struct {
+	Code        string   `json:"code"`
+	HttpStatus  int      `json:"http_status"`
+	Description string   `json:"description"`
+	Details     []string `json:"details,omitempty"` // names of the parameters returned in the error details
+	patterns    []*regexp.Regexp
+}
+
+// The error returned by all REST API handlers.
+type ApiError struct {
+	ID         int               `json:"id"` // legacy numeric error ID, kept for backwards compatibility
+	Code       string            `json:"code"`
+	Message    string            `json:"message"`
+	Details    map[string]string `json:"details,omitempty"`
+	HttpStatus int               `json:"-"`
+}
+
+func entry(code string, httpStatus int, description string, patterns ...string) CatalogEntry {
+	e := CatalogEntry{Code: code, HttpStatus: httpStatus, Description: description}
+	for _, v := range patterns {
+		re := regexp.MustCompile(v)
+		e.patterns = append(e.patterns, re)
+		for _, name := range re.SubexpNames() {
+			if len(name) > 0 && !slicesContains(e.Details, name) {
+				e.Details = append(e.Details, name)
+			}
+		}
+	}
+	return e
+}
+
+// Error catalog. Messages are matched in order, first match wins.
+var catalog = []CatalogEntry{
+	entry(CODE_VM_NOT_FOUND, http.StatusNotFound, "VM doesn't exist on this host",
+		`(?i)^vm (doesn't|does not) exist( on this system)?(: \S+)?$`, `(?i)^vm (was )?not found$`),
+	entry(CODE_VM_RUNNING, http.StatusConflict, "VM is running, but the action requires it to be stopped",
+		`(?i)^vm is (already )?running(: \S+)?$`),
+	entry(CODE_VM_NOT_RUNNING, http.StatusConflict, "VM is not running, but the action requires it to be started",
+		`(?i)^vm is (not running|already offline)(: \S+)?$`, `could not find the VM process specified`),
+	entry(CODE_JAIL_NOT_FOUND, http.StatusNotFound, "Jail doesn't exist on this host",
+		`(?i)^jail (doesn't|does not) exist(: \S+)?$`, `(?i)^jail (was )?not found$`),
+	entry(CODE_JAIL_RUNNING, http.StatusConflict, "Jail is running, but the action requires it to be stopped",
+		`(?i)^jail is (already )?running(: \S+)?$`),
+	entry(CODE_JAIL_NOT_RUNNING, http.StatusConflict, "Jail is not running, but the action requires it to be started",
+		`(?i)^jail is (not running|already offline)(: \S+)?$`),
+	entry(CODE_RESOURCE_IS_BACKUP, http.StatusConflict, "Resource is a backup from another host",
+		`is a backup from another host`),
+	entry(CODE_RESOURCE_NOT_FOUND, http.StatusNotFound, "VM or Jail doesn't exist on this host",
+		`^resource doesn't exist$`),
+	entry(CODE_SNAPSHOT_HAS_CLONES, http.StatusConflict, "Snapshot can't be destroyed or rolled back, because it has dependent clones",
+		`cannot destroy '(?P<snapshot>[^']+)': snapshot has dependent clones`, `snapshot has dependent clones`),
+	entry(CODE_SNAPSHOT_NOT_FOUND, http.StatusNotFound, "Snapshot doesn't exist",
+		`^snapshot doesn't exist$`, `^not a snapshot, provide a correct snapshot name$`),
+	entry(CODE_SNAPSHOT_TYPE_INVALID, http.StatusBadRequest, "Snapshot type doesn't exist",
+		`^snapshot type doesn't exist$`, `^please provide the correct snapshot type`),
+	entry(CODE_DATASET_LOCKED, http.StatusConflict, "ZFS dataset is encrypted and locked (the encryption key is not loaded)",
+		`encryption key not loaded`, `keystatus.*unavailable`),
+	entry(CODE_DATASET_BUSY, http.StatusConflict, "ZFS dataset is busy",
+		`cannot [a-z]+ '(?P<dataset>[^']+)': (pool or )?dataset is busy`, `dataset is busy`),
+	entry(CODE_NETWORK_NOT_FOUND, http.StatusNotFound, "Network doesn't exist",
+		`network with the name (?P<network>\S+) does not exist`, `^network bridge not found$`, `^network name supplied doesn't exist$`),
+	entry(CODE_HOST_NOT_FOUND, http.StatusNotFound, "Host was not found",
+		`^host was not found in our database$`),
+	entry(CODE_HOST_DISABLED, http.StatusConflict, "Host is disabled",
+		`^host is disabled$`),
+	entry(CODE_INVALID_INPUT, http.StatusBadRequest, "Request payload could not be parsed",
+		`^could not parse your input$`, `^invalid character .* looking for beginning`, `^json: cannot unmarshal`, `^unexpected EOF$`, `^EOF$`),
+	entry(CODE_CONFIG_CONFLICT, http.StatusPreconditionFailed, "Config has been modified since it was read (If-Match doesn't match the current ETag)",
+		`^config file has been modified by someone else`),
+	entry(CODE_PRECONDITION_REQUIRED, http.StatusPreconditionRequired, "If-Match header is required",
+		`^If-Match header is required`),
+	entry(CODE_CONSOLE_WRITER_TAKEN, http.StatusConflict, "Another client already has the write access to the serial console",
+		`already holds the write access`),
+	entry(CODE_VNC_TOKEN_INVALID, http.StatusUnauthorized, "VNC session token is invalid or has expired",
+		`^vnc session token is invalid or has expired$`),
+	entry(CODE_TOO_MANY_VIEWERS, http.StatusTooManyRequests, "Maximum number of concurrent VNC viewers has been reached",
+		`^maximum number of concurrent VNC viewers`),
+	entry(CODE_BULK_OPERATION_NOT_FOUND, http.StatusNotFound, "Bulk operation doesn't exist",
+		`^bulk operation could not be found: (?P<id>\S+)$`),
+	entry(CODE_ADMISSION_DENIED, http.StatusConflict, "Request would violate the host admission policy (CPU/RAM overcommit, pool free space or owner quota)",
+		`^admission denied: (?P<violations>.+)$`),
+	entry(CODE_NAME_CONFLICT, http.StatusConflict, "VM or Jail with the same name (or its dataset) already exists",
+		`^(vm|jail) with the name (?P<name>\S+) already exists$`, `^dataset already exists: (?P<dataset>\S+)$`, `(?i)^vm already exists(: (?P<name>\S+))?$`),
+	entry(CODE_DATASET_NOT_ACTIVE, http.StatusBadRequest, "Dataset is not one of the active datasets on this host",
+		`^dataset is not active on this host: (?P<dataset>\S*)$`),
+	entry(CODE_ARCHIVE_INVALID, http.StatusBadRequest, "VM export archive is invalid, truncated or doesn't match its checksum manifest",
+		`^not a Hoster VM export archive`, `^unsupported export format version`, `^could not read the archive`,
+		`^could not parse the archive (header|manifest|VM config)`, `^archive manifest is missing or invalid`,
+		`^archive is missing a file listed in the manifest: (?P<path>\S+)$`, `^checksum mismatch: (?P<path>\S+)$`,
+		`^archive file is not listed in the manifest: (?P<path>\S+)$`,
+		`^could not import (?P<path>\S+): (invalid archive entry|zvol is not listed in the archive header)$`),
+	entry(CODE_IMPORT_NETWORK_MISSING, http.StatusConflict, "Imported VM uses a network that doesn't exist on this host, pick the target network explicitly",
+		`^network (?P<network>\S+) doesn't exist on this host, pick the target network explicitly$`),
+	entry(CODE_UNAUTHORIZED, http.StatusUnauthorized, "Authentication has failed"),
+	entry(CODE_AUTH_LOCKED_OUT, http.StatusTooManyRequests, "Source IP or user name is locked out due to repeated authentication failures, retry after the number of seconds in the Retry-After header"),
+	entry(CODE_ROUTE_NOT_FOUND, http.StatusNotFound, "API route doesn't exist"),
+	entry(CODE_BAD_REQUEST, http.StatusBadRequest, "Request is invalid, check the message for more details"),
+	entry(CODE_INTERNAL_ERROR, http.StatusInternalServerError, "Unexpected error, check the message for more details"),
+}
+
+// Returns the full error catalog.
+func Catalog() []CatalogEntry {
+	return catalog
+}
+
+// Maps the error message to the catalog entry, and returns the structured API error.
+//
+// If the handler reported a generic 500 error, the catalog HTTP status is used instead (e.g. 404 for VM_NOT_FOUND).
+// Unknown messages are returned as INTERNAL_ERROR (5xx) or BAD_REQUEST (4xx).
+func Resolve(httpStatus int, message string) (r ApiError) {
+	r.ID = int(ValueLookup(message))
+	r.Message = message
+	r.HttpStatus = httpStatus
+	msg := strings.TrimSpace(message)
+
+	for _, v := range catalog {
+		details, ok := v.match(msg)
+		if !ok {
+			continue
+		}
+
+		r.Code = v.Code
+		if httpStatus == http.StatusInternalServerError {
+			r.HttpStatus = v.HttpStatus
+		}
+		r.Details = details
+		return
+	}
+
+	switch {
+	case httpStatus == http.StatusUnauthorized:
+		r.Code = CODE_UNAUTHORIZED
+	case httpStatus == http.StatusNotFound:
+		r.Code = CODE_RESOURCE_NOT_FOUND
+	case httpStatus == http.StatusPreconditionFailed:
+		r.Code = CODE_CONFIG_CONFLICT
+	case httpStatus >= 400 && httpStatus < 500:
+		r.Code = CODE_BAD_REQUEST
+	default:
+		r.Code = CODE_INTERNAL_ERROR
+	}
+
+	return
+}
+
+// Matches the message against the entry patterns, and returns the named parameters found in it.
+func (e CatalogEntry) match(message string) (map[string]string, bool) {
+	for _, re := range e.patterns {
+		match := re.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+
+		var details map[string]string
+		for i, name := range re.SubexpNames() {
+			if len(name) > 0 && len(match[i]) > 0 {
+				if details == nil {
+					details = make(map[string]string)
+				}
+				details[name] = match[i]
+			}
+		}
+		return details, true
+	}
+
+	return nil, false
+}
+
+func slicesContains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
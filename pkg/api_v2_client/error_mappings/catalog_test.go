@@ -0,0 +1,100 @@
+package ErrorMappings_test
+
+import (
+	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// Error messages produced by the VM and Jail packages, reported as a generic 500 by the handlers
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		message string
+		code    string
+		status  int
+	}{
+		{message: HosterVmUtils.ERRTXT_VM_IS_RUNNING, code: ErrorMappings.CODE_VM_RUNNING, status: http.StatusConflict},
+		{message: HosterVmUtils.ERRTXT_VM_IS_RUNNING + ": test-vm-1", code: ErrorMappings.CODE_VM_RUNNING, status: http.StatusConflict},
+		{message: HosterVmUtils.ERRTXT_VM_IS_STOPPED, code: ErrorMappings.CODE_VM_NOT_RUNNING, status: http.StatusConflict},
+		{message: HosterVmUtils.ERRTXT_VM_IS_STOPPED + ": test-vm-1", code: ErrorMappings.CODE_VM_NOT_RUNNING, status: http.StatusConflict},
+		{message: HosterVmUtils.ERRTXT_VM_DOESNT_EXIST, code: ErrorMappings.CODE_VM_NOT_FOUND, status: http.StatusNotFound},
+		{message: HosterVmUtils.ERRTXT_VM_DOESNT_EXIST + ": test-vm-1", code: ErrorMappings.CODE_VM_NOT_FOUND, status: http.StatusNotFound},
+		{message: HosterVmUtils.ERRTXT_VM_ALREADY_EXIST, code: ErrorMappings.CODE_NAME_CONFLICT, status: http.StatusConflict},
+		{message: HosterJailUtils.ERRTXT_JAIL_IS_RUNNING + ": test-jail-1", code: ErrorMappings.CODE_JAIL_RUNNING, status: http.StatusConflict},
+		{message: HosterJailUtils.ERRTXT_JAIL_IS_STOPPED + ": test-jail-1", code: ErrorMappings.CODE_JAIL_NOT_RUNNING, status: http.StatusConflict},
+		{message: HosterJailUtils.ERRTXT_JAIL_DOESNT_EXIST + ": test-jail-1", code: ErrorMappings.CODE_JAIL_NOT_FOUND, status: http.StatusNotFound},
+		{message: "vm is already running", code: ErrorMappings.CODE_VM_RUNNING, status: http.StatusConflict},
+		{message: "vm is running", code: ErrorMappings.CODE_VM_RUNNING, status: http.StatusConflict},
+		{message: "vm doesn't exist", code: ErrorMappings.CODE_VM_NOT_FOUND, status: http.StatusNotFound},
+		{message: "vm does not exist on this system", code: ErrorMappings.CODE_VM_NOT_FOUND, status: http.StatusNotFound},
+		{message: "vm not found", code: ErrorMappings.CODE_VM_NOT_FOUND, status: http.StatusNotFound},
+		{message: "vm was not found", code: ErrorMappings.CODE_VM_NOT_FOUND, status: http.StatusNotFound},
+		{message: ErrorMappings.VmIsNotRunning.String(), code: ErrorMappings.CODE_VM_NOT_RUNNING, status: http.StatusConflict},
+		{message: "Jail is already running: test-jail-1", code: ErrorMappings.CODE_JAIL_RUNNING, status: http.StatusConflict},
+		{message: "Jail is already offline: test-jail-1", code: ErrorMappings.CODE_JAIL_NOT_RUNNING, status: http.StatusConflict},
+		{message: "jail doesn't exist", code: ErrorMappings.CODE_JAIL_NOT_FOUND, status: http.StatusNotFound},
+		{message: "jail was not found", code: ErrorMappings.CODE_JAIL_NOT_FOUND, status: http.StatusNotFound},
+		{message: ErrorMappings.JailIsNotRunning.String(), code: ErrorMappings.CODE_JAIL_NOT_RUNNING, status: http.StatusConflict},
+		{message: ErrorMappings.ResourceDoesntExist.String(), code: ErrorMappings.CODE_RESOURCE_NOT_FOUND, status: http.StatusNotFound},
+		{message: "snapshot doesn't exist", code: ErrorMappings.CODE_SNAPSHOT_NOT_FOUND, status: http.StatusNotFound},
+		{message: "network bridge not found", code: ErrorMappings.CODE_NETWORK_NOT_FOUND, status: http.StatusNotFound},
+		{message: "network name supplied doesn't exist", code: ErrorMappings.CODE_NETWORK_NOT_FOUND, status: http.StatusNotFound},
+		{message: "vm must be offline to perform this operation", code: ErrorMappings.CODE_INTERNAL_ERROR, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			r := ErrorMappings.Resolve(http.StatusInternalServerError, tt.message)
+			if r.Code != tt.code || r.HttpStatus != tt.status {
+				t.Errorf("got %s/%d, want %s/%d", r.Code, r.HttpStatus, tt.code, tt.status)
+			}
+			if r.Message != tt.message {
+				t.Errorf("message: got %q, want %q", r.Message, tt.message)
+			}
+		})
+	}
+}
+
+// Sentinel errors, wrapped the same way the VM and Jail packages do it
+func TestResolveError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		want   int
+	}{
+		{name: "vm running", err: fmt.Errorf("%w: test-vm-1", HosterVmUtils.ErrVmIsRunning), status: http.StatusInternalServerError, code: ErrorMappings.CODE_VM_RUNNING, want: http.StatusConflict},
+		{name: "vm stopped", err: fmt.Errorf("%w: test-vm-1", HosterVmUtils.ErrVmIsStopped), status: http.StatusInternalServerError, code: ErrorMappings.CODE_VM_NOT_RUNNING, want: http.StatusConflict},
+		{name: "vm doesn't exist", err: fmt.Errorf("%w: test-vm-1", HosterVmUtils.ErrVmDoesntExist), status: http.StatusInternalServerError, code: ErrorMappings.CODE_VM_NOT_FOUND, want: http.StatusNotFound},
+		{name: "vm already exists", err: HosterVmUtils.ErrVmAlreadyExists, status: http.StatusInternalServerError, code: ErrorMappings.CODE_NAME_CONFLICT, want: http.StatusConflict},
+		{name: "jail running", err: fmt.Errorf("%w: test-jail-1", HosterJailUtils.ErrJailIsRunning), status: http.StatusInternalServerError, code: ErrorMappings.CODE_JAIL_RUNNING, want: http.StatusConflict},
+		{name: "jail stopped", err: fmt.Errorf("%w: test-jail-1", HosterJailUtils.ErrJailIsStopped), status: http.StatusInternalServerError, code: ErrorMappings.CODE_JAIL_NOT_RUNNING, want: http.StatusConflict},
+		{name: "jail doesn't exist", err: fmt.Errorf("%w: test-jail-1", HosterJailUtils.ErrJailDoesntExist), status: http.StatusInternalServerError, code: ErrorMappings.CODE_JAIL_NOT_FOUND, want: http.StatusNotFound},
+		{name: "wrapped twice", err: fmt.Errorf("could not start: %w", fmt.Errorf("%w: test-vm-1", HosterVmUtils.ErrVmIsRunning)), status: http.StatusInternalServerError, code: ErrorMappings.CODE_VM_RUNNING, want: http.StatusConflict},
+		{name: "code wins over the message", err: ErrorMappings.NewError(ErrorMappings.CODE_VM_RUNNING, "vm doesn't exist"), status: http.StatusInternalServerError, code: ErrorMappings.CODE_VM_RUNNING, want: http.StatusConflict},
+		{name: "explicit status is kept", err: HosterVmUtils.ErrVmIsRunning, status: http.StatusBadRequest, code: ErrorMappings.CODE_VM_RUNNING, want: http.StatusBadRequest},
+		{name: "plain error falls back to the message", err: errors.New("jail was not found"), status: http.StatusInternalServerError, code: ErrorMappings.CODE_JAIL_NOT_FOUND, want: http.StatusNotFound},
+		{name: "unknown error", err: errors.New("something went wrong"), status: http.StatusInternalServerError, code: ErrorMappings.CODE_INTERNAL_ERROR, want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := ErrorMappings.ResolveError(tt.status, tt.err)
+			if r.Code != tt.code || r.HttpStatus != tt.want {
+				t.Errorf("got %s/%d, want %s/%d", r.Code, r.HttpStatus, tt.code, tt.want)
+			}
+			if r.Message != tt.err.Error() {
+				t.Errorf("message: got %q, want %q", r.Message, tt.err.Error())
+			}
+		})
+	}
+
+	if !errors.Is(fmt.Errorf("%w: test-vm-1", HosterVmUtils.ErrVmIsRunning), HosterVmUtils.ErrVmIsRunning) {
+		t.Errorf("wrapped sentinel error doesn't match using errors.Is")
+	}
+}
@@ -0,0 +1,69 @@
+package ErrorMappings
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Error with the catalog code attached to it at the place where it was raised.
+// It survives the wrapping (fmt.Errorf("%w: ...")), so the message text can be changed freely,
+// without breaking the code returned to the API clients.
+type CodedError struct {
+	Code string
+	Err  error
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// Creates a new error with the catalog code attached to it.
+// Use it to define the sentinel errors, and check them using errors.Is:
+//
+//	var ErrVmIsRunning = ErrorMappings.NewError(ErrorMappings.CODE_VM_RUNNING, "VM is already running")
+//	return fmt.Errorf("%w: %s", ErrVmIsRunning, vmName)
+func NewError(code string, message string) error {
+	return &CodedError{Code: code, Err: errors.New(message)}
+}
+
+// Same as Resolve, but the catalog code attached to the error (if any) takes priority over the message matching.
+func ResolveError(httpStatus int, err error) (r ApiError) {
+	if err == nil {
+		return Resolve(httpStatus, "")
+	}
+
+	var coded *CodedError
+	if !errors.As(err, &coded) {
+		return Resolve(httpStatus, err.Error())
+	}
+
+	e, ok := catalogEntry(coded.Code)
+	if !ok {
+		return Resolve(httpStatus, err.Error())
+	}
+
+	r.ID = int(ValueLookup(err.Error()))
+	r.Code = e.Code
+	r.Message = err.Error()
+	r.HttpStatus = httpStatus
+	if httpStatus == http.StatusInternalServerError {
+		r.HttpStatus = e.HttpStatus
+	}
+	r.Details, _ = e.match(strings.TrimSpace(err.Error()))
+
+	return
+}
+
+func catalogEntry(code string) (CatalogEntry, bool) {
+	for _, v := range catalog {
+		if v.Code == code {
+			return v, true
+		}
+	}
+	return CatalogEntry{}, false
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package ApiV2Client
+
+import (
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Stable API error codes, see the GET /api/v2/errors for the full catalog.
+const (
+	CODE_INTERNAL_ERROR           = ErrorMappings.CODE_INTERNAL_ERROR
+	CODE_BAD_REQUEST              = ErrorMappings.CODE_BAD_REQUEST
+	CODE_INVALID_INPUT            = ErrorMappings.CODE_INVALID_INPUT
+	CODE_UNAUTHORIZED             = ErrorMappings.CODE_UNAUTHORIZED
+	CODE_ROUTE_NOT_FOUND          = ErrorMappings.CODE_ROUTE_NOT_FOUND
+	CODE_RESOURCE_NOT_FOUND       = ErrorMappings.CODE_RESOURCE_NOT_FOUND
+	CODE_VM_NOT_FOUND             = ErrorMappings.CODE_VM_NOT_FOUND
+	CODE_VM_RUNNING               = ErrorMappings.CODE_VM_RUNNING
+	CODE_VM_NOT_RUNNING           = ErrorMappings.CODE_VM_NOT_RUNNING
+	CODE_JAIL_NOT_FOUND           = ErrorMappings.CODE_JAIL_NOT_FOUND
+	CODE_JAIL_RUNNING             = ErrorMappings.CODE_JAIL_RUNNING
+	CODE_JAIL_NOT_RUNNING         = ErrorMappings.CODE_JAIL_NOT_RUNNING
+	CODE_RESOURCE_IS_BACKUP       = ErrorMappings.CODE_RESOURCE_IS_BACKUP
+	CODE_SNAPSHOT_NOT_FOUND       = ErrorMappings.CODE_SNAPSHOT_NOT_FOUND
+	CODE_SNAPSHOT_TYPE_INVALID    = ErrorMappings.CODE_SNAPSHOT_TYPE_INVALID
+	CODE_SNAPSHOT_HAS_CLONES      = ErrorMappings.CODE_SNAPSHOT_HAS_CLONES
+	CODE_DATASET_LOCKED           = ErrorMappings.CODE_DATASET_LOCKED
+	CODE_DATASET_BUSY             = ErrorMappings.CODE_DATASET_BUSY
+	CODE_NETWORK_NOT_FOUND        = ErrorMappings.CODE_NETWORK_NOT_FOUND
+	CODE_HOST_NOT_FOUND           = ErrorMappings.CODE_HOST_NOT_FOUND
+	CODE_HOST_DISABLED            = ErrorMappings.CODE_HOST_DISABLED
+	CODE_CONFIG_CONFLICT          = ErrorMappings.CODE_CONFIG_CONFLICT
+	CODE_PRECONDITION_REQUIRED    = ErrorMappings.CODE_PRECONDITION_REQUIRED
+	CODE_CONSOLE_WRITER_TAKEN     = ErrorMappings.CODE_CONSOLE_WRITER_TAKEN
+	CODE_VNC_TOKEN_INVALID        = ErrorMappings.CODE_VNC_TOKEN_INVALID
+	CODE_TOO_MANY_VIEWERS         = ErrorMappings.CODE_TOO_MANY_VIEWERS
+	CODE_BULK_OPERATION_NOT_FOUND = ErrorMappings.CODE_BULK_OPERATION_NOT_FOUND
+	CODE_ADMISSION_DENIED         = ErrorMappings.CODE_ADMISSION_DENIED
+	CODE_NAME_CONFLICT            = ErrorMappings.CODE_NAME_CONFLICT
+	CODE_DATASET_NOT_ACTIVE       = ErrorMappings.CODE_DATASET_NOT_ACTIVE
+	CODE_ARCHIVE_INVALID          = ErrorMappings.CODE_ARCHIVE_INVALID
+	CODE_IMPORT_NETWORK_MISSING   = ErrorMappings.CODE_IMPORT_NETWORK_MISSING
+	CODE_AUTH_LOCKED_OUT          = ErrorMappings.CODE_AUTH_LOCKED_OUT
+)
+
+// Error returned by the API (any non-2xx response).
+type Error struct {
+	StatusCode int               `json:"-"`
+	RequestID  string            `json:"-"` // X-Request-ID of the failed request, useful when looking through the node logs
+	ID         int               `json:"id"`
+	Code       string            `json:"code"`
+	Message    string            `json:"message"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Checks if the error is an API error with the given code, e.g. IsCode(err, ApiV2Client.CODE_VM_NOT_FOUND).
+func IsCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+func parseError(res *http.Response) error {
+	e := &Error{StatusCode: res.StatusCode, RequestID: res.Header.Get("X-Request-ID")}
+
+	data, _ := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	err := json.Unmarshal(data, e)
+	if err != nil || len(e.Code) < 1 {
+		// Not a structured API error (e.g. a reverse proxy response, or an older node)
+		resolved := ErrorMappings.Resolve(res.StatusCode, strings.TrimSpace(string(data)))
+		if len(e.Message) > 0 {
+			resolved = ErrorMappings.Resolve(res.StatusCode, e.Message)
+		}
+		if len(resolved.Message) < 1 {
+			resolved.Message = http.StatusText(res.StatusCode)
+		}
+		e.ID = resolved.ID
+		e.Code = resolved.Code
+		e.Message = resolved.Message
+		e.Details = resolved.Details
+	}
+
+	return e
+}
@@ -0,0 +1,1287 @@
+// Code generated by api_client_gen. DO NOT EDIT.
+
+package ApiV2Client
+
+import (
+	ApiV2Types "HosterCore/pkg/api_v2_types"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/websocket"
+)
+
+// Query the API audit log
+//
+// GET /api/v2/audit
+//
+// Query parameters:
+//   - user (string): Filter by user name
+//   - source_ip (string): Filter by source IP address
+//   - resource (string): Filter by the target resource name (e.g. VM or Jail name)
+//   - route (string): Filter by route (substring match), e.g. /vm/destroy
+//   - method (string): Filter by HTTP method
+//   - outcome (string): Filter by outcome: success or failure
+//   - since (string): Only show the entries after this time (RFC3339)
+//   - until (string): Only show the entries before this time (RFC3339)
+//   - limit (int): Maximum number of entries to return (100 by default)
+func (c *Client) AuditLogList(ctx context.Context, query url.Values) (r []ApiV2Types.AuditEntry, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/audit"}
+	req.query = query
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// List bulk operations
+//
+// GET /api/v2/bulk/operations
+func (c *Client) BulkListOperations(ctx context.Context) (r []ApiV2Types.BulkOperation, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/bulk/operations"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get the bulk operation status
+//
+// GET /api/v2/bulk/operations/{id}
+func (c *Client) BulkGetOperation(ctx context.Context, id string) (r ApiV2Types.BulkOperation, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/bulk/operations/" + url.PathEscape(id)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Start a bulk operation
+//
+// POST /api/v2/bulk/{action}
+func (c *Client) BulkPostAction(ctx context.Context, action string, input ApiV2Types.BulkInput) (r ApiV2Types.BulkOperation, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/bulk/" + url.PathEscape(action)}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Receive the cluster state from the master
+//
+// GET /api/v2/carp-ha/backups
+func (c *Client) CarpReturnListOfBackups(ctx context.Context) (r []ApiV2Types.CarpBackupInfo, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/carp-ha/backups"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Ping CARP interface
+//
+// POST /api/v2/carp-ha/ping
+func (c *Client) CarpPing(ctx context.Context, input ApiV2Types.CarpHostInfo) (r ApiV2Types.CarpPingResponse, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/carp-ha/ping"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Receive the cluster state from the master
+//
+// POST /api/v2/carp-ha/receive-state/{master_hostname}
+func (c *Client) CarpReceiveHostState(ctx context.Context, masterHostname string, input ApiV2Types.CarpHaStatus) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/carp-ha/receive-state/" + url.PathEscape(masterHostname)}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get active dataset list
+//
+// GET /api/v2/dataset/all
+func (c *Client) DatasetList(ctx context.Context) (r []ApiV2Types.DatasetInfo, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/dataset/all"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Unlock an encrypted dataset
+//
+// POST /api/v2/dataset/unlock
+func (c *Client) UnlockEncryptedDataset(ctx context.Context, input ApiV2Types.DatasetEncryptionInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/dataset/unlock"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// List the API error codes
+//
+// GET /api/v2/errors
+func (c *Client) ErrorCatalog(ctx context.Context) (r []ApiV2Types.ErrorCatalogEntry, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/errors"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Handle the HA enabled Jail list
+//
+// GET /api/v2/ha/jail-list
+func (c *Client) HaJailList(ctx context.Context) (r []ApiV2Types.HaJail, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/ha/jail-list"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Handle the HA node ping
+//
+// POST /api/v2/ha/ping
+func (c *Client) HaPing(ctx context.Context, input ApiV2Types.HaNode) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/ha/ping"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Handle the HA node registration
+//
+// POST /api/v2/ha/register
+func (c *Client) HaRegistration(ctx context.Context, input ApiV2Types.HaNode) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/ha/register"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Handle the HA graceful termination signal
+//
+// POST /api/v2/ha/terminate
+func (c *Client) HaTerminate(ctx context.Context) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/ha/terminate"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Handle the HA enabled VM list
+//
+// GET /api/v2/ha/vm-list
+func (c *Client) HaVmList(ctx context.Context) (r []ApiV2Types.HaVm, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/ha/vm-list"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// REST API server health status
+//
+// GET /api/v2/health
+func (c *Client) HealthCheck(ctx context.Context) (r string, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/health"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Check `any` user authentication
+//
+// GET /api/v2/health/auth/any
+func (c *Client) HealthCheckAnyAuth(ctx context.Context) (r string, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/health/auth/any"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Check the `HA` user authentication
+//
+// GET /api/v2/health/auth/ha
+func (c *Client) HealthCheckHaAuth(ctx context.Context) (r string, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/health/auth/ha"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Check the `regular` user authentication
+//
+// GET /api/v2/health/auth/regular
+func (c *Client) HealthCheckRegularAuth(ctx context.Context) (r string, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/health/auth/regular"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get Host info
+//
+// GET /api/v2/host/info
+func (c *Client) HostInfo(ctx context.Context) (r ApiV2Types.HostInfo, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/host/info"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get README.MD for this particular Hoster node
+//
+// GET /api/v2/host/readme
+func (c *Client) GetHostReadme(ctx context.Context) (r string, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/host/readme"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// List authentication failures and lockouts
+//
+// GET /api/v2/host/security/lockouts
+func (c *Client) HostSecurityLockouts(ctx context.Context) (r []ApiV2Types.LockoutEntry, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/host/security/lockouts"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Clear authentication lockouts
+//
+// DELETE /api/v2/host/security/lockouts/clear
+func (c *Client) HostSecurityLockoutsClear(ctx context.Context, input ApiV2Types.LockoutClearInput) (r string, e error) {
+	req := request{method: http.MethodDelete, path: "/api/v2/host/security/lockouts/clear"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get Host Settings
+//
+// GET /api/v2/host/settings
+func (c *Client) HostSettings(ctx context.Context) (r ApiV2Types.HostConfig, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/host/settings"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Add a new VM SSH access key
+//
+// POST /api/v2/host/settings/add-ssh-key
+func (c *Client) PostHostSettingsSshKey(ctx context.Context, input ApiV2Types.SshKeyInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/host/settings/add-ssh-key"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Add a new Host-related tag
+//
+// POST /api/v2/host/settings/add-tag/{tag}
+func (c *Client) PostHostTag(ctx context.Context, tag string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/host/settings/add-tag/" + url.PathEscape(tag)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Add a new upstream DNS server
+//
+// POST /api/v2/host/settings/add-upstream-dns
+func (c *Client) PostHostSettingsAddUpstreamDns(ctx context.Context, input ApiV2Types.UpstreamDnsInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/host/settings/add-upstream-dns"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get RestAPI Settings (including HA settings)
+//
+// GET /api/v2/host/settings/api
+func (c *Client) HostRestApiSettings(ctx context.Context) (r ApiV2Types.ApiConfig, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/host/settings/api"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Reload RestAPI Settings
+//
+// POST /api/v2/host/settings/api/reload
+func (c *Client) HostRestApiSettingsReload(ctx context.Context) (r []ApiV2Types.SettingChange, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/host/settings/api/reload"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Delete an existing SSH key
+//
+// DELETE /api/v2/host/settings/delete-ssh-key
+func (c *Client) DeleteHostSettingsSshKey(ctx context.Context, input ApiV2Types.SshKeyInput) (r string, e error) {
+	req := request{method: http.MethodDelete, path: "/api/v2/host/settings/delete-ssh-key"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Delete an existing Host-related tag
+//
+// DELETE /api/v2/host/settings/delete-tag/{tag}
+func (c *Client) DeleteHostTag(ctx context.Context, tag string) (r string, e error) {
+	req := request{method: http.MethodDelete, path: "/api/v2/host/settings/delete-tag/" + url.PathEscape(tag)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Delete an upstream DNS server
+//
+// DELETE /api/v2/host/settings/delete-upstream-dns
+func (c *Client) DeleteHostSettingsUpstreamDns(ctx context.Context, input ApiV2Types.UpstreamDnsInput) (r string, e error) {
+	req := request{method: http.MethodDelete, path: "/api/v2/host/settings/delete-upstream-dns"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Post a new DNS search domain
+//
+// POST /api/v2/host/settings/dns-search-domain
+func (c *Client) PostHostSettingsDnsSearchDomain(ctx context.Context, input ApiV2Types.DnsSearchDomainInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/host/settings/dns-search-domain"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Add a new host-level authorized SSH key
+//
+// POST /api/v2/host/settings/ssh-auth-key
+func (c *Client) PostHostSshAuthKey(ctx context.Context, input ApiV2Types.HostAuthSshKeyInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/host/settings/ssh-auth-key"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Post an updated VM template site
+//
+// POST /api/v2/host/settings/vm-templates
+func (c *Client) PostHostSettingsVmTemplateLink(ctx context.Context, input ApiV2Types.VmTemplateLink) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/host/settings/vm-templates"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// List all Jails
+//
+// GET /api/v2/jail/all
+//
+// Query parameters:
+//   - tag (string): Filter by tag (comma separated list, all tags must match)
+//   - running (bool): Filter by the running state
+//   - production (bool): Filter by the production flag
+//   - backup (bool): Filter by the backup status
+//   - owner (string): Filter by owner
+//   - dataset (string): Filter by ZFS dataset, e.g. zroot/vm-encrypted
+//   - name (string): Filter by name (glob pattern), e.g. web-*
+//   - sort (string): Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name
+//   - offset (int): Pagination offset
+//   - limit (int): Pagination limit
+//   - fields (string): Comma separated list of fields to return, e.g. name,running
+func (c *Client) JailList(ctx context.Context, query url.Values) (r []ApiV2Types.JailApi, totalCount int, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/jail/all"}
+	req.query = query
+	header, e := c.do(ctx, req, &r)
+	if e != nil {
+		return
+	}
+	totalCount = parseInt(header.Get("X-Total-Count"))
+	return
+}
+
+// List all Jails (cached version)
+//
+// GET /api/v2/jail/all/cache
+//
+// Query parameters:
+//   - tag (string): Filter by tag (comma separated list, all tags must match)
+//   - running (bool): Filter by the running state
+//   - production (bool): Filter by the production flag
+//   - backup (bool): Filter by the backup status
+//   - owner (string): Filter by owner
+//   - dataset (string): Filter by ZFS dataset, e.g. zroot/vm-encrypted
+//   - name (string): Filter by name (glob pattern), e.g. web-*
+//   - sort (string): Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name
+//   - offset (int): Pagination offset
+//   - limit (int): Pagination limit
+//   - fields (string): Comma separated list of fields to return, e.g. name,running
+func (c *Client) JailListCache(ctx context.Context, query url.Values) (r []ApiV2Types.JailApi, totalCount int, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/jail/all/cache"}
+	req.query = query
+	header, e := c.do(ctx, req, &r)
+	if e != nil {
+		return
+	}
+	totalCount = parseInt(header.Get("X-Total-Count"))
+	return
+}
+
+// Clone the Jail
+//
+// POST /api/v2/jail/clone
+func (c *Client) JailClone(ctx context.Context, input ApiV2Types.JailCloneInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/clone"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Deploy a new Jail
+//
+// POST /api/v2/jail/deploy
+func (c *Client) JailDeploy(ctx context.Context, input ApiV2Types.JailDeployInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/deploy"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Destroy a specific Jail
+//
+// DELETE /api/v2/jail/destroy/{jail_name}
+func (c *Client) JailDestroy(ctx context.Context, jailName string) (r string, e error) {
+	req := request{method: http.MethodDelete, path: "/api/v2/jail/destroy/" + url.PathEscape(jailName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get a list of active shells for a specific Jail
+//
+// GET /api/v2/jail/get/shells/{jail_name}
+func (c *Client) JailGetShells(ctx context.Context, jailName string) (r ApiV2Types.JailShells, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/jail/get/shells/" + url.PathEscape(jailName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get Jail info
+//
+// GET /api/v2/jail/info/{jail_name}
+func (c *Client) JailInfo(ctx context.Context, jailName string) (r ApiV2Types.JailApi, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/jail/info/" + url.PathEscape(jailName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get README.MD for a particular Jail
+//
+// GET /api/v2/jail/readme/{jail_name}
+func (c *Client) JailGetReadme(ctx context.Context, jailName string) (r string, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/jail/readme/" + url.PathEscape(jailName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Add a new tag for any particular Jail
+//
+// POST /api/v2/jail/settings/add-tag/{jail_name}
+func (c *Client) JailPostNewTag(ctx context.Context, jailName string, ifMatch string, input ApiV2Types.TagInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/settings/add-tag/" + url.PathEscape(jailName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Modify Jail's CPU limitation (in %, 1-100)
+//
+// POST /api/v2/jail/settings/cpu/{jail_name}/{limit}
+func (c *Client) JailPostCpuPercentageLimit(ctx context.Context, jailName string, limit string, ifMatch string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/settings/cpu/" + url.PathEscape(jailName) + "/" + url.PathEscape(limit)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Update Jails's description
+//
+// POST /api/v2/jail/settings/description/{jail_name}
+func (c *Client) JailPostDescription(ctx context.Context, jailName string, ifMatch string, input ApiV2Types.ResourceDescription) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/settings/description/" + url.PathEscape(jailName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Modify Jail's DNS settings
+//
+// POST /api/v2/jail/settings/dns/{jail_name}
+func (c *Client) JailPostSettingsDns(ctx context.Context, jailName string, ifMatch string, input ApiV2Types.JailDnsInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/settings/dns/" + url.PathEscape(jailName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Modify Jail's Network settings
+//
+// POST /api/v2/jail/settings/network/{jail_name}
+func (c *Client) JailPostSettingsNetwork(ctx context.Context, jailName string, ifMatch string, input ApiV2Types.JailNetworkInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/settings/network/" + url.PathEscape(jailName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Modify Jail's Workload type (e.g. is this a production Jail, true or false)
+//
+// POST /api/v2/jail/settings/production/{jail_name}/{production}
+func (c *Client) JailPostProductionSetting(ctx context.Context, jailName string, production string, ifMatch string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/settings/production/" + url.PathEscape(jailName) + "/" + url.PathEscape(production)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Modify Jail's RAM limit
+//
+// POST /api/v2/jail/settings/ram/{jail_name}/{limit}
+func (c *Client) JailPostRamLimit(ctx context.Context, jailName string, limit string, ifMatch string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/settings/ram/" + url.PathEscape(jailName) + "/" + url.PathEscape(limit)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get Jail config (settings)
+//
+// GET /api/v2/jail/settings/{jail_name}
+func (c *Client) JailGetSettings(ctx context.Context, jailName string) (r ApiV2Types.JailConfig, etag string, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/jail/settings/" + url.PathEscape(jailName)}
+	header, e := c.do(ctx, req, &r)
+	if e != nil {
+		return
+	}
+	etag = header.Get("ETag")
+	return
+}
+
+// Start all Jails
+//
+// POST /api/v2/jail/start-all/{production}
+func (c *Client) JailPostStartAll(ctx context.Context, production string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/start-all/" + url.PathEscape(production)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Start a specific Jail
+//
+// POST /api/v2/jail/start/{jail_name}
+func (c *Client) JailStart(ctx context.Context, jailName string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/start/" + url.PathEscape(jailName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Stop all Jails
+//
+// POST /api/v2/jail/stop-all
+func (c *Client) JailPostStopAll(ctx context.Context) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/stop-all"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Stop a specific Jail
+//
+// POST /api/v2/jail/stop/{jail_name}
+func (c *Client) JailStop(ctx context.Context, jailName string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/jail/stop/" + url.PathEscape(jailName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// List all Jail templates
+//
+// GET /api/v2/jail/template/list
+func (c *Client) JailListTemplates(ctx context.Context) (r []string, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/jail/template/list"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get the RCTL metrics for a specific Jail
+//
+// GET /api/v2/metrics/jail/{jail_name}
+func (c *Client) JailMetrics(ctx context.Context, jailName string) (r ApiV2Types.RctMetrics, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/metrics/jail/" + url.PathEscape(jailName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get the RCTL metrics for a specific VM
+//
+// GET /api/v2/metrics/vm/{vm_name}
+func (c *Client) VmMetrics(ctx context.Context, vmName string) (r ApiV2Types.RctMetrics, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/metrics/vm/" + url.PathEscape(vmName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Add a new network
+//
+// POST /api/v2/network/add-new-network
+func (c *Client) PostNewNetwork(ctx context.Context, input []ApiV2Types.NetworkConfig) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/network/add-new-network"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get the networks list
+//
+// GET /api/v2/network/all
+func (c *Client) NetworkList(ctx context.Context) (r []ApiV2Types.NetworkConfig, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/network/all"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Generate a Prometheus autodiscovery JSON output for all Hoster VMs
+//
+// GET /api/v2/prometheus/autodiscovery/vms
+func (c *Client) PrometheusAutoDiscoveryVms(ctx context.Context) (r []ApiV2Types.PrometheusTarget, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/prometheus/autodiscovery/vms"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Generate a Prometheus autodiscovery JSON output for all Hoster VMs
+//
+// GET /api/v2/prometheus/autodiscovery/vms/use-ips
+func (c *Client) PrometheusAutoDiscoveryVmsIps(ctx context.Context) (r []ApiV2Types.PrometheusTarget, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/prometheus/autodiscovery/vms/use-ips"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get the list of scheduled cron jobs
+//
+// GET /api/v2/scheduler/cron
+func (c *Client) SchedulerGetCron(ctx context.Context) (r []ApiV2Types.CronFile, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/scheduler/cron"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get the list of scheduled, active and past jobs
+//
+// GET /api/v2/scheduler/jobs
+func (c *Client) SchedulerGetJobs(ctx context.Context) (r []ApiV2Types.SchedulerJob, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/scheduler/jobs"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// List all snapshots for any given VM or a Jail
+//
+// GET /api/v2/snapshot/all/{res_name}
+func (c *Client) SnapshotList(ctx context.Context, resName string) (r []ApiV2Types.SnapshotInfo, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/snapshot/all/" + url.PathEscape(resName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// List all snapshots for any given VM or a Jail (cached version)
+//
+// GET /api/v2/snapshot/all/{res_name}/cache
+func (c *Client) SnapshotListCache(ctx context.Context, resName string) (r []ApiV2Types.SnapshotInfo, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/snapshot/all/" + url.PathEscape(resName) + "/cache"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Clone an existing VM or Jail snapshot
+//
+// POST /api/v2/snapshot/clone
+func (c *Client) SnapshotClone(ctx context.Context, input ApiV2Types.SnapshotInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/snapshot/clone"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Destroy a snapshot for any given VM or a Jail
+//
+// DELETE /api/v2/snapshot/destroy
+func (c *Client) SnapshotDestroy(ctx context.Context, input ApiV2Types.SnapshotName) (r string, e error) {
+	req := request{method: http.MethodDelete, path: "/api/v2/snapshot/destroy"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Rollback to a previous snapshot
+//
+// POST /api/v2/snapshot/rollback
+func (c *Client) SnapshotRollback(ctx context.Context, input ApiV2Types.SnapshotName) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/snapshot/rollback"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Take a new snapshot
+//
+// POST /api/v2/snapshot/take/immediate
+func (c *Client) SnapshotTakeImmediate(ctx context.Context, input ApiV2Types.SnapshotInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/snapshot/take/immediate"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// List all VMs
+//
+// GET /api/v2/vm/all
+//
+// Query parameters:
+//   - tag (string): Filter by tag (comma separated list, all tags must match)
+//   - running (bool): Filter by the running state
+//   - production (bool): Filter by the production flag
+//   - backup (bool): Filter by the backup status
+//   - owner (string): Filter by owner
+//   - dataset (string): Filter by ZFS dataset, e.g. zroot/vm-encrypted
+//   - name (string): Filter by name (glob pattern), e.g. web-*
+//   - sort (string): Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name
+//   - offset (int): Pagination offset
+//   - limit (int): Pagination limit
+//   - fields (string): Comma separated list of fields to return, e.g. name,running
+func (c *Client) VmList(ctx context.Context, query url.Values) (r []ApiV2Types.VmApi, totalCount int, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/vm/all"}
+	req.query = query
+	header, e := c.do(ctx, req, &r)
+	if e != nil {
+		return
+	}
+	totalCount = parseInt(header.Get("X-Total-Count"))
+	return
+}
+
+// List all VMs (cached version)
+//
+// GET /api/v2/vm/all/cache
+//
+// Query parameters:
+//   - tag (string): Filter by tag (comma separated list, all tags must match)
+//   - running (bool): Filter by the running state
+//   - production (bool): Filter by the production flag
+//   - backup (bool): Filter by the backup status
+//   - owner (string): Filter by owner
+//   - dataset (string): Filter by ZFS dataset, e.g. zroot/vm-encrypted
+//   - name (string): Filter by name (glob pattern), e.g. web-*
+//   - sort (string): Comma separated list of fields to sort by, prefix with - for the descending order, e.g. -running,name
+//   - offset (int): Pagination offset
+//   - limit (int): Pagination limit
+//   - fields (string): Comma separated list of fields to return, e.g. name,running
+func (c *Client) VmListCache(ctx context.Context, query url.Values) (r []ApiV2Types.VmApi, totalCount int, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/vm/all/cache"}
+	req.query = query
+	header, e := c.do(ctx, req, &r)
+	if e != nil {
+		return
+	}
+	totalCount = parseInt(header.Get("X-Total-Count"))
+	return
+}
+
+// Apply the declarative VM spec
+//
+// POST /api/v2/vm/apply
+//
+// Query parameters:
+//   - dry_run (bool): Only return the plan, without applying it
+func (c *Client) VmApply(ctx context.Context, query url.Values, input ApiV2Types.VmSpec) (r ApiV2Types.VmApplyResult, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/apply"}
+	req.query = query
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Clone the VM
+//
+// POST /api/v2/vm/clone
+func (c *Client) VmClone(ctx context.Context, input ApiV2Types.VmCloneInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/clone"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Mount a real CloudInit ISO
+//
+// POST /api/v2/vm/cloud-init/mount-iso/{vm_name}
+func (c *Client) VmPostMountCiIso(ctx context.Context, vmName string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/cloud-init/mount-iso/" + url.PathEscape(vmName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Replace a real CloudInit ISO with an empty one
+//
+// POST /api/v2/vm/cloud-init/unmount-iso/{vm_name}
+func (c *Client) VmPostUnmountCiIso(ctx context.Context, vmName string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/cloud-init/unmount-iso/" + url.PathEscape(vmName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Connect to the VM's serial console (WebSocket)
+//
+// GET /api/v2/vm/console/serial/{vm_name}
+//
+// Query parameters:
+//   - write (bool): Request the write access to the console
+func (c *Client) VmSerialConsole(ctx context.Context, vmName string, query url.Values) (*websocket.Conn, error) {
+	return c.dial(ctx, "/api/v2/vm/console/serial/"+url.PathEscape(vmName), query)
+}
+
+// List active VNC proxy sessions
+//
+// GET /api/v2/vm/console/vnc/sessions
+func (c *Client) VmVncSessions(ctx context.Context) (r []ApiV2Types.VncSession, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/vm/console/vnc/sessions"}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Issue a VNC proxy session token
+//
+// POST /api/v2/vm/console/vnc/token/{vm_name}
+func (c *Client) VmPostVncToken(ctx context.Context, vmName string) (r ApiV2Types.VncTokenResponse, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/console/vnc/token/" + url.PathEscape(vmName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Connect to the VM's VNC console (WebSocket)
+//
+// GET /api/v2/vm/console/vnc/{token}
+func (c *Client) VmVncProxy(ctx context.Context, token string) (*websocket.Conn, error) {
+	return c.dial(ctx, "/api/v2/vm/console/vnc/"+url.PathEscape(token), nil)
+}
+
+// Deploy the new VM
+//
+// POST /api/v2/vm/deploy
+func (c *Client) VmPostDeploy(ctx context.Context, input ApiV2Types.VmDeployInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/deploy"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Destroy the VM
+//
+// DELETE /api/v2/vm/destroy/{vm_name}
+func (c *Client) VmDestroy(ctx context.Context, vmName string) (r string, e error) {
+	req := request{method: http.MethodDelete, path: "/api/v2/vm/destroy/" + url.PathEscape(vmName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Export the VM into a portable archive (streamed)
+//
+// GET /api/v2/vm/export/{vm_name}
+func (c *Client) VmGetExport(ctx context.Context, vmName string) (r io.ReadCloser, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/vm/export/" + url.PathEscape(vmName)}
+	r, e = c.stream(ctx, req)
+	return
+}
+
+// Import the VM from an export archive (streamed)
+//
+// POST /api/v2/vm/import
+//
+// Query parameters:
+//   - vm_name (string): New VM name (the name stored in the archive is used by default)
+//   - dataset (string): Parent dataset, e.g. zroot/vm-encrypted (the first active dataset is used by default)
+//   - network (string): Move all network interfaces to this network
+//   - regenerate_mac (bool): Always generate new MAC addresses
+//   - reset_instance_id (bool): Reset the cloud-init instance-id if the primary NIC address changes (regenerates the SSH host keys, and applies the user-data again)
+func (c *Client) VmPostImport(ctx context.Context, query url.Values, input io.Reader) (r ApiV2Types.VmImportResult, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/import"}
+	req.query = query
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get the VM Info
+//
+// GET /api/v2/vm/info/{vm_name}
+func (c *Client) VmInfo(ctx context.Context, vmName string) (r ApiV2Types.VmApi, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/vm/info/" + url.PathEscape(vmName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get README.MD for a particular VM
+//
+// GET /api/v2/vm/readme/{vm_name}
+func (c *Client) VmGetReadme(ctx context.Context, vmName string) (r string, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/vm/readme/" + url.PathEscape(vmName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Rename the VM
+//
+// POST /api/v2/vm/rename/{vm_name}
+func (c *Client) VmPostRename(ctx context.Context, vmName string, input ApiV2Types.VmRenameInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/rename/" + url.PathEscape(vmName)}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Add a new tag for any particular VM
+//
+// POST /api/v2/vm/settings/add-tag/{vm_name}
+func (c *Client) VmPostNewTag(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.TagInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/add-tag/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Modify VM's CPU settings
+//
+// POST /api/v2/vm/settings/cpu/{vm_name}
+func (c *Client) VmPostCpuInfo(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.VmCpuInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/cpu/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Delete an existing tag for any specific VM
+//
+// DELETE /api/v2/vm/settings/delete-tag/{vm_name}
+func (c *Client) VmDeleteExistingTag(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.TagInput) (r string, e error) {
+	req := request{method: http.MethodDelete, path: "/api/v2/vm/settings/delete-tag/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Update VM's description
+//
+// POST /api/v2/vm/settings/description/{vm_name}
+func (c *Client) VmPostDescription(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.ResourceDescription) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/description/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Add a new VM data disk
+//
+// POST /api/v2/vm/settings/disk/add-new/{vm_name}
+func (c *Client) VmPostAddNewDisk(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.VmDisk) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/disk/add-new/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Detach a VM disk
+//
+// POST /api/v2/vm/settings/disk/detach/{vm_name}
+func (c *Client) VmPostDetachDisk(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.VmDiskDetachInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/disk/detach/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Expand an existing VM disk
+//
+// POST /api/v2/vm/settings/disk/expand/{vm_name}
+func (c *Client) VmPostExpandDisk(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.VmDiskExpandInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/disk/expand/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Remove a VM disk
+//
+// POST /api/v2/vm/settings/disk/remove/{vm_name}
+func (c *Client) VmPostRemoveDisk(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.VmDiskRemoveInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/disk/remove/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Change the VM disk order
+//
+// POST /api/v2/vm/settings/disk/reorder/{vm_name}
+func (c *Client) VmPostReorderDisks(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.VmDiskReorderInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/disk/reorder/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Modify VM's Firmware type (e.g. bootloader type, bios vs uefi)
+//
+// POST /api/v2/vm/settings/firmware/{vm_name}/{firmware}
+func (c *Client) VmPostFirmwareType(ctx context.Context, vmName string, firmware string, ifMatch string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/firmware/" + url.PathEscape(vmName) + "/" + url.PathEscape(firmware)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Mount a real ISO
+//
+// POST /api/v2/vm/settings/mount-iso/{vm_name}
+func (c *Client) VmPostMountIso(ctx context.Context, vmName string, ifMatch string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/mount-iso/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Add a new VM network interface
+//
+// POST /api/v2/vm/settings/network/add/{vm_name}
+func (c *Client) VmPostAddNewNetwork(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.VmNetwork) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/network/add/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Remove a VM network interface
+//
+// POST /api/v2/vm/settings/network/remove/{vm_name}/{nic_index}
+func (c *Client) VmPostRemoveNetwork(ctx context.Context, vmName string, nicIndex string, ifMatch string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/network/remove/" + url.PathEscape(vmName) + "/" + url.PathEscape(nicIndex)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Change the VM network interface settings
+//
+// POST /api/v2/vm/settings/network/update/{vm_name}/{nic_index}
+func (c *Client) VmPostUpdateNetwork(ctx context.Context, vmName string, nicIndex string, ifMatch string, input ApiV2Types.VmNetworkUpdate) (r ApiV2Types.VmNetwork, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/network/update/" + url.PathEscape(vmName) + "/" + url.PathEscape(nicIndex)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Modify VM's OS info (e.g. os_type - debian12, os_comment - Debian 12)
+//
+// POST /api/v2/vm/settings/os-info/{vm_name}
+func (c *Client) VmPostOsSettings(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.VmOsSettings) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/os-info/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Modify VM's Workload type (e.g. is this a production VM, true or false)
+//
+// POST /api/v2/vm/settings/production/{vm_name}/{production}
+func (c *Client) VmPostProductionSetting(ctx context.Context, vmName string, production string, ifMatch string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/production/" + url.PathEscape(vmName) + "/" + url.PathEscape(production)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Modify VM's RAM settings
+//
+// POST /api/v2/vm/settings/ram/{vm_name}
+func (c *Client) VmPostRamInfo(ctx context.Context, vmName string, ifMatch string, input ApiV2Types.VmRamInput) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/ram/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Unmount an installation ISO
+//
+// POST /api/v2/vm/settings/unmount-iso/{vm_name}
+func (c *Client) VmPostUnmountIso(ctx context.Context, vmName string, ifMatch string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/unmount-iso/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Modify VM's VNC Resolution
+//
+// POST /api/v2/vm/settings/vnc-resolution/{vm_name}/{resolution}
+func (c *Client) VmPostVncResolution(ctx context.Context, vmName string, resolution string, ifMatch string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/settings/vnc-resolution/" + url.PathEscape(vmName) + "/" + url.PathEscape(resolution)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get the settings for a particular VM
+//
+// GET /api/v2/vm/settings/{vm_name}
+func (c *Client) VmGetSettings(ctx context.Context, vmName string) (r ApiV2Types.VmConfig, etag string, e error) {
+	req := request{method: http.MethodGet, path: "/api/v2/vm/settings/" + url.PathEscape(vmName)}
+	header, e := c.do(ctx, req, &r)
+	if e != nil {
+		return
+	}
+	etag = header.Get("ETag")
+	return
+}
+
+// Modify VM settings using a JSON Merge Patch
+//
+// PATCH /api/v2/vm/settings/{vm_name}
+func (c *Client) VmPatchSettings(ctx context.Context, vmName string, ifMatch string, input interface{}) (r []ApiV2Types.VmConfigChange, e error) {
+	req := request{method: http.MethodPatch, path: "/api/v2/vm/settings/" + url.PathEscape(vmName)}
+	if len(ifMatch) > 0 {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Start all VMs
+//
+// POST /api/v2/vm/start-all/{production}
+func (c *Client) VmPostStartAll(ctx context.Context, production string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/start-all/" + url.PathEscape(production)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Start a specific VM (and wait for a VNC screen connection)
+//
+// POST /api/v2/vm/start/wait-vnc/{vm_name}
+func (c *Client) VmPostStartAndWaitVnc(ctx context.Context, vmName string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/start/wait-vnc/" + url.PathEscape(vmName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Start a specific VM
+//
+// POST /api/v2/vm/start/{vm_name}
+func (c *Client) VmPostStart(ctx context.Context, vmName string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/start/" + url.PathEscape(vmName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Stop all VMs
+//
+// POST /api/v2/vm/stop-all/{force}
+func (c *Client) VmPostStopAll(ctx context.Context, force string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/stop-all/" + url.PathEscape(force)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Stop (forcefully) a specific VM
+//
+// POST /api/v2/vm/stop/force/{vm_name}
+func (c *Client) VmPostStopForce(ctx context.Context, vmName string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/stop/force/" + url.PathEscape(vmName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Stop a specific VM
+//
+// POST /api/v2/vm/stop/{vm_name}
+func (c *Client) VmPostStop(ctx context.Context, vmName string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/stop/" + url.PathEscape(vmName)}
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Get the list of VM templates
+//
+// POST /api/v2/vm/templates
+func (c *Client) VmGetTemplates(ctx context.Context, input ApiV2Types.ZfsDatasetInput) (r ApiV2Types.VmTemplate, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/vm/templates"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
+
+// Accept any arbitrary bash script that brings up the WG interfaces
+//
+// POST /api/v2/wireguard/script
+func (c *Client) WireGuardScript(ctx context.Context, input string) (r string, e error) {
+	req := request{method: http.MethodPost, path: "/api/v2/wireguard/script"}
+	req.body = input
+	_, e = c.do(ctx, req, &r)
+	return
+}
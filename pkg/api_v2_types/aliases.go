@@ -0,0 +1,79 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+package ApiV2Types
+
+import (
+	CarpUtils "HosterCore/internal/app/ha_carp/utils"
+	ApiAudit "HosterCore/internal/app/rest_api_v2/pkg/audit"
+	ApiAuth "HosterCore/internal/app/rest_api_v2/pkg/auth"
+	RestApiConfig "HosterCore/internal/app/rest_api_v2/pkg/config"
+	VncProxy "HosterCore/internal/app/rest_api_v2/pkg/vnc_proxy"
+	SchedulerUtils "HosterCore/internal/app/scheduler/utils"
+	"HosterCore/internal/pkg/freebsd/rctl"
+	HosterBulk "HosterCore/internal/pkg/hoster/bulk"
+	HosterHost "HosterCore/internal/pkg/hoster/host"
+	HosterHostUtils "HosterCore/internal/pkg/hoster/host/utils"
+	HosterJail "HosterCore/internal/pkg/hoster/jail"
+	HosterJailUtils "HosterCore/internal/pkg/hoster/jail/utils"
+	HosterListQuery "HosterCore/internal/pkg/hoster/list_query"
+	HosterNetwork "HosterCore/internal/pkg/hoster/network"
+	HosterPrometheus "HosterCore/internal/pkg/hoster/prometheus"
+	HosterVm "HosterCore/internal/pkg/hoster/vm"
+	HosterVmUtils "HosterCore/internal/pkg/hoster/vm/utils"
+	zfsutils "HosterCore/internal/pkg/zfs_utils"
+	ErrorMappings "HosterCore/pkg/api_v2_client/error_mappings"
+)
+
+// The API payloads defined in the internal packages, re-exported here, so they can be used outside of this module.
+// The API client code generator maps the swagger types to these aliases (by the aliased type), so every type
+// used in the handler annotations must be listed below.
+
+// Host
+type HostInfo = HosterHostUtils.HostInfo
+type HostConfig = HosterHost.HostConfig
+type ApiConfig = RestApiConfig.RestApiConfig
+type SettingChange = RestApiConfig.SettingChange
+type LockoutEntry = ApiAuth.LockoutEntry
+type AuditEntry = ApiAudit.Entry
+type ErrorCatalogEntry = ErrorMappings.CatalogEntry
+type NetworkConfig = HosterNetwork.NetworkConfig
+type PrometheusTarget = HosterPrometheus.PrometheusTarget
+
+// VMs
+type VmApi = HosterVmUtils.VmApi
+type VmConfig = HosterVmUtils.VmConfig
+type VmConfigChange = HosterVmUtils.VmConfigChange
+type VmDisk = HosterVmUtils.VmDisk
+type VmNetwork = HosterVmUtils.VmNetwork
+type VmTemplate = HosterVmUtils.VmTemplate
+type VmDeployInput = HosterVm.VmDeployInput
+type VmSpec = HosterVm.VmSpec
+type VmApplyResult = HosterVm.VmApplyResult
+type VmNetworkUpdate = HosterVm.VmNetworkUpdate
+type VmImportResult = HosterVm.VmImportResult
+type VncSession = VncProxy.Session
+
+// Jails
+type JailApi = HosterJailUtils.JailApi
+type JailConfig = HosterJailUtils.JailConfig
+type JailDeployInput = HosterJail.DeployInput
+
+// Snapshots, metrics, scheduler and bulk operations
+type SnapshotInfo = zfsutils.SnapshotInfo
+type RctMetrics = rctl.RctMetrics
+type SchedulerJob = SchedulerUtils.Job
+type BulkSelector = HosterBulk.Selector
+type BulkParams = HosterBulk.Params
+type BulkOperation = HosterBulk.Operation
+
+// List filters (the query parameters of the VM and Jail list endpoints)
+type ListQuery = HosterListQuery.Query
+
+// HA
+type HaNode = RestApiConfig.HaNode
+type CarpHostInfo = CarpUtils.HostInfo
+type CarpHaStatus = CarpUtils.HaStatus
+type CarpBackupInfo = CarpUtils.BackupInfo
+type CarpPingResponse = CarpUtils.CarpPingResponse
@@ -0,0 +1,202 @@
+// Copyright 2024 Hoster Authors. All rights reserved.
+// Use of this source code is governed by an Apache License 2.0
+// license that can be found in the LICENSE file.
+
+// Request and response payloads of the REST API v2.
+// The structs are shared between the API handlers and the API client (HosterCore/pkg/api_v2_client).
+package ApiV2Types
+
+import (
+	VncProxy "HosterCore/internal/app/rest_api_v2/pkg/vnc_proxy"
+	HosterBulk "HosterCore/internal/pkg/hoster/bulk"
+)
+
+type DnsSearchDomainInput struct {
+	DnsSearchDomain string `json:"dns_search_domain"`
+}
+
+type VmTemplateLink struct {
+	Link string `json:"vm_template_link"`
+}
+
+type UpstreamDnsInput struct {
+	DnsServer string `json:"dns_server"`
+}
+
+type SshKeyInput struct {
+	KeyComment string `json:"key_comment"`
+	KeyValue   string `json:"key_value"`
+}
+
+type HostAuthSshKeyInput struct {
+	KeyValue string `json:"key_value"`
+}
+
+type LockoutClearInput struct {
+	Type string `json:"type"` // ip or user
+	Key  string `json:"key"`  // source IP address or user name
+}
+
+type WireGuardScriptInput struct {
+	Script string `json:"script"`
+}
+
+type DatasetInfo struct {
+	Encrypted      bool   `json:"encrypted"`
+	Mounted        bool   `json:"mounted"`
+	Used           uint64 `json:"used"`
+	Available      uint64 `json:"available"`
+	Total          uint64 `json:"total"`
+	UsedHuman      string `json:"used_human"`
+	AvailableHuman string `json:"available_human"`
+	TotalHuman     string `json:"total_human"`
+	Name           string `json:"name"`
+}
+
+type DatasetEncryptionInput struct {
+	Dataset  string `json:"dataset"`
+	Password string `json:"password"`
+}
+
+type ZfsDatasetInput struct {
+	Dataset string `json:"dataset"`
+}
+
+type VmCloneInput struct {
+	VmName       string `json:"vm_name"`
+	NewVmName    string `json:"new_vm_name"`
+	SnapshotName string `json:"snapshot_name"`
+}
+
+type VmCpuInput struct {
+	CpuSockets int `json:"cpu_sockets"`
+	CpuCores   int `json:"cpu_cores"`
+	CpuThreads int `json:"cpu_threads"`
+}
+
+type VmRamInput struct {
+	RamAmount  int    `json:"ram_amount"`
+	BytesValue string `json:"bytes_value"`
+}
+
+type VmOsSettings struct {
+	OsType    string `json:"os_type"`
+	OsComment string `json:"os_comment"`
+}
+
+type VmMountIsoInput struct {
+	IsoPath    string `json:"iso_path"`
+	IsoComment string `json:"iso_comment"`
+}
+
+type VmDiskExpandInput struct {
+	DiskImage     string `json:"disk_image"`
+	ExpansionSize int    `json:"expansion_size"`
+}
+
+type VmDiskDetachInput struct {
+	DiskImage string `json:"disk_image"` // disk image name (or zvol name), or an absolute path for the external disks
+}
+
+type VmDiskRemoveInput struct {
+	DiskImage string `json:"disk_image"` // disk image name (or zvol name)
+	Confirm   bool   `json:"confirm"`    // must be set to true, the disk image is deleted permanently
+}
+
+type VmDiskReorderInput struct {
+	Order []string `json:"order"` // disk images, the first one becomes the boot disk, the disks that are not listed keep their relative order
+}
+
+type VmRenameInput struct {
+	NewName string `json:"new_name"` // MAC and IP addresses, passwords and the cloud-init instance-id are kept as is
+}
+
+type VmStopInput struct {
+	ForceCleanup bool   `json:"force_cleanup"` // Kill the VM supervisor directly (useful in the situations where you want to destroy the VM, or roll it back to a previous snapshot)
+	ForceStop    bool   `json:"force_stop"`    // Send a SIGKILL instead of a graceful SIGTERM
+	VmName       string `json:"vm_name"`
+}
+
+type VncTokenResponse struct {
+	VncProxy.Token
+	WsPath      string `json:"ws_path"`
+	VncPassword string `json:"vnc_password"`
+}
+
+type JailCloneInput struct {
+	JailName     string `json:"jail_name"`
+	NewJailName  string `json:"new_jail_name"`
+	SnapshotName string `json:"snapshot_name"`
+}
+
+type JailShells struct {
+	AvailableShells []string `json:"available_shells"`
+}
+
+type JailDnsInput struct {
+	DnsServer    string `json:"dns_server"`
+	SearchDomain string `json:"search_domain"`
+}
+
+type JailNetworkInput struct {
+	NetworkBridge string `json:"network_bridge"`
+	IpAddress     string `json:"ip_address"`
+}
+
+type TagInput struct {
+	Tag string `json:"tag"`
+}
+
+type ResourceDescription struct {
+	Description string `json:"description"`
+}
+
+type SnapshotInput struct {
+	SnapshotsToKeep     int    `json:"snapshots_to_keep"`    // How many snapshots to keep, e.g. 5
+	SnapshotName        string `json:"snapshot_name"`        // Full snapshot name, including the whole path, e.g. "tank/vm-encrypted/vmTest1@snap1"
+	ResourceName        string `json:"res_name"`             // VM or Jail name
+	NewResourceName     string `json:"new_res_name"`         // Used in clone operation, e.g. newVmName, the internal call will automatically append the dataset name
+	SnapshotType        string `json:"snapshot_type"`        // "hourly", "daily", "weekly", "monthly", "frequent"
+	SnapshotDescription string `json:"snapshot_description"` // Description of the snapshot
+	SnapshotDataset     string `json:"-"`
+}
+
+type SnapshotName struct {
+	ResourceName string `json:"resource_name"` // VM or Jail name
+	SnapshotName string `json:"snapshot_name"` // Full snapshot name, including the whole path, e.g. "tank/vm-encrypted/vmTest1@snap1"
+}
+
+type CronJob struct {
+	Disabled bool   `json:"disabled"`
+	Time     string `json:"time"`
+	User     string `json:"user"`
+	Command  string `json:"command"`
+	Comment  string `json:"comment"`
+}
+
+type CronFile struct {
+	FileName      string    `json:"file_name"`
+	CronVariables []string  `json:"cron_variables"`
+	CronJobs      []CronJob `json:"cron_jobs"`
+}
+
+type BulkInput struct {
+	Selector HosterBulk.Selector `json:"selector"`
+	Params   HosterBulk.Params   `json:"params"`
+}
+
+type HaVm struct {
+	VmName         string `json:"vm_name"`
+	Live           bool   `json:"live"`
+	LatestSnapshot string `json:"latest_snapshot"`
+	ParentHost     string `json:"parent_host"`
+	CurrentHost    string `json:"current_host"`
+}
+
+type HaJail struct {
+	VmName         string `json:"jail_name"`
+	Live           bool   `json:"live"`
+	LatestSnapshot string `json:"latest_snapshot"`
+	ParentHost     string `json:"parent_host"`
+	CurrentHost    string `json:"current_host"`
+}